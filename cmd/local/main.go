@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	aws "github.com/aws/aws-sdk-go/aws"
 	awssession "github.com/aws/aws-sdk-go/aws/session"
@@ -16,14 +18,20 @@ import (
 	"local.com/go-clean-lambda/internal/controller"
 	"local.com/go-clean-lambda/internal/controller/api/car"
 	"local.com/go-clean-lambda/internal/controller/api/pet"
+	"local.com/go-clean-lambda/internal/domain"
 	"local.com/go-clean-lambda/internal/logger"
 	"local.com/go-clean-lambda/internal/repository"
 	"local.com/go-clean-lambda/internal/sdk/account"
 	"local.com/go-clean-lambda/internal/sdk/authentication"
 	"local.com/go-clean-lambda/internal/sdk/authorization"
+	"local.com/go-clean-lambda/internal/sdk/publisher"
+	"local.com/go-clean-lambda/internal/sdk/queue"
 	"local.com/go-clean-lambda/internal/usecase"
 )
 
+// localJobQueueSize jobs submitted before the worker takes them.
+const localJobQueueSize int = 100
+
 //nolint: all
 //
 //go:embed env.yml
@@ -79,26 +87,107 @@ func initControllers() ([]controller.MuxController, error) {
 	}
 	localSSMClient := NewLocalSSM(store)
 	// init repo
-	dummyRepo := repository.NewDummyDynamodbRepo(
-		appConfig.DynamodbCfg.DummyTableName,
-		dynamodbClient)
+	var dummyRepo domain.DummyRepository
+	var outbox domain.DummyOutbox
+	var transactor domain.Transactor
+	var jobRepo domain.JobRepository
+	var blocklist authentication.BlocklistStore
+	var refreshTokens authentication.RefreshTokenStore
+	var sessions authentication.SessionStore
+	var loginAttempts authentication.LoginAttemptStore
+	if appConfig.RepositoryDriver == app.RepositoryDriverMemory {
+		// run without aws
+		memoryRepo := repository.NewDummyMemoryRepo(nil).WithSoftDelete(appConfig.DynamodbCfg.DummySoftDeleteRetention)
+		dummyRepo, outbox = memoryRepo, memoryRepo
+		transactor = repository.NewMemoryTransactor()
+		jobRepo = repository.NewJobMemoryRepo()
+		blocklist = authentication.NewBlocklistMemoryStore()
+		refreshTokens = authentication.NewRefreshTokenMemoryStore()
+		sessions = authentication.NewSessionMemoryStore()
+		loginAttempts = authentication.NewLoginAttemptMemoryStore()
+	} else {
+		dynamodbRepo := repository.NewDummyDynamodbRepo(
+			appConfig.DynamodbCfg.DummyTableName,
+			dynamodbClient).WithSoftDelete(appConfig.DynamodbCfg.DummySoftDeleteRetention)
+		dummyRepo, outbox = dynamodbRepo, dynamodbRepo
+		transactor = repository.NewDynamodbTransactor(dynamodbClient)
+		jobRepo = repository.NewJobDynamodbRepo(
+			appConfig.DynamodbCfg.StateTableName,
+			dynamodbClient).WithRetention(appConfig.JobCfg.Retention)
+		blocklist = repository.NewBlocklistDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient)
+		refreshTokens = repository.NewRefreshTokenDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient)
+		sessions = repository.NewSessionDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient)
+		loginAttempts = repository.NewLoginAttemptDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient)
+	}
+	if appConfig.CacheCfg.Enabled {
+		dummyRepo = repository.NewDummyCacheRepo(
+			dummyRepo,
+			appConfig.CacheCfg.Size,
+			appConfig.CacheCfg.TTL,
+			appConfig.CacheCfg.NegativeTTL)
+	}
 	// init usecase
-	dummyUsecase := usecase.NewDummyUseCase(dummyRepo)
+	adminBit, err := authorization.GenerateGrantedBit([]int{controller.AuthIndexAppDummyAdmin})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate dummy admin bit")
+	}
+	dummyUsecase := usecase.NewDummyUseCase(dummyRepo, transactor).WithAdminPermission(adminBit)
+	if len(appConfig.EventCfg.LocalFile) > 0 {
+		relay := usecase.NewDummyEventRelay(outbox, publisher.NewFilePublisher(appConfig.EventCfg.LocalFile))
+		go relayEvents(relay, appConfig.EventCfg.RelayInterval)
+	}
+	// jobs are executed by a worker in the process instead of a sqs queue
+	jobQueue := queue.NewChannelJobQueue(localJobQueueSize)
+	jobUsecase := usecase.NewJobUseCase(jobRepo, jobQueue).
+		WithClaimTTL(appConfig.JobCfg.ClaimTTL).
+		Register(usecase.JobTypeDummyImport, usecase.NewDummyImportJob(dummyUsecase))
+	go jobQueue.Consume(context.Background(), jobUsecase.Execute)
 	// init sdk clients
 	jwtClient := authentication.NewAuthJwtDummyClient(
 		appConfig.AuthCfg.PublicKey,
 		appConfig.AuthCfg.PrivateKey,
 		localSSMClient,
-	)
+	).WithKeyProvider(authentication.NewSSMKeyProvider(
+		appConfig.AuthCfg.PublicKey,
+		appConfig.AuthCfg.PrivateKey,
+		localSSMClient,
+	).WithTTL(appConfig.AuthCfg.KeyCacheTTL)).
+		WithBlocklist(blocklist).
+		WithSessions(sessions).
+		WithIssuer(appConfig.AuthCfg.Issuer, appConfig.AuthCfg.Audience).
+		WithLeeway(appConfig.AuthCfg.Leeway).
+		WithLegacyTokens(appConfig.AuthCfg.LegacyUntil).
+		WithAlgorithms(appConfig.AuthCfg.Algorithm, appConfig.AuthCfg.AllowedAlgorithms)
+	refreshClient := authentication.NewRefreshTokenStoreClient(refreshTokens).
+		WithTTL(appConfig.AuthCfg.RefreshTokenTTL)
+	sessionClient := authentication.NewSessionStoreClient(sessions, blocklist, refreshTokens).
+		WithLeeway(appConfig.AuthCfg.Leeway)
 	roleClient := authorization.NewRoleDummyClient()
-	userClient := account.NewUserDummmyClient()
+	userClient := account.NewUserDummmyClient().
+		WithPasswordEncoder(account.NewPasswordEncoderOf(
+			appConfig.PasswordCfg.Algorithm,
+			appConfig.PasswordCfg.Argon2id,
+			appConfig.PasswordCfg.BcryptCost)).
+		WithPasswordPolicy(appConfig.PasswordCfg.Policy)
+	var cookie *controller.AuthCookie
+	if appConfig.AuthCfg.CookieMode {
+		cookie = controller.NewAuthCookie(appConfig.AuthCfg.CookieSameSite, appConfig.AuthCfg.CookieDomain)
+	}
+	var loginGuard authentication.LoginGuard
+	if appConfig.LoginCfg.Enabled {
+		loginGuard = authentication.NewLoginAttemptGuard(loginAttempts, appConfig.LoginCfg.Policy)
+	}
 	// init middlewares
 	logMdf := controller.GetLogMiddleware()
-	authMdf := controller.GetLoginAccessMiddleware(jwtClient)
+	authMdf := controller.GetLoginAccessMiddleware(jwtClient, cookie)
 	rolePingMdf := controller.GetRoleAccessMiddleware([]uint64{uint64(controller.AuthIndexAppPing)})
 	// init controllers
-	authController := controller.NewAuthController(logMdf, authMdf, jwtClient, roleClient, userClient)
-	dummyController := controller.NewDummyController(logMdf, dummyUsecase)
+	authController := controller.NewAuthController(
+		logMdf, authMdf, jwtClient, refreshClient, sessionClient, roleClient, userClient).
+		WithCookie(cookie).
+		WithLoginGuard(loginGuard)
+	dummyController := controller.NewDummyController(logMdf, authMdf, dummyUsecase, jobUsecase)
+	jobController := controller.NewJobController(logMdf, authMdf, jobUsecase)
 	pingController := controller.NewPingController(logMdf, authMdf, rolePingMdf)
 	apiPetController := pet.NewPetController(logMdf)
 	apiCarController := car.NewCarController(logMdf)
@@ -107,11 +196,28 @@ func initControllers() ([]controller.MuxController, error) {
 		apiCarController,
 		authController,
 		dummyController,
+		jobController,
 		pingController,
 		apiPetController,
 	}, nil
 }
 
+// relayEvents
+// publish the outbox periodically, failed events are retried by the next run
+//
+//	@param relay
+//	@param interval
+func relayEvents(relay *usecase.DummyEventRelay, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		_, err := relay.Relay(context.Background())
+		if err != nil {
+			logger.Error("failed to relay dummy events.", err)
+		}
+	}
+}
+
 func server() {
 	setLocalEnv()
 	port := 8080
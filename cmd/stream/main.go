@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"local.com/go-clean-lambda/internal/app"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+func main() {
+	streamController, err := app.InitDummyStreamController()
+	if err != nil {
+		logger.Error("execution end. failed to init lambda.", err)
+		return
+	}
+	logger.Info("stream handler initialization done")
+	lambda.Start(streamController.Handle)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"local.com/go-clean-lambda/internal/app"
+	"local.com/go-clean-lambda/internal/controller"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+func main() {
+	controllers, err := app.InitWorkerControllers()
+	if err != nil {
+		logger.Error("execution end. failed to init lambda.", err)
+		return
+	}
+	r := controller.NewEventRouter(controllers)
+	logger.Info("worker router initialization done")
+	lambda.Start(r.Handle)
+}
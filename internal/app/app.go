@@ -4,16 +4,21 @@ import (
 	aws "github.com/aws/aws-sdk-go/aws"
 	awssession "github.com/aws/aws-sdk-go/aws/session"
 	awsdynamodb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/pkg/errors"
 	"local.com/go-clean-lambda/internal/controller"
 	"local.com/go-clean-lambda/internal/controller/api/car"
 	"local.com/go-clean-lambda/internal/controller/api/pet"
+	"local.com/go-clean-lambda/internal/domain"
 	"local.com/go-clean-lambda/internal/logger"
 	"local.com/go-clean-lambda/internal/repository"
 	"local.com/go-clean-lambda/internal/sdk/account"
 	"local.com/go-clean-lambda/internal/sdk/authentication"
 	"local.com/go-clean-lambda/internal/sdk/authorization"
+	"local.com/go-clean-lambda/internal/sdk/publisher"
+	"local.com/go-clean-lambda/internal/sdk/queue"
 	"local.com/go-clean-lambda/internal/usecase"
 )
 
@@ -65,17 +70,37 @@ func InitDummyControllers() ([]controller.MuxController, error) {
 	}
 	awssess := awssession.Must(awssession.NewSessionWithOptions(awsopt))
 	dynamodbClient := awsdynamodb.New(awssess)
-	dummyRepo := repository.NewDummyDynamodbRepo(
+	ssmClient := ssm.New(awssess)
+	var dummyRepo domain.DummyRepository = repository.NewDummyDynamodbRepo(
 		appConfig.DynamodbCfg.DummyTableName,
-		dynamodbClient)
+		dynamodbClient).WithSoftDelete(appConfig.DynamodbCfg.DummySoftDeleteRetention)
+	if appConfig.CacheCfg.Enabled {
+		// warm lambda containers keep the cache between invocations
+		dummyRepo = repository.NewDummyCacheRepo(
+			dummyRepo,
+			appConfig.CacheCfg.Size,
+			appConfig.CacheCfg.TTL,
+			appConfig.CacheCfg.NegativeTTL)
+	}
+	transactor := repository.NewDynamodbTransactor(dynamodbClient)
 	// init usecase
-	dummyUsecase := usecase.NewDummyUseCase(dummyRepo)
+	adminBit, err := authorization.GenerateGrantedBit([]int{controller.AuthIndexAppDummyAdmin})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate dummy admin bit")
+	}
+	dummyUsecase := usecase.NewDummyUseCase(dummyRepo, transactor).WithAdminPermission(adminBit)
+	jobUsecase := newJobUseCase(appConfig, dynamodbClient, sqs.New(awssess), dummyUsecase)
+	// init sdk clients
+	jwtClient := newAuthJwtClient(appConfig, ssmClient, dynamodbClient)
 	// init middlewares
 	logMdf := controller.GetLogMiddleware()
+	authMdf := controller.GetLoginAccessMiddleware(jwtClient, newAuthCookie(appConfig))
 	// init controllers
-	dummyController := controller.NewDummyController(logMdf, dummyUsecase)
+	dummyController := controller.NewDummyController(logMdf, authMdf, dummyUsecase, jobUsecase)
+	jobController := controller.NewJobController(logMdf, authMdf, jobUsecase)
 	return []controller.MuxController{
 		dummyController,
+		jobController,
 	}, nil
 }
 
@@ -101,20 +126,33 @@ func InitAuthControllers() ([]controller.MuxController, error) {
 		awsopt.Profile = appConfig.AwsEnvCfg.Profile
 	}
 	awssess := awssession.Must(awssession.NewSessionWithOptions(awsopt))
+	dynamodbClient := awsdynamodb.New(awssess)
 	ssmClient := ssm.New(awssess)
 	// init sdk clients
-	jwtClient := authentication.NewAuthJwtDummyClient(
-		appConfig.AuthCfg.PublicKey,
-		appConfig.AuthCfg.PrivateKey,
-		ssmClient,
-	)
+	jwtClient := newAuthJwtClient(appConfig, ssmClient, dynamodbClient)
+	refreshTokens := repository.NewRefreshTokenDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient)
+	refreshClient := authentication.NewRefreshTokenStoreClient(refreshTokens).
+		WithTTL(appConfig.AuthCfg.RefreshTokenTTL)
+	sessionClient := authentication.NewSessionStoreClient(
+		repository.NewSessionDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient),
+		repository.NewBlocklistDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient),
+		refreshTokens,
+	).WithLeeway(appConfig.AuthCfg.Leeway)
 	roleClient := authorization.NewRoleDummyClient()
-	userClient := account.NewUserDummmyClient()
+	userClient := account.NewUserDummmyClient().
+		WithPasswordEncoder(account.NewPasswordEncoderOf(
+			appConfig.PasswordCfg.Algorithm,
+			appConfig.PasswordCfg.Argon2id,
+			appConfig.PasswordCfg.BcryptCost)).
+		WithPasswordPolicy(appConfig.PasswordCfg.Policy)
 	// init middlewares
 	logMdf := controller.GetLogMiddleware()
-	authMdf := controller.GetLoginAccessMiddleware(jwtClient)
+	authMdf := controller.GetLoginAccessMiddleware(jwtClient, newAuthCookie(appConfig))
 	// init controllers
-	authController := controller.NewAuthController(logMdf, authMdf, jwtClient, roleClient, userClient)
+	authController := controller.NewAuthController(
+		logMdf, authMdf, jwtClient, refreshClient, sessionClient, roleClient, userClient).
+		WithCookie(newAuthCookie(appConfig)).
+		WithLoginGuard(newLoginGuard(appConfig, dynamodbClient))
 	return []controller.MuxController{
 		authController,
 	}, nil
@@ -142,16 +180,13 @@ func InitPingControllers() ([]controller.MuxController, error) {
 		awsopt.Profile = appConfig.AwsEnvCfg.Profile
 	}
 	awssess := awssession.Must(awssession.NewSessionWithOptions(awsopt))
+	dynamodbClient := awsdynamodb.New(awssess)
 	ssmClient := ssm.New(awssess)
 	// init sdk clients
-	jwtClient := authentication.NewAuthJwtDummyClient(
-		appConfig.AuthCfg.PublicKey,
-		appConfig.AuthCfg.PrivateKey,
-		ssmClient,
-	)
+	jwtClient := newAuthJwtClient(appConfig, ssmClient, dynamodbClient)
 	// init middlewares
 	logMdf := controller.GetLogMiddleware()
-	authMdf := controller.GetLoginAccessMiddleware(jwtClient)
+	authMdf := controller.GetLoginAccessMiddleware(jwtClient, newAuthCookie(appConfig))
 	rolePingMdf := controller.GetRoleAccessMiddleware([]uint64{uint64(controller.AuthIndexAppPing)})
 	// init controllers
 	pingController := controller.NewPingController(logMdf, authMdf, rolePingMdf)
@@ -159,3 +194,195 @@ func InitPingControllers() ([]controller.MuxController, error) {
 		pingController,
 	}, nil
 }
+
+// InitDummyStreamController
+//
+//	@return *controller.DummyStreamController
+//	@return error
+func InitDummyStreamController() (*controller.DummyStreamController, error) {
+	// init configs
+	appConfig, err := NewAppConfig()
+	if err != nil {
+		return nil, errors.Errorf("failed to init app config. %s", err.Error())
+	}
+	// init logger
+	logger.SetLogLevels(appConfig.LogCfg.Levels, appConfig.LogCfg.MinLevel, appConfig.LogCfg.CrNewline)
+	// log configs after logger is inited
+	logger.Info("app config: %s", logger.Pretty(appConfig))
+	// init controllers
+	streamController := controller.NewDummyStreamController().
+		Register("log", controller.GetDummyStreamLogHandler())
+	return streamController, nil
+}
+
+// InitEventControllers
+//
+//	@return []controller.EventController
+//	@return error
+func InitEventControllers() ([]controller.EventController, error) {
+	// init configs
+	appConfig, err := NewAppConfig()
+	if err != nil {
+		return nil, errors.Errorf("failed to init app config. %s", err.Error())
+	}
+	// init logger
+	logger.SetLogLevels(appConfig.LogCfg.Levels, appConfig.LogCfg.MinLevel, appConfig.LogCfg.CrNewline)
+	// log configs after logger is inited
+	logger.Info("app config: %s", logger.Pretty(appConfig))
+	// init repo
+	awsopt := awssession.Options{
+		Config: aws.Config{Region: aws.String(appConfig.AwsEnvCfg.Region)},
+	}
+	if len(appConfig.AwsEnvCfg.Profile) > 0 {
+		awsopt.Profile = appConfig.AwsEnvCfg.Profile
+	}
+	awssess := awssession.Must(awssession.NewSessionWithOptions(awsopt))
+	dynamodbClient := awsdynamodb.New(awssess)
+	eventbridgeClient := eventbridge.New(awssess)
+	dynamodbRepo := repository.NewDummyDynamodbRepo(
+		appConfig.DynamodbCfg.DummyTableName,
+		dynamodbClient).WithSoftDelete(appConfig.DynamodbCfg.DummySoftDeleteRetention)
+	transactor := repository.NewDynamodbTransactor(dynamodbClient)
+	// init usecase
+	adminBit, err := authorization.GenerateGrantedBit([]int{controller.AuthIndexAppDummyAdmin})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate dummy admin bit")
+	}
+	dummyUsecase := usecase.NewDummyUseCase(dynamodbRepo, transactor).WithAdminPermission(adminBit)
+	relay := usecase.NewDummyEventRelay(
+		dynamodbRepo,
+		publisher.NewEventBridgePublisher(appConfig.EventCfg.BusName, eventbridgeClient))
+	// init controllers
+	dummyEventController := controller.NewDummyEventController(
+		dummyUsecase,
+		relay,
+		appConfig.EventCfg.DummyImportQueue,
+		appConfig.EventCfg.DummyImportUser,
+		appConfig.EventCfg.DummyRelayRule)
+	return []controller.EventController{
+		dummyEventController,
+	}, nil
+}
+
+// InitWorkerControllers
+//
+//	@return []controller.EventController
+//	@return error
+func InitWorkerControllers() ([]controller.EventController, error) {
+	// init configs
+	appConfig, err := NewAppConfig()
+	if err != nil {
+		return nil, errors.Errorf("failed to init app config. %s", err.Error())
+	}
+	// init logger
+	logger.SetLogLevels(appConfig.LogCfg.Levels, appConfig.LogCfg.MinLevel, appConfig.LogCfg.CrNewline)
+	// log configs after logger is inited
+	logger.Info("app config: %s", logger.Pretty(appConfig))
+	// init repo
+	awsopt := awssession.Options{
+		Config: aws.Config{Region: aws.String(appConfig.AwsEnvCfg.Region)},
+	}
+	if len(appConfig.AwsEnvCfg.Profile) > 0 {
+		awsopt.Profile = appConfig.AwsEnvCfg.Profile
+	}
+	awssess := awssession.Must(awssession.NewSessionWithOptions(awsopt))
+	dynamodbClient := awsdynamodb.New(awssess)
+	var dummyRepo domain.DummyRepository = repository.NewDummyDynamodbRepo(
+		appConfig.DynamodbCfg.DummyTableName,
+		dynamodbClient).WithSoftDelete(appConfig.DynamodbCfg.DummySoftDeleteRetention)
+	transactor := repository.NewDynamodbTransactor(dynamodbClient)
+	// init usecase
+	adminBit, err := authorization.GenerateGrantedBit([]int{controller.AuthIndexAppDummyAdmin})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate dummy admin bit")
+	}
+	dummyUsecase := usecase.NewDummyUseCase(dummyRepo, transactor).WithAdminPermission(adminBit)
+	jobUsecase := newJobUseCase(appConfig, dynamodbClient, sqs.New(awssess), dummyUsecase)
+	// init controllers
+	jobEventController := controller.NewJobEventController(jobUsecase, appConfig.JobCfg.QueueName)
+	return []controller.EventController{
+		jobEventController,
+	}, nil
+}
+
+// newJobUseCase
+// jobs are stored in the state table and handed to the workers by the job queue
+//
+//	@param appConfig
+//	@param dynamodbClient
+//	@param sqsClient
+//	@param dummyUsecase
+//	@return *usecase.JobUseCase
+func newJobUseCase(
+	appConfig *Config,
+	dynamodbClient *awsdynamodb.DynamoDB,
+	sqsClient *sqs.SQS,
+	dummyUsecase *usecase.DummyUseCase,
+) *usecase.JobUseCase {
+	jobRepo := repository.NewJobDynamodbRepo(
+		appConfig.DynamodbCfg.StateTableName,
+		dynamodbClient).WithRetention(appConfig.JobCfg.Retention)
+	jobQueue := queue.NewSQSJobQueue(appConfig.JobCfg.QueueName, sqsClient)
+	return usecase.NewJobUseCase(jobRepo, jobQueue).
+		WithClaimTTL(appConfig.JobCfg.ClaimTTL).
+		Register(usecase.JobTypeDummyImport, usecase.NewDummyImportJob(dummyUsecase))
+}
+
+// newAuthCookie
+//
+//	@param appConfig
+//	@return *controller.AuthCookie nil when the cookie mode is disabled
+func newAuthCookie(appConfig *Config) *controller.AuthCookie {
+	if !appConfig.AuthCfg.CookieMode {
+		return nil
+	}
+	return controller.NewAuthCookie(appConfig.AuthCfg.CookieSameSite, appConfig.AuthCfg.CookieDomain)
+}
+
+// newLoginGuard
+// the failures are counted in dynamodb, so they are shared by every lambda container
+//
+//	@param appConfig
+//	@param dynamodbClient
+//	@return authentication.LoginGuard nil when it is disabled
+func newLoginGuard(appConfig *Config, dynamodbClient *awsdynamodb.DynamoDB) authentication.LoginGuard {
+	if !appConfig.LoginCfg.Enabled {
+		return nil
+	}
+	return authentication.NewLoginAttemptGuard(
+		repository.NewLoginAttemptDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient),
+		appConfig.LoginCfg.Policy)
+}
+
+// newAuthJwtClient
+// blocked tokens and user epochs are stored in dynamodb, so a logout is seen by every lambda container.
+// the keys of ssm are cached by the container and refreshed in background
+//
+//	@param appConfig
+//	@param ssmClient
+//	@param dynamodbClient
+//	@return *authentication.AuthJwtDuummyClient
+func newAuthJwtClient(
+	appConfig *Config,
+	ssmClient *ssm.SSM,
+	dynamodbClient *awsdynamodb.DynamoDB,
+) *authentication.AuthJwtDuummyClient {
+	keys := authentication.NewSSMKeyProvider(
+		appConfig.AuthCfg.PublicKey,
+		appConfig.AuthCfg.PrivateKey,
+		ssmClient,
+	).WithTTL(appConfig.AuthCfg.KeyCacheTTL)
+	blocklist := repository.NewBlocklistDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient)
+	sessions := repository.NewSessionDynamodbStore(appConfig.DynamodbCfg.StateTableName, dynamodbClient)
+	return authentication.NewAuthJwtDummyClient(
+		appConfig.AuthCfg.PublicKey,
+		appConfig.AuthCfg.PrivateKey,
+		ssmClient,
+	).WithKeyProvider(keys).
+		WithBlocklist(blocklist).
+		WithSessions(sessions).
+		WithIssuer(appConfig.AuthCfg.Issuer, appConfig.AuthCfg.Audience).
+		WithLeeway(appConfig.AuthCfg.Leeway).
+		WithLegacyTokens(appConfig.AuthCfg.LegacyUntil).
+		WithAlgorithms(appConfig.AuthCfg.Algorithm, appConfig.AuthCfg.AllowedAlgorithms)
+}
@@ -1,18 +1,56 @@
 package app
 
 import (
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"local.com/go-clean-lambda/internal/sdk/account"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
 )
 
+const (
+	RepositoryDriverDynamodb string = "dynamodb"
+	RepositoryDriverMemory   string = "memory"
+
+	defaultCacheSize          int           = 1000
+	defaultCacheTTL           time.Duration = time.Minute
+	defaultCacheNegativeTTL   time.Duration = 10 * time.Second
+	defaultEventRelayInterval time.Duration = 5 * time.Second
+	defaultDummyImportUser    string        = "dummy-import"
+	defaultJobRetention       time.Duration = 7 * 24 * time.Hour
+	defaultJobClaimTTL        time.Duration = 15 * time.Minute
+	defaultJwtKeyCacheTTL     time.Duration = 15 * time.Minute
+	defaultJwtLeeway          time.Duration = 30 * time.Second
+	defaultJwtAlgorithm       string        = authentication.AlgorithmRS256
+	defaultRefreshTokenTTL    time.Duration = 30 * 24 * time.Hour
+)
+
+// cookieSameSites values of JWT_COOKIE_SAME_SITE in lower case
+var cookieSameSites = map[string]http.SameSite{
+	"strict": http.SameSiteStrictMode,
+	"lax":    http.SameSiteLaxMode,
+	"none":   http.SameSiteNoneMode,
+}
+
 type Config struct {
-	Appcode     string
-	Variant     string
-	Stage       string
-	AwsEnvCfg   *AwsEnvConfig
-	LogCfg      *LogConfig
-	AuthCfg     *AuthConfig
-	DynamodbCfg *DynamodbConfig
+	Appcode          string
+	Variant          string
+	Stage            string
+	RepositoryDriver string
+	AwsEnvCfg        *AwsEnvConfig
+	LogCfg           *LogConfig
+	AuthCfg          *AuthConfig
+	PasswordCfg      *PasswordConfig
+	LoginCfg         *LoginConfig
+	DynamodbCfg      *DynamodbConfig
+	CacheCfg         *CacheConfig
+	EventCfg         *EventConfig
+	JobCfg           *JobConfig
 }
 
 type LogConfig struct {
@@ -30,10 +68,85 @@ type AwsEnvConfig struct {
 type AuthConfig struct {
 	PublicKey  string
 	PrivateKey string
+	// KeyCacheTTL the keys of ssm are refreshed in background after it
+	KeyCacheTTL time.Duration
+	// Issuer iss of issued tokens, verified tokens must have it
+	Issuer string
+	// Audience aud of issued tokens, verified tokens must have it
+	Audience string
+	// Leeway clock skew allowed when the times of a token are checked
+	Leeway time.Duration
+	// LegacyUntil accept tokens issued before iss, aud and times in seconds were added until it, the zero time
+	// rejects them
+	LegacyUntil time.Time
+	// Algorithm signs issued tokens, the private key must be of it
+	Algorithm string
+	// AllowedAlgorithms verified tokens must be signed by one of them, keep the old algorithm here while migrating
+	AllowedAlgorithms []string
+	// RefreshTokenTTL a refresh token expires in it since it was issued, each refresh issues a new one
+	RefreshTokenTTL time.Duration
+	// CookieMode the tokens are set as Secure HttpOnly cookies instead of being written in the response body
+	CookieMode bool
+	// CookieSameSite SameSite of the cookies
+	CookieSameSite http.SameSite
+	// CookieDomain Domain of the cookies, the host of the api when it is blank
+	CookieDomain string
+}
+
+type PasswordConfig struct {
+	// Algorithm hashes new passwords, the hashes of the other algorithms are rehashed by it on login
+	Algorithm string
+	// Argon2id the parameters of argon2id, hashes of other parameters are rehashed on login
+	Argon2id account.Argon2idParams
+	// BcryptCost hashes of other costs are rehashed on login
+	BcryptCost int
+	// Policy new passwords must follow it
+	Policy *account.PasswordPolicy
+}
+
+type LoginConfig struct {
+	// Enabled the failed logins are throttled and the users are locked out
+	Enabled bool
+	// Policy thresholds of the throttling and the lockout
+	Policy *authentication.LoginAttemptPolicy
 }
 
 type DynamodbConfig struct {
 	DummyTableName string
+	// StateTableName table of the auth state and the jobs, apart from the dummy items and their stream
+	StateTableName string
+	// DummySoftDeleteRetention keeps deleted dummy items restorable for it, 0 deletes them at once
+	DummySoftDeleteRetention time.Duration
+}
+
+type EventConfig struct {
+	// LocalFile events of the outbox are published to it by local runs
+	LocalFile     string
+	RelayInterval time.Duration
+	// BusName EventBridge bus the outbox is relayed to by lambdas
+	BusName string
+	// DummyImportQueue name of the sqs queue of the dummy items to import, empty to disable
+	DummyImportQueue string
+	// DummyImportUser the service user owning the imported items
+	DummyImportUser string
+	// DummyRelayRule name of the schedule rule relaying the outbox, empty to disable
+	DummyRelayRule string
+}
+
+type JobConfig struct {
+	// QueueName sqs queue of the jobs, local runs use a channel
+	QueueName string
+	// Retention jobs are purged after it since they were submitted
+	Retention time.Duration
+	// ClaimTTL a running job is run again by a redelivery after it since it reported its progress
+	ClaimTTL time.Duration
+}
+
+type CacheConfig struct {
+	Enabled     bool
+	Size        int
+	TTL         time.Duration
+	NegativeTTL time.Duration
 }
 
 func NewAppConfig() (*Config, error) {
@@ -47,21 +160,355 @@ func NewAppConfig() (*Config, error) {
 		MinLevel:  os.Getenv("LOG_MIN_LEVEL"),
 		CrNewline: os.Getenv("LOG_CR_NEWLINE") == "true",
 	}
+	authConfig, err := newAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+	passwordConfig, err := newPasswordConfig()
+	if err != nil {
+		return nil, err
+	}
+	loginConfig, err := newLoginConfig()
+	if err != nil {
+		return nil, err
+	}
+	dynamodbConfig, err := newDynamodbConfig()
+	if err != nil {
+		return nil, err
+	}
+	cacheConfig, err := newCacheConfig()
+	if err != nil {
+		return nil, err
+	}
+	eventConfig, err := newEventConfig()
+	if err != nil {
+		return nil, err
+	}
+	jobConfig, err := newJobConfig()
+	if err != nil {
+		return nil, err
+	}
+	repositoryDriver := os.Getenv("REPOSITORY_DRIVER")
+	if repositoryDriver == "" {
+		repositoryDriver = RepositoryDriverDynamodb
+	}
+	appConfig := Config{
+		Appcode:          os.Getenv("APPCODE"),
+		Variant:          os.Getenv("VARIANT"),
+		Stage:            os.Getenv("STAGE"),
+		RepositoryDriver: repositoryDriver,
+		AwsEnvCfg:        awsEnvConfig,
+		LogCfg:           logConfig,
+		AuthCfg:          authConfig,
+		PasswordCfg:      passwordConfig,
+		LoginCfg:         loginConfig,
+		DynamodbCfg:      dynamodbConfig,
+		CacheCfg:         cacheConfig,
+		EventCfg:         eventConfig,
+		JobCfg:           jobConfig,
+	}
+	return &appConfig, nil
+}
+
+// newAuthConfig
+// a blank value keeps the default
+//
+//	@return *AuthConfig
+//	@return error
+func newAuthConfig() (*AuthConfig, error) {
 	authConfig := &AuthConfig{
-		PublicKey:  os.Getenv("JWT_PUBLIC_KEY"),
-		PrivateKey: os.Getenv("JWT_PRIVATE_KEY"),
+		PublicKey:       os.Getenv("JWT_PUBLIC_KEY"),
+		PrivateKey:      os.Getenv("JWT_PRIVATE_KEY"),
+		KeyCacheTTL:     defaultJwtKeyCacheTTL,
+		Issuer:          os.Getenv("JWT_ISSUER"),
+		Audience:        os.Getenv("JWT_AUDIENCE"),
+		Leeway:          defaultJwtLeeway,
+		Algorithm:       defaultJwtAlgorithm,
+		RefreshTokenTTL: defaultRefreshTokenTTL,
+		CookieMode:      os.Getenv("JWT_COOKIE_MODE") == "true",
+		CookieSameSite:  http.SameSiteStrictMode,
+		CookieDomain:    os.Getenv("JWT_COOKIE_DOMAIN"),
+	}
+	if value := os.Getenv("JWT_KEY_CACHE_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return nil, errors.Errorf("invalid JWT_KEY_CACHE_TTL: %s", value)
+		}
+		authConfig.KeyCacheTTL = ttl
+	}
+	if value := os.Getenv("JWT_LEEWAY"); value != "" {
+		leeway, err := time.ParseDuration(value)
+		if err != nil || leeway < 0 {
+			return nil, errors.Errorf("invalid JWT_LEEWAY: %s", value)
+		}
+		authConfig.Leeway = leeway
+	}
+	if value := os.Getenv("JWT_LEGACY_UNTIL"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.Errorf("invalid JWT_LEGACY_UNTIL: %s", value)
+		}
+		authConfig.LegacyUntil = until
+	}
+	if value := os.Getenv("JWT_ALGORITHM"); value != "" {
+		if !authentication.IsSupportedAlgorithm(value) {
+			return nil, errors.Errorf("invalid JWT_ALGORITHM: %s", value)
+		}
+		authConfig.Algorithm = value
+	}
+	if value := os.Getenv("JWT_REFRESH_TOKEN_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return nil, errors.Errorf("invalid JWT_REFRESH_TOKEN_TTL: %s", value)
+		}
+		authConfig.RefreshTokenTTL = ttl
+	}
+	if value := os.Getenv("JWT_COOKIE_SAME_SITE"); value != "" {
+		sameSite, ok := cookieSameSites[strings.ToLower(value)]
+		if !ok {
+			return nil, errors.Errorf("invalid JWT_COOKIE_SAME_SITE: %s", value)
+		}
+		authConfig.CookieSameSite = sameSite
+	}
+	authConfig.AllowedAlgorithms = []string{authConfig.Algorithm}
+	if value := os.Getenv("JWT_ALLOWED_ALGORITHMS"); value != "" {
+		authConfig.AllowedAlgorithms = []string{}
+		for _, alg := range strings.Split(value, ",") {
+			alg = strings.TrimSpace(alg)
+			if !authentication.IsSupportedAlgorithm(alg) {
+				return nil, errors.Errorf("invalid JWT_ALLOWED_ALGORITHMS: %s", value)
+			}
+			authConfig.AllowedAlgorithms = append(authConfig.AllowedAlgorithms, alg)
+		}
+	}
+	return authConfig, nil
+}
+
+// newPasswordConfig
+// a blank value keeps the default
+//
+//	@return *PasswordConfig
+//	@return error
+func newPasswordConfig() (*PasswordConfig, error) {
+	passwordConfig := &PasswordConfig{
+		Algorithm:  account.PasswordAlgorithmArgon2id,
+		Argon2id:   account.DefaultArgon2idParams(),
+		BcryptCost: bcrypt.DefaultCost,
+		Policy:     account.DefaultPasswordPolicy(),
+	}
+	if value := os.Getenv("PASSWORD_HASH_ALGORITHM"); value != "" {
+		if value != account.PasswordAlgorithmArgon2id && value != account.PasswordAlgorithmBcrypt {
+			return nil, errors.Errorf("invalid PASSWORD_HASH_ALGORITHM: %s", value)
+		}
+		passwordConfig.Algorithm = value
+	}
+	uint32Envs := map[string]*uint32{
+		"PASSWORD_ARGON2ID_MEMORY":     &passwordConfig.Argon2id.Memory,
+		"PASSWORD_ARGON2ID_ITERATIONS": &passwordConfig.Argon2id.Iterations,
+	}
+	for name, field := range uint32Envs {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || parsed == 0 {
+				return nil, errors.Errorf("invalid %s: %s", name, value)
+			}
+			*field = uint32(parsed)
+		}
+	}
+	if value := os.Getenv("PASSWORD_ARGON2ID_PARALLELISM"); value != "" {
+		parallelism, err := strconv.ParseUint(value, 10, 8)
+		if err != nil || parallelism == 0 {
+			return nil, errors.Errorf("invalid PASSWORD_ARGON2ID_PARALLELISM: %s", value)
+		}
+		passwordConfig.Argon2id.Parallelism = uint8(parallelism)
 	}
+	if value := os.Getenv("PASSWORD_BCRYPT_COST"); value != "" {
+		cost, err := strconv.Atoi(value)
+		if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, errors.Errorf("invalid PASSWORD_BCRYPT_COST: %s", value)
+		}
+		passwordConfig.BcryptCost = cost
+	}
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		length, err := strconv.Atoi(value)
+		if err != nil || length < 1 {
+			return nil, errors.Errorf("invalid PASSWORD_MIN_LENGTH: %s", value)
+		}
+		passwordConfig.Policy.MinLength = length
+	}
+	if value := os.Getenv("PASSWORD_MAX_LENGTH"); value != "" {
+		length, err := strconv.Atoi(value)
+		if err != nil || length < passwordConfig.Policy.MinLength {
+			return nil, errors.Errorf("invalid PASSWORD_MAX_LENGTH: %s", value)
+		}
+		passwordConfig.Policy.MaxLength = length
+	}
+	if value := os.Getenv("PASSWORD_REQUIRED_CLASSES"); value != "" {
+		for _, class := range strings.Split(value, ",") {
+			class = strings.TrimSpace(class)
+			if !account.IsPasswordClass(class) {
+				return nil, errors.Errorf("invalid PASSWORD_REQUIRED_CLASSES: %s", value)
+			}
+			passwordConfig.Policy.RequiredClasses = append(passwordConfig.Policy.RequiredClasses, class)
+		}
+	}
+	return passwordConfig, nil
+}
+
+// newLoginConfig
+// a blank value keeps the default, a threshold of 0 disables its rule
+//
+//	@return *LoginConfig
+//	@return error
+func newLoginConfig() (*LoginConfig, error) {
+	loginConfig := &LoginConfig{
+		Enabled: os.Getenv("LOGIN_GUARD_ENABLED") != "false",
+		Policy:  authentication.DefaultLoginAttemptPolicy(),
+	}
+	intEnvs := map[string]*int{
+		"LOGIN_DELAY_THRESHOLD":   &loginConfig.Policy.DelayThreshold,
+		"LOGIN_LOCKOUT_THRESHOLD": &loginConfig.Policy.LockoutThreshold,
+		"LOGIN_IP_THRESHOLD":      &loginConfig.Policy.IPThreshold,
+	}
+	for name, field := range intEnvs {
+		if value := os.Getenv(name); value != "" {
+			threshold, err := strconv.Atoi(value)
+			if err != nil || threshold < 0 {
+				return nil, errors.Errorf("invalid %s: %s", name, value)
+			}
+			*field = threshold
+		}
+	}
+	durationEnvs := map[string]*time.Duration{
+		"LOGIN_BASE_DELAY":       &loginConfig.Policy.BaseDelay,
+		"LOGIN_MAX_DELAY":        &loginConfig.Policy.MaxDelay,
+		"LOGIN_LOCKOUT_DURATION": &loginConfig.Policy.LockoutDuration,
+		"LOGIN_WINDOW":           &loginConfig.Policy.Window,
+	}
+	for name, field := range durationEnvs {
+		if value := os.Getenv(name); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration <= 0 {
+				return nil, errors.Errorf("invalid %s: %s", name, value)
+			}
+			*field = duration
+		}
+	}
+	if loginConfig.Policy.MaxDelay < loginConfig.Policy.BaseDelay {
+		return nil, errors.Errorf("invalid LOGIN_MAX_DELAY: %s, it is less than the base delay",
+			loginConfig.Policy.MaxDelay)
+	}
+	return loginConfig, nil
+}
+
+// newDynamodbConfig
+// a blank retention disables soft delete, a blank state table keeps the state in the dummy table
+//
+//	@return *DynamodbConfig
+//	@return error
+func newDynamodbConfig() (*DynamodbConfig, error) {
 	dynamodbConfig := &DynamodbConfig{
 		DummyTableName: os.Getenv("DUMMY_TABLE_NAME"),
+		StateTableName: os.Getenv("STATE_TABLE_NAME"),
 	}
-	appConfig := Config{
-		Appcode:     os.Getenv("APPCODE"),
-		Variant:     os.Getenv("VARIANT"),
-		Stage:       os.Getenv("STAGE"),
-		AwsEnvCfg:   awsEnvConfig,
-		LogCfg:      logConfig,
-		AuthCfg:     authConfig,
-		DynamodbCfg: dynamodbConfig,
+	if dynamodbConfig.StateTableName == "" {
+		dynamodbConfig.StateTableName = dynamodbConfig.DummyTableName
 	}
-	return &appConfig, nil
+	if value := os.Getenv("DUMMY_SOFT_DELETE_RETENTION"); value != "" {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention < 0 {
+			return nil, errors.Errorf("invalid DUMMY_SOFT_DELETE_RETENTION: %s", value)
+		}
+		dynamodbConfig.DummySoftDeleteRetention = retention
+	}
+	return dynamodbConfig, nil
+}
+
+// newCacheConfig
+// a blank value keeps the default
+//
+//	@return *CacheConfig
+//	@return error
+func newCacheConfig() (*CacheConfig, error) {
+	cacheConfig := &CacheConfig{
+		Enabled:     os.Getenv("CACHE_ENABLED") == "true",
+		Size:        defaultCacheSize,
+		TTL:         defaultCacheTTL,
+		NegativeTTL: defaultCacheNegativeTTL,
+	}
+	var err error
+	if value := os.Getenv("CACHE_SIZE"); value != "" {
+		cacheConfig.Size, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.Errorf("invalid CACHE_SIZE: %s", value)
+		}
+	}
+	if value := os.Getenv("CACHE_TTL"); value != "" {
+		cacheConfig.TTL, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.Errorf("invalid CACHE_TTL: %s", value)
+		}
+	}
+	if value := os.Getenv("CACHE_NEGATIVE_TTL"); value != "" {
+		cacheConfig.NegativeTTL, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.Errorf("invalid CACHE_NEGATIVE_TTL: %s", value)
+		}
+	}
+	return cacheConfig, nil
+}
+
+// newEventConfig
+// a blank value keeps the default
+//
+//	@return *EventConfig
+//	@return error
+func newEventConfig() (*EventConfig, error) {
+	eventConfig := &EventConfig{
+		LocalFile:        os.Getenv("EVENT_LOCAL_FILE"),
+		RelayInterval:    defaultEventRelayInterval,
+		BusName:          os.Getenv("EVENT_BUS_NAME"),
+		DummyImportQueue: os.Getenv("DUMMY_IMPORT_QUEUE_NAME"),
+		DummyImportUser:  defaultDummyImportUser,
+		DummyRelayRule:   os.Getenv("DUMMY_RELAY_RULE_NAME"),
+	}
+	if value := os.Getenv("DUMMY_IMPORT_USER_ID"); value != "" {
+		eventConfig.DummyImportUser = value
+	}
+	if value := os.Getenv("EVENT_RELAY_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return nil, errors.Errorf("invalid EVENT_RELAY_INTERVAL: %s", value)
+		}
+		eventConfig.RelayInterval = interval
+	}
+	return eventConfig, nil
+}
+
+// newJobConfig
+// a blank value keeps the default
+//
+//	@return *JobConfig
+//	@return error
+func newJobConfig() (*JobConfig, error) {
+	jobConfig := &JobConfig{
+		QueueName: os.Getenv("JOB_QUEUE_NAME"),
+		Retention: defaultJobRetention,
+		ClaimTTL:  defaultJobClaimTTL,
+	}
+	if value := os.Getenv("JOB_RETENTION"); value != "" {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention <= 0 {
+			return nil, errors.Errorf("invalid JOB_RETENTION: %s", value)
+		}
+		jobConfig.Retention = retention
+	}
+	if value := os.Getenv("JOB_CLAIM_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return nil, errors.Errorf("invalid JOB_CLAIM_TTL: %s", value)
+		}
+		jobConfig.ClaimTTL = ttl
+	}
+	return jobConfig, nil
 }
@@ -1,9 +1,16 @@
 package controller
 
 import (
+	"context"
 	nativeerr "errors"
+	"fmt"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"local.com/go-clean-lambda/internal/logger"
@@ -13,21 +20,36 @@ import (
 )
 
 const (
-	AuthIndexApp         int = iota // index: 0
-	AuthIndexAppDummy               // index: 1
-	AuthIndexAppDummyNew            // index: 2
-	AuthIndexAppPing                // index: 3
+	AuthIndexApp           int = iota // index: 0
+	AuthIndexAppDummy                 // index: 1
+	AuthIndexAppDummyNew              // index: 2
+	AuthIndexAppPing                  // index: 3
+	AuthIndexAppDummyAdmin            // index: 4
 )
 
-var ErrInvalidUserIDOrPassword error = nativeerr.New("invalid user id or password")
+// jwksMaxAge verifiers may cache the keys for it, new keys must be published longer than it before they sign.
+const jwksMaxAge int = 300
+
+var (
+	ErrInvalidUserIDOrPassword error = nativeerr.New("invalid user id or password")
+	ErrTooManyLoginAttempts    error = nativeerr.New("too many login attempts")
+	// ErrInvalidGrant error type of rejected credentials, as the error code of OAuth 2.0
+	ErrInvalidGrant error = nativeerr.New("invalid_grant")
+)
 
 // AuthController
 // works as extends MuxControllerImpl.
 type AuthController struct {
 	*MuxControllerImpl
-	jwtClient  authentication.AuthJwtClient
-	roleClient authorization.RoleClient
-	userClient account.UserClient
+	jwtClient     authentication.AuthJwtClient
+	refreshClient authentication.RefreshTokenClient
+	sessionClient authentication.SessionClient
+	roleClient    authorization.RoleClient
+	userClient    account.UserClient
+	// cookie carries the tokens by cookies when it is set
+	cookie *AuthCookie
+	// loginGuard throttles the failed logins when it is set
+	loginGuard authentication.LoginGuard
 }
 
 // NewAuthController
@@ -35,6 +57,8 @@ type AuthController struct {
 //	@param logMdf
 //	@param authMdf
 //	@param jwtClient
+//	@param refreshClient
+//	@param sessionClient
 //	@param roleClient
 //	@param userClient
 //	@return *AuthController
@@ -42,6 +66,8 @@ func NewAuthController(
 	logMdf mux.MiddlewareFunc,
 	authMdf mux.MiddlewareFunc,
 	jwtClient authentication.AuthJwtClient,
+	refreshClient authentication.RefreshTokenClient,
+	sessionClient authentication.SessionClient,
 	roleClient authorization.RoleClient,
 	userClient account.UserClient,
 ) *AuthController {
@@ -50,9 +76,11 @@ func NewAuthController(
 			"/auth",
 			make(map[string]map[string]*MuxRouterHandler),
 		),
-		jwtClient:  jwtClient,
-		userClient: userClient,
-		roleClient: roleClient,
+		jwtClient:     jwtClient,
+		refreshClient: refreshClient,
+		sessionClient: sessionClient,
+		userClient:    userClient,
+		roleClient:    roleClient,
 	}
 	c.AddMuxRouter("/login", []string{
 		http.MethodPost,
@@ -61,6 +89,13 @@ func NewAuthController(
 	}, func(w http.ResponseWriter, r *http.Request) error {
 		return c.login(w, r)
 	})
+	c.AddMuxRouter("/refresh", []string{
+		http.MethodPost,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		return c.refresh(w, r)
+	})
 	c.AddMuxRouter("/logout", []string{
 		http.MethodPost,
 	}, []mux.MiddlewareFunc{
@@ -69,6 +104,31 @@ func NewAuthController(
 	}, func(w http.ResponseWriter, r *http.Request) error {
 		return c.logout(w, r)
 	})
+	c.AddMuxRouter("/sessions", []string{
+		http.MethodGet,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		return c.listSessions(w, r)
+	})
+	c.AddMuxRouter("/sessions", []string{
+		http.MethodDelete,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		return c.revokeAllSessions(w, r)
+	})
+	c.AddMuxRouter("/sessions/{id}", []string{
+		http.MethodDelete,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		vars := mux.Vars(r)
+		return c.revokeSession(w, r, vars["id"])
+	})
 	c.AddMuxRouter("/register", []string{
 		http.MethodPost,
 	}, []mux.MiddlewareFunc{
@@ -76,10 +136,41 @@ func NewAuthController(
 	}, func(w http.ResponseWriter, r *http.Request) error {
 		return c.register(w, r)
 	})
+	c.AddMuxRouter("/.well-known/jwks.json", []string{
+		http.MethodGet,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		return c.jwks(w, r)
+	})
+	return c
+}
+
+// WithCookie
+// the tokens are set as cookies instead of being written in the body
+//
+//	@receiver c
+//	@param cookie
+//	@return *AuthController
+func (c *AuthController) WithCookie(cookie *AuthCookie) *AuthController {
+	c.cookie = cookie
+	return c
+}
+
+// WithLoginGuard
+// the failed logins are delayed and the users are locked out by it
+//
+//	@receiver c
+//	@param loginGuard
+//	@return *AuthController
+func (c *AuthController) WithLoginGuard(loginGuard authentication.LoginGuard) *AuthController {
+	c.loginGuard = loginGuard
 	return c
 }
 
 // login
+// a wrong user id or password is answered by 401, a throttled login by 429 and a locked out user by 423,
+// both with Retry-After
 //
 // curl -X POST {host}/auth/login -d "userId={xxx}&password={xxx}"
 //
@@ -91,40 +182,105 @@ func (c *AuthController) login(w http.ResponseWriter, r *http.Request) error {
 	userID := r.FormValue("userId")
 	password := r.FormValue("password")
 	ctx := r.Context()
+	ip := sourceIP(r)
+	// the attempt is counted as a failure before the password is verified, and forgotten when it is right
+	var attempt *authentication.LoginAttempt
+	if c.loginGuard != nil {
+		guarded, retryAfter, err := c.loginGuard.Attempt(ctx, userID, ip)
+		if err != nil {
+			return c.rejectLogin(w, retryAfter, err)
+		}
+		attempt = guarded
+	}
 	verifed, err := c.userClient.VerifyPassword(ctx, userID, password)
 	if err != nil {
-		return errors.Wrapf(err, "failed to verify password. user_id: %s, password: %s", userID, password)
+		return errors.Wrapf(err, "failed to verify password. user_id: %s", userID)
 	}
 	if !verifed {
+		if c.loginGuard != nil {
+			err = c.loginGuard.Fail(ctx, attempt)
+			if err != nil {
+				return errors.Wrapf(err, "failed to record login failure. user_id: %s, ip: %s", userID, ip)
+			}
+		}
 		errMsg := ErrInvalidUserIDOrPassword.Error()
-		logger.Info("%s. user_id: %s, password: %s", errMsg, userID, password)
-		return c.WriteResponse(w, errMsg)
+		logger.Info("%s. user_id: %s, ip: %s", errMsg, userID, ip)
+		return c.WriteErrorResponse(w, http.StatusUnauthorized, ErrInvalidGrant.Error(), errMsg)
 	}
-	indices, err := c.roleClient.ListGrantedIndices(ctx, userID)
-	if err != nil {
-		return errors.Wrapf(err, "failed to list granted permissions. user_id: %s", userID)
+	if c.loginGuard != nil {
+		err = c.loginGuard.Succeed(ctx, attempt)
+		if err != nil {
+			return errors.Wrapf(err, "failed to reset login failures. user_id: %s, ip: %s", userID, ip)
+		}
+	}
+	return c.startSession(w, r, userID)
+}
+
+// rejectLogin
+//
+//	@receiver c
+//	@param w
+//	@param retryAfter
+//	@param err of LoginGuard.Attempt
+//	@return error
+func (c *AuthController) rejectLogin(w http.ResponseWriter, retryAfter time.Duration, err error) error {
+	status := http.StatusTooManyRequests
+	switch {
+	case errors.Is(err, authentication.ErrLoginLocked):
+		status = http.StatusLocked
+	case !errors.Is(err, authentication.ErrLoginThrottled):
+		return errors.Wrap(err, "failed to check login attempts")
+	}
+	logger.Info("login is rejected. %s", err.Error())
+	// Retry-After is in whole seconds, round it up to never invite a retry too early
+	w.Header().Set("Retry-After", strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+	return c.WriteErrorResponse(w, status, ErrTooManyLoginAttempts.Error(), errors.Cause(err).Error())
+}
+
+// refresh
+// the refresh token is rotated, a used one revokes every token rotated from the same login
+//
+// curl -X POST {host}/auth/refresh -d "refreshToken={xxx}"
+// curl -X POST {host}/auth/refresh -b "refresh_token={xxx}; csrf_token={xxx}" -H "X-CSRF-Token: {xxx}"
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@return error
+func (c *AuthController) refresh(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	presented := r.FormValue("refreshToken")
+	if presented == "" && c.cookie != nil {
+		presented = c.cookie.RefreshToken(r)
+		// the cookie is sent by browsers whoever asks, as in the login middleware
+		if presented != "" {
+			if err := c.cookie.VerifyCSRF(r); err != nil {
+				logger.Info("refresh is rejected. error: %s", err.Error())
+				return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), ErrInvalidCSRFToken.Error())
+			}
+		}
+	}
+	refreshToken, token, err := c.refreshClient.Rotate(ctx, presented)
+	if errors.Is(err, authentication.ErrInvalidRefreshToken) || errors.Is(err, authentication.ErrReusedRefreshToken) {
+		logger.Info("refresh token is rejected. error: %s", err.Error())
+		return c.WriteErrorResponse(w, http.StatusUnauthorized, ErrUnauthenticated.Error(), "invalid refresh token")
 	}
-	bit, err := c.roleClient.GetPermissionBit(ctx, indices, userID == account.UserRootID)
 	if err != nil {
-		return errors.Wrapf(err, "failed to grant permission bit. user_id: %s", userID)
+		return errors.Wrap(err, "failed to rotate refresh token")
+	}
+	jwt, claim, err := c.issueJwt(ctx, token.UserID, token.FamilyID)
+	if errors.Is(err, account.ErrInvalidUserID) {
+		logger.Info("user of refresh token is not found. user_id: %s", token.UserID)
+		return c.WriteErrorResponse(w, http.StatusUnauthorized, ErrUnauthenticated.Error(), "invalid refresh token")
 	}
-	user, err := c.userClient.GetUser(ctx, userID)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get user info. user_id: %s", userID)
+		return err
 	}
-	jwt, err := c.jwtClient.Issue(ctx, &authentication.AuthJwtClaim{
-		User: &authentication.UserContext{
-			UserID:        userID,
-			UserName:      user.Name,
-			Locale:        "en",
-			ZoneID:        "Asia/Tokyo",
-			PermissionBit: bit,
-		},
-	})
+	err = c.saveSession(ctx, r, token, jwt, claim)
 	if err != nil {
-		return errors.Wrapf(err, "failed to issue jwt. user_id: %s", userID)
+		return err
 	}
-	return c.WriteResponse(w, jwt)
+	return c.writeTokenResponse(w, jwt, claim, refreshToken, token)
 }
 
 // logout
@@ -136,16 +292,104 @@ func (c *AuthController) login(w http.ResponseWriter, r *http.Request) error {
 //	@param r
 //	@return error
 func (c *AuthController) logout(w http.ResponseWriter, r *http.Request) error {
-	jwth := r.Header.Get(authentication.JwtHeader)
-	jwt := jwth[len(authentication.JwtHeaderPrefix):]
+	jwt := c.accessToken(r)
 	ctx := r.Context()
 	err := c.jwtClient.Block(ctx, jwt)
 	if err != nil {
 		return errors.Wrapf(err, "failed to logout. jwt: %s", jwt)
 	}
+	// the session of the token is ended with its refresh tokens
+	userID, _ := ctx.Value(authentication.UserIDKey).(string)
+	if sessionID, ok := ctx.Value(authentication.SessionIDKey).(string); ok {
+		err = c.sessionClient.Revoke(ctx, userID, sessionID)
+		if err != nil && !errors.Is(err, authentication.ErrSessionNotFound) {
+			return errors.Wrapf(err, "failed to revoke session. user_id: %s, id: %s", userID, sessionID)
+		}
+	}
+	// the refresh token is optional, it is revoked with the tokens rotated from it
+	refreshToken := r.FormValue("refreshToken")
+	if refreshToken == "" && c.cookie != nil {
+		refreshToken = c.cookie.RefreshToken(r)
+	}
+	if refreshToken != "" {
+		err = c.refreshClient.Revoke(ctx, refreshToken)
+		if err != nil && !errors.Is(err, authentication.ErrInvalidRefreshToken) {
+			return errors.Wrap(err, "failed to revoke refresh token")
+		}
+	}
+	if c.cookie != nil {
+		c.cookie.Clear(w)
+	}
 	return c.WriteResponse(w, "logout done")
 }
 
+// listSessions
+// the sessions of the caller, the one of the presented token is marked as current
+//
+// curl -X GET {host}/auth/sessions -H "Authorization: Bearer {jwt}"
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@return error
+func (c *AuthController) listSessions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	userID, _ := ctx.Value(authentication.UserIDKey).(string)
+	currentID, _ := ctx.Value(authentication.SessionIDKey).(string)
+	sessions, err := c.sessionClient.List(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list sessions. user_id: %s", userID)
+	}
+	res := make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, toSessionResponse(session, currentID))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return c.WriteResponse(w, logger.Pretty(res))
+}
+
+// revokeSession
+// the access token and the refresh tokens of the session are revoked at once
+//
+// curl -X DELETE {host}/auth/sessions/{id} -H "Authorization: Bearer {jwt}"
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@param id
+//	@return error
+func (c *AuthController) revokeSession(w http.ResponseWriter, r *http.Request, id string) error {
+	ctx := r.Context()
+	userID, _ := ctx.Value(authentication.UserIDKey).(string)
+	err := c.sessionClient.Revoke(ctx, userID, id)
+	if errors.Is(err, authentication.ErrSessionNotFound) {
+		return c.WriteErrorResponse(w, http.StatusNotFound, ErrObjectNotFound.Error(), fmt.Sprintf("session id: %s", id))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke session. user_id: %s, id: %s", userID, id)
+	}
+	return c.WriteResponse(w, "session revoked")
+}
+
+// revokeAllSessions
+// logout everywhere, every token issued to the caller so far is revoked
+//
+// curl -X DELETE {host}/auth/sessions -H "Authorization: Bearer {jwt}"
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@return error
+func (c *AuthController) revokeAllSessions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	userID, _ := ctx.Value(authentication.UserIDKey).(string)
+	err := c.sessionClient.RevokeAll(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke all sessions. user_id: %s", userID)
+	}
+	return c.WriteResponse(w, "all sessions revoked")
+}
+
 // register
 //
 // curl -X POST {host}/auth/register -d "userId={xxx}&name={xxx}&password={xxx}"
@@ -164,27 +408,230 @@ func (c *AuthController) register(w http.ResponseWriter, r *http.Request) error
 		Name:   userName,
 	}
 	err := c.userClient.RegisterUser(ctx, user, password)
+	// the reasons of an invalid password are told, so the user can choose another one
+	if errors.Is(err, account.ErrInvalidPassword) || errors.Is(err, account.ErrInvalidUserInfo) {
+		logger.Info("invalid user to register. user_id: %s, error: %s", userID, err.Error())
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(), err.Error())
+	}
 	if err != nil {
 		return errors.Wrapf(err, "failed to register new user: %s", logger.Pretty(user))
 	}
+	// a registered user is logged in at once
+	return c.startSession(w, r, userID)
+}
+
+// startSession
+// issue the tokens of a new session of the user
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@param userID
+//	@return error
+func (c *AuthController) startSession(w http.ResponseWriter, r *http.Request, userID string) error {
+	ctx := r.Context()
+	// the family of the refresh tokens is the id of the session
+	refreshToken, token, err := c.refreshClient.Issue(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to issue refresh token. user_id: %s", userID)
+	}
+	jwt, claim, err := c.issueJwt(ctx, userID, token.FamilyID)
+	if err != nil {
+		return err
+	}
+	err = c.saveSession(ctx, r, token, jwt, claim)
+	if err != nil {
+		return err
+	}
+	return c.writeTokenResponse(w, jwt, claim, refreshToken, token)
+}
+
+// issueJwt
+// the permissions and the name of the user are read again whenever a token is issued
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@param sessionID
+//	@return string
+//	@return *authentication.AuthJwtClaim the claim of the issued token
+//	@return error account.ErrInvalidUserID when the user is not found, and others
+func (c *AuthController) issueJwt(
+	ctx context.Context,
+	userID string,
+	sessionID string,
+) (string, *authentication.AuthJwtClaim, error) {
 	indices, err := c.roleClient.ListGrantedIndices(ctx, userID)
 	if err != nil {
-		return errors.Wrapf(err, "failed to list granted permissions. user_id: %s", userID)
+		return "", nil, errors.Wrapf(err, "failed to list granted permissions. user_id: %s", userID)
 	}
 	bit, err := c.roleClient.GetPermissionBit(ctx, indices, userID == account.UserRootID)
 	if err != nil {
-		return errors.Wrapf(err, "failed to grant permission bit. user_id: %s", userID)
+		return "", nil, errors.Wrapf(err, "failed to grant permission bit. user_id: %s", userID)
+	}
+	user, err := c.userClient.GetUser(ctx, userID)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to get user info. user_id: %s", userID)
 	}
-	jwt, err := c.jwtClient.Issue(ctx, &authentication.AuthJwtClaim{
+	if user == nil {
+		return "", nil, errors.Wrapf(account.ErrInvalidUserID, "user not found. user_id: %s", userID)
+	}
+	claim := &authentication.AuthJwtClaim{
 		User: &authentication.UserContext{
 			UserID:        userID,
+			UserName:      user.Name,
 			Locale:        "en",
 			ZoneID:        "Asia/Tokyo",
 			PermissionBit: bit,
 		},
-	})
+		SessionID: sessionID,
+	}
+	jwt, err := c.jwtClient.Issue(ctx, claim)
 	if err != nil {
-		return errors.Wrapf(err, "failed to issue jwt. user_id: %s", userID)
+		return "", nil, errors.Wrapf(err, "failed to issue jwt. user_id: %s", userID)
+	}
+	return jwt, claim, nil
+}
+
+// saveSession
+// the session is created by login and updated by every refresh, it keeps the latest access token to revoke it
+//
+//	@receiver c
+//	@param ctx
+//	@param r
+//	@param token the issued or rotated refresh token
+//	@param jwt
+//	@param claim the claim of jwt
+//	@return error
+func (c *AuthController) saveSession(
+	ctx context.Context,
+	r *http.Request,
+	token *authentication.RefreshToken,
+	jwt string,
+	claim *authentication.AuthJwtClaim,
+) error {
+	session, err := c.sessionClient.Get(ctx, token.UserID, token.FamilyID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		session = &authentication.Session{
+			ID:       token.FamilyID,
+			UserID:   token.UserID,
+			IssuedAt: token.IssuedAt,
+		}
+	}
+	session.Device = r.UserAgent()
+	session.IP = sourceIP(r)
+	session.RefreshedAt = token.IssuedAt
+	session.ExpiresAt = token.ExpiresAt
+	session.TokenID = claim.ID
+	session.TokenKey = authentication.BlocklistKey(jwt)
+	session.TokenExpiresAt = claim.ExpiresTime()
+	return c.sessionClient.Save(ctx, session)
+}
+
+// writeTokenResponse
+// tokens must never be cached. in the cookie mode they are set as cookies, and only the csrf token is written
+//
+//	@receiver c
+//	@param w
+//	@param jwt
+//	@param claim the claim of jwt
+//	@param refreshToken
+//	@param token the saved refreshToken
+//	@return error
+func (c *AuthController) writeTokenResponse(
+	w http.ResponseWriter,
+	jwt string,
+	claim *authentication.AuthJwtClaim,
+	refreshToken string,
+	token *authentication.RefreshToken,
+) error {
+	res := &TokenResponse{
+		TokenType: TokenTypeBearer,
+		ExpiresIn: int(authentication.ExpireDuration.Seconds()),
+	}
+	if c.cookie != nil {
+		csrfToken, err := c.cookie.SetTokens(w, jwt, claim.ExpiresTime(), refreshToken, token.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		res.CSRFToken = csrfToken
+	} else {
+		res.AccessToken = jwt
+		res.RefreshToken = refreshToken
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	return c.WriteResponse(w, logger.Pretty(res))
+}
+
+// accessToken
+//
+//	@receiver c
+//	@param r
+//	@return string the token verified by the middleware
+func (c *AuthController) accessToken(r *http.Request) string {
+	jwth := r.Header.Get(authentication.JwtHeader)
+	if strings.HasPrefix(jwth, authentication.JwtHeaderPrefix) {
+		return jwth[len(authentication.JwtHeaderPrefix):]
+	}
+	if c.cookie != nil {
+		return c.cookie.AccessToken(r)
+	}
+	return ""
+}
+
+// jwks
+// publish the public keys which verify the issued tokens
+//
+// curl -X GET {host}/auth/.well-known/jwks.json
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@return error
+func (c *AuthController) jwks(w http.ResponseWriter, r *http.Request) error {
+	set, err := c.jwtClient.JWKS(r.Context())
+	if err != nil {
+		return errors.Wrap(err, "failed to get jwks")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", jwksMaxAge))
+	return c.WriteResponse(w, logger.Pretty(set))
+}
+
+// sourceIP
+// the source ip seen by API Gateway, or the remote address when it is served locally
+//
+//	@param r
+//	@return string
+func sourceIP(r *http.Request) string {
+	if apiCtx, ok := core.GetAPIGatewayContextFromContext(r.Context()); ok && apiCtx.Identity.SourceIP != "" {
+		return apiCtx.Identity.SourceIP
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// toSessionResponse
+//
+//	@param session
+//	@param currentID the session of the caller
+//	@return *SessionResponse
+func toSessionResponse(session *authentication.Session, currentID string) *SessionResponse {
+	return &SessionResponse{
+		ID:          session.ID,
+		Device:      session.Device,
+		IP:          session.IP,
+		IssuedAt:    session.IssuedAt,
+		RefreshedAt: session.RefreshedAt,
+		ExpiresAt:   session.ExpiresAt,
+		Current:     session.ID == currentID,
 	}
-	return c.WriteResponse(w, jwt)
 }
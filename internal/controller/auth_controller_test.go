@@ -0,0 +1,80 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/controller"
+	"local.com/go-clean-lambda/internal/sdk/account"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestAuthControllerWithWrongPasswordRejectLoginAsInvalidGrant(t *testing.T) {
+	noop := mux.MiddlewareFunc(func(next http.Handler) http.Handler { return next })
+	authController := controller.NewAuthController(noop, noop, &jwtClientStub{}, nil, nil, nil,
+		account.NewUserDummmyClient())
+	router := controller.NewRouter([]controller.MuxController{authController})
+	form := url.Values{"userId": {account.User01ID}, "password": {"wrong password"}}
+	r := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	msg := "wrong password is not rejected"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusUnauthorized, w.Code, msg, "status")
+	errResp := &controller.ErrorResponse{}
+	assertions.Nil(json.Unmarshal(w.Body.Bytes(), errResp), msg, "body")
+	assertions.Equal(controller.ErrInvalidGrant.Error(), errResp.ErrorType, msg, "error type")
+	assertions.Equal(controller.ErrInvalidUserIDOrPassword.Error(), errResp.ErrorMessage, msg, "message")
+}
+
+func TestAuthControllerWithWrongPasswordsRejectLoginWithRetryAfter(t *testing.T) {
+	noop := mux.MiddlewareFunc(func(next http.Handler) http.Handler { return next })
+	guard := authentication.NewLoginAttemptGuard(authentication.NewLoginAttemptMemoryStore(),
+		&authentication.LoginAttemptPolicy{
+			LockoutThreshold: 2,
+			LockoutDuration:  15 * time.Minute,
+			IPThreshold:      3,
+			Window:           10 * time.Minute,
+		})
+	authController := controller.NewAuthController(noop, noop, &jwtClientStub{}, nil, nil, nil,
+		account.NewUserDummmyClient()).WithLoginGuard(guard)
+	router := controller.NewRouter([]controller.MuxController{authController})
+	login := func(userID string) *httptest.ResponseRecorder {
+		form := url.Values{"userId": {userID}, "password": {"wrong password"}}
+		r := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w1 := login(account.User01ID)
+	w2 := login(account.User01ID)
+	w3 := login(account.User01ID)
+	w4 := login(account.User012ID)
+	w5 := login(account.User012ID)
+
+	msg := "wrong passwords are not throttled"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusUnauthorized, w1.Code, msg, "status of first failure")
+	errResp := &controller.ErrorResponse{}
+	assertions.Nil(json.Unmarshal(w1.Body.Bytes(), errResp), msg, "body of first failure")
+	assertions.Equal(controller.ErrInvalidGrant.Error(), errResp.ErrorType, msg, "error type of first failure")
+	assertions.Equal(controller.ErrInvalidUserIDOrPassword.Error(), errResp.ErrorMessage, msg, "message of first failure")
+	assertions.Equal(http.StatusUnauthorized, w2.Code, msg, "status of second failure")
+	assertions.Equal(http.StatusLocked, w3.Code, msg, "status of locked user")
+	assertions.Equal("900", w3.Header().Get("Retry-After"), msg, "Retry-After of locked user")
+	assertions.Equal(http.StatusUnauthorized, w4.Code, msg, "other user is locked")
+	assertions.Equal(http.StatusTooManyRequests, w5.Code, msg, "status of blocked ip")
+	assertions.Equal("600", w5.Header().Get("Retry-After"), msg, "Retry-After of blocked ip")
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	nativeerr "errors"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AccessTokenCookie  string = "access_token"
+	RefreshTokenCookie string = "refresh_token"
+	// CSRFTokenCookie readable by scripts, which send it back in CSRFTokenHeader
+	CSRFTokenCookie string = "csrf_token"
+	CSRFTokenHeader string = "X-CSRF-Token"
+	// csrfTokenSize bytes of a csrf token
+	csrfTokenSize int = 32
+)
+
+var ErrInvalidCSRFToken error = nativeerr.New("invalid csrf token")
+
+// AuthCookie
+// carries the tokens by Secure HttpOnly cookies instead of the response body and the Authorization header.
+// requests authenticated by the cookies are protected from CSRF by the double-submit of a csrf token.
+type AuthCookie struct {
+	sameSite http.SameSite
+	// domain of the cookies, the host of the request when it is blank
+	domain string
+}
+
+// NewAuthCookie
+//
+//	@param sameSite
+//	@param domain
+//	@return *AuthCookie
+func NewAuthCookie(sameSite http.SameSite, domain string) *AuthCookie {
+	return &AuthCookie{
+		sameSite: sameSite,
+		domain:   domain,
+	}
+}
+
+// SetTokens
+// a new csrf token is set with the tokens
+//
+//	@receiver c
+//	@param w
+//	@param accessToken
+//	@param accessExpiresAt
+//	@param refreshToken
+//	@param refreshExpiresAt
+//	@return string the csrf token
+//	@return error
+func (c *AuthCookie) SetTokens(
+	w http.ResponseWriter,
+	accessToken string,
+	accessExpiresAt time.Time,
+	refreshToken string,
+	refreshExpiresAt time.Time,
+) (string, error) {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, c.newCookie(AccessTokenCookie, accessToken, accessExpiresAt, true))
+	http.SetCookie(w, c.newCookie(RefreshTokenCookie, refreshToken, refreshExpiresAt, true))
+	// the csrf token lives as long as the refresh token, as it is kept by refresh
+	http.SetCookie(w, c.newCookie(CSRFTokenCookie, csrfToken, refreshExpiresAt, false))
+	return csrfToken, nil
+}
+
+// Clear
+// expire every cookie of the tokens
+//
+//	@receiver c
+//	@param w
+func (c *AuthCookie) Clear(w http.ResponseWriter) {
+	for _, name := range []string{AccessTokenCookie, RefreshTokenCookie, CSRFTokenCookie} {
+		cookie := c.newCookie(name, "", time.Unix(0, 0), name != CSRFTokenCookie)
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+// AccessToken
+//
+//	@receiver c
+//	@param r
+//	@return string blank when there is no cookie
+func (c *AuthCookie) AccessToken(r *http.Request) string {
+	return cookieValue(r, AccessTokenCookie)
+}
+
+// RefreshToken
+//
+//	@receiver c
+//	@param r
+//	@return string blank when there is no cookie
+func (c *AuthCookie) RefreshToken(r *http.Request) string {
+	return cookieValue(r, RefreshTokenCookie)
+}
+
+// VerifyCSRF
+// unsafe methods must send the csrf token of the cookie in the header too, other sites can't read it to do so
+//
+//	@receiver c
+//	@param r
+//	@return error ErrInvalidCSRFToken
+func (c *AuthCookie) VerifyCSRF(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+	cookieToken := cookieValue(r, CSRFTokenCookie)
+	headerToken := r.Header.Get(CSRFTokenHeader)
+	if cookieToken == "" || headerToken == "" {
+		return errors.Wrap(ErrInvalidCSRFToken, "no csrf token found")
+	}
+	if subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+		return errors.Wrap(ErrInvalidCSRFToken, "csrf token of header doesn't match cookie")
+	}
+	return nil
+}
+
+// newCookie
+//
+//	@receiver c
+//	@param name
+//	@param value
+//	@param expiresAt
+//	@param httpOnly
+//	@return *http.Cookie
+func (c *AuthCookie) newCookie(name string, value string, expiresAt time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   c.domain,
+		Expires:  expiresAt,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: c.sameSite,
+	}
+}
+
+// cookieValue
+//
+//	@param r
+//	@param name
+//	@return string blank when there is no cookie
+func cookieValue(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// newCSRFToken
+//
+//	@return string
+//	@return error
+func newCSRFToken() (string, error) {
+	buf := make([]byte, csrfTokenSize)
+	_, err := rand.Read(buf)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return "", errors.Wrap(rootErr, "failed to generate csrf token")
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,106 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/controller"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+const testJwt string = "header.payload.signature"
+
+func TestLoginAccessMiddlewareWithCookieRequireCSRFTokenOnUnsafeMethods(t *testing.T) {
+	cookie := controller.NewAuthCookie(http.SameSiteStrictMode, "")
+	handler := controller.GetLoginAccessMiddleware(&jwtClientStub{}, cookie)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	newRequest := func(method string, csrfHeader string) *http.Request {
+		r := httptest.NewRequest(method, "/api/dummy", nil)
+		r.AddCookie(&http.Cookie{Name: controller.AccessTokenCookie, Value: testJwt})
+		r.AddCookie(&http.Cookie{Name: controller.CSRFTokenCookie, Value: "csrf_1"})
+		if csrfHeader != "" {
+			r.Header.Set(controller.CSRFTokenHeader, csrfHeader)
+		}
+		return r
+	}
+	bearer := httptest.NewRequest(http.MethodPost, "/api/dummy", nil)
+	bearer.Header.Set(authentication.JwtHeader, authentication.JwtHeaderPrefix+testJwt)
+
+	statuses := map[string]int{}
+	for name, r := range map[string]*http.Request{
+		"get":       newRequest(http.MethodGet, ""),
+		"no header": newRequest(http.MethodPost, ""),
+		"mismatch":  newRequest(http.MethodDelete, "csrf_2"),
+		"match":     newRequest(http.MethodPost, "csrf_1"),
+		"bearer":    bearer,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		statuses[name] = w.Code
+	}
+
+	msg := "csrf token of cookie mode is not checked"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusOK, statuses["get"], msg, "safe method is rejected")
+	assertions.Equal(http.StatusForbidden, statuses["no header"], msg, "no csrf header is accepted")
+	assertions.Equal(http.StatusForbidden, statuses["mismatch"], msg, "other csrf token is accepted")
+	assertions.Equal(http.StatusOK, statuses["match"], msg, "csrf token of cookie is rejected")
+	assertions.Equal(http.StatusOK, statuses["bearer"], msg, "authorization header requires csrf token")
+}
+
+func TestAuthCookieWithSetTokensSetSecureCookies(t *testing.T) {
+	cookie := controller.NewAuthCookie(http.SameSiteLaxMode, "example.com")
+	w := httptest.NewRecorder()
+	expiresAt := time.Now().Add(time.Hour)
+
+	csrfToken, err := cookie.SetTokens(w, testJwt, expiresAt, "refresh_1", expiresAt)
+
+	msg := "cookies of tokens are not secure"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found set error")
+	cookies := map[string]*http.Cookie{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	assertions.Len(cookies, 3, msg, "cookies")
+	for _, name := range []string{controller.AccessTokenCookie, controller.RefreshTokenCookie} {
+		assertions.True(cookies[name].HttpOnly, msg, "%s is not HttpOnly", name)
+	}
+	for name, c := range cookies {
+		assertions.True(c.Secure, msg, "%s is not Secure", name)
+		assertions.Equal(http.SameSiteLaxMode, c.SameSite, msg, "SameSite of %s", name)
+		assertions.Equal("example.com", c.Domain, msg, "Domain of %s", name)
+	}
+	assertions.Equal(testJwt, cookies[controller.AccessTokenCookie].Value, msg, "access token")
+	assertions.False(cookies[controller.CSRFTokenCookie].HttpOnly, msg, "csrf token is not readable by scripts")
+	assertions.Equal(csrfToken, cookies[controller.CSRFTokenCookie].Value, msg, "csrf token")
+}
+
+// jwtClientStub
+// verifies testJwt only.
+type jwtClientStub struct{}
+
+func (c *jwtClientStub) Issue(ctx context.Context, claim *authentication.AuthJwtClaim) (string, error) {
+	return testJwt, nil
+}
+
+func (c *jwtClientStub) Block(ctx context.Context, tokenStr string) error {
+	return nil
+}
+
+func (c *jwtClientStub) Verify(ctx context.Context, tokenStr string) (*authentication.AuthJwtClaim, error) {
+	if tokenStr != testJwt {
+		return nil, authentication.ErrInvalidJwt
+	}
+	return &authentication.AuthJwtClaim{User: &authentication.UserContext{UserID: "user_1"}}, nil
+}
+
+func (c *jwtClientStub) JWKS(ctx context.Context) (*authentication.JSONWebKeySet, error) {
+	return &authentication.JSONWebKeySet{}, nil
+}
@@ -1,50 +1,87 @@
 package controller
 
 import (
+	"encoding/json"
 	nativeerr "errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
 	"local.com/go-clean-lambda/internal/logger"
 	"local.com/go-clean-lambda/internal/usecase"
 )
 
-var ErrObjectNotFound error = nativeerr.New("object not found")
+// maxBatchBodyBytes limit of the body of a bulk request.
+const maxBatchBodyBytes int64 = 4 << 20
+
+var (
+	ErrObjectNotFound error = nativeerr.New("object not found")
+	ErrInvalidRequest error = nativeerr.New("invalid request")
+)
+
+// DummyRequest
+// one item of a bulk create request.
+type DummyRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Attr string `json:"attr"`
+}
 
 // DummyController
 // works as extends MuxControllerImpl.
 type DummyController struct {
 	*MuxControllerImpl
-	usecase *usecase.DummyUseCase
+	usecase    *usecase.DummyUseCase
+	jobUsecase *usecase.JobUseCase
 }
 
 // NewDummyController
 //
 //	@param logMdf
+//	@param authMdf sets the caller checked by the usecase
 //	@param usecase
+//	@param jobUsecase submits import jobs
 //	@return *DummyController
-func NewDummyController(logMdf mux.MiddlewareFunc, usecase *usecase.DummyUseCase) *DummyController {
+func NewDummyController(
+	logMdf mux.MiddlewareFunc,
+	authMdf mux.MiddlewareFunc,
+	usecase *usecase.DummyUseCase,
+	jobUsecase *usecase.JobUseCase,
+) *DummyController {
 	c := &DummyController{
 		MuxControllerImpl: NewMuxControllerImpl(
 			"/api/dummy",
 			make(map[string]map[string]*MuxRouterHandler),
 		),
-		usecase: usecase,
+		usecase:    usecase,
+		jobUsecase: jobUsecase,
 	}
 	c.AddMuxRouter("/{id}", []string{
 		http.MethodGet,
 	}, []mux.MiddlewareFunc{
 		logMdf,
+		authMdf,
 	}, func(w http.ResponseWriter, r *http.Request) error {
 		vars := mux.Vars(r)
 		return c.handleGet(w, r, vars["id"])
 	})
+	c.AddMuxRouter("/{id}/history", []string{
+		http.MethodGet,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		vars := mux.Vars(r)
+		return c.handleGetHistory(w, r, vars["id"])
+	})
 	c.AddMuxRouter("", []string{
 		http.MethodPost,
 	}, []mux.MiddlewareFunc{
 		logMdf,
+		authMdf,
 	}, func(w http.ResponseWriter, r *http.Request) error {
 		return c.handlePost(w, r)
 	})
@@ -52,10 +89,44 @@ func NewDummyController(logMdf mux.MiddlewareFunc, usecase *usecase.DummyUseCase
 		http.MethodDelete,
 	}, []mux.MiddlewareFunc{
 		logMdf,
+		authMdf,
 	}, func(w http.ResponseWriter, r *http.Request) error {
 		vars := mux.Vars(r)
 		return c.handleDelete(w, r, vars["id"])
 	})
+	c.AddMuxRouter("/{id}:restore", []string{
+		http.MethodPost,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		vars := mux.Vars(r)
+		return c.handleRestore(w, r, vars["id"])
+	})
+	c.AddMuxRouter(":batch", []string{
+		http.MethodPost,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		return c.handleBatchPost(w, r)
+	})
+	c.AddMuxRouter(":batch", []string{
+		http.MethodDelete,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		return c.handleBatchDelete(w, r)
+	})
+	c.AddMuxRouter(":import", []string{
+		http.MethodPost,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		return c.handleImport(w, r)
+	})
 	return c
 }
 
@@ -68,6 +139,9 @@ func NewDummyController(logMdf mux.MiddlewareFunc, usecase *usecase.DummyUseCase
 //	@return error
 func (c *DummyController) handleGet(w http.ResponseWriter, r *http.Request, id string) error {
 	logger.Debug("get by id: %s", id)
+	if asOf := r.URL.Query().Get("asOf"); len(asOf) > 0 {
+		return c.handleGetAsOf(w, r, id, asOf)
+	}
 	bo, err := c.usecase.Get(r.Context(), id)
 	if err != nil {
 		return errors.Wrap(err, "get item error")
@@ -77,6 +151,55 @@ func (c *DummyController) handleGet(w http.ResponseWriter, r *http.Request, id s
 	return c.WriteResponse(w, s)
 }
 
+// handleGetAsOf
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@param id
+//	@param asOf RFC3339 time
+//	@return error
+func (c *DummyController) handleGetAsOf(w http.ResponseWriter, r *http.Request, id string, asOf string) error {
+	t, err := time.Parse(time.RFC3339Nano, asOf)
+	if err != nil {
+		logger.Info("invalid asOf. id: %s, asOf: %s", id, asOf)
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(), fmt.Sprintf("asOf: %s", asOf))
+	}
+	bo, err := c.usecase.GetAsOf(r.Context(), id, t)
+	if errors.Is(err, usecase.ErrForbidden) {
+		logger.Info("get deleted item as of time forbidden. id: %s", id)
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), fmt.Sprintf("id: %s", id))
+	}
+	if err != nil {
+		return errors.Wrap(err, "get item as of time error")
+	}
+	s := fmt.Sprintf("handle get. id: %s, asOf: %s, got bo: %s", id, asOf, logger.Pretty(bo))
+	logger.Info(s)
+	return c.WriteResponse(w, s)
+}
+
+// handleGetHistory
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@param id
+//	@return error
+func (c *DummyController) handleGetHistory(w http.ResponseWriter, r *http.Request, id string) error {
+	logger.Debug("get history by id: %s", id)
+	bos, err := c.usecase.ListHistory(r.Context(), id)
+	if errors.Is(err, usecase.ErrForbidden) {
+		logger.Info("list deleted item history forbidden. id: %s", id)
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), fmt.Sprintf("id: %s", id))
+	}
+	if err != nil {
+		return errors.Wrap(err, "list item history error")
+	}
+	s := logger.Pretty(bos)
+	logger.Debug("handle get history. id: %s, bos: %s", id, s)
+	return c.WriteResponse(w, s)
+}
+
 // handlePost
 //
 //	@receiver c
@@ -93,6 +216,10 @@ func (c *DummyController) handlePost(w http.ResponseWriter, r *http.Request) err
 		Name: name,
 		Attr: attr,
 	})
+	if errors.Is(err, usecase.ErrForbidden) {
+		logger.Info("add item forbidden. id: %s", id)
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), fmt.Sprintf("id: %s", id))
+	}
 	if err != nil {
 		return errors.Wrap(err, "add item error")
 	}
@@ -111,13 +238,215 @@ func (c *DummyController) handlePost(w http.ResponseWriter, r *http.Request) err
 func (c *DummyController) handleDelete(w http.ResponseWriter, r *http.Request, id string) error {
 	logger.Debug("delete by id: %s", id)
 	bo, err := c.usecase.Remove(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && bo == nil) {
+		logger.Info("no item to delete. id: %s", id)
+		return c.WriteErrorResponse(w, http.StatusNotFound, ErrObjectNotFound.Error(), fmt.Sprintf("id: %s", id))
+	}
+	if errors.Is(err, usecase.ErrForbidden) {
+		logger.Info("remove item forbidden. id: %s", id)
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), fmt.Sprintf("id: %s", id))
+	}
 	if err != nil {
 		return errors.Wrap(err, "remove item error")
 	}
-	if bo == nil {
-		return errors.Wrapf(ErrObjectNotFound, "id: %s", id)
+	s := logger.Pretty(bo)
+	logger.Debug("handle delete. bo: %s", s)
+	return c.WriteResponse(w, s)
+}
+
+// handleRestore
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@param id
+//	@return error
+func (c *DummyController) handleRestore(w http.ResponseWriter, r *http.Request, id string) error {
+	logger.Debug("restore by id: %s", id)
+	bo, err := c.usecase.RestoreDeleted(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && bo == nil) {
+		logger.Info("no deleted item to restore. id: %s", id)
+		return c.WriteErrorResponse(w, http.StatusNotFound, ErrObjectNotFound.Error(), fmt.Sprintf("id: %s", id))
+	}
+	if errors.Is(err, usecase.ErrForbidden) {
+		logger.Info("restore item forbidden. id: %s", id)
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), fmt.Sprintf("id: %s", id))
+	}
+	if err != nil {
+		return errors.Wrap(err, "restore item error")
 	}
-	s := fmt.Sprintf("handle delete. id: %s", id)
-	logger.Debug(s)
+	s := logger.Pretty(bo)
+	logger.Debug("handle restore. bo: %s", s)
 	return c.WriteResponse(w, s)
 }
+
+// handleBatchPost
+// the body is a json array of DummyRequest, the response is multi-status with one result per item
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@return error
+func (c *DummyController) handleBatchPost(w http.ResponseWriter, r *http.Request) error {
+	reqs := []*DummyRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&reqs)
+	if err != nil {
+		logger.Info("invalid batch post body. %s", err.Error())
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(), "body must be a json array of items")
+	}
+	bos := make([]*usecase.DummyBo, len(reqs))
+	for i, req := range reqs {
+		if req != nil {
+			bos[i] = &usecase.DummyBo{ID: req.ID, Name: req.Name, Attr: req.Attr}
+		}
+	}
+	logger.Debug("handle batch post. items: %d", len(bos))
+	results, err := c.usecase.AddBatch(r.Context(), bos)
+	if errors.Is(err, usecase.ErrInvalidInput) {
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(),
+			fmt.Sprintf("items: %d, max: %d", len(bos), usecase.MaxBatchSize))
+	}
+	if errors.Is(err, usecase.ErrForbidden) {
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), "no authenticated user")
+	}
+	if err != nil {
+		return errors.Wrap(err, "add batch error")
+	}
+	return c.writeBatchResponse(w, results, http.StatusCreated)
+}
+
+// handleBatchDelete
+// the body is a json array of ids, the response is multi-status with one result per id
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@return error
+func (c *DummyController) handleBatchDelete(w http.ResponseWriter, r *http.Request) error {
+	ids := []string{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&ids)
+	if err != nil {
+		logger.Info("invalid batch delete body. %s", err.Error())
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(), "body must be a json array of ids")
+	}
+	logger.Debug("handle batch delete. ids: %d", len(ids))
+	results, err := c.usecase.RemoveBatch(r.Context(), ids)
+	if errors.Is(err, usecase.ErrInvalidInput) {
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(),
+			fmt.Sprintf("ids: %d, max: %d", len(ids), usecase.MaxBatchSize))
+	}
+	if errors.Is(err, usecase.ErrForbidden) {
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), "no authenticated user")
+	}
+	if err != nil {
+		return errors.Wrap(err, "remove batch error")
+	}
+	return c.writeBatchResponse(w, results, http.StatusOK)
+}
+
+// handleImport
+// the body is a json array of DummyRequest like a bulk create of any size, the items are added by a job
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@return error
+func (c *DummyController) handleImport(w http.ResponseWriter, r *http.Request) error {
+	reqs := []*DummyRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&reqs)
+	if err != nil || len(reqs) == 0 {
+		logger.Info("invalid import body. %v", err)
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(), "body must be a json array of items")
+	}
+	bos := make([]*usecase.DummyBo, len(reqs))
+	for i, req := range reqs {
+		if req != nil {
+			bos[i] = &usecase.DummyBo{ID: req.ID, Name: req.Name, Attr: req.Attr}
+		}
+	}
+	bo, err := c.jobUsecase.Submit(r.Context(), usecase.JobTypeDummyImport, bos)
+	if errors.Is(err, usecase.ErrInvalidInput) {
+		return c.WriteErrorResponse(w, http.StatusBadRequest, ErrInvalidRequest.Error(), "too many items to import")
+	}
+	if errors.Is(err, usecase.ErrForbidden) {
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), "no authenticated user")
+	}
+	if err != nil {
+		return errors.Wrap(err, "submit import job error")
+	}
+	logger.Info("handle import. items: %d, job: %s", len(bos), bo.ID)
+	return WriteJobAccepted(c.MuxControllerImpl, w, bo)
+}
+
+// writeBatchResponse
+//
+//	@receiver c
+//	@param w
+//	@param results
+//	@param successStatus status of a succeeded item
+//	@return error
+func (c *DummyController) writeBatchResponse(
+	w http.ResponseWriter,
+	results []*usecase.DummyBatchResultBo,
+	successStatus int,
+) error {
+	resp := &BatchResponse{
+		Results: make([]*BatchItemResponse, 0, len(results)),
+	}
+	for i, result := range results {
+		item := &BatchItemResponse{
+			Index:  i,
+			ID:     result.ID,
+			Status: successStatus,
+		}
+		if result.Bo != nil {
+			item.Item = result.Bo
+		}
+		if result.Err != nil {
+			item.Status, item.Error = batchItemError(result.Err)
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+	logger.Info("handle batch. succeeded: %d, failed: %d", resp.Succeeded, resp.Failed)
+	w.WriteHeader(http.StatusMultiStatus)
+	return c.WriteResponse(w, logger.Pretty(resp))
+}
+
+// batchItemError
+//
+//	@param err
+//	@return int http status of the item
+//	@return *ErrorResponse
+func batchItemError(err error) (int, *ErrorResponse) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInput):
+		return http.StatusBadRequest, &ErrorResponse{
+			ErrorType:    ErrInvalidRequest.Error(),
+			ErrorMessage: err.Error(),
+		}
+	case errors.Is(err, usecase.ErrForbidden):
+		return http.StatusForbidden, &ErrorResponse{
+			ErrorType:    ErrForbidden.Error(),
+			ErrorMessage: "only the owner or an admin may change the item",
+		}
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, &ErrorResponse{
+			ErrorType:    ErrObjectNotFound.Error(),
+			ErrorMessage: "no item of the id",
+		}
+	case errors.Is(err, domain.ErrBatchItemUnprocessed):
+		return http.StatusServiceUnavailable, &ErrorResponse{
+			ErrorType:    domain.ErrBatchItemUnprocessed.Error(),
+			ErrorMessage: "retry the item later",
+		}
+	default:
+		logger.Error("failed to process batch item.", err)
+		return http.StatusInternalServerError, &ErrorResponse{
+			ErrorType:    "server error",
+			ErrorMessage: "failed to process the item",
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/controller"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+	"local.com/go-clean-lambda/internal/usecase"
+)
+
+func TestDummyDeleteWithItemsReturnDeletedOrNotFound(t *testing.T) {
+	router := newDummyRouter("user_1", &domain.Dummy{ID: "id_1", Name: "name_1", CreatedBy: "user_1"})
+
+	w1 := serveDummy(router, http.MethodDelete, "/api/dummy/id_1", nil)
+	w2 := serveDummy(router, http.MethodDelete, "/api/dummy/id_1", nil)
+
+	msg := "failed to delete item"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusOK, w1.Code, msg, "status of deleted item")
+	deleted := &usecase.DummyBo{}
+	assertions.Nil(json.Unmarshal(w1.Body.Bytes(), deleted), msg, "body of deleted item")
+	assertions.Equal("id_1", deleted.ID, msg, "id of deleted item")
+	assertions.Equal("name_1", deleted.Name, msg, "name of deleted item")
+	assertions.Equal(http.StatusNotFound, w2.Code, msg, "status of missing item")
+	errResp := &controller.ErrorResponse{}
+	assertions.Nil(json.Unmarshal(w2.Body.Bytes(), errResp), msg, "body of missing item")
+	assertions.Equal(controller.ErrObjectNotFound.Error(), errResp.ErrorType, msg, "error type of missing item")
+}
+
+func TestDummyBatchDeleteWithMixedItemsReturnMultiStatus(t *testing.T) {
+	router := newDummyRouter("user_1",
+		&domain.Dummy{ID: "id_1", Name: "name_1", CreatedBy: "user_1"},
+		&domain.Dummy{ID: "id_2", Name: "name_2", CreatedBy: "user_2"})
+
+	w := serveDummy(router, http.MethodDelete, "/api/dummy:batch", []string{"id_1", "id_2", "id_3"})
+
+	msg := "wrong multi-status of batch delete"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusMultiStatus, w.Code, msg, "status")
+	resp := &controller.BatchResponse{}
+	assertions.Nil(json.Unmarshal(w.Body.Bytes(), resp), msg, "body")
+	assertions.Equal(1, resp.Succeeded, msg, "succeeded")
+	assertions.Equal(2, resp.Failed, msg, "failed")
+	assertions.Len(resp.Results, 3, msg, "result count")
+	expected := []struct {
+		id        string
+		status    int
+		errorType string
+	}{
+		{"id_1", http.StatusOK, ""},
+		{"id_2", http.StatusForbidden, controller.ErrForbidden.Error()},
+		{"id_3", http.StatusNotFound, controller.ErrObjectNotFound.Error()},
+	}
+	for i, result := range resp.Results {
+		assertions.Equal(i, result.Index, msg, "index")
+		assertions.Equal(expected[i].id, result.ID, msg, "id")
+		assertions.Equal(expected[i].status, result.Status, msg, "status of", result.ID)
+		if expected[i].errorType == "" {
+			assertions.Nil(result.Error, msg, "error of", result.ID)
+			continue
+		}
+		if assertions.NotNil(result.Error, msg, "error of", result.ID) {
+			assertions.Equal(expected[i].errorType, result.Error.ErrorType, msg, "error type of", result.ID)
+		}
+	}
+	w = serveDummy(router, http.MethodGet, "/api/dummy/id_2", nil)
+	assertions.Contains(w.Body.String(), "name_2", msg, "item of other owner removed")
+}
+
+func TestDummyBatchPostWithMixedItemsReturnMultiStatus(t *testing.T) {
+	router := newDummyRouter("user_1", &domain.Dummy{ID: "id_2", Name: "name_2", CreatedBy: "user_2"})
+
+	w := serveDummy(router, http.MethodPost, "/api/dummy:batch", []*controller.DummyRequest{
+		{ID: "id_1", Name: "name_1"},
+		{ID: "id_2", Name: "new_name_2"},
+		{ID: "id_3"},
+	})
+
+	msg := "wrong multi-status of batch post"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusMultiStatus, w.Code, msg, "status")
+	resp := &controller.BatchResponse{}
+	assertions.Nil(json.Unmarshal(w.Body.Bytes(), resp), msg, "body")
+	assertions.Equal(1, resp.Succeeded, msg, "succeeded")
+	assertions.Equal(2, resp.Failed, msg, "failed")
+	statuses := []int{}
+	for _, result := range resp.Results {
+		statuses = append(statuses, result.Status)
+	}
+	assertions.Equal([]int{http.StatusCreated, http.StatusForbidden, http.StatusBadRequest}, statuses, msg, "statuses")
+}
+
+func TestDummyPostWithSoftDeletedIDOfOtherUserReturnForbidden(t *testing.T) {
+	repo := repository.NewDummyMemoryRepo([]*domain.Dummy{{ID: "id_1", Name: "name_1", CreatedBy: "user_1"}}).
+		WithSoftDelete(time.Hour)
+	owner := newDummyRouterOf("user_1", repo)
+	other := newDummyRouterOf("user_2", repo)
+	w := serveDummy(owner, http.MethodDelete, "/api/dummy/id_1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("error happened when deleting item, status: %d", w.Code)
+	}
+
+	w1 := serveDummy(other, http.MethodPost, "/api/dummy?id=id_1&name=name_2", nil)
+	w2 := serveDummy(owner, http.MethodPost, "/api/dummy/id_1:restore", nil)
+
+	msg := "soft deleted item is taken over"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusForbidden, w1.Code, msg, "status of post by other user")
+	assertions.Equal(http.StatusOK, w2.Code, msg, "status of restore by owner")
+	assertions.Contains(w2.Body.String(), "name_1", msg, "body of restore by owner")
+}
+
+func TestDummyGetHistoryWithDeletedItemOfOtherUserReturnForbidden(t *testing.T) {
+	repo := repository.NewDummyMemoryRepo([]*domain.Dummy{{ID: "id_1", Name: "name_1", CreatedBy: "user_1"}}).
+		WithSoftDelete(time.Hour)
+	owner := newDummyRouterOf("user_1", repo)
+	other := newDummyRouterOf("user_2", repo)
+	w := serveDummy(owner, http.MethodDelete, "/api/dummy/id_1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("error happened when deleting item, status: %d", w.Code)
+	}
+	asOf := time.Now().UTC().Format(time.RFC3339Nano)
+
+	w1 := serveDummy(other, http.MethodGet, "/api/dummy/id_1/history", nil)
+	w2 := serveDummy(other, http.MethodGet, "/api/dummy/id_1?asOf="+asOf, nil)
+	w3 := serveDummy(owner, http.MethodGet, "/api/dummy/id_1/history", nil)
+
+	msg := "history of deleted item is read by other user"
+	assertions := assert.New(t)
+	assertions.Equal(http.StatusForbidden, w1.Code, msg, "status of history by other user")
+	assertions.Equal(http.StatusForbidden, w2.Code, msg, "status of as of time by other user")
+	assertions.Equal(http.StatusOK, w3.Code, msg, "status of history by owner")
+	assertions.Contains(w3.Body.String(), "name_1", msg, "body of history by owner")
+}
+
+// newDummyRouter
+//
+//	@param userID the caller of all requests
+//	@param entities
+//	@return *mux.Router
+func newDummyRouter(userID string, entities ...*domain.Dummy) *mux.Router {
+	return newDummyRouterOf(userID, repository.NewDummyMemoryRepo(entities))
+}
+
+// newDummyRouterOf
+//
+//	@param userID the caller of all requests
+//	@param repo
+//	@return *mux.Router
+func newDummyRouterOf(userID string, repo *repository.DummyMemoryRepo) *mux.Router {
+	noop := mux.MiddlewareFunc(func(next http.Handler) http.Handler { return next })
+	authMdf := mux.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), authentication.UserContextKey,
+				authentication.UserContext{UserID: userID})
+			ctx = context.WithValue(ctx, authentication.UserIDKey, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	uc := usecase.NewDummyUseCase(repo, repository.NewMemoryTransactor())
+	return controller.NewRouter([]controller.MuxController{controller.NewDummyController(noop, authMdf, uc, nil)})
+}
+
+// serveDummy
+//
+//	@param router
+//	@param method
+//	@param target
+//	@param body json body, none when it is nil
+//	@return *httptest.ResponseRecorder
+func serveDummy(router *mux.Router, method string, target string, body any) *httptest.ResponseRecorder {
+	data := []byte{}
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	r := httptest.NewRequest(method, target, bytes.NewReader(data))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+	"local.com/go-clean-lambda/internal/usecase"
+)
+
+// dummyImportUserAttr message attribute of the user importing the item, set by the former producers.
+// it is not trusted, any producer allowed by the queue could claim any user.
+const dummyImportUserAttr string = "userId"
+
+// DummyEventController
+// works as extends EventControllerImpl.
+type DummyEventController struct {
+	*EventControllerImpl
+	usecase    *usecase.DummyUseCase
+	relay      *usecase.DummyEventRelay
+	importUser string
+}
+
+// NewDummyEventController
+//
+//	@param usecase
+//	@param relay
+//	@param importQueue queue of the items to import, empty to disable
+//	@param importUser the service user importing the items, the owner of the new items
+//	@param relayRule schedule rule relaying the outbox, empty to disable
+//	@return *DummyEventController
+func NewDummyEventController(
+	usecase *usecase.DummyUseCase,
+	relay *usecase.DummyEventRelay,
+	importQueue string,
+	importUser string,
+	relayRule string,
+) *DummyEventController {
+	c := &DummyEventController{
+		EventControllerImpl: NewEventControllerImpl(),
+		usecase:             usecase,
+		relay:               relay,
+		importUser:          importUser,
+	}
+	c.AddSQSHandler(importQueue, c.handleImport)
+	c.AddScheduleHandler(relayRule, c.handleRelay)
+	for _, eventType := range []domain.DummyEventType{domain.DummyCreated, domain.DummyUpdated, domain.DummyDeleted} {
+		c.AddEventBridgeHandler(string(eventType), c.handleDummyEvent)
+	}
+	return c
+}
+
+// handleImport
+// a message is a DummyRequest added by the service user of the imports, so it cannot replace the items of the users.
+// the message attribute userId is ignored as the producers are not authenticated.
+// invalid messages are dropped as they never succeed.
+//
+//	@receiver c
+//	@param ctx
+//	@param message
+//	@return error
+func (c *DummyEventController) handleImport(ctx context.Context, message events.SQSMessage) error {
+	req := &DummyRequest{}
+	err := json.Unmarshal([]byte(message.Body), req)
+	if err != nil {
+		logger.Warn("drop invalid import message. message: %s, error: %s", message.MessageId, err.Error())
+		return nil
+	}
+	if userID := aws.StringValue(message.MessageAttributes[dummyImportUserAttr].StringValue); userID != "" {
+		logger.Warn("ignore user of import message. message: %s, user: %s", message.MessageId, userID)
+	}
+	ctx = context.WithValue(ctx, authentication.UserContextKey, authentication.UserContext{UserID: c.importUser})
+	ctx = context.WithValue(ctx, authentication.UserIDKey, c.importUser)
+	bo, err := c.usecase.Add(ctx, &usecase.DummyBo{
+		ID:   req.ID,
+		Name: req.Name,
+		Attr: req.Attr,
+	})
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInput), errors.Is(err, usecase.ErrForbidden):
+		logger.Warn("drop rejected import message. message: %s, error: %s", message.MessageId, err.Error())
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "failed to import. message: %s", message.MessageId)
+	}
+	logger.Info("imported. message: %s, id: %s", message.MessageId, bo.ID)
+	return nil
+}
+
+// handleRelay
+//
+//	@receiver c
+//	@param ctx
+//	@param event
+//	@return error
+func (c *DummyEventController) handleRelay(ctx context.Context, event events.CloudWatchEvent) error {
+	published, err := c.relay.Relay(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "relay stopped after %d events", published)
+	}
+	logger.Info("relayed dummy events. count: %d", published)
+	return nil
+}
+
+// handleDummyEvent
+// logs the published events of dummy items
+//
+//	@receiver c
+//	@param ctx
+//	@param event
+//	@return error
+func (c *DummyEventController) handleDummyEvent(ctx context.Context, event events.CloudWatchEvent) error {
+	dummyEvent := &domain.DummyEvent{}
+	err := json.Unmarshal(event.Detail, dummyEvent)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "unmarshal dummy event error. event: %s", event.ID)
+	}
+	logger.Info("dummy event. type: %s, id: %s, aggregate: %s, version: %d, actor: %s",
+		dummyEvent.Type, dummyEvent.ID, dummyEvent.AggregateID, dummyEvent.AggregateVersion, dummyEvent.Actor)
+	return nil
+}
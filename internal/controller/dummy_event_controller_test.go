@@ -0,0 +1,38 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/controller"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/usecase"
+)
+
+func TestDummyEventHandleImportWithUserAttrRunAsImportUser(t *testing.T) {
+	repo := repository.NewDummyMemoryRepo([]*domain.Dummy{{ID: "id_1", Name: "name_1", CreatedBy: "user_1"}})
+	c := controller.NewDummyEventController(usecase.NewDummyUseCase(repo, nil), nil, "test-queue", "importer", "")
+	r := controller.NewEventRouter([]controller.EventController{c})
+	claimed := newSQSMessage(testQueueArn, "m0", `{"id":"id_1","name":"name_2"}`)
+	claimed.MessageAttributes = map[string]events.SQSMessageAttribute{
+		"userId": {StringValue: aws.String("user_1"), DataType: "String"},
+	}
+
+	response, err := r.HandleSQS(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{
+		claimed,
+		newSQSMessage(testQueueArn, "m1", `{"id":"id_2","name":"name_2"}`),
+	}})
+
+	msg := "import message claims its user"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Empty(response.BatchItemFailures, msg, "batch item failures")
+	replaced, _ := repo.GetByID(context.TODO(), "id_1")
+	assertions.Equal("name_1", replaced.Name, msg, "item of user replaced")
+	imported, _ := repo.GetByID(context.TODO(), "id_2")
+	assertions.Equal("importer", imported.CreatedBy, msg, "owner of imported item")
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/repository"
+)
+
+// DummyStreamRecord
+// an item level change of a dummy entity decoded from a DynamoDB stream record.
+type DummyStreamRecord struct {
+	EventID string
+	// EventName INSERT, MODIFY or REMOVE of the item
+	EventName      string
+	SequenceNumber string
+	ApproximateAt  time.Time
+	// OldImage the entity before the change, nil when it did not exist or was soft deleted
+	OldImage *domain.Dummy
+	// NewImage the entity after the change, nil when it was removed or soft deleted
+	NewImage *domain.Dummy
+}
+
+// Change
+// the change of the entity seen by the readers, a soft delete is a removal and a restore is a creation
+//
+//	@receiver r
+//	@return domain.DummyChange
+func (r *DummyStreamRecord) Change() domain.DummyChange {
+	switch {
+	case r.OldImage == nil:
+		return domain.DummyChangeCreated
+	case r.NewImage == nil:
+		return domain.DummyChangeDeleted
+	default:
+		return domain.DummyChangeUpdated
+	}
+}
+
+// DummyStreamHandler
+// handles a change, a record is redelivered when a handler fails so handlers must be idempotent.
+type DummyStreamHandler func(ctx context.Context, record *DummyStreamRecord) error
+
+// DummyStreamController
+// decodes the records of the stream of the dummy table and dispatches them to the registered handlers.
+type DummyStreamController struct {
+	names    []string
+	handlers map[string]DummyStreamHandler
+}
+
+// NewDummyStreamController
+//
+//	@return *DummyStreamController
+func NewDummyStreamController() *DummyStreamController {
+	return &DummyStreamController{
+		names:    []string{},
+		handlers: make(map[string]DummyStreamHandler),
+	}
+}
+
+// Register
+// handlers are called in the order they are registered, registering a name again replaces its handler
+//
+//	@receiver c
+//	@param name used in logs
+//	@param handler
+//	@return *DummyStreamController
+func (c *DummyStreamController) Register(name string, handler DummyStreamHandler) *DummyStreamController {
+	if _, ok := c.handlers[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.handlers[name] = handler
+	return c
+}
+
+// Handle
+//
+// records of a shard are handled in order. the first record failing to decode or handle is reported as
+// a batch item failure and the records after it are not handled, the stream retries from that record.
+// records of history and outbox items and changes invisible to readers are skipped.
+// the function needs ReportBatchItemFailures in the event source mapping.
+//
+//	@receiver c
+//	@param ctx
+//	@param event
+//	@return events.DynamoDBEventResponse
+//	@return error
+func (c *DummyStreamController) Handle(
+	ctx context.Context,
+	event events.DynamoDBEvent,
+) (events.DynamoDBEventResponse, error) {
+	response := events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{},
+	}
+	for i, r := range event.Records {
+		err := c.handleRecord(ctx, r)
+		if err != nil {
+			logger.Error("failed to handle stream record. event: %s, sequence: %s, skipped: %d",
+				err, r.EventID, r.Change.SequenceNumber, len(event.Records)-i-1)
+			response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{
+				ItemIdentifier: r.Change.SequenceNumber,
+			})
+			return response, nil
+		}
+	}
+	return response, nil
+}
+
+// handleRecord
+//
+//	@receiver c
+//	@param ctx
+//	@param r
+//	@return error
+func (c *DummyStreamController) handleRecord(ctx context.Context, r events.DynamoDBEventRecord) error {
+	if !repository.IsDummyStreamKeys(r.Change.Keys) {
+		return nil
+	}
+	record, err := ToDummyStreamRecord(r)
+	if err != nil {
+		return err
+	}
+	if record.OldImage == nil && record.NewImage == nil {
+		// ttl purge of a soft deleted item or a change of a soft deleted item
+		logger.Debug("skip invisible stream record. event: %s", r.EventID)
+		return nil
+	}
+	for _, name := range c.names {
+		err = c.handlers[name](ctx, record)
+		if err != nil {
+			return errors.Wrapf(err, "handler: %s", name)
+		}
+	}
+	return nil
+}
+
+// ToDummyStreamRecord
+//
+//	@param r
+//	@return *DummyStreamRecord
+//	@return error
+func ToDummyStreamRecord(r events.DynamoDBEventRecord) (*DummyStreamRecord, error) {
+	oldImage, err := repository.ToDummyStreamEntity(r.Change.OldImage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode old image")
+	}
+	newImage, err := repository.ToDummyStreamEntity(r.Change.NewImage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode new image")
+	}
+	return &DummyStreamRecord{
+		EventID:        r.EventID,
+		EventName:      r.EventName,
+		SequenceNumber: r.Change.SequenceNumber,
+		ApproximateAt:  r.Change.ApproximateCreationDateTime.Time,
+		OldImage:       oldImage,
+		NewImage:       newImage,
+	}, nil
+}
+
+// GetDummyStreamLogHandler
+// logs the changes
+//
+//	@return DummyStreamHandler
+func GetDummyStreamLogHandler() DummyStreamHandler {
+	return func(ctx context.Context, record *DummyStreamRecord) error {
+		logger.Info("dummy %s. event: %s, old: %s, new: %s",
+			record.Change(), record.EventID, logger.Pretty(record.OldImage), logger.Pretty(record.NewImage))
+		return nil
+	}
+}
@@ -0,0 +1,217 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/controller"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+)
+
+var errBadHandle = errors.New("mocked handle error")
+
+func TestDummyStreamHandleWithChangesDecodeImages(t *testing.T) {
+	created := newStreamDummy("id_1", "created")
+	updated := newStreamDummy("id_1", "updated")
+	var handled []*controller.DummyStreamRecord
+	c := controller.NewDummyStreamController().
+		Register("record", func(ctx context.Context, record *controller.DummyStreamRecord) error {
+			handled = append(handled, record)
+			return nil
+		})
+
+	response, err := c.Handle(context.TODO(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		newStreamRecord(t, "1", nil, created),
+		newStreamRecord(t, "2", created, updated),
+		newStreamRecord(t, "3", updated, nil),
+	}})
+
+	msg := "failed to decode stream records"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Empty(response.BatchItemFailures, msg, "batch item failures")
+	assertions.Len(handled, 3, msg, "handled records")
+	assertions.Equal(domain.DummyChangeCreated, handled[0].Change(), msg, "change of insert")
+	assertions.Equal(created, handled[0].NewImage, msg, "new image of insert")
+	assertions.Equal(domain.DummyChangeUpdated, handled[1].Change(), msg, "change of modify")
+	assertions.Equal(created, handled[1].OldImage, msg, "old image of modify")
+	assertions.Equal(updated, handled[1].NewImage, msg, "new image of modify")
+	assertions.Equal(domain.DummyChangeDeleted, handled[2].Change(), msg, "change of remove")
+	assertions.Nil(handled[2].NewImage, msg, "new image of remove")
+}
+
+func TestDummyStreamHandleWithSoftDeleteDecodeAsRemoval(t *testing.T) {
+	dummy := newStreamDummy("id_1", "deleted")
+	live := toStreamImage(t, dummy, nil)
+	deleted := toStreamImage(t, dummy, map[string]*dynamodb.AttributeValue{
+		repository.FieldDummyDeletedAt: {S: aws.String(time.Now().UTC().Format(time.RFC3339Nano))},
+	})
+	var handled []*controller.DummyStreamRecord
+	c := controller.NewDummyStreamController().
+		Register("record", func(ctx context.Context, record *controller.DummyStreamRecord) error {
+			handled = append(handled, record)
+			return nil
+		})
+
+	response, err := c.Handle(context.TODO(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		newStreamImageRecord("1", live, deleted),
+		newStreamImageRecord("2", deleted, nil),
+		newStreamImageRecord("3", deleted, live),
+	}})
+
+	msg := "failed to decode soft delete"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Empty(response.BatchItemFailures, msg, "batch item failures")
+	assertions.Len(handled, 2, msg, "handled records, ttl purge skipped")
+	assertions.Equal(domain.DummyChangeDeleted, handled[0].Change(), msg, "change of soft delete")
+	assertions.Equal(domain.DummyChangeCreated, handled[1].Change(), msg, "change of restore")
+}
+
+func TestDummyStreamHandleWithOtherItemsSkip(t *testing.T) {
+	called := 0
+	c := controller.NewDummyStreamController().
+		Register("count", func(ctx context.Context, record *controller.DummyStreamRecord) error {
+			called++
+			return nil
+		})
+	record := newStreamImageRecord("1", nil, map[string]events.DynamoDBAttributeValue{
+		repository.FieldDummyPK: events.NewStringAttribute("dummy_outbox"),
+		repository.FieldDummySK: events.NewStringAttribute("2023-01-02T03:04:05.000000000Z#event_1"),
+	})
+
+	response, err := c.Handle(context.TODO(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{record}})
+
+	msg := "records of other items are not skipped"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Empty(response.BatchItemFailures, msg, "batch item failures")
+	assertions.Equal(0, called, msg, "handler called")
+}
+
+func TestDummyStreamHandleWithHandlerErrorReportFirstFailure(t *testing.T) {
+	handled := []string{}
+	c := controller.NewDummyStreamController().
+		Register("fail", func(ctx context.Context, record *controller.DummyStreamRecord) error {
+			if record.NewImage.ID == "id_2" {
+				return errBadHandle
+			}
+			handled = append(handled, record.NewImage.ID)
+			return nil
+		})
+	records := []events.DynamoDBEventRecord{}
+	for i := 0; i < 5; i++ {
+		records = append(records, newStreamRecord(t, fmt.Sprintf("%d", i), nil, newStreamDummy(fmt.Sprintf("id_%d", i), "name")))
+	}
+
+	response, err := c.Handle(context.TODO(), events.DynamoDBEvent{Records: records})
+
+	msg := "failed record is not reported"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal([]string{"id_0", "id_1"}, handled, msg, "handled records")
+	assertions.Equal([]events.DynamoDBBatchItemFailure{{ItemIdentifier: "2"}}, response.BatchItemFailures,
+		msg, "batch item failures")
+}
+
+func TestDummyStreamHandleWithInvalidImageReportFailure(t *testing.T) {
+	c := controller.NewDummyStreamController()
+	image := toStreamImage(t, newStreamDummy("id_1", "name"), map[string]*dynamodb.AttributeValue{
+		"name": {SS: aws.StringSlice([]string{"a", "b"})},
+	})
+
+	response, err := c.Handle(context.TODO(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		newStreamImageRecord("1", nil, image),
+	}})
+
+	msg := "invalid image is not reported"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal([]events.DynamoDBBatchItemFailure{{ItemIdentifier: "1"}}, response.BatchItemFailures,
+		msg, "batch item failures")
+}
+
+func newStreamDummy(id string, name string) *domain.Dummy {
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &domain.Dummy{
+		ID:        id,
+		Name:      name,
+		SomeAttr:  "attr",
+		CreatedAt: at,
+		CreatedBy: "user_1",
+		UpdatedAt: at,
+		UpdatedBy: "user_1",
+	}
+}
+
+func newStreamRecord(t *testing.T, sequence string, oldDummy *domain.Dummy, newDummy *domain.Dummy) events.DynamoDBEventRecord {
+	return newStreamImageRecord(sequence, toStreamImage(t, oldDummy, nil), toStreamImage(t, newDummy, nil))
+}
+
+func newStreamImageRecord(
+	sequence string,
+	oldImage map[string]events.DynamoDBAttributeValue,
+	newImage map[string]events.DynamoDBAttributeValue,
+) events.DynamoDBEventRecord {
+	eventName := string(events.DynamoDBOperationTypeModify)
+	keys := newImage
+	switch {
+	case oldImage == nil:
+		eventName = string(events.DynamoDBOperationTypeInsert)
+	case newImage == nil:
+		eventName = string(events.DynamoDBOperationTypeRemove)
+		keys = oldImage
+	}
+	return events.DynamoDBEventRecord{
+		EventID:   "event_" + sequence,
+		EventName: eventName,
+		Change: events.DynamoDBStreamRecord{
+			Keys: map[string]events.DynamoDBAttributeValue{
+				repository.FieldDummyPK: keys[repository.FieldDummyPK],
+				repository.FieldDummySK: keys[repository.FieldDummySK],
+			},
+			OldImage:       oldImage,
+			NewImage:       newImage,
+			SequenceNumber: sequence,
+		},
+	}
+}
+
+func toStreamImage(
+	t *testing.T,
+	dummy *domain.Dummy,
+	extra map[string]*dynamodb.AttributeValue,
+) map[string]events.DynamoDBAttributeValue {
+	if dummy == nil {
+		return nil
+	}
+	item, err := repository.ToDummyDBItem(dummy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range extra {
+		item[name] = value
+	}
+	image := make(map[string]events.DynamoDBAttributeValue, len(item))
+	for name, value := range item {
+		switch {
+		case value.S != nil:
+			image[name] = events.NewStringAttribute(aws.StringValue(value.S))
+		case value.N != nil:
+			image[name] = events.NewNumberAttribute(aws.StringValue(value.N))
+		case value.SS != nil:
+			image[name] = events.NewStringSetAttribute(aws.StringValueSlice(value.SS))
+		default:
+			t.Fatalf("unsupported attribute: %s", name)
+		}
+	}
+	return image
+}
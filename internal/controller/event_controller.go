@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SQSHandler
+// handles a message, a failed message is redelivered so handlers must be idempotent.
+type SQSHandler func(ctx context.Context, message events.SQSMessage) error
+
+// EventBridgeHandler
+// handles an EventBridge event, both of the events matched by a rule pattern and of the scheduled rules.
+type EventBridgeHandler func(ctx context.Context, event events.CloudWatchEvent) error
+
+// EventController
+// parallel to MuxController for the entries which are not http requests.
+type EventController interface {
+	// GetSQSHandlers
+	//  @return map queue name to handler
+	GetSQSHandlers() map[string]SQSHandler
+
+	// GetEventBridgeHandlers
+	//  @return map detail-type to handler
+	GetEventBridgeHandlers() map[string]EventBridgeHandler
+
+	// GetScheduleHandlers
+	//  @return map schedule rule name to handler
+	GetScheduleHandlers() map[string]EventBridgeHandler
+}
+
+// EventControllerImpl implements interface EventController.
+type EventControllerImpl struct {
+	sqsHandlers         map[string]SQSHandler
+	eventBridgeHandlers map[string]EventBridgeHandler
+	scheduleHandlers    map[string]EventBridgeHandler
+}
+
+// NewEventControllerImpl
+//
+//	@return *EventControllerImpl
+func NewEventControllerImpl() *EventControllerImpl {
+	return &EventControllerImpl{
+		sqsHandlers:         make(map[string]SQSHandler),
+		eventBridgeHandlers: make(map[string]EventBridgeHandler),
+		scheduleHandlers:    make(map[string]EventBridgeHandler),
+	}
+}
+
+// GetSQSHandlers
+//
+//	@receiver c
+//	@return map
+func (c *EventControllerImpl) GetSQSHandlers() map[string]SQSHandler {
+	return c.sqsHandlers
+}
+
+// GetEventBridgeHandlers
+//
+//	@receiver c
+//	@return map
+func (c *EventControllerImpl) GetEventBridgeHandlers() map[string]EventBridgeHandler {
+	return c.eventBridgeHandlers
+}
+
+// GetScheduleHandlers
+//
+//	@receiver c
+//	@return map
+func (c *EventControllerImpl) GetScheduleHandlers() map[string]EventBridgeHandler {
+	return c.scheduleHandlers
+}
+
+// AddSQSHandler
+// an empty queue name skips the handler, so optional queues can be left unconfigured
+//
+//	@receiver c
+//	@param queue name of the queue, not the url or arn
+//	@param handler
+func (c *EventControllerImpl) AddSQSHandler(queue string, handler SQSHandler) {
+	if len(queue) == 0 {
+		return
+	}
+	c.sqsHandlers[queue] = handler
+}
+
+// AddEventBridgeHandler
+//
+//	@receiver c
+//	@param detailType
+//	@param handler
+func (c *EventControllerImpl) AddEventBridgeHandler(detailType string, handler EventBridgeHandler) {
+	if len(detailType) == 0 {
+		return
+	}
+	c.eventBridgeHandlers[detailType] = handler
+}
+
+// AddScheduleHandler
+// an empty rule name skips the handler, so optional schedules can be left unconfigured
+//
+//	@receiver c
+//	@param rule name of the schedule rule
+//	@param handler
+func (c *EventControllerImpl) AddScheduleHandler(rule string, handler EventBridgeHandler) {
+	if len(rule) == 0 {
+		return
+	}
+	c.scheduleHandlers[rule] = handler
+}
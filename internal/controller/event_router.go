@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	nativeerr "errors"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	sqsEventSource string = "aws:sqs"
+	// scheduledDetailType detail-type of the events sent by schedule rules
+	scheduledDetailType string = "Scheduled Event"
+	scheduledSource     string = "aws.events"
+	fifoQueueSuffix     string = ".fifo"
+)
+
+var (
+	ErrNoEventHandler       error = nativeerr.New("no event handler")
+	ErrUnsupportedEventType error = nativeerr.New("unsupported event type")
+)
+
+// EventRouter
+// routes the events of a lambda to the handlers of event controllers, parallel to NewRouter for http requests.
+type EventRouter struct {
+	sqsHandlers         map[string]SQSHandler
+	eventBridgeHandlers map[string]EventBridgeHandler
+	scheduleHandlers    map[string]EventBridgeHandler
+}
+
+// NewEventRouter
+// a later controller replaces the handler of the same key of an earlier one
+//
+//	@param controllers
+//	@return *EventRouter
+func NewEventRouter(controllers []EventController) *EventRouter {
+	r := &EventRouter{
+		sqsHandlers:         make(map[string]SQSHandler),
+		eventBridgeHandlers: make(map[string]EventBridgeHandler),
+		scheduleHandlers:    make(map[string]EventBridgeHandler),
+	}
+	for _, c := range controllers {
+		for queue, handler := range c.GetSQSHandlers() {
+			logger.Info("add sqs router. queue: %s", queue)
+			r.sqsHandlers[queue] = handler
+		}
+		for detailType, handler := range c.GetEventBridgeHandlers() {
+			logger.Info("add eventbridge router. detail-type: %s", detailType)
+			r.eventBridgeHandlers[detailType] = handler
+		}
+		for rule, handler := range c.GetScheduleHandlers() {
+			logger.Info("add schedule router. rule: %s", rule)
+			r.scheduleHandlers[rule] = handler
+		}
+	}
+	return r
+}
+
+// eventProbe fields telling the type of a lambda payload.
+type eventProbe struct {
+	Records []struct {
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+	DetailType string `json:"detail-type"`
+}
+
+// Handle
+// entry of a lambda subscribed to sqs queues, EventBridge rules or schedules, the payload is routed by its shape
+//
+//	@receiver r
+//	@param ctx
+//	@param payload
+//	@return interface{} events.SQSEventResponse for sqs events, nil for the others
+//	@return error ErrUnsupportedEventType, ErrNoEventHandler and errors of the handlers
+func (r *EventRouter) Handle(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	probe := &eventProbe{}
+	err := json.Unmarshal(payload, probe)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "unmarshal event payload error")
+	}
+	switch {
+	case len(probe.Records) > 0 && probe.Records[0].EventSource == sqsEventSource:
+		event := events.SQSEvent{}
+		err = json.Unmarshal(payload, &event)
+		if err != nil {
+			rootErr := errors.New(err.Error())
+			return nil, errors.Wrap(rootErr, "unmarshal sqs event error")
+		}
+		return r.HandleSQS(ctx, event)
+	case len(probe.DetailType) > 0:
+		event := events.CloudWatchEvent{}
+		err = json.Unmarshal(payload, &event)
+		if err != nil {
+			rootErr := errors.New(err.Error())
+			return nil, errors.Wrap(rootErr, "unmarshal eventbridge event error")
+		}
+		return nil, r.HandleEventBridge(ctx, event)
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedEventType, "payload: %.200s", string(payload))
+	}
+}
+
+// HandleSQS
+//
+// messages failing to be handled are reported as batch item failures, only they are redelivered.
+// the messages of a fifo queue after a failed one are reported as well to keep their order.
+// the function needs ReportBatchItemFailures in the event source mapping.
+//
+//	@receiver r
+//	@param ctx
+//	@param event
+//	@return events.SQSEventResponse
+//	@return error
+func (r *EventRouter) HandleSQS(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	response := events.SQSEventResponse{
+		BatchItemFailures: []events.SQSBatchItemFailure{},
+	}
+	failed := false
+	for _, message := range event.Records {
+		queue := queueName(message.EventSourceARN)
+		if failed && strings.HasSuffix(queue, fifoQueueSuffix) {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+			continue
+		}
+		err := r.handleMessage(ctx, queue, message)
+		if err != nil {
+			logger.Error("failed to handle sqs message. queue: %s, message: %s", err, queue, message.MessageId)
+			failed = true
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+		}
+	}
+	return response, nil
+}
+
+// handleMessage
+//
+//	@receiver r
+//	@param ctx
+//	@param queue
+//	@param message
+//	@return error
+func (r *EventRouter) handleMessage(ctx context.Context, queue string, message events.SQSMessage) error {
+	handler, ok := r.sqsHandlers[queue]
+	if !ok {
+		return errors.Wrapf(ErrNoEventHandler, "queue: %s", queue)
+	}
+	return handler(ctx, message)
+}
+
+// HandleEventBridge
+// scheduled events are routed by the rule name, the others by the detail-type
+//
+//	@receiver r
+//	@param ctx
+//	@param event
+//	@return error a failed event is retried by EventBridge
+func (r *EventRouter) HandleEventBridge(ctx context.Context, event events.CloudWatchEvent) error {
+	if event.Source == scheduledSource && event.DetailType == scheduledDetailType {
+		rule := ruleName(event.Resources)
+		handler, ok := r.scheduleHandlers[rule]
+		if !ok {
+			return errors.Wrapf(ErrNoEventHandler, "schedule rule: %s", rule)
+		}
+		logger.Debug("run schedule. rule: %s, event: %s", rule, event.ID)
+		return handler(ctx, event)
+	}
+	handler, ok := r.eventBridgeHandlers[event.DetailType]
+	if !ok {
+		return errors.Wrapf(ErrNoEventHandler, "detail-type: %s, source: %s", event.DetailType, event.Source)
+	}
+	logger.Debug("handle eventbridge event. detail-type: %s, event: %s", event.DetailType, event.ID)
+	return handler(ctx, event)
+}
+
+// queueName
+//
+//	@param arn e.g. arn:aws:sqs:{region}:{account}:{queue}
+//	@return string
+func queueName(arn string) string {
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
+
+// ruleName
+//
+//	@param resources resources of a scheduled event, e.g. arn:aws:events:{region}:{account}:rule/{bus}/{rule}
+//	@return string empty when there is no rule
+func ruleName(resources []string) string {
+	for _, resource := range resources {
+		if strings.Contains(resource, ":rule/") {
+			return resource[strings.LastIndex(resource, "/")+1:]
+		}
+	}
+	return ""
+}
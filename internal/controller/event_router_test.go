@@ -0,0 +1,146 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/controller"
+)
+
+const (
+	testQueueArn     string = "arn:aws:sqs:ap-northeast-1:123456789012:test-queue"
+	testFifoQueueArn string = "arn:aws:sqs:ap-northeast-1:123456789012:test-queue.fifo"
+)
+
+func TestEventRouterHandleSQSWithFailedMessageReportOnlyIt(t *testing.T) {
+	handled := []string{}
+	c := controller.NewEventControllerImpl()
+	c.AddSQSHandler("test-queue", func(ctx context.Context, message events.SQSMessage) error {
+		if message.Body == "fail" {
+			return errBadHandle
+		}
+		handled = append(handled, message.MessageId)
+		return nil
+	})
+	r := controller.NewEventRouter([]controller.EventController{c})
+
+	response, err := r.HandleSQS(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{
+		newSQSMessage(testQueueArn, "m0", "ok"),
+		newSQSMessage(testQueueArn, "m1", "fail"),
+		newSQSMessage(testQueueArn, "m2", "ok"),
+		newSQSMessage("arn:aws:sqs:ap-northeast-1:123456789012:other-queue", "m3", "ok"),
+	}})
+
+	msg := "failed messages are not reported"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal([]string{"m0", "m2"}, handled, msg, "handled messages")
+	assertions.Equal([]events.SQSBatchItemFailure{{ItemIdentifier: "m1"}, {ItemIdentifier: "m3"}},
+		response.BatchItemFailures, msg, "batch item failures, unrouted message included")
+}
+
+func TestEventRouterHandleSQSWithFifoFailureReportFollowingMessages(t *testing.T) {
+	handled := []string{}
+	c := controller.NewEventControllerImpl()
+	c.AddSQSHandler("test-queue.fifo", func(ctx context.Context, message events.SQSMessage) error {
+		if message.Body == "fail" {
+			return errBadHandle
+		}
+		handled = append(handled, message.MessageId)
+		return nil
+	})
+	r := controller.NewEventRouter([]controller.EventController{c})
+
+	response, err := r.HandleSQS(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{
+		newSQSMessage(testFifoQueueArn, "m0", "ok"),
+		newSQSMessage(testFifoQueueArn, "m1", "fail"),
+		newSQSMessage(testFifoQueueArn, "m2", "ok"),
+	}})
+
+	msg := "order of fifo messages is not kept"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal([]string{"m0"}, handled, msg, "handled messages")
+	assertions.Equal([]events.SQSBatchItemFailure{{ItemIdentifier: "m1"}, {ItemIdentifier: "m2"}},
+		response.BatchItemFailures, msg, "batch item failures")
+}
+
+func TestEventRouterHandleWithPayloadsRouteByShape(t *testing.T) {
+	called := []string{}
+	c := controller.NewEventControllerImpl()
+	c.AddSQSHandler("test-queue", func(ctx context.Context, message events.SQSMessage) error {
+		called = append(called, "sqs:"+message.Body)
+		return nil
+	})
+	c.AddEventBridgeHandler("DummyCreated", func(ctx context.Context, event events.CloudWatchEvent) error {
+		called = append(called, "eventbridge:"+event.ID)
+		return nil
+	})
+	c.AddScheduleHandler("test-rule", func(ctx context.Context, event events.CloudWatchEvent) error {
+		called = append(called, "schedule:"+event.ID)
+		return nil
+	})
+	r := controller.NewEventRouter([]controller.EventController{c})
+
+	sqsResponse, sqsErr := r.Handle(context.TODO(), mustMarshal(t, events.SQSEvent{Records: []events.SQSMessage{
+		newSQSMessage(testQueueArn, "m0", "body"),
+	}}))
+	_, eventErr := r.Handle(context.TODO(), mustMarshal(t, events.CloudWatchEvent{
+		ID:         "e0",
+		DetailType: "DummyCreated",
+		Source:     "go-clean-lambda.dummy",
+		Detail:     json.RawMessage("{}"),
+	}))
+	_, scheduleErr := r.Handle(context.TODO(), mustMarshal(t, events.CloudWatchEvent{
+		ID:         "e1",
+		DetailType: "Scheduled Event",
+		Source:     "aws.events",
+		Resources:  []string{"arn:aws:events:ap-northeast-1:123456789012:rule/test-rule"},
+		Detail:     json.RawMessage("{}"),
+	}))
+	_, unknownErr := r.Handle(context.TODO(), json.RawMessage(`{"foo":"bar"}`))
+
+	msg := "payloads are not routed"
+	assertions := assert.New(t)
+	assertions.Nil(sqsErr, msg, "found sqs error")
+	assertions.Equal(events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}, sqsResponse,
+		msg, "sqs response")
+	assertions.Nil(eventErr, msg, "found eventbridge error")
+	assertions.Nil(scheduleErr, msg, "found schedule error")
+	assertions.ErrorIs(unknownErr, controller.ErrUnsupportedEventType, msg, "error of unknown payload")
+	assertions.Equal([]string{"sqs:body", "eventbridge:e0", "schedule:e1"}, called, msg, "called handlers")
+}
+
+func TestEventRouterHandleEventBridgeWithUnknownDetailTypeReturnError(t *testing.T) {
+	r := controller.NewEventRouter([]controller.EventController{controller.NewEventControllerImpl()})
+
+	err := r.HandleEventBridge(context.TODO(), events.CloudWatchEvent{
+		ID:         "e0",
+		DetailType: "Unknown",
+		Source:     "test",
+	})
+
+	msg := "unrouted event is not rejected"
+	assertions := assert.New(t)
+	assertions.ErrorIs(err, controller.ErrNoEventHandler, msg, "error type")
+}
+
+func newSQSMessage(arn string, id string, body string) events.SQSMessage {
+	return events.SQSMessage{
+		MessageId:      id,
+		Body:           body,
+		EventSource:    "aws:sqs",
+		EventSourceARN: arn,
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal payload error: %s", err.Error())
+	}
+	return payload
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/usecase"
+)
+
+// jobRootPath root path of the job status api, the Location of accepted jobs.
+const jobRootPath string = "/api/jobs"
+
+// JobController
+// works as extends MuxControllerImpl.
+type JobController struct {
+	*MuxControllerImpl
+	usecase *usecase.JobUseCase
+}
+
+// NewJobController
+//
+//	@param logMdf
+//	@param authMdf
+//	@param usecase
+//	@return *JobController
+func NewJobController(
+	logMdf mux.MiddlewareFunc,
+	authMdf mux.MiddlewareFunc,
+	usecase *usecase.JobUseCase,
+) *JobController {
+	c := &JobController{
+		MuxControllerImpl: NewMuxControllerImpl(
+			jobRootPath,
+			make(map[string]map[string]*MuxRouterHandler),
+		),
+		usecase: usecase,
+	}
+	c.AddMuxRouter("/{id}", []string{
+		http.MethodGet,
+	}, []mux.MiddlewareFunc{
+		logMdf,
+		authMdf,
+	}, func(w http.ResponseWriter, r *http.Request) error {
+		vars := mux.Vars(r)
+		return c.handleGet(w, r, vars["id"])
+	})
+	return c
+}
+
+// handleGet
+// only the owner may see a job
+//
+//	@receiver c
+//	@param w
+//	@param r
+//	@param id
+//	@return error
+func (c *JobController) handleGet(w http.ResponseWriter, r *http.Request, id string) error {
+	bo, err := c.usecase.Get(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return c.WriteErrorResponse(w, http.StatusNotFound, ErrObjectNotFound.Error(), fmt.Sprintf("job id: %s", id))
+	}
+	if errors.Is(err, usecase.ErrForbidden) {
+		return c.WriteErrorResponse(w, http.StatusForbidden, ErrForbidden.Error(), fmt.Sprintf("job id: %s", id))
+	}
+	if err != nil {
+		return errors.Wrap(err, "get job error")
+	}
+	logger.Debug("handle get job. id: %s, state: %s", bo.ID, bo.State)
+	return c.WriteResponse(w, logger.Pretty(toJobResponse(bo)))
+}
+
+// WriteJobAccepted
+// answer a request which submitted a job with 202 and the Location of its status
+//
+//	@param c
+//	@param w
+//	@param bo
+//	@return error
+func WriteJobAccepted(c *MuxControllerImpl, w http.ResponseWriter, bo *usecase.JobBo) error {
+	w.Header().Set("Location", fmt.Sprintf("%s/%s", jobRootPath, bo.ID))
+	w.WriteHeader(http.StatusAccepted)
+	return c.WriteResponse(w, logger.Pretty(toJobResponse(bo)))
+}
+
+// toJobResponse
+//
+//	@param bo
+//	@return *JobResponse
+func toJobResponse(bo *usecase.JobBo) *JobResponse {
+	return &JobResponse{
+		ID:    bo.ID,
+		Type:  bo.Type,
+		State: bo.State,
+		Progress: &ProgressResponse{
+			Done:  bo.Done,
+			Total: bo.Total,
+		},
+		Result:    bo.Result,
+		Error:     bo.Error,
+		CreatedAt: bo.CreatedAt,
+		UpdatedAt: bo.UpdatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/sdk/queue"
+	"local.com/go-clean-lambda/internal/usecase"
+)
+
+// JobEventController
+// works as extends EventControllerImpl, executes the jobs of the job queue.
+type JobEventController struct {
+	*EventControllerImpl
+	usecase *usecase.JobUseCase
+}
+
+// NewJobEventController
+//
+//	@param usecase
+//	@param jobQueue name of the job queue
+//	@return *JobEventController
+func NewJobEventController(usecase *usecase.JobUseCase, jobQueue string) *JobEventController {
+	c := &JobEventController{
+		EventControllerImpl: NewEventControllerImpl(),
+		usecase:             usecase,
+	}
+	c.AddSQSHandler(jobQueue, c.handleJob)
+	return c
+}
+
+// handleJob
+// a message failing with an error of the repository is redelivered
+//
+//	@receiver c
+//	@param ctx
+//	@param message
+//	@return error
+func (c *JobEventController) handleJob(ctx context.Context, message events.SQSMessage) error {
+	jobMessage := &queue.JobMessage{}
+	err := json.Unmarshal([]byte(message.Body), jobMessage)
+	if err != nil || len(jobMessage.JobID) == 0 {
+		logger.Warn("drop invalid job message. message: %s, body: %.200s", message.MessageId, message.Body)
+		return nil
+	}
+	err = c.usecase.Execute(ctx, jobMessage.JobID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute job. message: %s", message.MessageId)
+	}
+	return nil
+}
@@ -143,14 +143,15 @@ func (c *MuxControllerImpl) AddMuxRouter(
 
 // formatPath
 //
-// make sure the path begins with '/' and not ends with '/'
+// make sure the path begins with '/' and not ends with '/'.
+// a path beginning with ':' is a custom method of the root path, e.g. "/api/dummy:batch", and is kept.
 //
 //	@receiver c
 //	@param path
 //	@return string
 func (c *MuxControllerImpl) formatPath(path string) string {
 	path = strings.TrimSpace(path)
-	if len(path) > 0 && path[0] != '/' {
+	if len(path) > 0 && path[0] != '/' && path[0] != ':' {
 		path = "/" + path
 	}
 	if len(path) > 0 && path[len(path)-1] == '/' {
@@ -172,3 +173,24 @@ func (c *MuxControllerImpl) WriteResponse(w http.ResponseWriter, body string) er
 	}
 	return nil
 }
+
+// WriteErrorResponse
+//
+//	@receiver c
+//	@param w
+//	@param status http status code
+//	@param errorType
+//	@param errorMessage
+//	@return error
+func (c *MuxControllerImpl) WriteErrorResponse(
+	w http.ResponseWriter,
+	status int,
+	errorType string,
+	errorMessage string,
+) error {
+	w.WriteHeader(status)
+	return c.WriteResponse(w, logger.Pretty(&ErrorResponse{
+		ErrorType:    errorType,
+		ErrorMessage: errorMessage,
+	}))
+}
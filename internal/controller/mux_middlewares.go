@@ -36,21 +36,39 @@ func GetLogMiddleware() mux.MiddlewareFunc {
 }
 
 // GetLoginAccessMiddleware
+// the token is read from the Authorization header, or from the cookie when the cookie mode is enabled
 //
 //	@param authClient
+//	@param cookie nil to accept the Authorization header only
 //	@return mux.MiddlewareFunc
-func GetLoginAccessMiddleware(authClient authentication.AuthJwtClient) mux.MiddlewareFunc {
+func GetLoginAccessMiddleware(authClient authentication.AuthJwtClient, cookie *AuthCookie) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// verify
 			jwtHeader := r.Header.Get(authentication.JwtHeader)
-			if !strings.HasPrefix(jwtHeader, authentication.JwtHeaderPrefix) {
+			jwt := ""
+			if strings.HasPrefix(jwtHeader, authentication.JwtHeaderPrefix) {
+				jwt = jwtHeader[len(authentication.JwtHeaderPrefix):]
+			} else if cookie != nil && jwtHeader == "" {
+				jwt = cookie.AccessToken(r)
+				// cookies are sent by browsers whoever asks, so requests by them must prove they are of our pages
+				if jwt != "" {
+					if err := cookie.VerifyCSRF(r); err != nil {
+						logger.Info(
+							"request blocked by middleware. path: %s, method: %s, middleware: auth. cause: %s",
+							r.URL.Path, r.Method, err.Error())
+						http.Error(w, ErrInvalidCSRFToken.Error(), http.StatusForbidden)
+						return
+					}
+				}
+			}
+			if jwt == "" {
 				err := errors.WithStack(ErrInvalidAuthenticationHeader)
 				logger.Error("failed to check auth. header: %s", err, jwtHeader)
 				http.Error(w, err.Error(), http.StatusUnauthorized)
 				return
 			}
-			claim, err := authClient.Verify(r.Context(), jwtHeader[len(authentication.JwtHeaderPrefix):])
+			claim, err := authClient.Verify(r.Context(), jwt)
 			if err != nil {
 				if errors.Is(err, authentication.ErrExpiredClaim) ||
 					errors.Is(err, authentication.ErrBlockedClaim) {
@@ -72,6 +90,9 @@ func GetLoginAccessMiddleware(authClient authentication.AuthJwtClient) mux.Middl
 			ctx := r.Context()
 			ctx = context.WithValue(ctx, authentication.UserContextKey, claim.User.DeepCopy())
 			ctx = context.WithValue(ctx, authentication.UserIDKey, claim.User.UserID)
+			if claim.SessionID != "" {
+				ctx = context.WithValue(ctx, authentication.SessionIDKey, claim.SessionID)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
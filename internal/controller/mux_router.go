@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"local.com/go-clean-lambda/internal/logger"
@@ -25,19 +26,45 @@ func NewRouter(controllers []MuxController) *mux.Router {
 			http.Error(w, "", http.StatusMethodNotAllowed)
 		}
 	})
+	// custom methods of a root path, e.g. "/api/dummy:batch", cannot be routed by the subrouter of the root path
+	// because subrouter paths must begin with '/'. they are added to the router first with the full path.
+	for _, c := range controllers {
+		for path, methodMap := range c.GetHandlers() {
+			if !strings.HasPrefix(path, ":") {
+				continue
+			}
+			for method, handler := range methodMap {
+				logger.Info("add request router. path: %s%s, method: %s", c.GetRootPath(), path, method)
+				addHandler(r, c.GetRootPath()+path, method, handler)
+			}
+		}
+	}
 	for _, c := range controllers {
 		s := r.PathPrefix(c.GetRootPath()).Subrouter()
 		handlerMap := c.GetHandlers()
 		for path, methodMap := range handlerMap {
+			if strings.HasPrefix(path, ":") {
+				continue
+			}
 			for method, handler := range methodMap {
 				logger.Info("add request router. path: %s%s, method: %s", c.GetRootPath(), path, method)
-				ss := s.Methods(method).Subrouter()
-				ss.HandleFunc(path, handler.GetHandleFunc())
-				for _, mdw := range handler.GetMiddlewareFuncs() {
-					ss.Use(mdw)
-				}
+				addHandler(s, path, method, handler)
 			}
 		}
 	}
 	return r
 }
+
+// addHandler
+//
+//	@param r
+//	@param path
+//	@param method
+//	@param handler
+func addHandler(r *mux.Router, path string, method string, handler *MuxRouterHandler) {
+	ss := r.Methods(method).Subrouter()
+	ss.HandleFunc(path, handler.GetHandleFunc())
+	for _, mdw := range handler.GetMiddlewareFuncs() {
+		ss.Use(mdw)
+	}
+}
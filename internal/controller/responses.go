@@ -1,7 +1,78 @@
 package controller
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // ErrorResponse.
 type ErrorResponse struct {
 	ErrorType    string `json:"errorType"`
 	ErrorMessage string `json:"errorMessage"`
 }
+
+// BatchResponse
+// multi-status body of a bulk request.
+type BatchResponse struct {
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []*BatchItemResponse `json:"results"`
+}
+
+// BatchItemResponse
+// result of one item of a bulk request, results are in the order of the request.
+type BatchItemResponse struct {
+	Index  int            `json:"index"`
+	ID     string         `json:"id"`
+	Status int            `json:"status"`
+	Item   any            `json:"item,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+// JobResponse
+// status of an async job.
+type JobResponse struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	State     string            `json:"state"`
+	Progress  *ProgressResponse `json:"progress"`
+	Result    json.RawMessage   `json:"result,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// ProgressResponse.
+type ProgressResponse struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// TokenTypeBearer token_type of the issued access tokens.
+const TokenTypeBearer string = "Bearer"
+
+// TokenResponse
+// tokens issued by login, register and refresh as the successful response of RFC 6749.
+// the tokens are omitted in the cookie mode, which writes the csrf token instead.
+type TokenResponse struct {
+	AccessToken string `json:"access_token,omitempty"`
+	TokenType   string `json:"token_type"`
+	// ExpiresIn seconds the access token lives
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// CSRFToken send it in the header X-CSRF-Token with unsafe requests in the cookie mode
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
+
+// SessionResponse
+// a login of the caller.
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	Device      string    `json:"device"`
+	IP          string    `json:"ip"`
+	IssuedAt    time.Time `json:"issuedAt"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	// Current the session of the token of the request
+	Current bool `json:"current"`
+}
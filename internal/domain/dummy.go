@@ -1,6 +1,17 @@
 package domain
 
-import "context"
+import (
+	"context"
+	nativeerr "errors"
+	"time"
+)
+
+var (
+	ErrNotFound             error = nativeerr.New("entity not found")
+	ErrConditionFailed      error = nativeerr.New("condition check failed")
+	ErrBatchItemInvalid     error = nativeerr.New("invalid batch item")
+	ErrBatchItemUnprocessed error = nativeerr.New("batch item unprocessed")
+)
 
 // Dummy.
 //
@@ -9,6 +20,11 @@ type Dummy struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	SomeAttr string `json:"test_field_name"`
+	// audit fields, zero for items written before they were added
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy string    `json:"createdBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	UpdatedBy string    `json:"updatedBy"`
 }
 
 // ToKeyDummy
@@ -21,9 +37,138 @@ func ToKeyDummy(id string) *Dummy {
 	}
 }
 
-// DummyRepository.
+// DeleteOptions
+// conditions an entity must meet before it is deleted, restored or replaced.
+type DeleteOptions struct {
+	// ExpectedAttrs maps attribute names (json field names) to the values they must hold
+	ExpectedAttrs map[string]string
+}
+
+// DeleteOption.
+type DeleteOption func(opts *DeleteOptions)
+
+// WithExpectedAttr
+// only write the entity when the attribute holds the expected value
+//
+//	@param name json field name of the attribute
+//	@param value
+//	@return DeleteOption
+func WithExpectedAttr(name string, value string) DeleteOption {
+	return func(opts *DeleteOptions) {
+		if opts.ExpectedAttrs == nil {
+			opts.ExpectedAttrs = make(map[string]string)
+		}
+		opts.ExpectedAttrs[name] = value
+	}
+}
+
+// BuildDeleteOptions
+//
+//	@param opts
+//	@return *DeleteOptions
+func BuildDeleteOptions(opts ...DeleteOption) *DeleteOptions {
+	options := &DeleteOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// DummyBatchResult
+// result of one item of a batch operation, results are in the order of the input.
+type DummyBatchResult struct {
+	ID string
+	// Dummy is the loaded entity of a batch get, nil when not found
+	Dummy *Dummy
+	// Err is ErrBatchItemInvalid, ErrBatchItemUnprocessed or others when the item failed
+	Err error
+}
+
+// DummyRepository
+// every write of an entity also writes a DummyHistory record in the same transaction.
+// a repository keeping deleted entities for a retention window hides them from reads until they are restored.
 type DummyRepository interface {
+	// GetByID
+	//  @param ctx
+	//  @param id
+	//  @return *Dummy nil when not found
+	//  @return error
 	GetByID(ctx context.Context, id string) (*Dummy, error)
-	Insert(ctx context.Context, dummy *Dummy) (*Dummy, error)
-	DeleteByID(ctx context.Context, id string) (*Dummy, error)
+
+	// Insert
+	// create the entity, or replace the existing one keeping its CreatedAt and CreatedBy
+	//  @param ctx
+	//  @param dummy
+	//  @param opts conditions checked before replacing an existing entity, a new entity is created without them
+	//  @return *Dummy
+	//  @return error ErrConditionFailed and others
+	Insert(ctx context.Context, dummy *Dummy, opts ...DeleteOption) (*Dummy, error)
+
+	// DeleteByID
+	//  @param ctx
+	//  @param id
+	//  @param opts conditions checked before deleting
+	//  @return *Dummy the deleted entity
+	//  @return error ErrNotFound, ErrConditionFailed and others
+	DeleteByID(ctx context.Context, id string, opts ...DeleteOption) (*Dummy, error)
+
+	// RestoreByID
+	// restore an entity soft deleted within the retention window
+	//  @param ctx
+	//  @param id
+	//  @param opts conditions checked before restoring
+	//  @return *Dummy the restored entity
+	//  @return error ErrNotFound when there is no restorable entity, ErrConditionFailed and others
+	RestoreByID(ctx context.Context, id string, opts ...DeleteOption) (*Dummy, error)
+
+	// BatchGetByIDs
+	//  @param ctx
+	//  @param ids
+	//  @return []*DummyBatchResult one per id
+	//  @return error when the whole batch failed
+	BatchGetByIDs(ctx context.Context, ids []string) ([]*DummyBatchResult, error)
+
+	// BatchInsert
+	// items are not written atomically unless ctx is inside a transaction, every item is written with its history
+	// record like Insert
+	//  @param ctx
+	//  @param dummies
+	//  @param opts conditions checked for every item like Insert
+	//  @return []*DummyBatchResult one per dummy, a repeated id fails with ErrBatchItemInvalid, an unmet condition
+	//  with ErrConditionFailed
+	//  @return error when the whole batch failed
+	BatchInsert(ctx context.Context, dummies []*Dummy, opts ...DeleteOption) ([]*DummyBatchResult, error)
+
+	// BatchDeleteByIDs
+	// items are not deleted atomically unless ctx is inside a transaction
+	//  @param ctx
+	//  @param ids
+	//  @param opts conditions checked for every item like DeleteByID
+	//  @return []*DummyBatchResult one per id, a repeated id fails with ErrBatchItemInvalid, a missing id with
+	//  ErrNotFound and an unmet condition with ErrConditionFailed
+	//  @return error when the whole batch failed
+	BatchDeleteByIDs(ctx context.Context, ids []string, opts ...DeleteOption) ([]*DummyBatchResult, error)
+
+	// ListHistory
+	//  @param ctx
+	//  @param id
+	//  @return []*DummyHistory records in version order, empty when the entity never changed
+	//  @return error
+	ListHistory(ctx context.Context, id string) ([]*DummyHistory, error)
+
+	// GetHistoryVersion
+	//  @param ctx
+	//  @param id
+	//  @param version
+	//  @return *DummyHistory nil when not found
+	//  @return error
+	GetHistoryVersion(ctx context.Context, id string, version int64) (*DummyHistory, error)
+
+	// GetHistoryAsOf
+	//  @param ctx
+	//  @param id
+	//  @param asOf
+	//  @return *DummyHistory the last record changed at or before asOf, nil when there is none
+	//  @return error
+	GetHistoryAsOf(ctx context.Context, id string, asOf time.Time) (*DummyHistory, error)
 }
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DummyEventType type of a domain event of dummy entities.
+type DummyEventType string
+
+const (
+	DummyCreated DummyEventType = "DummyCreated"
+	DummyUpdated DummyEventType = "DummyUpdated"
+	DummyDeleted DummyEventType = "DummyDeleted"
+
+	// DummyEventSource source of the envelope of dummy events
+	DummyEventSource string = "go-clean-lambda.dummy"
+	// DummyEventSchemaVersion version of the envelope and the data, increased by breaking changes
+	DummyEventSchemaVersion string = "1"
+)
+
+// DummyEvent
+// envelope of a domain event of a dummy entity, persisted in the outbox with the change in the same transaction.
+type DummyEvent struct {
+	// ID unique id of the event, consumers deduplicate redelivered events by it
+	ID string `json:"id"`
+	// Type set by the repository from the change it writes with the event
+	Type          DummyEventType `json:"type"`
+	Source        string         `json:"source"`
+	SchemaVersion string         `json:"schemaVersion"`
+	AggregateID   string         `json:"aggregateId"`
+	// AggregateVersion version of the history record written with the event, set by the repository
+	AggregateVersion int64     `json:"aggregateVersion"`
+	OccurredAt       time.Time `json:"occurredAt"`
+	Actor            string    `json:"actor"`
+	// Data the entity after the change, the removed entity of DummyDeleted, set by the repository
+	Data *Dummy `json:"data"`
+}
+
+// NewDummyEvent
+// the type is left to the repository, which knows the change from the state it writes over
+//
+//	@param aggregateID
+//	@param actor user id
+//	@param at
+//	@return *DummyEvent
+func NewDummyEvent(aggregateID string, actor string, at time.Time) *DummyEvent {
+	return &DummyEvent{
+		ID:            uuid.New().String(),
+		Source:        DummyEventSource,
+		SchemaVersion: DummyEventSchemaVersion,
+		AggregateID:   aggregateID,
+		OccurredAt:    at,
+		Actor:         actor,
+	}
+}
+
+// DummyEventTypeOf
+//
+//	@param change
+//	@return DummyEventType a restored entity is DummyCreated since it appears again to downstream systems
+func DummyEventTypeOf(change DummyChange) DummyEventType {
+	switch change {
+	case DummyChangeCreated, DummyChangeRestored:
+		return DummyCreated
+	case DummyChangeDeleted:
+		return DummyDeleted
+	default:
+		return DummyUpdated
+	}
+}
+
+type eventsContextKey struct{}
+
+// WithDummyEvents
+// record events of the changes of ctx, a repository writing an entity persists the event of its id with the change
+//
+//	@param ctx
+//	@param events at most one event per aggregate id, a later one replaces an earlier one
+//	@return context.Context
+func WithDummyEvents(ctx context.Context, events ...*DummyEvent) context.Context {
+	byID := make(map[string]*DummyEvent)
+	if parent, ok := ctx.Value(eventsContextKey{}).(map[string]*DummyEvent); ok {
+		for id, event := range parent {
+			byID[id] = event
+		}
+	}
+	for _, event := range events {
+		if event != nil {
+			byID[event.AggregateID] = event
+		}
+	}
+	return context.WithValue(ctx, eventsContextKey{}, byID)
+}
+
+// DummyEventFromContext
+//
+//	@param ctx
+//	@param aggregateID
+//	@return *DummyEvent nil when no event is recorded for the id
+func DummyEventFromContext(ctx context.Context, aggregateID string) *DummyEvent {
+	if ctx == nil {
+		return nil
+	}
+	byID, _ := ctx.Value(eventsContextKey{}).(map[string]*DummyEvent)
+	return byID[aggregateID]
+}
+
+// DummyOutbox
+// events persisted with the changes and not published yet.
+type DummyOutbox interface {
+	// ListOutbox
+	//  @param ctx
+	//  @param limit
+	//  @return []*DummyEvent the oldest events first
+	//  @return error
+	ListOutbox(ctx context.Context, limit int) ([]*DummyEvent, error)
+
+	// DeleteOutbox
+	// deleting a missing event succeeds
+	//  @param ctx
+	//  @param event
+	//  @return error
+	DeleteOutbox(ctx context.Context, event *DummyEvent) error
+}
+
+// DummyEventPublisher publishes events to downstream systems.
+type DummyEventPublisher interface {
+	// Publish
+	// an event may be published more than once
+	//  @param ctx
+	//  @param event
+	//  @return error
+	Publish(ctx context.Context, event *DummyEvent) error
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DummyChange kind of change recorded in a history record.
+type DummyChange string
+
+const (
+	DummyChangeCreated DummyChange = "created"
+	DummyChangeUpdated DummyChange = "updated"
+	DummyChangeDeleted DummyChange = "deleted"
+	// DummyChangeRestored a soft deleted entity is restored
+	DummyChangeRestored DummyChange = "restored"
+)
+
+// DummyHistory
+// immutable record of one change of a dummy entity, written with the change in the same transaction.
+type DummyHistory struct {
+	ID string `json:"id"`
+	// Version starts from 1 and is increased by every change of the entity
+	Version   int64       `json:"version"`
+	Change    DummyChange `json:"change"`
+	ChangedAt time.Time   `json:"changedAt"`
+	ChangedBy string      `json:"changedBy"`
+	// Snapshot the entity after the change, the removed entity for DummyChangeDeleted
+	Snapshot *Dummy `json:"snapshot"`
+}
+
+type changeContextKey struct{}
+
+// changeMeta.
+type changeMeta struct {
+	by string
+	at time.Time
+}
+
+// WithChange
+// record who makes the changes of ctx and when, repositories write them into history records
+//
+//	@param ctx
+//	@param by user id
+//	@param at
+//	@return context.Context
+func WithChange(ctx context.Context, by string, at time.Time) context.Context {
+	return context.WithValue(ctx, changeContextKey{}, &changeMeta{by: by, at: at})
+}
+
+// ChangeFromContext
+//
+//	@param ctx
+//	@return string user id, empty when not recorded
+//	@return time.Time time.Now when not recorded
+func ChangeFromContext(ctx context.Context) (string, time.Time) {
+	if ctx != nil {
+		if meta, ok := ctx.Value(changeContextKey{}).(*changeMeta); ok {
+			return meta.by, meta.at
+		}
+	}
+	return "", time.Now().UTC()
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// JobState state of an async job.
+type JobState string
+
+const (
+	JobPending   JobState = "pending"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+)
+
+// IsFinished
+//
+//	@receiver s
+//	@return bool true when the job will not change any more
+func (s JobState) IsFinished() bool {
+	return s == JobSucceeded || s == JobFailed
+}
+
+// JobProgress
+// items of a job done so far, Total is 0 until the job knows it.
+type JobProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// JobCaller
+// the user submitting a job, with the permissions of the user at the submission.
+type JobCaller struct {
+	UserID        string `json:"userId"`
+	UserName      string `json:"userName,omitempty"`
+	Locale        string `json:"locale,omitempty"`
+	ZoneID        string `json:"zoneId,omitempty"`
+	PermissionBit uint64 `json:"pbit,omitempty"`
+}
+
+// Job
+// an operation submitted by a request and executed by a worker.
+type Job struct {
+	ID    string   `json:"id"`
+	Type  string   `json:"type"`
+	State JobState `json:"state"`
+	// Owner user submitting the job, the job runs as the user
+	Owner string `json:"owner"`
+	// Caller the owner as it submitted the job, nil for the jobs stored before it was kept
+	Caller   *JobCaller      `json:"caller,omitempty"`
+	Input    json.RawMessage `json:"input,omitempty"`
+	Progress JobProgress     `json:"progress"`
+	// Result output of a succeeded job
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error reason of a failed job
+	Error string `json:"error,omitempty"`
+	// ClaimedUntil a running job is claimed by its worker until it, another worker may claim it again after it
+	ClaimedUntil time.Time `json:"claimedUntil"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// IsClaimable
+//
+//	@receiver job
+//	@param now
+//	@return bool true when the job is pending, or running with its claim expired before now
+func (job *Job) IsClaimable(now time.Time) bool {
+	if job.State == JobPending {
+		return true
+	}
+	return job.State == JobRunning && !job.ClaimedUntil.IsZero() && job.ClaimedUntil.Before(now)
+}
+
+// JobRepository.
+type JobRepository interface {
+	// CreateJob
+	//  @param ctx
+	//  @param job
+	//  @return error ErrConditionFailed when the id exists
+	CreateJob(ctx context.Context, job *Job) error
+
+	// GetJob
+	//  @param ctx
+	//  @param id
+	//  @return *Job
+	//  @return error ErrNotFound and others
+	GetJob(ctx context.Context, id string) (*Job, error)
+
+	// UpdateJob
+	// replace a stored job
+	//  @param ctx
+	//  @param job
+	//  @return error ErrNotFound and others
+	UpdateJob(ctx context.Context, job *Job) error
+
+	// ClaimJob
+	// replace a stored job only while it is pending, or running with its claim expired,
+	// only one of the workers given the same job claims it
+	//  @param ctx
+	//  @param job e.g. in the running state
+	//  @param now the claims expired before it are taken over
+	//  @return error ErrConditionFailed when it is missing or not claimable, and others
+	ClaimJob(ctx context.Context, job *Job, now time.Time) error
+}
+
+// JobQueue
+// hands jobs to the workers.
+type JobQueue interface {
+	// Enqueue
+	// a job may be delivered more than once
+	//  @param ctx
+	//  @param jobID
+	//  @return error
+	Enqueue(ctx context.Context, jobID string) error
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+	nativeerr "errors"
+)
+
+var (
+	ErrTransactionCanceled error = nativeerr.New("transaction canceled")
+	ErrTransactionConflict error = nativeerr.New("transaction conflict")
+)
+
+// Transactor runs a function inside a unit of work.
+type Transactor interface {
+	// WithinTransaction
+	// writes made by repositories with the context passed to fn are committed atomically after fn returns nil,
+	// and discarded when fn returns an error. nested calls join the outer unit of work.
+	//  @param ctx
+	//  @param fn
+	//  @return error ErrTransactionCanceled, ErrConditionFailed, ErrTransactionConflict, errors of fn and others
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
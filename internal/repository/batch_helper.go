@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// batchPlan
+// ids to process once each and the positions of their results.
+type batchPlan struct {
+	results   []*domain.DummyBatchResult
+	ids       []string
+	positions map[string][]int
+}
+
+// newBatchPlan
+//
+//	@param ids
+//	@param allowDuplicates a repeated id shares the result of the first one, otherwise it fails
+//	@return *batchPlan
+func newBatchPlan(ids []string, allowDuplicates bool) *batchPlan {
+	plan := &batchPlan{
+		results:   make([]*domain.DummyBatchResult, len(ids)),
+		ids:       []string{},
+		positions: make(map[string][]int),
+	}
+	for i, id := range ids {
+		plan.results[i] = &domain.DummyBatchResult{ID: id}
+		if len(id) == 0 {
+			plan.results[i].Err = errors.Wrap(domain.ErrBatchItemInvalid, "blank id")
+			continue
+		}
+		if _, ok := plan.positions[id]; ok {
+			if !allowDuplicates {
+				plan.results[i].Err = errors.Wrapf(domain.ErrBatchItemInvalid, "repeated id: %s", id)
+				continue
+			}
+		} else {
+			plan.ids = append(plan.ids, id)
+		}
+		plan.positions[id] = append(plan.positions[id], i)
+	}
+	return plan
+}
+
+// newDummyBatchPlan
+//
+//	@param dummies
+//	@return *batchPlan nil dummies fail with domain.ErrBatchItemInvalid
+func newDummyBatchPlan(dummies []*domain.Dummy) *batchPlan {
+	ids := make([]string, len(dummies))
+	for i, dummy := range dummies {
+		if dummy != nil {
+			ids[i] = dummy.ID
+		}
+	}
+	return newBatchPlan(ids, false)
+}
+
+// setDummy
+//
+//	@receiver plan
+//	@param id
+//	@param dummy
+func (plan *batchPlan) setDummy(id string, dummy *domain.Dummy) {
+	for _, i := range plan.positions[id] {
+		plan.results[i].Dummy = copyDummy(dummy)
+	}
+}
+
+// setErr
+// the item failed, its entity is cleared
+//
+//	@receiver plan
+//	@param id
+//	@param err
+func (plan *batchPlan) setErr(id string, err error) {
+	for _, i := range plan.positions[id] {
+		plan.results[i].Dummy = nil
+		plan.results[i].Err = err
+	}
+}
+
+// chunkIDs
+//
+//	@param ids
+//	@param size
+//	@return [][]string
+func chunkIDs(ids []string, size int) [][]string {
+	chunks := [][]string{}
+	for start := 0; start < len(ids); start += size {
+		end := start + size
+		if end > len(ids) {
+			end = len(ids)
+		}
+		chunks = append(chunks, ids[start:end])
+	}
+	return chunks
+}
+
+// backoffDelay
+// exponential backoff with full jitter
+//
+//	@param attempt starts from 1
+//	@param base
+//	@param maxDelay
+//	@return time.Duration
+func backoffDelay(attempt int, base time.Duration, maxDelay time.Duration) time.Duration {
+	delay := base << (attempt - 1)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	//nolint:gosec
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// sleepWithContext
+//
+//	@param ctx
+//	@param d
+//	@return error ctx error when ctx is done first
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+// runConcurrently
+// call fn for every index from 0 to count, at most limit calls at the same time
+//
+//	@param count
+//	@param limit
+//	@param fn
+func runConcurrently(count int, limit int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	blocklistPKPrefix string = "blocklist#"
+	blocklistSK       string = "blocklist"
+)
+
+// BlocklistDynamodbStore
+// implements authentication.BlocklistStore, a blocked key is purged by the ttl of the table once it expires.
+type BlocklistDynamodbStore struct {
+	tableName string
+	client    dynamodbiface.DynamoDBAPI
+	now       func() time.Time
+}
+
+// NewBlocklistDynamodbStore
+//
+//	@param tableName
+//	@param client
+//	@return *BlocklistDynamodbStore
+func NewBlocklistDynamodbStore(tableName string, client dynamodbiface.DynamoDBAPI) *BlocklistDynamodbStore {
+	return &BlocklistDynamodbStore{
+		tableName: tableName,
+		client:    client,
+		now:       time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *BlocklistDynamodbStore
+func (s *BlocklistDynamodbStore) WithClock(now func() time.Time) *BlocklistDynamodbStore {
+	s.now = now
+	return s
+}
+
+// Block
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@param expireAt
+//	@return error
+func (s *BlocklistDynamodbStore) Block(ctx context.Context, key string, expireAt time.Time) error {
+	if !expireAt.After(s.now()) {
+		// already expired, no need to block it
+		return nil
+	}
+	// the ttl is in seconds, round it up to never unblock a key too early
+	expireAtSec := expireAt.Add(time.Second - time.Nanosecond).Unix()
+	item := toBlocklistDBKey(key)
+	item[FieldDummyExpireAt] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expireAtSec, 10))}
+	_, err := s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "put db blocklist error. table: %s, key: %s", s.tableName, key)
+	}
+	logger.Debug("put blocklist to db. key: %s, expireAt: %s", key, expireAt)
+	return nil
+}
+
+// IsBlocked
+// the ttl of DynamoDB purges items lazily, so the expiration is checked here too
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@return bool
+//	@return error
+func (s *BlocklistDynamodbStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	data, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            toBlocklistDBKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return false, errors.Wrapf(rootErr, "get db blocklist error. table: %s, key: %s", s.tableName, key)
+	}
+	value, ok := data.Item[FieldDummyExpireAt]
+	if !ok || value.N == nil {
+		return false, nil
+	}
+	expireAt, err := strconv.ParseInt(*value.N, 10, 64)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return false, errors.Wrapf(rootErr, "invalid db blocklist expireAt. key: %s", key)
+	}
+	return expireAt > s.now().Unix(), nil
+}
+
+// toBlocklistDBKey
+//
+//	@param key
+//	@return map
+func toBlocklistDBKey(key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(blocklistPKPrefix + key)},
+		FieldDummySK: {S: aws.String(blocklistSK)},
+	}
+}
@@ -0,0 +1,16 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestBlocklistDynamodbStoreContract(t *testing.T) {
+	repositorytest.RunBlocklistStoreContract(t, func(t *testing.T, now func() time.Time) authentication.BlocklistStore {
+		return repository.NewBlocklistDynamodbStore(dummyTableName, ddb.client).WithClock(now)
+	})
+}
@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+// CacheStats counters of a cache since it was created.
+type CacheStats struct {
+	Hits uint64 `json:"hits"`
+	// NegativeHits hits of cached misses, they are counted in Hits too
+	NegativeHits uint64 `json:"negativeHits"`
+	Misses       uint64 `json:"misses"`
+	// SharedLoads misses served by a load started by another caller
+	SharedLoads   uint64 `json:"sharedLoads"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+}
+
+// dummyCacheEntry
+// dummy is nil for a cached miss.
+type dummyCacheEntry struct {
+	dummy *domain.Dummy
+}
+
+// DummyCacheRepo
+// read-through caching decorator of domain.DummyRepository.
+// GetByID results, including misses, are cached. Insert, DeleteByID and RestoreByID invalidate the entry,
+// writes in a transaction invalidate it again when the transaction is committed.
+type DummyCacheRepo struct {
+	inner       domain.DummyRepository
+	ttl         time.Duration
+	negativeTTL time.Duration
+	cache       *lruCache[*dummyCacheEntry]
+	flight      flightGroup[*dummyCacheEntry]
+	// generations of the ids being loaded, an invalidation of an id increases its generation so that the loads
+	// started before it are not cached. the ids without loads need none, the next load starts after the invalidation
+	generationMu sync.Mutex
+	generations  map[string]*loadGeneration
+	stats        CacheStats
+}
+
+// loadGeneration
+// the generation of an id and its loads in progress.
+type loadGeneration struct {
+	value uint64
+	loads int
+}
+
+// NewDummyCacheRepo
+//
+//	@param inner
+//	@param size max cached entries
+//	@param ttl
+//	@param negativeTTL ttl of cached misses, misses are not cached when it is 0
+//	@return *DummyCacheRepo
+func NewDummyCacheRepo(inner domain.DummyRepository, size int, ttl time.Duration, negativeTTL time.Duration) *DummyCacheRepo {
+	return &DummyCacheRepo{
+		inner:       inner,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		cache:       newLruCache[*dummyCacheEntry](size, time.Now),
+		generations: make(map[string]*loadGeneration),
+	}
+}
+
+// WithClock
+// replace the clock used to expire entries
+//
+//	@receiver repo
+//	@param now
+//	@return *DummyCacheRepo
+func (repo *DummyCacheRepo) WithClock(now func() time.Time) *DummyCacheRepo {
+	repo.cache.mu.Lock()
+	defer repo.cache.mu.Unlock()
+	repo.cache.now = now
+	return repo
+}
+
+// GetByID
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return *domain.Dummy
+//	@return error
+func (repo *DummyCacheRepo) GetByID(ctx context.Context, id string) (*domain.Dummy, error) {
+	if len(id) == 0 {
+		return nil, nil
+	}
+	if entry, ok := repo.cache.get(id); ok {
+		atomic.AddUint64(&repo.stats.Hits, 1)
+		if entry.dummy == nil {
+			atomic.AddUint64(&repo.stats.NegativeHits, 1)
+		}
+		return copyDummy(entry.dummy), nil
+	}
+	atomic.AddUint64(&repo.stats.Misses, 1)
+	entry, shared, err := repo.flight.do(ctx, id, func(ctx context.Context) (*dummyCacheEntry, error) {
+		// a load finished between the cache read and the call may have filled the entry
+		if entry, ok := repo.cache.get(id); ok {
+			return entry, nil
+		}
+		return repo.load(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		atomic.AddUint64(&repo.stats.SharedLoads, 1)
+	}
+	return copyDummy(entry.dummy), nil
+}
+
+// load
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return *dummyCacheEntry
+//	@return error
+func (repo *DummyCacheRepo) load(ctx context.Context, id string) (*dummyCacheEntry, error) {
+	generation := repo.beginLoad(id)
+	dummy, err := repo.inner.GetByID(ctx, id)
+	current := repo.endLoad(id, generation)
+	if err != nil {
+		return nil, err
+	}
+	entry := &dummyCacheEntry{dummy: copyDummy(dummy)}
+	if !current {
+		// the entry may be stale when an invalidation of id happened during the load
+		return entry, nil
+	}
+	repo.store(id, entry)
+	return entry, nil
+}
+
+// beginLoad
+//
+//	@receiver repo
+//	@param id
+//	@return uint64 the generation of id
+func (repo *DummyCacheRepo) beginLoad(id string) uint64 {
+	repo.generationMu.Lock()
+	defer repo.generationMu.Unlock()
+	generation, ok := repo.generations[id]
+	if !ok {
+		generation = &loadGeneration{}
+		repo.generations[id] = generation
+	}
+	generation.loads++
+	return generation.value
+}
+
+// endLoad
+//
+//	@receiver repo
+//	@param id
+//	@param started the generation of id when the load started
+//	@return bool false when id was invalidated during the load
+func (repo *DummyCacheRepo) endLoad(id string, started uint64) bool {
+	repo.generationMu.Lock()
+	defer repo.generationMu.Unlock()
+	generation := repo.generations[id]
+	generation.loads--
+	if generation.loads == 0 {
+		delete(repo.generations, id)
+	}
+	return generation.value == started
+}
+
+// store
+//
+//	@receiver repo
+//	@param id
+//	@param entry
+func (repo *DummyCacheRepo) store(id string, entry *dummyCacheEntry) {
+	ttl := repo.ttl
+	if entry.dummy == nil {
+		ttl = repo.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	if repo.cache.set(id, entry, ttl) {
+		atomic.AddUint64(&repo.stats.Evictions, 1)
+	}
+}
+
+// Insert
+//
+//	@receiver repo
+//	@param ctx
+//	@param dummy
+//	@param opts
+//	@return *domain.Dummy
+//	@return error
+func (repo *DummyCacheRepo) Insert(ctx context.Context, dummy *domain.Dummy, opts ...domain.DeleteOption) (*domain.Dummy, error) {
+	result, err := repo.inner.Insert(ctx, dummy, opts...)
+	if dummy != nil {
+		repo.invalidateOnCommit(ctx, dummy.ID)
+	}
+	return result, err
+}
+
+// DeleteByID
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param opts
+//	@return *domain.Dummy
+//	@return error
+func (repo *DummyCacheRepo) DeleteByID(ctx context.Context, id string, opts ...domain.DeleteOption) (*domain.Dummy, error) {
+	result, err := repo.inner.DeleteByID(ctx, id, opts...)
+	repo.invalidateOnCommit(ctx, id)
+	return result, err
+}
+
+// RestoreByID
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param opts
+//	@return *domain.Dummy
+//	@return error
+func (repo *DummyCacheRepo) RestoreByID(ctx context.Context, id string, opts ...domain.DeleteOption) (*domain.Dummy, error) {
+	result, err := repo.inner.RestoreByID(ctx, id, opts...)
+	repo.invalidateOnCommit(ctx, id)
+	return result, err
+}
+
+// BatchGetByIDs
+// cached ids are served from the cache, the others are loaded by one batch of the inner repository
+//
+//	@receiver repo
+//	@param ctx
+//	@param ids
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyCacheRepo) BatchGetByIDs(ctx context.Context, ids []string) ([]*domain.DummyBatchResult, error) {
+	results := make([]*domain.DummyBatchResult, len(ids))
+	missed := []string{}
+	missedAt := []int{}
+	for i, id := range ids {
+		entry, ok := repo.cache.get(id)
+		if len(id) == 0 || !ok {
+			missed = append(missed, id)
+			missedAt = append(missedAt, i)
+			continue
+		}
+		atomic.AddUint64(&repo.stats.Hits, 1)
+		if entry.dummy == nil {
+			atomic.AddUint64(&repo.stats.NegativeHits, 1)
+		}
+		results[i] = &domain.DummyBatchResult{ID: id, Dummy: copyDummy(entry.dummy)}
+	}
+	if len(missed) == 0 {
+		return results, nil
+	}
+	atomic.AddUint64(&repo.stats.Misses, uint64(len(missed)))
+	generations := make([]uint64, len(missed))
+	for i, id := range missed {
+		generations[i] = repo.beginLoad(id)
+	}
+	loaded, err := repo.inner.BatchGetByIDs(ctx, missed)
+	cacheable := make([]bool, len(missed))
+	for i, id := range missed {
+		cacheable[i] = repo.endLoad(id, generations[i])
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range loaded {
+		results[missedAt[i]] = result
+		if !cacheable[i] || result.Err != nil || len(result.ID) == 0 {
+			continue
+		}
+		repo.store(result.ID, &dummyCacheEntry{dummy: copyDummy(result.Dummy)})
+	}
+	return results, nil
+}
+
+// BatchInsert
+//
+//	@receiver repo
+//	@param ctx
+//	@param dummies
+//	@param opts
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyCacheRepo) BatchInsert(
+	ctx context.Context,
+	dummies []*domain.Dummy,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	results, err := repo.inner.BatchInsert(ctx, dummies, opts...)
+	for _, dummy := range dummies {
+		if dummy != nil {
+			repo.invalidateOnCommit(ctx, dummy.ID)
+		}
+	}
+	return results, err
+}
+
+// BatchDeleteByIDs
+//
+//	@receiver repo
+//	@param ctx
+//	@param ids
+//	@param opts
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyCacheRepo) BatchDeleteByIDs(
+	ctx context.Context,
+	ids []string,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	results, err := repo.inner.BatchDeleteByIDs(ctx, ids, opts...)
+	for _, id := range ids {
+		repo.invalidateOnCommit(ctx, id)
+	}
+	return results, err
+}
+
+// ListHistory
+// history is not cached
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return []*domain.DummyHistory
+//	@return error
+func (repo *DummyCacheRepo) ListHistory(ctx context.Context, id string) ([]*domain.DummyHistory, error) {
+	return repo.inner.ListHistory(ctx, id)
+}
+
+// GetHistoryVersion
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param version
+//	@return *domain.DummyHistory
+//	@return error
+func (repo *DummyCacheRepo) GetHistoryVersion(ctx context.Context, id string, version int64) (*domain.DummyHistory, error) {
+	return repo.inner.GetHistoryVersion(ctx, id, version)
+}
+
+// GetHistoryAsOf
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param asOf
+//	@return *domain.DummyHistory
+//	@return error
+func (repo *DummyCacheRepo) GetHistoryAsOf(ctx context.Context, id string, asOf time.Time) (*domain.DummyHistory, error) {
+	return repo.inner.GetHistoryAsOf(ctx, id, asOf)
+}
+
+// Invalidate
+// remove the cached entry of id
+//
+//	@receiver repo
+//	@param id
+func (repo *DummyCacheRepo) Invalidate(id string) {
+	if len(id) == 0 {
+		return
+	}
+	repo.generationMu.Lock()
+	if generation, ok := repo.generations[id]; ok {
+		generation.value++
+	}
+	repo.generationMu.Unlock()
+	atomic.AddUint64(&repo.stats.Invalidations, 1)
+	repo.flight.forget(id)
+	repo.cache.remove(id)
+	logger.Debug("dummy cache invalidated. id: %s", id)
+}
+
+// Stats
+//
+//	@receiver repo
+//	@return CacheStats
+func (repo *DummyCacheRepo) Stats() CacheStats {
+	return CacheStats{
+		Hits:          atomic.LoadUint64(&repo.stats.Hits),
+		NegativeHits:  atomic.LoadUint64(&repo.stats.NegativeHits),
+		Misses:        atomic.LoadUint64(&repo.stats.Misses),
+		SharedLoads:   atomic.LoadUint64(&repo.stats.SharedLoads),
+		Evictions:     atomic.LoadUint64(&repo.stats.Evictions),
+		Invalidations: atomic.LoadUint64(&repo.stats.Invalidations),
+	}
+}
+
+// invalidateOnCommit
+// invalidate now, and again after the transaction in ctx is committed
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+func (repo *DummyCacheRepo) invalidateOnCommit(ctx context.Context, id string) {
+	repo.Invalidate(id)
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		uow.AfterCommit(func() { repo.Invalidate(id) })
+	}
+	if uow := MemoryUnitOfWorkFromContext(ctx); uow != nil {
+		uow.AfterCommit(func() { repo.Invalidate(id) })
+	}
+}
@@ -0,0 +1,250 @@
+package repository_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+)
+
+// countingDummyRepo counts reads of the inner repository, reads wait for gate when it is set.
+type countingDummyRepo struct {
+	domain.DummyRepository
+	gets int64
+	gate chan struct{}
+}
+
+func (repo *countingDummyRepo) GetByID(ctx context.Context, id string) (*domain.Dummy, error) {
+	atomic.AddInt64(&repo.gets, 1)
+	if repo.gate != nil {
+		<-repo.gate
+	}
+	return repo.DummyRepository.GetByID(ctx, id)
+}
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestDummyCacheRepoContract(t *testing.T) {
+	repositorytest.RunDummyRepositoryContract(t, func(t *testing.T) (domain.DummyRepository, domain.Transactor) {
+		inner := repository.NewDummyMemoryRepo(nil)
+		return repository.NewDummyCacheRepo(inner, 10, time.Minute, time.Minute), repository.NewMemoryTransactor()
+	})
+}
+
+func TestDummyCacheGetByIDWithCachedIDReturnWithoutLoad(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	dummy := repositorytest.NewDummy()
+	inner := &countingDummyRepo{DummyRepository: repository.NewDummyMemoryRepo([]*domain.Dummy{dummy})}
+	repo := repository.NewDummyCacheRepo(inner, 10, time.Minute, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		result, err := repo.GetByID(context.TODO(), dummy.ID)
+		require.NoError(err, "get should succeed")
+		assert.Equal(dummy, result, "get should return the entity")
+	}
+	result, _ := repo.GetByID(context.TODO(), dummy.ID)
+	result.Name = "changed"
+	cached, _ := repo.GetByID(context.TODO(), dummy.ID)
+	assert.Equal(dummy.Name, cached.Name, "changing a result should not change the cache")
+	assert.Equal(int64(1), atomic.LoadInt64(&inner.gets), "inner repo should be read once")
+	stats := repo.Stats()
+	assert.Equal(uint64(4), stats.Hits, "hits should be counted")
+	assert.Equal(uint64(1), stats.Misses, "misses should be counted")
+}
+
+func TestDummyCacheGetByIDWithMissingIDCacheMissUntilNegativeTTL(t *testing.T) {
+	assert := assert.New(t)
+	clock := &testClock{now: time.Now()}
+	inner := &countingDummyRepo{DummyRepository: repository.NewDummyMemoryRepo(nil)}
+	repo := repository.NewDummyCacheRepo(inner, 10, time.Minute, time.Second).WithClock(clock.Now)
+
+	result, _ := repo.GetByID(context.TODO(), "missing")
+	assert.Nil(result, "missing entity should return nil")
+	result, _ = repo.GetByID(context.TODO(), "missing")
+	assert.Nil(result, "cached miss should return nil")
+	assert.Equal(int64(1), atomic.LoadInt64(&inner.gets), "miss should be cached")
+	assert.Equal(uint64(1), repo.Stats().NegativeHits, "negative hit should be counted")
+
+	clock.Add(time.Second)
+	_, _ = repo.GetByID(context.TODO(), "missing")
+	assert.Equal(int64(2), atomic.LoadInt64(&inner.gets), "expired miss should be loaded again")
+}
+
+func TestDummyCacheGetByIDWithFullCacheEvictLeastRecentlyUsed(t *testing.T) {
+	assert := assert.New(t)
+	dummies := []*domain.Dummy{repositorytest.NewDummy(), repositorytest.NewDummy(), repositorytest.NewDummy()}
+	inner := &countingDummyRepo{DummyRepository: repository.NewDummyMemoryRepo(dummies)}
+	repo := repository.NewDummyCacheRepo(inner, 2, time.Minute, time.Minute)
+
+	_, _ = repo.GetByID(context.TODO(), dummies[0].ID)
+	_, _ = repo.GetByID(context.TODO(), dummies[1].ID)
+	_, _ = repo.GetByID(context.TODO(), dummies[0].ID)
+	_, _ = repo.GetByID(context.TODO(), dummies[2].ID)
+	assert.Equal(uint64(1), repo.Stats().Evictions, "one entry should be evicted")
+	_, _ = repo.GetByID(context.TODO(), dummies[0].ID)
+	assert.Equal(int64(3), atomic.LoadInt64(&inner.gets), "recently used entry should stay")
+	_, _ = repo.GetByID(context.TODO(), dummies[1].ID)
+	assert.Equal(int64(4), atomic.LoadInt64(&inner.gets), "least recently used entry should be evicted")
+}
+
+func TestDummyCacheInsertWithCachedIDInvalidate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	repo := repository.NewDummyCacheRepo(repository.NewDummyMemoryRepo(nil), 10, time.Minute, time.Minute)
+	dummy := repositorytest.NewDummy()
+
+	result, _ := repo.GetByID(context.TODO(), dummy.ID)
+	require.Nil(result, "entity should not exist yet")
+	_, err := repo.Insert(context.TODO(), dummy)
+	require.NoError(err, "insert should succeed")
+	result, _ = repo.GetByID(context.TODO(), dummy.ID)
+	assert.Equal(dummy, result, "cached miss should be invalidated by insert")
+
+	_, err = repo.DeleteByID(context.TODO(), dummy.ID)
+	require.NoError(err, "delete should succeed")
+	result, _ = repo.GetByID(context.TODO(), dummy.ID)
+	assert.Nil(result, "cached entity should be invalidated by delete")
+}
+
+func TestDummyCacheInsertInTransactionInvalidateAfterCommit(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	repo := repository.NewDummyCacheRepo(repository.NewDummyMemoryRepo(nil), 10, time.Minute, time.Minute)
+	transactor := repository.NewMemoryTransactor()
+	dummy := repositorytest.NewDummy()
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, dummy)
+		if err != nil {
+			return err
+		}
+		// fill the cache with the value before commit
+		result, _ := repo.GetByID(context.TODO(), dummy.ID)
+		assert.Nil(result, "write should not be visible before commit")
+		return nil
+	})
+	require.NoError(err, "transaction should succeed")
+	result, _ := repo.GetByID(context.TODO(), dummy.ID)
+	assert.Equal(dummy, result, "cache should be invalidated after commit")
+}
+
+func TestDummyCacheGetByIDConcurrentlyLoadOnce(t *testing.T) {
+	assert := assert.New(t)
+	dummy := repositorytest.NewDummy()
+	inner := &countingDummyRepo{
+		DummyRepository: repository.NewDummyMemoryRepo([]*domain.Dummy{dummy}),
+		gate:            make(chan struct{}),
+	}
+	repo := repository.NewDummyCacheRepo(inner, 10, time.Minute, time.Minute)
+
+	callers := 8
+	started := sync.WaitGroup{}
+	done := sync.WaitGroup{}
+	results := make([]*domain.Dummy, callers)
+	for i := 0; i < callers; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			results[i], _ = repo.GetByID(context.TODO(), dummy.ID)
+		}(i)
+	}
+	started.Wait()
+	// let the callers reach the in-flight load before it returns
+	assert.Eventually(func() bool {
+		return repo.Stats().Misses == uint64(callers)
+	}, time.Second, time.Millisecond, "all callers should miss")
+	close(inner.gate)
+	done.Wait()
+	assert.Equal(int64(1), atomic.LoadInt64(&inner.gets), "concurrent misses should load once")
+	for _, result := range results {
+		assert.Equal(dummy, result, "every caller should get the entity")
+	}
+}
+
+func TestDummyCacheGetByIDWithCanceledCallerServeSharedLoad(t *testing.T) {
+	assert := assert.New(t)
+	dummy := repositorytest.NewDummy()
+	inner := &countingDummyRepo{
+		DummyRepository: repository.NewDummyMemoryRepo([]*domain.Dummy{dummy}),
+		gate:            make(chan struct{}),
+	}
+	repo := repository.NewDummyCacheRepo(inner, 10, time.Minute, time.Minute)
+	ctx, cancel := context.WithCancel(context.TODO())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := repo.GetByID(ctx, dummy.ID)
+		firstErr <- err
+	}()
+	assert.Eventually(func() bool {
+		return atomic.LoadInt64(&inner.gets) == 1
+	}, time.Second, time.Millisecond, "first caller should start the load")
+	second := make(chan *domain.Dummy, 1)
+	go func() {
+		result, _ := repo.GetByID(context.TODO(), dummy.ID)
+		second <- result
+	}()
+	assert.Eventually(func() bool {
+		return repo.Stats().Misses == 2
+	}, time.Second, time.Millisecond, "second caller should miss")
+
+	cancel()
+	err := <-firstErr
+	close(inner.gate)
+
+	assert.ErrorIs(err, context.Canceled, "canceled caller should stop waiting")
+	assert.Equal(dummy, <-second, "waiting caller should get the entity")
+	result, _ := repo.GetByID(context.TODO(), dummy.ID)
+	assert.Equal(dummy, result, "entity should be cached")
+	assert.Equal(int64(1), atomic.LoadInt64(&inner.gets), "load of canceled caller should be cached")
+}
+
+func TestDummyCacheInvalidateWithOtherIDCacheLoad(t *testing.T) {
+	assert := assert.New(t)
+	dummy := repositorytest.NewDummy()
+	inner := &countingDummyRepo{
+		DummyRepository: repository.NewDummyMemoryRepo([]*domain.Dummy{dummy}),
+		gate:            make(chan struct{}),
+	}
+	repo := repository.NewDummyCacheRepo(inner, 10, time.Minute, time.Minute)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = repo.GetByID(context.TODO(), dummy.ID)
+	}()
+	assert.Eventually(func() bool {
+		return atomic.LoadInt64(&inner.gets) == 1
+	}, time.Second, time.Millisecond, "caller should start the load")
+
+	repo.Invalidate("other_id")
+	close(inner.gate)
+	<-done
+	result, _ := repo.GetByID(context.TODO(), dummy.ID)
+
+	assert.Equal(dummy, result, "wrong entity")
+	assert.Equal(int64(1), atomic.LoadInt64(&inner.gets), "invalidation of other id should not skip caching")
+}
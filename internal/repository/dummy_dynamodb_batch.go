@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	// MaxBatchGetItems limit of keys in one BatchGetItem request.
+	MaxBatchGetItems int = 100
+	// batchWriteConcurrency items of a batch written at the same time outside a unit of work.
+	batchWriteConcurrency int = 8
+
+	defaultBatchMaxAttempts int           = 5
+	defaultBatchBaseDelay   time.Duration = 50 * time.Millisecond
+	defaultBatchMaxDelay    time.Duration = 2 * time.Second
+)
+
+// batchRetry
+// backoff of unprocessed items.
+type batchRetry struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// newBatchRetry
+//
+//	@return *batchRetry
+func newBatchRetry() *batchRetry {
+	return &batchRetry{
+		maxAttempts: defaultBatchMaxAttempts,
+		baseDelay:   defaultBatchBaseDelay,
+		maxDelay:    defaultBatchMaxDelay,
+	}
+}
+
+// BatchGetByIDs
+// ids are read in chunks of MaxBatchGetItems, unprocessed keys are retried with backoff.
+//
+//	@receiver repo
+//	@param ctx
+//	@param ids
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyDynamodbRepo) BatchGetByIDs(ctx context.Context, ids []string) ([]*domain.DummyBatchResult, error) {
+	plan := newBatchPlan(ids, true)
+	for _, chunk := range chunkIDs(plan.ids, MaxBatchGetItems) {
+		keys := make([]map[string]*dynamodb.AttributeValue, 0, len(chunk))
+		for _, id := range chunk {
+			keys = append(keys, ToDummyDBKey(domain.ToKeyDummy(id)))
+		}
+		repo.batchGetChunk(ctx, keys, plan)
+	}
+	return plan.results, nil
+}
+
+// batchGetChunk
+//
+//	@receiver repo
+//	@param ctx
+//	@param keys
+//	@param plan found entities are set to it, keys not processed fail
+func (repo *DummyDynamodbRepo) batchGetChunk(
+	ctx context.Context,
+	keys []map[string]*dynamodb.AttributeValue,
+	plan *batchPlan,
+) {
+	for attempt := 1; len(keys) > 0; attempt++ {
+		if attempt > 1 {
+			err := sleepWithContext(ctx, backoffDelay(attempt-1, repo.retry.baseDelay, repo.retry.maxDelay))
+			if err != nil {
+				failKeys(plan, keys, errors.Wrap(err, "batch get canceled"))
+				return
+			}
+		}
+		data, err := repo.client.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]*dynamodb.KeysAndAttributes{
+				repo.tableName: {Keys: keys},
+			},
+		})
+		if err != nil {
+			rootErr := errors.New(err.Error())
+			failKeys(plan, keys, errors.Wrapf(rootErr, "batch get db items error. table: %s, keys: %d",
+				repo.tableName, len(keys)))
+			return
+		}
+		for _, item := range data.Responses[repo.tableName] {
+			if isSoftDeletedDBItem(item) {
+				continue
+			}
+			entity, err := ToDummyEntity(item)
+			if err != nil {
+				id := aws.StringValue(item[FieldDummySK].S)
+				plan.setErr(id, errors.Wrapf(err, "failed to parse db item. table: %s, id: %s", repo.tableName, id))
+				continue
+			}
+			plan.setDummy(entity.ID, entity)
+		}
+		keys = nil
+		if unprocessed, ok := data.UnprocessedKeys[repo.tableName]; ok && unprocessed != nil {
+			keys = unprocessed.Keys
+		}
+		if len(keys) > 0 && attempt >= repo.retry.maxAttempts {
+			logger.Warn("batch get gave up unprocessed keys. table: %s, keys: %d, attempts: %d",
+				repo.tableName, len(keys), attempt)
+			failKeys(plan, keys, errors.Wrapf(domain.ErrBatchItemUnprocessed, "attempts: %d", attempt))
+			return
+		}
+	}
+}
+
+// failKeys
+//
+//	@param plan
+//	@param keys
+//	@param err
+func failKeys(plan *batchPlan, keys []map[string]*dynamodb.AttributeValue, err error) {
+	for _, key := range keys {
+		plan.setErr(aws.StringValue(key[FieldDummySK].S), err)
+	}
+}
+
+// BatchInsert
+// outside a unit of work every item is written with its history record in a transaction of its own,
+// batchWriteConcurrency items at the same time, and throttled items are retried with backoff.
+// inside a unit of work every item joins the transaction instead.
+//
+// BatchWriteItem is not used: it cannot condition an item on its version, so the history record could not be
+// numbered safely. the items cost a consistent read and a transaction each instead of a share of a chunk,
+// the concurrency takes the place of the chunks and the backoff of throttled transactions the place of the
+// retry of unprocessed items.
+//
+//	@receiver repo
+//	@param ctx
+//	@param dummies
+//	@param opts
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyDynamodbRepo) BatchInsert(
+	ctx context.Context,
+	dummies []*domain.Dummy,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	plan := newDummyBatchPlan(dummies)
+	valid := make(map[string]*domain.Dummy, len(plan.ids))
+	for i, dummy := range dummies {
+		if dummy != nil && plan.results[i].Err == nil {
+			valid[dummy.ID] = dummy
+		}
+	}
+	ids := make([]string, 0, len(valid))
+	for _, id := range plan.ids {
+		if _, ok := valid[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	repo.batchWriteEach(ctx, plan, ids, func(ctx context.Context, id string) (*domain.Dummy, error) {
+		return repo.Insert(ctx, valid[id], opts...)
+	})
+	return plan.results, nil
+}
+
+// BatchDeleteByIDs
+// outside a unit of work every item is deleted with its history record in a transaction of its own,
+// batchWriteConcurrency items at the same time, and throttled items are retried with backoff.
+// inside a unit of work every item joins the transaction instead. see BatchInsert for the cost.
+//
+//	@receiver repo
+//	@param ctx
+//	@param ids
+//	@param opts
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyDynamodbRepo) BatchDeleteByIDs(
+	ctx context.Context,
+	ids []string,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	plan := newBatchPlan(ids, false)
+	repo.batchWriteEach(ctx, plan, plan.ids, func(ctx context.Context, id string) (*domain.Dummy, error) {
+		_, err := repo.DeleteByID(ctx, id, opts...)
+		return nil, err
+	})
+	return plan.results, nil
+}
+
+// batchWriteEach
+//
+//	@receiver repo
+//	@param ctx
+//	@param plan results of the ids are set to it
+//	@param ids
+//	@param write writes one item
+func (repo *DummyDynamodbRepo) batchWriteEach(
+	ctx context.Context,
+	plan *batchPlan,
+	ids []string,
+	write func(ctx context.Context, id string) (*domain.Dummy, error),
+) {
+	if UnitOfWorkFromContext(ctx) != nil {
+		// writes are only added to the unit of work, one at a time keeps their order
+		for _, id := range ids {
+			dummy, err := write(ctx, id)
+			repo.setWriteResult(plan, id, dummy, err)
+		}
+		return
+	}
+	var mu sync.Mutex
+	runConcurrently(len(ids), batchWriteConcurrency, func(i int) {
+		dummy, err := write(ctx, ids[i])
+		mu.Lock()
+		defer mu.Unlock()
+		repo.setWriteResult(plan, ids[i], dummy, err)
+	})
+}
+
+// setWriteResult
+//
+//	@receiver repo
+//	@param plan
+//	@param id
+//	@param dummy
+//	@param err throttled items fail with domain.ErrBatchItemUnprocessed
+func (repo *DummyDynamodbRepo) setWriteResult(plan *batchPlan, id string, dummy *domain.Dummy, err error) {
+	switch {
+	case errors.Is(err, ErrThrottled):
+		logger.Warn("batch write gave up throttled item. table: %s, id: %s", repo.tableName, id)
+		plan.setErr(id, errors.Wrapf(domain.ErrBatchItemUnprocessed, "attempts: %d", repo.retry.maxAttempts))
+	case err != nil:
+		plan.setErr(id, err)
+	default:
+		plan.setDummy(id, dummy)
+	}
+}
@@ -0,0 +1,442 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	// FieldDummyVersion version of a dummy item, it is not a field of the entity.
+	FieldDummyVersion string = "version"
+
+	dummyHistoryPKPrefix string = "dummy_history#"
+)
+
+// versionedDummy
+// current entity and its version, the version is 0 for an item written before versioning.
+// the version of a missing item is the one of its last history record, 0 when there is none.
+type versionedDummy struct {
+	dummy   *domain.Dummy
+	version int64
+	// deletedAt is zero unless the item is soft deleted
+	deletedAt time.Time
+}
+
+// itemVersion
+//
+//	@receiver current
+//	@return int64 version attribute the stored item holds, 0 when the item is missing
+func (current *versionedDummy) itemVersion() int64 {
+	if current.dummy == nil {
+		return 0
+	}
+	return current.version
+}
+
+// live
+//
+//	@receiver current
+//	@return *domain.Dummy nil when the item is missing or soft deleted
+func (current *versionedDummy) live() *domain.Dummy {
+	if !current.deletedAt.IsZero() {
+		return nil
+	}
+	return current.dummy
+}
+
+// getVersioned
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return *versionedDummy
+//	@return error
+func (repo *DummyDynamodbRepo) getVersioned(ctx context.Context, id string) (*versionedDummy, error) {
+	data, err := repo.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(repo.tableName),
+		Key:            ToDummyDBKey(domain.ToKeyDummy(id)),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "get db item error. table: %s, id: %s", repo.tableName, id)
+	}
+	dummy, err := ToDummyEntity(data.Item)
+	if err != nil {
+		return nil, err
+	}
+	current := &versionedDummy{dummy: dummy}
+	if dummy == nil {
+		// a deleted item keeps its history, a new item continues from its last version
+		current.version, err = repo.lastHistoryVersion(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return current, nil
+	}
+	if attr, ok := data.Item[FieldDummyVersion]; ok && attr.N != nil {
+		current.version, err = strconv.ParseInt(aws.StringValue(attr.N), 10, 64)
+		if err != nil {
+			rootErr := errors.New(err.Error())
+			return nil, errors.Wrapf(rootErr, "invalid version of db item. table: %s, id: %s", repo.tableName, id)
+		}
+	}
+	current.deletedAt, err = parseDeletedAt(data.Item)
+	if err != nil {
+		return nil, errors.Wrapf(err, "table: %s, id: %s", repo.tableName, id)
+	}
+	return current, nil
+}
+
+// lastHistoryVersion
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return int64 0 when there is no history record
+//	@return error
+func (repo *DummyDynamodbRepo) lastHistoryVersion(ctx context.Context, id string) (int64, error) {
+	data, err := repo.client.QueryWithContext(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(repo.tableName),
+		KeyConditionExpression:   aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]*string{"#pk": aws.String(FieldDummyPK)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk": {S: aws.String(dummyHistoryPKPrefix + id)},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int64(1),
+		ConsistentRead:   aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return 0, errors.Wrapf(rootErr, "query last db history item error. table: %s, id: %s", repo.tableName, id)
+	}
+	if len(data.Items) == 0 {
+		return 0, nil
+	}
+	record, err := ToDummyHistoryEntity(data.Items[0])
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse db history item. table: %s, id: %s", repo.tableName, id)
+	}
+	return record.Version, nil
+}
+
+// writeVersioned
+// build adds writes to the unit of work of ctx, or to a new one committed at once.
+// a new unit of work losing a race on the version is built and committed again with fresh reads.
+//
+//	@receiver repo
+//	@param ctx
+//	@param build own is true when the unit of work is not shared with the caller
+//	@return error
+func (repo *DummyDynamodbRepo) writeVersioned(ctx context.Context, build func(uow *UnitOfWork, own bool) error) error {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return build(uow, false)
+	}
+	transactor := NewDynamodbTransactor(repo.client)
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			err := sleepWithContext(ctx, backoffDelay(attempt-1, repo.retry.baseDelay, repo.retry.maxDelay))
+			if err != nil {
+				return errors.Wrap(err, "versioned write canceled")
+			}
+		}
+		uow := NewUnitOfWork()
+		err := build(uow, true)
+		if err != nil {
+			return err
+		}
+		err = transactor.Commit(ctx, uow)
+		if err == nil {
+			return nil
+		}
+		retryable := errors.Is(err, domain.ErrConditionFailed) ||
+			errors.Is(err, domain.ErrTransactionConflict) ||
+			errors.Is(err, ErrThrottled)
+		if !retryable || attempt >= repo.retry.maxAttempts {
+			return err
+		}
+		logger.Debug("retry versioned write. attempt: %d, cause: %s", attempt, err.Error())
+	}
+}
+
+// addPut
+// add the put of dummy, conditioned on the current version and the expected attributes, and its history record
+//
+//	@receiver repo
+//	@param uow
+//	@param dummy
+//	@param current
+//	@param change
+//	@param expected json field name to value
+//	@param write
+//	@return error
+func (repo *DummyDynamodbRepo) addPut(
+	uow *UnitOfWork,
+	dummy *domain.Dummy,
+	current *versionedDummy,
+	change domain.DummyChange,
+	expected map[string]string,
+	write *dummyWrite,
+) error {
+	item, err := ToDummyDBItem(dummy)
+	if err != nil {
+		return errors.Wrap(err, "failed build db item")
+	}
+	version := current.version + 1
+	item[FieldDummyVersion] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(version, 10))}
+	expr, err := buildVersionedCondition(current.itemVersion(), expected, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build put condition. id: %s", dummy.ID)
+	}
+	uow.Put(&dynamodb.Put{
+		TableName:                 aws.String(repo.tableName),
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}, fmt.Sprintf("put dummy %s", dummy.ID))
+	return repo.addHistory(uow, write.historyOf(dummy.ID, version, change, dummy), write)
+}
+
+// addDelete
+// add the delete of the current item, conditioned on its version and the expected attributes, and its history record.
+// the item is kept with deletedAt and the ttl attribute instead when soft delete is enabled.
+//
+//	@receiver repo
+//	@param uow
+//	@param current must hold an entity
+//	@param options
+//	@param write
+//	@return error
+func (repo *DummyDynamodbRepo) addDelete(
+	uow *UnitOfWork,
+	current *versionedDummy,
+	options *domain.DeleteOptions,
+	write *dummyWrite,
+) error {
+	id := current.dummy.ID
+	expr, err := buildVersionedCondition(current.version, options.ExpectedAttrs, true)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build delete condition. id: %s", id)
+	}
+	if repo.softDeleteRetention > 0 {
+		item, err := repo.toSoftDeletedDBItem(current.dummy, current.version+1, write.at)
+		if err != nil {
+			return err
+		}
+		uow.Put(&dynamodb.Put{
+			TableName:                 aws.String(repo.tableName),
+			Item:                      item,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}, fmt.Sprintf("soft delete dummy %s", id))
+	} else {
+		uow.Delete(&dynamodb.Delete{
+			TableName:                 aws.String(repo.tableName),
+			Key:                       ToDummyDBKey(domain.ToKeyDummy(id)),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}, fmt.Sprintf("delete dummy %s", id))
+	}
+	return repo.addHistory(uow, write.historyOf(id, current.version+1, domain.DummyChangeDeleted, current.dummy), write)
+}
+
+// addHistory
+// history records are never overwritten, the event recorded for the write is added to the outbox with it
+//
+//	@receiver repo
+//	@param uow
+//	@param record
+//	@param write
+//	@return error
+func (repo *DummyDynamodbRepo) addHistory(uow *UnitOfWork, record *domain.DummyHistory, write *dummyWrite) error {
+	item, err := ToDummyHistoryDBItem(record)
+	if err != nil {
+		return err
+	}
+	uow.Put(&dynamodb.Put{
+		TableName:                aws.String(repo.tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+		ExpressionAttributeNames: map[string]*string{"#pk": aws.String(FieldDummyPK)},
+	}, fmt.Sprintf("put dummy history %s v%d", record.ID, record.Version))
+	if event := write.eventOf(record); event != nil {
+		return repo.addOutbox(uow, event)
+	}
+	return nil
+}
+
+// ListHistory
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return []*domain.DummyHistory
+//	@return error
+func (repo *DummyDynamodbRepo) ListHistory(ctx context.Context, id string) ([]*domain.DummyHistory, error) {
+	records := []*domain.DummyHistory{}
+	err := repo.queryHistory(ctx, id, true, func(record *domain.DummyHistory) bool {
+		records = append(records, record)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetHistoryVersion
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param version
+//	@return *domain.DummyHistory
+//	@return error
+func (repo *DummyDynamodbRepo) GetHistoryVersion(ctx context.Context, id string, version int64) (*domain.DummyHistory, error) {
+	if len(id) == 0 || version < 1 {
+		return nil, nil
+	}
+	data, err := repo.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(repo.tableName),
+		Key:       toDummyHistoryDBKey(id, version),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "get db history item error. table: %s, id: %s, version: %d",
+			repo.tableName, id, version)
+	}
+	return ToDummyHistoryEntity(data.Item)
+}
+
+// GetHistoryAsOf
+// records are read from the newest one until one changed at or before asOf
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param asOf
+//	@return *domain.DummyHistory
+//	@return error
+func (repo *DummyDynamodbRepo) GetHistoryAsOf(ctx context.Context, id string, asOf time.Time) (*domain.DummyHistory, error) {
+	var found *domain.DummyHistory
+	err := repo.queryHistory(ctx, id, false, func(record *domain.DummyHistory) bool {
+		if record.ChangedAt.After(asOf) {
+			return true
+		}
+		found = record
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// queryHistory
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param forward true for ascending versions
+//	@param fn returns false to stop reading
+//	@return error
+func (repo *DummyDynamodbRepo) queryHistory(
+	ctx context.Context,
+	id string,
+	forward bool,
+	fn func(record *domain.DummyHistory) bool,
+) error {
+	if len(id) == 0 {
+		return nil
+	}
+	input := &dynamodb.QueryInput{
+		TableName:                aws.String(repo.tableName),
+		KeyConditionExpression:   aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]*string{"#pk": aws.String(FieldDummyPK)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk": {S: aws.String(dummyHistoryPKPrefix + id)},
+		},
+		ScanIndexForward: aws.Bool(forward),
+	}
+	for {
+		data, err := repo.client.QueryWithContext(ctx, input)
+		if err != nil {
+			rootErr := errors.New(err.Error())
+			return errors.Wrapf(rootErr, "query db history items error. table: %s, id: %s", repo.tableName, id)
+		}
+		for _, item := range data.Items {
+			record, err := ToDummyHistoryEntity(item)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse db history item. table: %s, id: %s", repo.tableName, id)
+			}
+			if !fn(record) {
+				return nil
+			}
+		}
+		if len(data.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = data.LastEvaluatedKey
+	}
+}
+
+// ToDummyHistoryDBItem
+//
+//	@param record
+//	@return map
+//	@return error
+func ToDummyHistoryDBItem(record *domain.DummyHistory) (map[string]*dynamodb.AttributeValue, error) {
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "marshal dummy history error")
+	}
+	for name, value := range toDummyHistoryDBKey(record.ID, record.Version) {
+		item[name] = value
+	}
+	return item, nil
+}
+
+// ToDummyHistoryEntity
+//
+//	@param item
+//	@return *domain.DummyHistory
+//	@return error
+func ToDummyHistoryEntity(item map[string]*dynamodb.AttributeValue) (*domain.DummyHistory, error) {
+	if len(item) == 0 {
+		return nil, nil
+	}
+	record := &domain.DummyHistory{}
+	err := dynamodbattribute.UnmarshalMap(item, record)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "unmarshal dummy history item error")
+	}
+	return record, nil
+}
+
+// toDummyHistoryDBKey
+// records of an entity share the partition, sorted by the zero padded version
+//
+//	@param id
+//	@param version
+//	@return map
+func toDummyHistoryDBKey(id string, version int64) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(dummyHistoryPKPrefix + id)},
+		FieldDummySK: {S: aws.String(fmt.Sprintf("%020d", version))},
+	}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	dummyOutboxPK string = "dummy_outbox"
+	// outboxTimeLayout fixed width time of the sort key, keys sort in the order of the events
+	outboxTimeLayout string = "2006-01-02T15:04:05.000000000Z"
+)
+
+// addOutbox
+//
+//	@receiver repo
+//	@param uow
+//	@param event
+//	@return error
+func (repo *DummyDynamodbRepo) addOutbox(uow *UnitOfWork, event *domain.DummyEvent) error {
+	item, err := ToDummyOutboxDBItem(event)
+	if err != nil {
+		return err
+	}
+	uow.Put(&dynamodb.Put{
+		TableName:                aws.String(repo.tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+		ExpressionAttributeNames: map[string]*string{"#pk": aws.String(FieldDummyPK)},
+	}, fmt.Sprintf("put dummy outbox %s %s", event.Type, event.ID))
+	return nil
+}
+
+// ListOutbox
+//
+//	@receiver repo
+//	@param ctx
+//	@param limit
+//	@return []*domain.DummyEvent
+//	@return error
+func (repo *DummyDynamodbRepo) ListOutbox(ctx context.Context, limit int) ([]*domain.DummyEvent, error) {
+	events := []*domain.DummyEvent{}
+	if limit <= 0 {
+		return events, nil
+	}
+	data, err := repo.client.QueryWithContext(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(repo.tableName),
+		KeyConditionExpression:   aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]*string{"#pk": aws.String(FieldDummyPK)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk": {S: aws.String(dummyOutboxPK)},
+		},
+		Limit:          aws.Int64(int64(limit)),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "query db outbox items error. table: %s", repo.tableName)
+	}
+	for _, item := range data.Items {
+		event, err := ToDummyOutboxEntity(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse db outbox item. table: %s", repo.tableName)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// DeleteOutbox
+//
+//	@receiver repo
+//	@param ctx
+//	@param event
+//	@return error
+func (repo *DummyDynamodbRepo) DeleteOutbox(ctx context.Context, event *domain.DummyEvent) error {
+	if event == nil {
+		return nil
+	}
+	_, err := repo.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(repo.tableName),
+		Key:       toDummyOutboxDBKey(event),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "delete db outbox item error. table: %s, event: %s", repo.tableName, event.ID)
+	}
+	logger.Debug("delete outbox from db. event: %s", event.ID)
+	return nil
+}
+
+// ToDummyOutboxDBItem
+//
+//	@param event
+//	@return map
+//	@return error
+func ToDummyOutboxDBItem(event *domain.DummyEvent) (map[string]*dynamodb.AttributeValue, error) {
+	item, err := dynamodbattribute.MarshalMap(event)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "marshal dummy event error")
+	}
+	for name, value := range toDummyOutboxDBKey(event) {
+		item[name] = value
+	}
+	return item, nil
+}
+
+// ToDummyOutboxEntity
+//
+//	@param item
+//	@return *domain.DummyEvent
+//	@return error
+func ToDummyOutboxEntity(item map[string]*dynamodb.AttributeValue) (*domain.DummyEvent, error) {
+	if len(item) == 0 {
+		return nil, nil
+	}
+	event := &domain.DummyEvent{}
+	err := dynamodbattribute.UnmarshalMap(item, event)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "unmarshal dummy outbox item error")
+	}
+	return event, nil
+}
+
+// toDummyOutboxDBKey
+// events share one partition, sorted by the time they occurred and then by id
+//
+//	@param event
+//	@return map
+func toDummyOutboxDBKey(event *domain.DummyEvent) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(dummyOutboxPK)},
+		FieldDummySK: {S: aws.String(fmt.Sprintf("%s#%s", event.OccurredAt.UTC().Format(outboxTimeLayout), event.ID))},
+	}
+}
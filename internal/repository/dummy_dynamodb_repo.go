@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/pkg/errors"
 	"local.com/go-clean-lambda/internal/domain"
 	"local.com/go-clean-lambda/internal/logger"
@@ -14,12 +16,18 @@ import (
 const (
 	FieldDummyPK string = "pk"
 	FieldDummySK string = "sk"
+
+	// dummyPK partition of the dummy items
+	dummyPK string = "test"
 )
 
 // DummyDynamodbRepo.
 type DummyDynamodbRepo struct {
 	tableName string
-	client    *dynamodb.DynamoDB
+	client    dynamodbiface.DynamoDBAPI
+	retry     *batchRetry
+	// softDeleteRetention keeps deleted items restorable for it, items are deleted at once when it is 0
+	softDeleteRetention time.Duration
 }
 
 // NewDummyDynamodbRepo
@@ -27,11 +35,29 @@ type DummyDynamodbRepo struct {
 //	@param tableName
 //	@param client
 //	@return *DummyDynamodbRepo
-func NewDummyDynamodbRepo(tableName string, client *dynamodb.DynamoDB) *DummyDynamodbRepo {
+func NewDummyDynamodbRepo(tableName string, client dynamodbiface.DynamoDBAPI) *DummyDynamodbRepo {
 	return &DummyDynamodbRepo{
 		tableName: tableName,
 		client:    client,
+		retry:     newBatchRetry(),
+	}
+}
+
+// WithBatchRetry
+// retries of unprocessed items of batch operations
+//
+//	@receiver repo
+//	@param maxAttempts requests sent for one chunk, including the first one
+//	@param baseDelay backoff before the first retry, doubled for each retry
+//	@param maxDelay
+//	@return *DummyDynamodbRepo
+func (repo *DummyDynamodbRepo) WithBatchRetry(maxAttempts int, baseDelay time.Duration, maxDelay time.Duration) *DummyDynamodbRepo {
+	repo.retry = &batchRetry{
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
 	}
+	return repo
 }
 
 // GetByID
@@ -42,12 +68,25 @@ func NewDummyDynamodbRepo(tableName string, client *dynamodb.DynamoDB) *DummyDyn
 //	@return *domain.Dummy
 //	@return error
 func (repo *DummyDynamodbRepo) GetByID(ctx context.Context, id string) (*domain.Dummy, error) {
+	return repo.getByID(ctx, id, false)
+}
+
+// getByID
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param consistent use strongly consistent read
+//	@return *domain.Dummy
+//	@return error
+func (repo *DummyDynamodbRepo) getByID(ctx context.Context, id string, consistent bool) (*domain.Dummy, error) {
 	if len(id) == 0 {
 		return nil, nil
 	}
-	data, err := repo.client.GetItem(&dynamodb.GetItemInput{
-		TableName: aws.String(repo.tableName),
-		Key:       ToDummyDBKey(domain.ToKeyDummy(id)),
+	data, err := repo.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(repo.tableName),
+		Key:            ToDummyDBKey(domain.ToKeyDummy(id)),
+		ConsistentRead: aws.Bool(consistent),
 	})
 	if err != nil {
 		// new an error to record stack from current position
@@ -55,59 +94,112 @@ func (repo *DummyDynamodbRepo) GetByID(ctx context.Context, id string) (*domain.
 		return nil, errors.Wrapf(rootErr, "get db item error. table: %s, id: %s", repo.tableName, id)
 	}
 	logger.Debug("get from db. item: %s", logger.Pretty(data.Item))
+	if isSoftDeletedDBItem(data.Item) {
+		return nil, nil
+	}
 	return ToDummyEntity(data.Item)
 }
 
 // Insert
+// the item and its history record are written in one transaction.
+// the put is conditioned on the version read first, and on the expected attributes when it replaces a stored item,
+// a soft deleted one included, so only its owner can create it again during the retention.
+// the creation fields of a live item are kept from the read item, a stale read of the caller cannot overwrite them.
 //
 //	@receiver repo
 //	@param ctx
 //	@param dummy
+//	@param opts
 //	@return *domain.Dummy
-//	@return error
-func (repo *DummyDynamodbRepo) Insert(ctx context.Context, dummy *domain.Dummy) (*domain.Dummy, error) {
+//	@return error ErrConditionFailed and others
+func (repo *DummyDynamodbRepo) Insert(
+	ctx context.Context,
+	dummy *domain.Dummy,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
 	if dummy == nil || len(dummy.ID) == 0 {
 		return nil, nil
 	}
-	item, err := ToDummyDBItem(dummy)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed build db item")
-	}
-	_, err = repo.client.PutItem(&dynamodb.PutItemInput{
-		TableName: aws.String(repo.tableName),
-		Item:      item,
+	options := domain.BuildDeleteOptions(opts...)
+	write := newDummyWrite(ctx, dummy.ID)
+	written := dummy
+	err := repo.writeVersioned(ctx, func(uow *UnitOfWork, own bool) error {
+		current, err := repo.getVersioned(ctx, dummy.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load db item before put. id: %s", dummy.ID)
+		}
+		written = dummy
+		if current.dummy == nil {
+			return repo.addPut(uow, dummy, current, domain.DummyChangeCreated, nil, write)
+		}
+		if own {
+			// a shared unit of work reports the failed condition when it is committed
+			err = checkExpectedAttrs(current.dummy, options.ExpectedAttrs)
+			if err != nil {
+				return errors.Wrapf(err, "table: %s, id: %s", repo.tableName, dummy.ID)
+			}
+		}
+		change := domain.DummyChangeCreated
+		if live := current.live(); live != nil {
+			change = domain.DummyChangeUpdated
+			written = keepCreation(dummy, live)
+		}
+		return repo.addPut(uow, written, current, change, options.ExpectedAttrs, write)
 	})
 	if err != nil {
-		rootErr := errors.New(err.Error())
-		return nil, errors.Wrapf(rootErr, "put db item error. table: %s, item: %s", repo.tableName, logger.Pretty(dummy))
+		return nil, errors.Wrapf(err, "put db item error. table: %s, item: %s", repo.tableName, logger.Pretty(dummy))
 	}
-	logger.Debug("put to db. item: %s", logger.Pretty(dummy))
-	return dummy, nil
+	logger.Debug("put to db. item: %s", logger.Pretty(written))
+	return written, nil
 }
 
 // DeleteByID
 //
+// the item is read first, and the delete is conditioned on its version and the expected attributes.
+// the delete and its history record are written in one transaction, see WithSoftDelete to keep the item.
+// a transaction cannot return the old item like ReturnValues ALL_OLD, the read item is returned instead,
+// the version condition makes sure it is the one deleted.
+//
 //	@receiver repo
 //	@param ctx
 //	@param id
+//	@param opts
 //	@return *domain.Dummy
-//	@return error
-func (repo *DummyDynamodbRepo) DeleteByID(ctx context.Context, id string) (*domain.Dummy, error) {
+//	@return error ErrNotFound, ErrConditionFailed and others
+func (repo *DummyDynamodbRepo) DeleteByID(
+	ctx context.Context,
+	id string,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
 	if len(id) == 0 {
 		return nil, nil
 	}
-	_, err := repo.client.DeleteItem(&dynamodb.DeleteItemInput{
-		TableName: aws.String(repo.tableName),
-		Key:       ToDummyDBKey(domain.ToKeyDummy(id)),
+	options := domain.BuildDeleteOptions(opts...)
+	write := newDummyWrite(ctx, id)
+	var deleted *domain.Dummy
+	err := repo.writeVersioned(ctx, func(uow *UnitOfWork, own bool) error {
+		current, err := repo.getVersioned(ctx, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load db item before delete. id: %s", id)
+		}
+		if current.live() == nil {
+			return errors.Wrapf(domain.ErrNotFound, "no db item to delete. table: %s, id: %s", repo.tableName, id)
+		}
+		if own {
+			// a shared unit of work reports the failed condition when it is committed
+			err = checkExpectedAttrs(current.dummy, options.ExpectedAttrs)
+			if err != nil {
+				return errors.Wrapf(err, "table: %s, id: %s", repo.tableName, id)
+			}
+		}
+		deleted = current.dummy
+		return repo.addDelete(uow, current, options, write)
 	})
 	if err != nil {
-		rootErr := errors.New(err.Error())
-		return nil, errors.Wrapf(rootErr, "delete db item error. table: %s, id: %s", repo.tableName, id)
+		return nil, err
 	}
 	logger.Debug("delete from db. id: %s", id)
-	return &domain.Dummy{
-		ID: id,
-	}, nil
+	return deleted, nil
 }
 
 // ToDummyDBKey
@@ -164,7 +256,7 @@ func ToDummyEntity(item map[string]*dynamodb.AttributeValue) (*domain.Dummy, err
 //	@param dummy
 //	@return map
 func addDummyKeys(item map[string]*dynamodb.AttributeValue, dummy *domain.Dummy) map[string]*dynamodb.AttributeValue {
-	item[FieldDummyPK] = &dynamodb.AttributeValue{S: aws.String("test")}
+	item[FieldDummyPK] = &dynamodb.AttributeValue{S: aws.String(dummyPK)}
 	item[FieldDummySK] = &dynamodb.AttributeValue{S: aws.String(dummy.ID)}
 	return item
 }
@@ -0,0 +1,498 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/dynamodbfake"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+)
+
+const (
+	dummyTableName        string = "dummy_table_name"
+	invalidDummyTableName string = "invalid_dummy_table_name"
+)
+
+func buildCreateDummyTableInput() *dynamodb.CreateTableInput {
+	return &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String(repository.FieldDummyPK),
+				AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+			},
+			{
+				AttributeName: aws.String(repository.FieldDummySK),
+				AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+			},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String(repository.FieldDummyPK),
+				KeyType:       aws.String(dynamodb.KeyTypeHash),
+			},
+			{
+				AttributeName: aws.String(repository.FieldDummySK),
+				KeyType:       aws.String(dynamodb.KeyTypeRange),
+			},
+		},
+		TableName: aws.String(dummyTableName),
+	}
+}
+
+func TestDummyGetByIDWithBadRepoReturnError(t *testing.T) {
+	assert := require.New(t)
+	repo := repository.NewDummyDynamodbRepo(invalidDummyTableName, ddb.client)
+
+	id := uuid.New().String()
+	item, err := repo.GetByID(context.TODO(), id)
+
+	msg := "get dummy entity with bad repo didin't fail"
+	assert.Nil(item, msg, "returned item")
+	assert.NotNil(err, msg, "error not found")
+}
+
+func TestDummyGetByIDWithIdReturnEntity(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to get entity by valid id"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+
+	random := uuid.New().String()
+	expected := &domain.Dummy{
+		ID:       random,
+		Name:     fmt.Sprintf("test_name_%s", random),
+		SomeAttr: fmt.Sprintf("test_some_attr_%s", random),
+	}
+	err1 := saveDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{expected},
+		repository.ToDummyDBItem,
+	)
+	if err1 != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err1)
+	}
+
+	actual, err2 := repo.GetByID(context.TODO(), expected.ID)
+	assert.Nil(err2, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong entity")
+}
+
+func TestDummyInsertWithEntityReturnEntity(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to insert valid entity"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+
+	random := uuid.New().String()
+	expected := &domain.Dummy{
+		ID:       random,
+		Name:     fmt.Sprintf("test_name_%s", random),
+		SomeAttr: fmt.Sprintf("test_some_attr_%s", random),
+	}
+
+	actual, err1 := repo.Insert(context.TODO(), expected)
+
+	loaded, err2 := loadDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{expected},
+		repository.ToDummyDBKey,
+		repository.ToDummyEntity,
+	)
+	if err2 != nil {
+		t.Fatalf("%s. error happened when load db data, %v", msg, err2)
+	}
+	assert.Nil(err1, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong actual return item")
+	assert.Len(loaded, 1, msg, "wrong loaded item size")
+	assert.Equal(expected, loaded[0], msg, "wrong actual db item")
+}
+
+func TestDummyDeleteByIDWithIDReturnDeletedEntity(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to delete entity by valid id"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+
+	random := uuid.New().String()
+	expected := &domain.Dummy{
+		ID:       random,
+		Name:     fmt.Sprintf("test_name_%s", random),
+		SomeAttr: fmt.Sprintf("test_some_attr_%s", random),
+	}
+	err1 := saveDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{expected},
+		repository.ToDummyDBItem,
+	)
+	if err1 != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err1)
+	}
+
+	actual, err2 := repo.DeleteByID(context.TODO(), expected.ID)
+
+	loaded, err3 := loadDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{expected},
+		repository.ToDummyDBKey,
+		repository.ToDummyEntity,
+	)
+	if err3 != nil {
+		t.Fatalf("%s. error happened when load db data, %v", msg, err3)
+	}
+	assert.Nil(err2, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong deleted item")
+	assert.Len(loaded, 0, msg, "item left in db")
+}
+
+func TestDummyDeleteByIDWithMissingIDReturnNotFound(t *testing.T) {
+	assert := require.New(t)
+	msg := "delete entity by missing id didn't fail"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+
+	actual, err := repo.DeleteByID(context.TODO(), uuid.New().String())
+
+	assert.Nil(actual, msg, "returned item")
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+}
+
+func TestDummyDeleteByIDWithUnmetConditionReturnConditionFailed(t *testing.T) {
+	assert := require.New(t)
+	msg := "delete entity with unmet condition didn't fail"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+
+	random := uuid.New().String()
+	expected := &domain.Dummy{
+		ID:       random,
+		Name:     fmt.Sprintf("test_name_%s", random),
+		SomeAttr: fmt.Sprintf("test_some_attr_%s", random),
+	}
+	err1 := saveDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{expected},
+		repository.ToDummyDBItem,
+	)
+	if err1 != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err1)
+	}
+
+	actual, err2 := repo.DeleteByID(context.TODO(), expected.ID, domain.WithExpectedAttr("name", "other_name"))
+
+	loaded, err3 := loadDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{expected},
+		repository.ToDummyDBKey,
+		repository.ToDummyEntity,
+	)
+	if err3 != nil {
+		t.Fatalf("%s. error happened when load db data, %v", msg, err3)
+	}
+	assert.Nil(actual, msg, "returned item")
+	assert.ErrorIs(err2, domain.ErrConditionFailed, msg, "wrong error")
+	assert.Len(loaded, 1, msg, "item not kept in db")
+}
+
+func TestDummyDeleteByIDWithMetConditionReturnDeletedEntity(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to delete entity with met condition"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+
+	random := uuid.New().String()
+	expected := &domain.Dummy{
+		ID:       random,
+		Name:     fmt.Sprintf("test_name_%s", random),
+		SomeAttr: fmt.Sprintf("test_some_attr_%s", random),
+	}
+	err1 := saveDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{expected},
+		repository.ToDummyDBItem,
+	)
+	if err1 != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err1)
+	}
+
+	actual, err2 := repo.DeleteByID(context.TODO(), expected.ID, domain.WithExpectedAttr("name", expected.Name))
+
+	assert.Nil(err2, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong deleted item")
+}
+
+func TestDummyWriteInTransactionCommitTogether(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to write entities in one transaction"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+	transactor := repository.NewDynamodbTransactor(ddb.client)
+
+	random := uuid.New().String()
+	existing := &domain.Dummy{ID: random + "_old", Name: "test_name_old"}
+	created := &domain.Dummy{ID: random + "_new", Name: "test_name_new"}
+	err1 := saveDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{existing},
+		repository.ToDummyDBItem,
+	)
+	if err1 != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err1)
+	}
+
+	err2 := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, created)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, existing.ID)
+		return err
+	})
+
+	loaded, err3 := loadDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{existing, created},
+		repository.ToDummyDBKey,
+		repository.ToDummyEntity,
+	)
+	if err3 != nil {
+		t.Fatalf("%s. error happened when load db data, %v", msg, err3)
+	}
+	assert.Nil(err2, msg, "found error")
+	assert.Len(loaded, 1, msg, "wrong loaded item size")
+	assert.Equal(created, loaded[0], msg, "wrong db item")
+}
+
+func TestDummyWriteInTransactionWithUnmetConditionWriteNothing(t *testing.T) {
+	assert := require.New(t)
+	msg := "canceled transaction wrote items"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+	transactor := repository.NewDynamodbTransactor(ddb.client)
+
+	random := uuid.New().String()
+	existing := &domain.Dummy{ID: random + "_old", Name: "test_name_old"}
+	created := &domain.Dummy{ID: random + "_new", Name: "test_name_new"}
+	err1 := saveDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{existing},
+		repository.ToDummyDBItem,
+	)
+	if err1 != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err1)
+	}
+
+	err2 := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, created)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, existing.ID, domain.WithExpectedAttr("name", "other_name"))
+		return err
+	})
+
+	loaded, err3 := loadDdbItems(
+		dummyTableName,
+		[]*domain.Dummy{existing, created},
+		repository.ToDummyDBKey,
+		repository.ToDummyEntity,
+	)
+	if err3 != nil {
+		t.Fatalf("%s. error happened when load db data, %v", msg, err3)
+	}
+	var canceled *repository.TransactCanceledError
+	assert.ErrorAs(err2, &canceled, msg, "wrong error type")
+	assert.ErrorIs(err2, domain.ErrConditionFailed, msg, "wrong error")
+	assert.Len(canceled.Items, 1, msg, "wrong failed item count")
+	// every write is followed by its history record, the delete is the third item
+	assert.Equal(2, canceled.Items[0].Index, msg, "wrong failed item")
+	assert.Len(loaded, 1, msg, "wrong loaded item size")
+	assert.Equal(existing, loaded[0], msg, "wrong db item")
+}
+
+func TestDummyDynamodbRepoContract(t *testing.T) {
+	repositorytest.RunDummyRepositoryContract(t, func(t *testing.T) (domain.DummyRepository, domain.Transactor) {
+		return repository.NewDummyDynamodbRepo(dummyTableName, ddb.client), repository.NewDynamodbTransactor(ddb.client)
+	})
+}
+
+func TestDummyDynamodbRepoOutboxContract(t *testing.T) {
+	repositorytest.RunDummyOutboxContract(t, func(t *testing.T) (domain.DummyRepository, domain.DummyOutbox, domain.Transactor) {
+		repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+		return repo, repo, repository.NewDynamodbTransactor(ddb.client)
+	})
+}
+
+func TestDummyDynamodbRepoSoftDeleteContract(t *testing.T) {
+	repositorytest.RunDummySoftDeleteContract(t, func(t *testing.T, retention time.Duration) (domain.DummyRepository, domain.Transactor) {
+		repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client).WithSoftDelete(retention)
+		return repo, repository.NewDynamodbTransactor(ddb.client)
+	})
+}
+
+// newThrottledDummyRepo
+// the fake processes at most capacity items per batch request
+func newThrottledDummyRepo(t *testing.T, capacity int, maxAttempts int) *repository.DummyDynamodbRepo {
+	t.Helper()
+	client := dynamodbfake.New().WithBatchCapacity(capacity)
+	err := createDdbTables(client)
+	if err != nil {
+		t.Fatalf("error happened when creating table, %v", err)
+	}
+	return repository.NewDummyDynamodbRepo(dummyTableName, client).
+		WithBatchRetry(maxAttempts, time.Millisecond, time.Millisecond)
+}
+
+// throttledTransactClient
+// cancels the first transactions writing each id with a throttling reason.
+type throttledTransactClient struct {
+	dynamodbiface.DynamoDBAPI
+	mu       sync.Mutex
+	throttle func(id string) bool
+	attempts map[string]int
+	limit    int
+}
+
+func (c *throttledTransactClient) TransactWriteItemsWithContext(
+	ctx aws.Context,
+	input *dynamodb.TransactWriteItemsInput,
+	opts ...request.Option,
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	id := aws.StringValue(input.TransactItems[0].Put.Item[repository.FieldDummySK].S)
+	c.mu.Lock()
+	c.attempts[id]++
+	throttled := c.throttle(id) && c.attempts[id] <= c.limit
+	c.mu.Unlock()
+	if throttled {
+		reasons := make([]*dynamodb.CancellationReason, len(input.TransactItems))
+		for i := range reasons {
+			reasons[i] = &dynamodb.CancellationReason{Code: aws.String("ThrottlingError")}
+		}
+		return nil, &dynamodb.TransactionCanceledException{CancellationReasons: reasons}
+	}
+	return c.DynamoDBAPI.TransactWriteItemsWithContext(ctx, input, opts...)
+}
+
+// newThrottledTransactDummyRepo
+// transactions writing an id accepted by throttle are canceled limit times
+func newThrottledTransactDummyRepo(
+	t *testing.T, throttle func(id string) bool, limit int, maxAttempts int,
+) *repository.DummyDynamodbRepo {
+	t.Helper()
+	client := &throttledTransactClient{
+		DynamoDBAPI: dynamodbfake.New(),
+		throttle:    throttle,
+		attempts:    make(map[string]int),
+		limit:       limit,
+	}
+	err := createDdbTables(client)
+	if err != nil {
+		t.Fatalf("error happened when creating table, %v", err)
+	}
+	return repository.NewDummyDynamodbRepo(dummyTableName, client).
+		WithBatchRetry(maxAttempts, time.Millisecond, time.Millisecond)
+}
+
+func TestDummyBatchInsertWithThrottledItemsRetry(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to retry throttled items"
+	repo := newThrottledTransactDummyRepo(t, func(string) bool { return true }, 2, 3)
+	entities := make([]*domain.Dummy, 30)
+	ids := make([]string, len(entities))
+	for i := range entities {
+		entities[i] = repositorytest.NewDummy()
+		ids[i] = entities[i].ID
+	}
+
+	results, err := repo.BatchInsert(context.TODO(), entities)
+	assert.Nil(err, msg, "found error")
+	for _, result := range results {
+		assert.Nil(result.Err, msg, "found item error")
+	}
+	loaded, err := repo.BatchGetByIDs(context.TODO(), ids)
+	assert.Nil(err, msg, "found error")
+	for i, result := range loaded {
+		assert.Nil(result.Err, msg, "found item error")
+		assert.Equal(entities[i], result.Dummy, msg, "wrong loaded entity")
+	}
+}
+
+func TestDummyBatchInsertWithExhaustedRetriesReportUnprocessed(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to report unprocessed items"
+	entities := make([]*domain.Dummy, 25)
+	throttled := make(map[string]bool)
+	for i := range entities {
+		entities[i] = repositorytest.NewDummy()
+		throttled[entities[i].ID] = i >= 10
+	}
+	repo := newThrottledTransactDummyRepo(t, func(id string) bool { return throttled[id] }, 2, 2)
+
+	results, err := repo.BatchInsert(context.TODO(), entities)
+
+	assert.Nil(err, msg, "found error")
+	processed := 0
+	for _, result := range results {
+		if result.Err == nil {
+			processed++
+			continue
+		}
+		assert.True(throttled[result.ID], msg, "item not throttled failed")
+		assert.ErrorIs(result.Err, domain.ErrBatchItemUnprocessed, msg, "wrong item error")
+		assert.Nil(result.Dummy, msg, "failed item returned entity")
+	}
+	assert.Equal(10, processed, msg, "wrong processed count")
+}
+
+func TestDummyHistoryWithChangesReturnVersions(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to write history records"
+	repo := repository.NewDummyDynamodbRepo(dummyTableName, ddb.client)
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	entity := repositorytest.NewDummy()
+	// written before versioning, without a version attribute
+	err := saveDdbItems(dummyTableName, []*domain.Dummy{entity}, repository.ToDummyDBItem)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	updated := *entity
+	updated.Name = "updated_name"
+	_, err = repo.Insert(domain.WithChange(context.TODO(), "updater", created), &updated)
+	assert.Nil(err, msg, "found error")
+	_, err = repo.DeleteByID(domain.WithChange(context.TODO(), "deleter", created.Add(time.Hour)), entity.ID)
+	assert.Nil(err, msg, "found error")
+
+	records, err := repo.ListHistory(context.TODO(), entity.ID)
+	assert.Nil(err, msg, "found error")
+	assert.Len(records, 2, msg, "wrong record count")
+	assert.Equal(int64(1), records[0].Version, msg, "wrong first version")
+	assert.Equal(domain.DummyChangeUpdated, records[0].Change, msg, "wrong first change")
+	assert.Equal("updater", records[0].ChangedBy, msg, "wrong first actor")
+	assert.Equal(&updated, records[0].Snapshot, msg, "wrong first snapshot")
+	assert.Equal(int64(2), records[1].Version, msg, "wrong second version")
+	assert.Equal(domain.DummyChangeDeleted, records[1].Change, msg, "wrong second change")
+	asOf, err := repo.GetHistoryAsOf(context.TODO(), entity.ID, created.Add(time.Minute))
+	assert.Nil(err, msg, "found error")
+	assert.Equal(records[0], asOf, msg, "wrong record as of time")
+	version, err := repo.GetHistoryVersion(context.TODO(), entity.ID, 2)
+	assert.Nil(err, msg, "found error")
+	assert.Equal(records[1], version, msg, "wrong record of version")
+}
+
+func TestDummyBatchGetByIDsWithCanceledContextReportError(t *testing.T) {
+	assert := require.New(t)
+	msg := "canceled batch get didn't fail"
+	repo := newThrottledDummyRepo(t, 1, 5)
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	results, err := repo.BatchGetByIDs(ctx, []string{uuid.New().String(), uuid.New().String()})
+
+	assert.Nil(err, msg, "found error")
+	assert.Nil(results[0].Err, msg, "processed item failed")
+	assert.ErrorIs(results[1].Err, context.Canceled, msg, "wrong item error")
+}
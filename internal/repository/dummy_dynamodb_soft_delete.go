@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	// FieldDummyDeletedAt time a soft deleted item was deleted, it is not a field of the entity.
+	FieldDummyDeletedAt string = "deletedAt"
+	// FieldDummyExpireAt ttl attribute of the table in epoch seconds, the item is purged by DynamoDB after it.
+	FieldDummyExpireAt string = "expireAt"
+)
+
+// WithSoftDelete
+// keep deleted items restorable for the retention, they are hidden from reads and purged by the table ttl
+//
+//	@receiver repo
+//	@param retention items are deleted at once when it is 0
+//	@return *DummyDynamodbRepo
+func (repo *DummyDynamodbRepo) WithSoftDelete(retention time.Duration) *DummyDynamodbRepo {
+	repo.softDeleteRetention = retention
+	return repo
+}
+
+// RestoreByID
+//
+// the restore and its history record are written in one transaction.
+// an item past its retention is not restored even when DynamoDB has not purged it yet.
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param opts
+//	@return *domain.Dummy
+//	@return error ErrNotFound, ErrConditionFailed and others
+func (repo *DummyDynamodbRepo) RestoreByID(
+	ctx context.Context,
+	id string,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
+	if len(id) == 0 {
+		return nil, nil
+	}
+	options := domain.BuildDeleteOptions(opts...)
+	write := newDummyWrite(ctx, id)
+	var restored *domain.Dummy
+	err := repo.writeVersioned(ctx, func(uow *UnitOfWork, own bool) error {
+		current, err := repo.getVersioned(ctx, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load db item before restore. id: %s", id)
+		}
+		if current.dummy == nil || current.deletedAt.IsZero() {
+			return errors.Wrapf(domain.ErrNotFound, "no deleted db item to restore. table: %s, id: %s", repo.tableName, id)
+		}
+		if !write.at.Before(current.deletedAt.Add(repo.softDeleteRetention)) {
+			return errors.Wrapf(domain.ErrNotFound, "retention of deleted db item expired. table: %s, id: %s, deletedAt: %s",
+				repo.tableName, id, current.deletedAt.Format(time.RFC3339))
+		}
+		if own {
+			err = checkExpectedAttrs(current.dummy, options.ExpectedAttrs)
+			if err != nil {
+				return errors.Wrapf(err, "table: %s, id: %s", repo.tableName, id)
+			}
+		}
+		restored = current.dummy
+		return repo.addPut(uow, current.dummy, current, domain.DummyChangeRestored, options.ExpectedAttrs, write)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("restore in db. id: %s", id)
+	return restored, nil
+}
+
+// toSoftDeletedDBItem
+//
+//	@receiver repo
+//	@param dummy
+//	@param version
+//	@param deletedAt
+//	@return map
+//	@return error
+func (repo *DummyDynamodbRepo) toSoftDeletedDBItem(
+	dummy *domain.Dummy,
+	version int64,
+	deletedAt time.Time,
+) (map[string]*dynamodb.AttributeValue, error) {
+	item, err := ToDummyDBItem(dummy)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed build db item")
+	}
+	expireAt := deletedAt.Add(repo.softDeleteRetention).Unix()
+	item[FieldDummyVersion] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(version, 10))}
+	item[FieldDummyDeletedAt] = &dynamodb.AttributeValue{S: aws.String(deletedAt.UTC().Format(time.RFC3339Nano))}
+	item[FieldDummyExpireAt] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expireAt, 10))}
+	return item, nil
+}
+
+// isSoftDeletedDBItem
+//
+//	@param item
+//	@return bool
+func isSoftDeletedDBItem(item map[string]*dynamodb.AttributeValue) bool {
+	_, ok := item[FieldDummyDeletedAt]
+	return ok
+}
+
+// parseDeletedAt
+//
+//	@param item
+//	@return time.Time zero when the item is not soft deleted
+//	@return error
+func parseDeletedAt(item map[string]*dynamodb.AttributeValue) (time.Time, error) {
+	attr, ok := item[FieldDummyDeletedAt]
+	if !ok {
+		return time.Time{}, nil
+	}
+	deletedAt, err := time.Parse(time.RFC3339Nano, aws.StringValue(attr.S))
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return time.Time{}, errors.Wrap(rootErr, "invalid deletedAt of db item")
+	}
+	return deletedAt, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// IsDummyStreamKeys
+// the table also holds history and outbox items, only the changes of dummy items are decoded as entities
+//
+//	@param keys keys of a stream record
+//	@return bool
+func IsDummyStreamKeys(keys map[string]events.DynamoDBAttributeValue) bool {
+	pk, ok := keys[FieldDummyPK]
+	return ok && pk.DataType() == events.DataTypeString && pk.String() == dummyPK
+}
+
+// ToDummyStreamEntity
+// decode an old or new image of a stream record with ToDummyEntity.
+// a soft deleted item is not visible to reads, its image is decoded as nil.
+//
+//	@param image
+//	@return *domain.Dummy nil when the image is missing or soft deleted
+//	@return error
+func ToDummyStreamEntity(image map[string]events.DynamoDBAttributeValue) (*domain.Dummy, error) {
+	item, err := FromStreamImage(image)
+	if err != nil {
+		return nil, err
+	}
+	if isSoftDeletedDBItem(item) {
+		return nil, nil
+	}
+	return ToDummyEntity(item)
+}
+
+// FromStreamImage
+// convert an image of a stream record to the attribute values of the sdk
+//
+//	@param image
+//	@return map
+//	@return error
+func FromStreamImage(image map[string]events.DynamoDBAttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+	item := make(map[string]*dynamodb.AttributeValue, len(image))
+	for name, value := range image {
+		attr, err := fromStreamAttribute(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "attribute: %s", name)
+		}
+		item[name] = attr
+	}
+	return item, nil
+}
+
+// fromStreamAttribute
+//
+//	@param value
+//	@return *dynamodb.AttributeValue
+//	@return error
+func fromStreamAttribute(value events.DynamoDBAttributeValue) (*dynamodb.AttributeValue, error) {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &dynamodb.AttributeValue{S: aws.String(value.String())}, nil
+	case events.DataTypeNumber:
+		return &dynamodb.AttributeValue{N: aws.String(value.Number())}, nil
+	case events.DataTypeBoolean:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(value.Boolean())}, nil
+	case events.DataTypeBinary:
+		return &dynamodb.AttributeValue{B: value.Binary()}, nil
+	case events.DataTypeNull:
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}, nil
+	case events.DataTypeStringSet:
+		return &dynamodb.AttributeValue{SS: aws.StringSlice(value.StringSet())}, nil
+	case events.DataTypeNumberSet:
+		return &dynamodb.AttributeValue{NS: aws.StringSlice(value.NumberSet())}, nil
+	case events.DataTypeBinarySet:
+		return &dynamodb.AttributeValue{BS: value.BinarySet()}, nil
+	case events.DataTypeList:
+		list := make([]*dynamodb.AttributeValue, 0, len(value.List()))
+		for _, elem := range value.List() {
+			attr, err := fromStreamAttribute(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, attr)
+		}
+		return &dynamodb.AttributeValue{L: list}, nil
+	case events.DataTypeMap:
+		m, err := FromStreamImage(value.Map())
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.AttributeValue{M: m}, nil
+	default:
+		return nil, errors.Errorf("unsupported stream attribute type: %d", value.DataType())
+	}
+}
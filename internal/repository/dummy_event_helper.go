@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// dummyWrite
+// who writes an entity, when, and the event recorded for the write.
+type dummyWrite struct {
+	by    string
+	at    time.Time
+	event *domain.DummyEvent
+}
+
+// newDummyWrite
+//
+//	@param ctx
+//	@param id
+//	@return *dummyWrite
+func newDummyWrite(ctx context.Context, id string) *dummyWrite {
+	by, at := domain.ChangeFromContext(ctx)
+	return &dummyWrite{
+		by:    by,
+		at:    at,
+		event: domain.DummyEventFromContext(ctx, id),
+	}
+}
+
+// historyOf
+//
+//	@receiver write
+//	@param id
+//	@param version
+//	@param change
+//	@param snapshot
+//	@return *domain.DummyHistory
+func (write *dummyWrite) historyOf(
+	id string,
+	version int64,
+	change domain.DummyChange,
+	snapshot *domain.Dummy,
+) *domain.DummyHistory {
+	return &domain.DummyHistory{
+		ID:        id,
+		Version:   version,
+		Change:    change,
+		ChangedAt: write.at,
+		ChangedBy: write.by,
+		Snapshot:  snapshot,
+	}
+}
+
+// eventOf
+// complete a copy of the recorded event with the history record written with it, the type follows its change
+//
+//	@receiver write
+//	@param record
+//	@return *domain.DummyEvent nil when no event is recorded
+func (write *dummyWrite) eventOf(record *domain.DummyHistory) *domain.DummyEvent {
+	if write.event == nil {
+		return nil
+	}
+	event := *write.event
+	event.Type = domain.DummyEventTypeOf(record.Change)
+	event.AggregateVersion = record.Version
+	event.Data = copyDummy(record.Snapshot)
+	return &event
+}
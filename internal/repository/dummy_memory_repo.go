@@ -0,0 +1,667 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+// DummyMemoryRepo
+// implements domain.DummyRepository in memory, safe for concurrent use.
+type DummyMemoryRepo struct {
+	mu      sync.RWMutex
+	items   map[string]*domain.Dummy
+	history map[string][]*domain.DummyHistory
+	// deleted holds soft deleted items, they are kept for softDeleteRetention
+	deleted             map[string]*softDeletedDummy
+	softDeleteRetention time.Duration
+	// outbox holds events not published yet, in the order they were written
+	outbox []*domain.DummyEvent
+}
+
+// softDeletedDummy.
+type softDeletedDummy struct {
+	dummy     *domain.Dummy
+	deletedAt time.Time
+}
+
+// NewDummyMemoryRepo
+//
+//	@param entities initial entities
+//	@return *DummyMemoryRepo
+func NewDummyMemoryRepo(entities []*domain.Dummy) *DummyMemoryRepo {
+	items := make(map[string]*domain.Dummy)
+	for _, entity := range entities {
+		if entity == nil || len(entity.ID) == 0 {
+			continue
+		}
+		items[entity.ID] = copyDummy(entity)
+	}
+	return &DummyMemoryRepo{
+		items:   items,
+		history: make(map[string][]*domain.DummyHistory),
+		deleted: make(map[string]*softDeletedDummy),
+	}
+}
+
+// WithSoftDelete
+// keep deleted items restorable for the retention, they are hidden from reads and purged after it
+//
+//	@receiver repo
+//	@param retention items are deleted at once when it is 0
+//	@return *DummyMemoryRepo
+func (repo *DummyMemoryRepo) WithSoftDelete(retention time.Duration) *DummyMemoryRepo {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.softDeleteRetention = retention
+	return repo
+}
+
+// GetByID
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return *domain.Dummy
+//	@return error
+func (repo *DummyMemoryRepo) GetByID(ctx context.Context, id string) (*domain.Dummy, error) {
+	if len(id) == 0 {
+		return nil, nil
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return copyDummy(repo.items[id]), nil
+}
+
+// Insert
+//
+//	@receiver repo
+//	@param ctx
+//	@param dummy
+//	@param opts
+//	@return *domain.Dummy
+//	@return error ErrConditionFailed and others
+func (repo *DummyMemoryRepo) Insert(
+	ctx context.Context,
+	dummy *domain.Dummy,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
+	if dummy == nil || len(dummy.ID) == 0 {
+		return nil, nil
+	}
+	options := domain.BuildDeleteOptions(opts...)
+	stored := copyDummy(dummy)
+	write := newDummyWrite(ctx, dummy.ID)
+	if uow := MemoryUnitOfWorkFromContext(ctx); uow != nil {
+		uow.add(&memoryTxOp{
+			locker: &repo.mu,
+			desc:   fmt.Sprintf("put dummy %s", dummy.ID),
+			check: func() error {
+				return repo.checkReplaceable(dummy.ID, options, write.at)
+			},
+			apply: func() {
+				repo.put(stored, write)
+			},
+		})
+		return dummy, nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	err := repo.checkReplaceable(dummy.ID, options, write.at)
+	if err != nil {
+		return nil, err
+	}
+	repo.put(stored, write)
+	logger.Debug("put to memory. item: %s", logger.Pretty(stored))
+	return copyDummy(stored), nil
+}
+
+// DeleteByID
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param opts
+//	@return *domain.Dummy
+//	@return error ErrNotFound, ErrConditionFailed and others
+func (repo *DummyMemoryRepo) DeleteByID(
+	ctx context.Context,
+	id string,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
+	if len(id) == 0 {
+		return nil, nil
+	}
+	options := domain.BuildDeleteOptions(opts...)
+	write := newDummyWrite(ctx, id)
+	if uow := MemoryUnitOfWorkFromContext(ctx); uow != nil {
+		current, _ := repo.GetByID(ctx, id)
+		if current == nil {
+			return nil, errors.Wrapf(domain.ErrNotFound, "no memory item to delete. id: %s", id)
+		}
+		uow.add(&memoryTxOp{
+			locker: &repo.mu,
+			desc:   fmt.Sprintf("delete dummy %s", id),
+			check: func() error {
+				return repo.checkExpected(id, options)
+			},
+			apply: func() {
+				repo.remove(id, write)
+			},
+		})
+		return current, nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	err := repo.checkExpected(id, options)
+	if err != nil {
+		return nil, err
+	}
+	deleted := repo.remove(id, write)
+	logger.Debug("delete from memory. id: %s", id)
+	return deleted, nil
+}
+
+// RestoreByID
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param opts
+//	@return *domain.Dummy
+//	@return error ErrNotFound, ErrConditionFailed and others
+func (repo *DummyMemoryRepo) RestoreByID(
+	ctx context.Context,
+	id string,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
+	if len(id) == 0 {
+		return nil, nil
+	}
+	options := domain.BuildDeleteOptions(opts...)
+	write := newDummyWrite(ctx, id)
+	if uow := MemoryUnitOfWorkFromContext(ctx); uow != nil {
+		repo.mu.RLock()
+		current, err := repo.restorable(id, write.at)
+		repo.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		uow.add(&memoryTxOp{
+			locker: &repo.mu,
+			desc:   fmt.Sprintf("restore dummy %s", id),
+			check: func() error {
+				current, err := repo.restorable(id, write.at)
+				if err != nil {
+					return err
+				}
+				return errors.Wrapf(checkExpectedAttrs(current, options.ExpectedAttrs), "id: %s", id)
+			},
+			apply: func() {
+				repo.restore(id, write)
+			},
+		})
+		return copyDummy(current), nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	current, err := repo.restorable(id, write.at)
+	if err != nil {
+		return nil, err
+	}
+	err = checkExpectedAttrs(current, options.ExpectedAttrs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "id: %s", id)
+	}
+	restored := repo.restore(id, write)
+	logger.Debug("restore in memory. id: %s", id)
+	return copyDummy(restored), nil
+}
+
+// BatchGetByIDs
+//
+//	@receiver repo
+//	@param ctx
+//	@param ids
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyMemoryRepo) BatchGetByIDs(ctx context.Context, ids []string) ([]*domain.DummyBatchResult, error) {
+	plan := newBatchPlan(ids, true)
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	for _, id := range plan.ids {
+		plan.setDummy(id, repo.items[id])
+	}
+	return plan.results, nil
+}
+
+// BatchInsert
+//
+//	@receiver repo
+//	@param ctx
+//	@param dummies
+//	@param opts
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyMemoryRepo) BatchInsert(
+	ctx context.Context,
+	dummies []*domain.Dummy,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	options := domain.BuildDeleteOptions(opts...)
+	plan := newDummyBatchPlan(dummies)
+	stored := []*domain.Dummy{}
+	for i, dummy := range dummies {
+		if dummy == nil || plan.results[i].Err != nil {
+			continue
+		}
+		plan.results[i].Dummy = dummy
+		stored = append(stored, copyDummy(dummy))
+	}
+	if uow := MemoryUnitOfWorkFromContext(ctx); uow != nil {
+		for _, dummy := range stored {
+			dummy := dummy
+			write := newDummyWrite(ctx, dummy.ID)
+			uow.add(&memoryTxOp{
+				locker: &repo.mu,
+				desc:   fmt.Sprintf("put dummy %s", dummy.ID),
+				check: func() error {
+					return repo.checkReplaceable(dummy.ID, options, write.at)
+				},
+				apply: func() {
+					repo.put(dummy, write)
+				},
+			})
+		}
+		return plan.results, nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, dummy := range stored {
+		write := newDummyWrite(ctx, dummy.ID)
+		err := repo.checkReplaceable(dummy.ID, options, write.at)
+		if err != nil {
+			plan.setErr(dummy.ID, err)
+			continue
+		}
+		repo.put(dummy, write)
+		plan.setDummy(dummy.ID, dummy)
+	}
+	logger.Debug("batch put to memory. items: %d", len(stored))
+	return plan.results, nil
+}
+
+// BatchDeleteByIDs
+//
+//	@receiver repo
+//	@param ctx
+//	@param ids
+//	@param opts
+//	@return []*domain.DummyBatchResult
+//	@return error
+func (repo *DummyMemoryRepo) BatchDeleteByIDs(
+	ctx context.Context,
+	ids []string,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	options := domain.BuildDeleteOptions(opts...)
+	plan := newBatchPlan(ids, false)
+	if uow := MemoryUnitOfWorkFromContext(ctx); uow != nil {
+		for _, id := range plan.ids {
+			id := id
+			if current, _ := repo.GetByID(ctx, id); current == nil {
+				plan.setErr(id, errors.Wrapf(domain.ErrNotFound, "no memory item to delete. id: %s", id))
+				continue
+			}
+			write := newDummyWrite(ctx, id)
+			uow.add(&memoryTxOp{
+				locker: &repo.mu,
+				desc:   fmt.Sprintf("delete dummy %s", id),
+				check: func() error {
+					return repo.checkExpected(id, options)
+				},
+				apply: func() {
+					repo.remove(id, write)
+				},
+			})
+		}
+		return plan.results, nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, id := range plan.ids {
+		err := repo.checkExpected(id, options)
+		if err != nil {
+			plan.setErr(id, err)
+			continue
+		}
+		repo.remove(id, newDummyWrite(ctx, id))
+	}
+	logger.Debug("batch delete from memory. items: %d", len(plan.ids))
+	return plan.results, nil
+}
+
+// ListHistory
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return []*domain.DummyHistory
+//	@return error
+func (repo *DummyMemoryRepo) ListHistory(ctx context.Context, id string) ([]*domain.DummyHistory, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	records := make([]*domain.DummyHistory, 0, len(repo.history[id]))
+	for _, record := range repo.history[id] {
+		records = append(records, copyDummyHistory(record))
+	}
+	return records, nil
+}
+
+// GetHistoryVersion
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param version
+//	@return *domain.DummyHistory
+//	@return error
+func (repo *DummyMemoryRepo) GetHistoryVersion(ctx context.Context, id string, version int64) (*domain.DummyHistory, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	records := repo.history[id]
+	if version < 1 || version > int64(len(records)) {
+		return nil, nil
+	}
+	return copyDummyHistory(records[version-1]), nil
+}
+
+// GetHistoryAsOf
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@param asOf
+//	@return *domain.DummyHistory
+//	@return error
+func (repo *DummyMemoryRepo) GetHistoryAsOf(ctx context.Context, id string, asOf time.Time) (*domain.DummyHistory, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	records := repo.history[id]
+	for i := len(records) - 1; i >= 0; i-- {
+		if !records[i].ChangedAt.After(asOf) {
+			return copyDummyHistory(records[i]), nil
+		}
+	}
+	return nil, nil
+}
+
+// ListOutbox
+//
+//	@receiver repo
+//	@param ctx
+//	@param limit
+//	@return []*domain.DummyEvent
+//	@return error
+func (repo *DummyMemoryRepo) ListOutbox(ctx context.Context, limit int) ([]*domain.DummyEvent, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	events := []*domain.DummyEvent{}
+	for _, event := range repo.outbox {
+		if len(events) >= limit {
+			break
+		}
+		copied := *event
+		copied.Data = copyDummy(event.Data)
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// DeleteOutbox
+//
+//	@receiver repo
+//	@param ctx
+//	@param event
+//	@return error
+func (repo *DummyMemoryRepo) DeleteOutbox(ctx context.Context, event *domain.DummyEvent) error {
+	if event == nil {
+		return nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for i, pending := range repo.outbox {
+		if pending.ID == event.ID {
+			repo.outbox = append(repo.outbox[:i], repo.outbox[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// put
+// must be called with the lock held, the creation fields of the existing item are set to dummy
+//
+//	@receiver repo
+//	@param dummy
+//	@param write
+func (repo *DummyMemoryRepo) put(dummy *domain.Dummy, write *dummyWrite) {
+	change := domain.DummyChangeCreated
+	if existing, ok := repo.items[dummy.ID]; ok {
+		change = domain.DummyChangeUpdated
+		dummy.CreatedAt = existing.CreatedAt
+		dummy.CreatedBy = existing.CreatedBy
+	}
+	delete(repo.deleted, dummy.ID)
+	repo.items[dummy.ID] = dummy
+	repo.appendHistory(dummy.ID, change, dummy, write)
+}
+
+// remove
+// must be called with the lock held
+//
+//	@receiver repo
+//	@param id
+//	@param write
+//	@return *domain.Dummy the removed entity, nil when missing
+func (repo *DummyMemoryRepo) remove(id string, write *dummyWrite) *domain.Dummy {
+	deleted, ok := repo.items[id]
+	if !ok {
+		return nil
+	}
+	delete(repo.items, id)
+	repo.purgeDeleted(write.at)
+	if repo.softDeleteRetention > 0 {
+		repo.deleted[id] = &softDeletedDummy{dummy: deleted, deletedAt: write.at}
+	}
+	repo.appendHistory(id, domain.DummyChangeDeleted, deleted, write)
+	return deleted
+}
+
+// restorable
+// must be called with the lock held
+//
+//	@receiver repo
+//	@param id
+//	@param at time of the restore
+//	@return *domain.Dummy
+//	@return error ErrNotFound when the item is not soft deleted or its retention expired
+func (repo *DummyMemoryRepo) restorable(id string, at time.Time) (*domain.Dummy, error) {
+	deleted, ok := repo.deleted[id]
+	if !ok {
+		return nil, errors.Wrapf(domain.ErrNotFound, "no deleted memory item to restore. id: %s", id)
+	}
+	if !at.Before(deleted.deletedAt.Add(repo.softDeleteRetention)) {
+		return nil, errors.Wrapf(domain.ErrNotFound, "retention of deleted memory item expired. id: %s", id)
+	}
+	return deleted.dummy, nil
+}
+
+// restore
+// must be called with the lock held
+//
+//	@receiver repo
+//	@param id
+//	@param write
+//	@return *domain.Dummy the restored entity, nil when it is not soft deleted
+func (repo *DummyMemoryRepo) restore(id string, write *dummyWrite) *domain.Dummy {
+	deleted, ok := repo.deleted[id]
+	if !ok {
+		return nil
+	}
+	delete(repo.deleted, id)
+	repo.items[id] = deleted.dummy
+	repo.appendHistory(id, domain.DummyChangeRestored, deleted.dummy, write)
+	return deleted.dummy
+}
+
+// purgeDeleted
+// drop soft deleted items past their retention, must be called with the lock held
+//
+//	@receiver repo
+//	@param now
+func (repo *DummyMemoryRepo) purgeDeleted(now time.Time) {
+	for id, deleted := range repo.deleted {
+		if !now.Before(deleted.deletedAt.Add(repo.softDeleteRetention)) {
+			delete(repo.deleted, id)
+		}
+	}
+}
+
+// appendHistory
+// the event recorded for the write is added to the outbox with the record, must be called with the lock held
+//
+//	@receiver repo
+//	@param id
+//	@param change
+//	@param snapshot
+//	@param write
+func (repo *DummyMemoryRepo) appendHistory(id string, change domain.DummyChange, snapshot *domain.Dummy, write *dummyWrite) {
+	record := write.historyOf(id, int64(len(repo.history[id])+1), change, copyDummy(snapshot))
+	repo.history[id] = append(repo.history[id], record)
+	if event := write.eventOf(record); event != nil {
+		repo.outbox = append(repo.outbox, event)
+	}
+}
+
+// checkExpected
+// must be called with the lock held
+//
+//	@receiver repo
+//	@param id
+//	@param options
+//	@return error ErrNotFound, ErrConditionFailed and others
+func (repo *DummyMemoryRepo) checkExpected(id string, options *domain.DeleteOptions) error {
+	current, ok := repo.items[id]
+	if !ok {
+		return errors.Wrapf(domain.ErrNotFound, "id: %s", id)
+	}
+	return errors.Wrapf(checkExpectedAttrs(current, options.ExpectedAttrs), "id: %s", id)
+}
+
+// checkReplaceable
+// a missing item is created without checking, a soft deleted one is checked until its retention expires.
+// must be called with the lock held
+//
+//	@receiver repo
+//	@param id
+//	@param options
+//	@param at time of the write
+//	@return error ErrConditionFailed and others
+func (repo *DummyMemoryRepo) checkReplaceable(id string, options *domain.DeleteOptions, at time.Time) error {
+	current, ok := repo.items[id]
+	if !ok {
+		deleted, ok := repo.deleted[id]
+		if !ok || !at.Before(deleted.deletedAt.Add(repo.softDeleteRetention)) {
+			return nil
+		}
+		current = deleted.dummy
+	}
+	return errors.Wrapf(checkExpectedAttrs(current, options.ExpectedAttrs), "id: %s", id)
+}
+
+// checkExpectedAttrs
+//
+//	@param entity
+//	@param expected json field name to value
+//	@return error ErrConditionFailed when an attribute is missing or holds another value, and others
+func checkExpectedAttrs(entity any, expected map[string]string) error {
+	if len(expected) == 0 {
+		return nil
+	}
+	attrs, err := toAttrMap(entity)
+	if err != nil {
+		return err
+	}
+	for name, value := range expected {
+		actual, ok := attrs[name]
+		if !ok || fmt.Sprint(actual) != value {
+			return errors.Wrapf(domain.ErrConditionFailed, "attribute: %s", name)
+		}
+	}
+	return nil
+}
+
+// toAttrMap
+// convert entity into a map keyed by json field names, the same names used as db attributes
+//
+//	@param v
+//	@return map[string]any
+//	@return error
+func toAttrMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "marshal entity error")
+	}
+	attrs := make(map[string]any)
+	err = json.Unmarshal(data, &attrs)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "unmarshal entity error")
+	}
+	return attrs, nil
+}
+
+// copyDummy
+//
+//	@param dummy
+//	@return *domain.Dummy
+func copyDummy(dummy *domain.Dummy) *domain.Dummy {
+	if dummy == nil {
+		return nil
+	}
+	copied := *dummy
+	return &copied
+}
+
+// keepCreation
+//
+//	@param dummy
+//	@param existing
+//	@return *domain.Dummy a copy of dummy with the creation fields of existing
+func keepCreation(dummy *domain.Dummy, existing *domain.Dummy) *domain.Dummy {
+	kept := copyDummy(dummy)
+	kept.CreatedAt = existing.CreatedAt
+	kept.CreatedBy = existing.CreatedBy
+	return kept
+}
+
+// copyDummyHistory
+//
+//	@param record
+//	@return *domain.DummyHistory
+func copyDummyHistory(record *domain.DummyHistory) *domain.DummyHistory {
+	if record == nil {
+		return nil
+	}
+	copied := *record
+	copied.Snapshot = copyDummy(record.Snapshot)
+	return &copied
+}
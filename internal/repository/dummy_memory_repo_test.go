@@ -0,0 +1,29 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+)
+
+func TestDummyMemoryRepoContract(t *testing.T) {
+	repositorytest.RunDummyRepositoryContract(t, func(t *testing.T) (domain.DummyRepository, domain.Transactor) {
+		return repository.NewDummyMemoryRepo(nil), repository.NewMemoryTransactor()
+	})
+}
+
+func TestDummyMemoryRepoSoftDeleteContract(t *testing.T) {
+	repositorytest.RunDummySoftDeleteContract(t, func(t *testing.T, retention time.Duration) (domain.DummyRepository, domain.Transactor) {
+		return repository.NewDummyMemoryRepo(nil).WithSoftDelete(retention), repository.NewMemoryTransactor()
+	})
+}
+
+func TestDummyMemoryRepoOutboxContract(t *testing.T) {
+	repositorytest.RunDummyOutboxContract(t, func(t *testing.T) (domain.DummyRepository, domain.DummyOutbox, domain.Transactor) {
+		repo := repository.NewDummyMemoryRepo(nil)
+		return repo, repo, repository.NewMemoryTransactor()
+	})
+}
@@ -0,0 +1,20 @@
+//go:build !integration
+// +build !integration
+
+package repository_test
+
+import (
+	"log"
+
+	"local.com/go-clean-lambda/internal/repository/dynamodbfake"
+)
+
+func setup() {
+	ddb.client = dynamodbfake.New()
+	err := createDdbTables(ddb.client)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func teardown() {}
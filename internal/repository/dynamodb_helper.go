@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/pkg/errors"
+)
+
+// buildVersionedCondition
+// build a condition requiring the item to hold version, and for an existing item every attribute to equal the expected value
+//
+//	@param version 0 for an item that is missing or written before versioning
+//	@param expected attribute name to value
+//	@param mustExist require the item to exist
+//	@return *expression.Expression
+//	@return error
+func buildVersionedCondition(version int64, expected map[string]string, mustExist bool) (*expression.Expression, error) {
+	cond := expression.Name(FieldDummyVersion).Equal(expression.Value(version))
+	if version == 0 {
+		cond = expression.AttributeNotExists(expression.Name(FieldDummyVersion))
+	}
+	if mustExist {
+		cond = expression.AttributeExists(expression.Name(FieldDummyPK)).And(cond)
+	}
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	// keep the expression stable for logs and tests
+	sort.Strings(names)
+	for _, name := range names {
+		cond = cond.And(expression.Name(name).Equal(expression.Value(expected[name])))
+	}
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "build condition expression error")
+	}
+	return &expr, nil
+}
+
+// isAwsErrorCode
+//
+//	@param err
+//	@param code
+//	@return bool
+func isAwsErrorCode(err error, code string) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	return awsErr.Code() == code
+}
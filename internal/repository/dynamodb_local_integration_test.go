@@ -0,0 +1,98 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func setup() {
+	err := buildDockerComposePath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = startDdbLocal()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("docker compose started")
+	ddb.client = buildDdbClient()
+	fmt.Println("dynamodb client inited")
+	err = createDdbTables(ddb.client)
+	if err != nil {
+		fmt.Printf("failed to create dynamodb tables. caused by: %v \n", err)
+		err2 := shutDdbLocal()
+		if err2 != nil {
+			fmt.Printf("failed to shutdown dynamodb local. caused by: %v \n", err2)
+		}
+		log.Fatal(err)
+	}
+	fmt.Println("dynamodb tables created")
+	fmt.Println("dynamodb local setup completed")
+}
+
+func buildDockerComposePath() error {
+	currDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	configDir, err := filepath.Abs(currDir + "../../../test/dynamodb-local")
+	if err != nil {
+		return err
+	}
+	ddb.dcPath = configDir
+	fmt.Printf("ddb local docker compose config: %s \n", configDir)
+	return nil
+}
+
+func teardown() {
+	err := shutDdbLocal()
+	if err != nil {
+		fmt.Printf("failed to shutdown dynamodb local, caused by: %v \n", err)
+	} else {
+		fmt.Println("dynamodb local teardown completed")
+	}
+}
+
+func startDdbLocal() error {
+	// another solution to run docker: use testcontainers
+	// problem: import toooo many changes in go.sum
+	// refs
+	//   - github.com/testcontainers/testcontainers-go
+	//   - https://golang.testcontainers.org/features/docker_compose/
+	cmd := exec.Command("docker-compose", "up", "-d", "--force-recreate")
+	cmd.Dir = ddb.dcPath
+	cmdOutput, err := cmd.Output()
+	fmt.Printf("%s std:\n    %s \n", cmd.String(), string(cmdOutput))
+	return err
+}
+
+func shutDdbLocal() error {
+	cmd := exec.Command("docker-compose", "down")
+	cmd.Dir = ddb.dcPath
+	cmdOutput, err := cmd.Output()
+	fmt.Printf("%s std:\n    %s \n", cmd.String(), string(cmdOutput))
+	return err
+}
+
+func buildDdbClient() *dynamodb.DynamoDB {
+	awsopt := session.Options{
+		Config: aws.Config{
+			Region:      aws.String("local"),
+			Endpoint:    aws.String("http://localhost:8000"),
+			Credentials: credentials.NewStaticCredentials("dummy", "dummy", "dummy"),
+		},
+	}
+	awssess := session.Must(session.NewSessionWithOptions(awsopt))
+	return dynamodb.New(awssess)
+}
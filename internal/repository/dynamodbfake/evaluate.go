@@ -0,0 +1,556 @@
+package dynamodbfake
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+type item = map[string]*dynamodb.AttributeValue
+
+const (
+	typeS    string = "S"
+	typeN    string = "N"
+	typeB    string = "B"
+	typeBOOL string = "BOOL"
+	typeNULL string = "NULL"
+	typeL    string = "L"
+	typeM    string = "M"
+	typeSS   string = "SS"
+	typeNS   string = "NS"
+	typeBS   string = "BS"
+)
+
+// evaluate
+//
+//	@param cond nil is always true
+//	@param it item to evaluate, nil when the item does not exist
+//	@return bool
+//	@return error
+func evaluate(cond *condition, it item) (bool, error) {
+	if cond == nil {
+		return true, nil
+	}
+	switch cond.op {
+	case "AND", "OR":
+		left, err := evaluate(cond.children[0], it)
+		if err != nil {
+			return false, err
+		}
+		if cond.op == "AND" && !left {
+			return false, nil
+		}
+		if cond.op == "OR" && left {
+			return true, nil
+		}
+		return evaluate(cond.children[1], it)
+	case "NOT":
+		result, err := evaluate(cond.children[0], it)
+		return !result, err
+	case funcAttributeExists:
+		return resolvePath(it, cond.operands[0].path) != nil, nil
+	case funcAttributeNotExists:
+		return resolvePath(it, cond.operands[0].path) == nil, nil
+	}
+	operands := make([]*dynamodb.AttributeValue, len(cond.operands))
+	for i, o := range cond.operands {
+		v, err := resolveOperand(it, o)
+		if err != nil {
+			return false, err
+		}
+		operands[i] = v
+	}
+	switch cond.op {
+	case funcAttributeType:
+		return evaluateAttributeType(operands[0], operands[1])
+	case funcBeginsWith:
+		return evaluateBeginsWith(operands[0], operands[1]), nil
+	case funcContains:
+		return evaluateContains(operands[0], operands[1]), nil
+	case "BETWEEN":
+		low, ok1 := compare(operands[0], operands[1])
+		high, ok2 := compare(operands[0], operands[2])
+		return ok1 && ok2 && low >= 0 && high <= 0, nil
+	case "IN":
+		for _, candidate := range operands[1:] {
+			if equal(operands[0], candidate) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return evaluateComparator(cond.op, operands[0], operands[1]), nil
+	}
+}
+
+// evaluateComparator
+// comparing with a missing attribute or a value of another type is false, except for <> between existing values.
+func evaluateComparator(op string, left *dynamodb.AttributeValue, right *dynamodb.AttributeValue) bool {
+	if left == nil || right == nil {
+		return false
+	}
+	switch op {
+	case "=":
+		return equal(left, right)
+	case "<>":
+		return !equal(left, right)
+	}
+	result, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+	switch op {
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	default:
+		return false
+	}
+}
+
+func evaluateAttributeType(v *dynamodb.AttributeValue, typ *dynamodb.AttributeValue) (bool, error) {
+	if typ == nil || typ.S == nil {
+		return false, errors.New("attribute_type requires a string type operand")
+	}
+	if v == nil {
+		return false, nil
+	}
+	return typeOf(v) == *typ.S, nil
+}
+
+func evaluateBeginsWith(v *dynamodb.AttributeValue, prefix *dynamodb.AttributeValue) bool {
+	if v == nil || prefix == nil {
+		return false
+	}
+	switch {
+	case v.S != nil && prefix.S != nil:
+		return strings.HasPrefix(*v.S, *prefix.S)
+	case v.B != nil && prefix.B != nil:
+		return bytes.HasPrefix(v.B, prefix.B)
+	default:
+		return false
+	}
+}
+
+func evaluateContains(v *dynamodb.AttributeValue, elem *dynamodb.AttributeValue) bool {
+	if v == nil || elem == nil {
+		return false
+	}
+	switch {
+	case v.S != nil && elem.S != nil:
+		return strings.Contains(*v.S, *elem.S)
+	case v.B != nil && elem.B != nil:
+		return bytes.Contains(v.B, elem.B)
+	case v.SS != nil && elem.S != nil:
+		return containsString(v.SS, *elem.S)
+	case v.NS != nil && elem.N != nil:
+		for _, n := range v.NS {
+			if compareNumber(aws.StringValue(n), *elem.N) == 0 {
+				return true
+			}
+		}
+		return false
+	case v.BS != nil && elem.B != nil:
+		for _, b := range v.BS {
+			if bytes.Equal(b, elem.B) {
+				return true
+			}
+		}
+		return false
+	case v.L != nil:
+		for _, e := range v.L {
+			if equal(e, elem) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// resolveOperand
+//
+//	@param it
+//	@param o
+//	@return *dynamodb.AttributeValue nil when the path does not exist
+//	@return error
+func resolveOperand(it item, o *operand) (*dynamodb.AttributeValue, error) {
+	switch {
+	case o.value != nil:
+		return o.value, nil
+	case o.path != nil:
+		return resolvePath(it, o.path), nil
+	case o.fn == funcSize:
+		return evaluateSize(resolvePath(it, o.args[0].path)), nil
+	case o.fn == funcIfNotExists:
+		current := resolvePath(it, o.args[0].path)
+		if current != nil {
+			return current, nil
+		}
+		return resolveOperand(it, o.args[1])
+	case o.fn == funcListAppend:
+		return evaluateListAppend(it, o.args[0], o.args[1])
+	case o.arith != "":
+		return evaluateArith(it, o)
+	default:
+		return nil, errors.New("invalid operand")
+	}
+}
+
+func evaluateSize(v *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if v == nil {
+		return nil
+	}
+	size := -1
+	switch {
+	case v.S != nil:
+		size = len(*v.S)
+	case v.B != nil:
+		size = len(v.B)
+	case v.SS != nil:
+		size = len(v.SS)
+	case v.NS != nil:
+		size = len(v.NS)
+	case v.BS != nil:
+		size = len(v.BS)
+	case v.L != nil:
+		size = len(v.L)
+	case v.M != nil:
+		size = len(v.M)
+	}
+	if size < 0 {
+		return nil
+	}
+	return &dynamodb.AttributeValue{N: aws.String(big.NewInt(int64(size)).String())}
+}
+
+func evaluateListAppend(it item, first *operand, second *operand) (*dynamodb.AttributeValue, error) {
+	left, err := resolveOperand(it, first)
+	if err != nil {
+		return nil, err
+	}
+	right, err := resolveOperand(it, second)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil || left.L == nil || right.L == nil {
+		return nil, errors.New("list_append requires two lists")
+	}
+	list := make([]*dynamodb.AttributeValue, 0, len(left.L)+len(right.L))
+	list = append(list, left.L...)
+	list = append(list, right.L...)
+	return &dynamodb.AttributeValue{L: list}, nil
+}
+
+func evaluateArith(it item, o *operand) (*dynamodb.AttributeValue, error) {
+	left, err := resolveOperand(it, o.args[0])
+	if err != nil {
+		return nil, err
+	}
+	right, err := resolveOperand(it, o.args[1])
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil || left.N == nil || right.N == nil {
+		return nil, errors.New("an operand in the update expression has an incorrect data type")
+	}
+	x, ok1 := new(big.Float).SetString(*left.N)
+	y, ok2 := new(big.Float).SetString(*right.N)
+	if !ok1 || !ok2 {
+		return nil, errors.New("invalid number")
+	}
+	if o.arith == "+" {
+		x = x.Add(x, y)
+	} else {
+		x = x.Sub(x, y)
+	}
+	return &dynamodb.AttributeValue{N: aws.String(formatNumber(x))}, nil
+}
+
+// resolvePath
+//
+//	@param it
+//	@param p
+//	@return *dynamodb.AttributeValue nil when the path does not exist
+func resolvePath(it item, p path) *dynamodb.AttributeValue {
+	if it == nil || len(p) == 0 {
+		return nil
+	}
+	current := it[p[0].name]
+	for _, e := range p[1:] {
+		if current == nil {
+			return nil
+		}
+		if e.isIdx {
+			if current.L == nil || e.index >= len(current.L) {
+				return nil
+			}
+			current = current.L[e.index]
+		} else {
+			if current.M == nil {
+				return nil
+			}
+			current = current.M[e.name]
+		}
+	}
+	return current
+}
+
+// typeOf
+//
+//	@param v
+//	@return string
+func typeOf(v *dynamodb.AttributeValue) string {
+	switch {
+	case v == nil:
+		return ""
+	case v.S != nil:
+		return typeS
+	case v.N != nil:
+		return typeN
+	case v.B != nil:
+		return typeB
+	case v.BOOL != nil:
+		return typeBOOL
+	case v.NULL != nil:
+		return typeNULL
+	case v.L != nil:
+		return typeL
+	case v.M != nil:
+		return typeM
+	case v.SS != nil:
+		return typeSS
+	case v.NS != nil:
+		return typeNS
+	case v.BS != nil:
+		return typeBS
+	default:
+		return ""
+	}
+}
+
+// compare
+// order of scalar values of the same type
+//
+//	@param a
+//	@param b
+//	@return int
+//	@return bool false when the values cannot be ordered
+func compare(a *dynamodb.AttributeValue, b *dynamodb.AttributeValue) (int, bool) {
+	if a == nil || b == nil || typeOf(a) != typeOf(b) {
+		return 0, false
+	}
+	switch typeOf(a) {
+	case typeS:
+		return strings.Compare(*a.S, *b.S), true
+	case typeN:
+		return compareNumber(*a.N, *b.N), true
+	case typeB:
+		return bytes.Compare(a.B, b.B), true
+	default:
+		return 0, false
+	}
+}
+
+// equal
+//
+//	@param a
+//	@param b
+//	@return bool
+func equal(a *dynamodb.AttributeValue, b *dynamodb.AttributeValue) bool {
+	if a == nil || b == nil || typeOf(a) != typeOf(b) {
+		return false
+	}
+	if result, ok := compare(a, b); ok {
+		return result == 0
+	}
+	switch typeOf(a) {
+	case typeSS:
+		return sameStringSet(a.SS, b.SS)
+	case typeNS:
+		return sameNumberSet(a.NS, b.NS)
+	case typeL:
+		if len(a.L) != len(b.L) {
+			return false
+		}
+		for i := range a.L {
+			if !equal(a.L[i], b.L[i]) {
+				return false
+			}
+		}
+		return true
+	case typeM:
+		if len(a.M) != len(b.M) {
+			return false
+		}
+		for k, v := range a.M {
+			if !equal(v, b.M[k]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+func compareNumber(a string, b string) int {
+	x, ok1 := new(big.Float).SetString(a)
+	y, ok2 := new(big.Float).SetString(b)
+	if !ok1 || !ok2 {
+		return strings.Compare(a, b)
+	}
+	return x.Cmp(y)
+}
+
+func formatNumber(f *big.Float) string {
+	if f.IsInt() {
+		i, _ := f.Int(nil)
+		return i.String()
+	}
+	return f.Text('f', -1)
+}
+
+func containsString(values []*string, s string) bool {
+	for _, v := range values {
+		if aws.StringValue(v) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sameStringSet(a []*string, b []*string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		if !containsString(b, aws.StringValue(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameNumberSet(a []*string, b []*string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if compareNumber(aws.StringValue(x), aws.StringValue(y)) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// copyValue
+// deep copy so stored items never share memory with callers
+//
+//	@param v
+//	@return *dynamodb.AttributeValue
+func copyValue(v *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if v == nil {
+		return nil
+	}
+	c := &dynamodb.AttributeValue{}
+	if v.S != nil {
+		c.S = aws.String(*v.S)
+	}
+	if v.N != nil {
+		c.N = aws.String(*v.N)
+	}
+	if v.B != nil {
+		c.B = append([]byte{}, v.B...)
+	}
+	if v.BOOL != nil {
+		c.BOOL = aws.Bool(*v.BOOL)
+	}
+	if v.NULL != nil {
+		c.NULL = aws.Bool(*v.NULL)
+	}
+	if v.SS != nil {
+		c.SS = aws.StringSlice(aws.StringValueSlice(v.SS))
+	}
+	if v.NS != nil {
+		c.NS = aws.StringSlice(aws.StringValueSlice(v.NS))
+	}
+	if v.BS != nil {
+		c.BS = make([][]byte, len(v.BS))
+		for i, b := range v.BS {
+			c.BS[i] = append([]byte{}, b...)
+		}
+	}
+	if v.L != nil {
+		c.L = make([]*dynamodb.AttributeValue, len(v.L))
+		for i, e := range v.L {
+			c.L[i] = copyValue(e)
+		}
+	}
+	if v.M != nil {
+		c.M = copyItem(v.M)
+	}
+	return c
+}
+
+// copyItem
+//
+//	@param it
+//	@return item
+func copyItem(it item) item {
+	if it == nil {
+		return nil
+	}
+	c := make(item, len(it))
+	for k, v := range it {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+// project
+// keep only the projected paths of an item
+//
+//	@param it
+//	@param paths nil keeps all attributes
+//	@return item
+func project(it item, paths []path) item {
+	if it == nil {
+		return nil
+	}
+	if paths == nil {
+		return copyItem(it)
+	}
+	result := make(item)
+	for _, p := range paths {
+		v := resolvePath(it, p)
+		if v == nil {
+			continue
+		}
+		if len(p) == 1 {
+			result[p[0].name] = copyValue(v)
+			continue
+		}
+		// nested projection keeps the enclosing maps and lists, lists are compacted as dynamodb does
+		_ = setPath(result, p, copyValue(v), true)
+	}
+	return result
+}
@@ -0,0 +1,595 @@
+package dynamodbfake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenName  // #name placeholder
+	tokenValue // :value placeholder
+	tokenNumber
+	tokenSymbol
+)
+
+const (
+	funcAttributeExists    string = "attribute_exists"
+	funcAttributeNotExists string = "attribute_not_exists"
+	funcAttributeType      string = "attribute_type"
+	funcBeginsWith         string = "begins_with"
+	funcContains           string = "contains"
+	funcSize               string = "size"
+	funcIfNotExists        string = "if_not_exists"
+	funcListAppend         string = "list_append"
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize
+// split an expression into tokens
+//
+//	@param expr
+//	@return []token
+//	@return error
+func tokenize(expr string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' || r == ':':
+			j := i + 1
+			for j < len(runes) && isIdentRune(runes[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, errors.Errorf("invalid placeholder at %d in expression: %s", i, expr)
+			}
+			kind := tokenName
+			if r == ':' {
+				kind = tokenValue
+			}
+			tokens = append(tokens, token{kind: kind, text: string(runes[i:j])})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[i:j])})
+			i = j
+		case isIdentRune(r):
+			j := i
+			for j < len(runes) && isIdentRune(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[i:j])})
+			i = j
+		case r == '<' || r == '>':
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				tokens = append(tokens, token{kind: tokenSymbol, text: string(runes[i : i+2])})
+				i += 2
+			} else {
+				tokens = append(tokens, token{kind: tokenSymbol, text: string(r)})
+				i++
+			}
+		case strings.ContainsRune("=(),.[]+-", r):
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r)})
+			i++
+		default:
+			return nil, errors.Errorf("unexpected character %q at %d in expression: %s", r, i, expr)
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF})
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// pathElem one step of a document path, either a map key or a list index.
+type pathElem struct {
+	name  string
+	index int
+	isIdx bool
+}
+
+type path []pathElem
+
+// topName
+//
+//	@receiver p
+//	@return string
+func (p path) topName() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return p[0].name
+}
+
+// String
+//
+//	@receiver p
+//	@return string
+func (p path) String() string {
+	sb := strings.Builder{}
+	for i, e := range p {
+		switch {
+		case e.isIdx:
+			sb.WriteString(fmt.Sprintf("[%d]", e.index))
+		case i == 0:
+			sb.WriteString(e.name)
+		default:
+			sb.WriteString("." + e.name)
+		}
+	}
+	return sb.String()
+}
+
+// operand
+// a value in an expression: a document path, a value placeholder or a function returning a value.
+type operand struct {
+	path  path
+	value *dynamodb.AttributeValue
+	fn    string
+	args  []*operand
+	// arith is + or - between args[0] and args[1] in update expressions
+	arith string
+}
+
+// condition
+// node of a condition, filter or key condition expression.
+type condition struct {
+	// op is one of AND, OR, NOT, BETWEEN, IN, a comparator, or a function name
+	op       string
+	children []*condition
+	operands []*operand
+}
+
+// parser
+// recursive descent parser for condition, projection and update expressions.
+type parser struct {
+	tokens []token
+	pos    int
+	names  map[string]*string
+	values map[string]*dynamodb.AttributeValue
+	// used records placeholders that were referenced
+	used map[string]bool
+}
+
+func newParser(
+	expr string,
+	names map[string]*string,
+	values map[string]*dynamodb.AttributeValue,
+) (*parser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{
+		tokens: tokens,
+		names:  names,
+		values: values,
+		used:   make(map[string]bool),
+	}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(word string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && strings.EqualFold(t.text, word)
+}
+
+func (p *parser) isSymbol(symbol string) bool {
+	t := p.peek()
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	t := p.next()
+	if t.kind != tokenSymbol || t.text != symbol {
+		return errors.Errorf("expected %q but got %q", symbol, t.text)
+	}
+	return nil
+}
+
+func (p *parser) expectEOF() error {
+	if t := p.peek(); t.kind != tokenEOF {
+		return errors.Errorf("unexpected token %q", t.text)
+	}
+	return nil
+}
+
+// parseCondition
+//
+//	condition := and (OR and)*
+//	@receiver p
+//	@return *condition
+//	@return error
+func (p *parser) parseCondition() (*condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &condition{op: "OR", children: []*condition{left, right}}
+	}
+	return left, nil
+}
+
+// parseAnd
+//
+//	and := not (AND not)*
+func (p *parser) parseAnd() (*condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &condition{op: "AND", children: []*condition{left, right}}
+	}
+	return left, nil
+}
+
+// parseNot
+//
+//	not := NOT not | primary
+func (p *parser) parseNot() (*condition, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		child, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &condition{op: "NOT", children: []*condition{child}}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary
+//
+//	primary := '(' condition ')' | function | operand comparator operand
+//	         | operand BETWEEN operand AND operand | operand IN '(' operand (',' operand)* ')'
+func (p *parser) parsePrimary() (*condition, error) {
+	if p.isSymbol("(") {
+		p.next()
+		cond, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expectSymbol(")")
+	}
+	t := p.peek()
+	if t.kind == tokenIdent && p.peekAt(1).text == "(" && isConditionFunc(t.text) {
+		return p.parseConditionFunc()
+	}
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.isKeyword("BETWEEN"):
+		p.next()
+		low, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("AND") {
+			return nil, errors.Errorf("expected AND in BETWEEN but got %q", p.peek().text)
+		}
+		p.next()
+		high, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &condition{op: "BETWEEN", operands: []*operand{left, low, high}}, nil
+	case p.isKeyword("IN"):
+		p.next()
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		operands := []*operand{left}
+		for {
+			o, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, o)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+		return &condition{op: "IN", operands: operands}, p.expectSymbol(")")
+	}
+	comparator := p.next()
+	if comparator.kind != tokenSymbol || !isComparator(comparator.text) {
+		return nil, errors.Errorf("expected comparator but got %q", comparator.text)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &condition{op: comparator.text, operands: []*operand{left, right}}, nil
+}
+
+// parseConditionFunc
+//
+//	function := name '(' operand (',' operand)* ')'
+func (p *parser) parseConditionFunc() (*condition, error) {
+	name := strings.ToLower(p.next().text)
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	want := 2
+	if name == funcAttributeExists || name == funcAttributeNotExists {
+		want = 1
+	}
+	if len(args) != want || args[0].path == nil {
+		return nil, errors.Errorf("invalid arguments of function %s", name)
+	}
+	return &condition{op: name, operands: args}, nil
+}
+
+func (p *parser) parseArgs() ([]*operand, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	args := []*operand{}
+	for {
+		o, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, o)
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	return args, p.expectSymbol(")")
+}
+
+// parseOperand
+//
+//	operand := path | :value | size(path) | if_not_exists(path, operand) | list_append(operand, operand)
+func (p *parser) parseOperand() (*operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenValue:
+		p.next()
+		v, ok := p.values[t.text]
+		if !ok {
+			return nil, errors.Errorf("value placeholder %s is not defined", t.text)
+		}
+		p.used[t.text] = true
+		return &operand{value: v}, nil
+	case t.kind == tokenIdent && p.peekAt(1).text == "(":
+		name := strings.ToLower(t.text)
+		if name != funcSize && name != funcIfNotExists && name != funcListAppend {
+			return nil, errors.Errorf("function %s cannot be used as operand", t.text)
+		}
+		p.next()
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		if (name == funcSize && len(args) != 1) || (name != funcSize && len(args) != 2) {
+			return nil, errors.Errorf("invalid arguments of function %s", name)
+		}
+		if (name == funcSize || name == funcIfNotExists) && args[0].path == nil {
+			return nil, errors.Errorf("first argument of function %s must be a path", name)
+		}
+		return &operand{fn: name, args: args}, nil
+	case t.kind == tokenIdent || t.kind == tokenName:
+		pth, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		return &operand{path: pth}, nil
+	default:
+		return nil, errors.Errorf("expected operand but got %q", t.text)
+	}
+}
+
+// parsePath
+//
+//	path := name ('.' name | '[' number ']')*
+func (p *parser) parsePath() (path, error) {
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	result := path{{name: name}}
+	for {
+		switch {
+		case p.isSymbol("."):
+			p.next()
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, pathElem{name: name})
+		case p.isSymbol("["):
+			p.next()
+			t := p.next()
+			if t.kind != tokenNumber {
+				return nil, errors.Errorf("expected list index but got %q", t.text)
+			}
+			index, err := strconv.Atoi(t.text)
+			if err != nil {
+				return nil, errors.Errorf("invalid list index %q", t.text)
+			}
+			if err := p.expectSymbol("]"); err != nil {
+				return nil, err
+			}
+			result = append(result, pathElem{index: index, isIdx: true})
+		default:
+			return result, nil
+		}
+	}
+}
+
+func (p *parser) parseName() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenName:
+		name, ok := p.names[t.text]
+		if !ok || name == nil {
+			return "", errors.Errorf("name placeholder %s is not defined", t.text)
+		}
+		p.used[t.text] = true
+		return *name, nil
+	case tokenIdent:
+		if isReservedWord(t.text) {
+			return "", errors.Errorf("attribute name is a reserved keyword: %s", t.text)
+		}
+		return t.text, nil
+	default:
+		return "", errors.Errorf("expected attribute name but got %q", t.text)
+	}
+}
+
+// parseProjection
+//
+//	projection := path (',' path)*
+func (p *parser) parseProjection() ([]path, error) {
+	paths := []path{}
+	for {
+		pth, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, pth)
+		if !p.isSymbol(",") {
+			break
+		}
+		p.next()
+	}
+	return paths, p.expectEOF()
+}
+
+func isComparator(s string) bool {
+	switch s {
+	case "=", "<>", "<", "<=", ">", ">=":
+		return true
+	default:
+		return false
+	}
+}
+
+func isConditionFunc(name string) bool {
+	switch strings.ToLower(name) {
+	case funcAttributeExists, funcAttributeNotExists, funcAttributeType, funcBeginsWith, funcContains:
+		return true
+	default:
+		return false
+	}
+}
+
+// isReservedWord
+// only the keywords of the expression grammar are rejected, the full list of dynamodb is much longer.
+func isReservedWord(word string) bool {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT", "BETWEEN", "IN", "SET", "REMOVE", "ADD", "DELETE":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseConditionExpression
+//
+//	@param expr
+//	@param names
+//	@param values
+//	@return *condition nil when expr is nil or blank
+//	@return map[string]bool placeholders used
+//	@return error
+func parseConditionExpression(
+	expr *string,
+	names map[string]*string,
+	values map[string]*dynamodb.AttributeValue,
+) (*condition, map[string]bool, error) {
+	if expr == nil || strings.TrimSpace(*expr) == "" {
+		return nil, map[string]bool{}, nil
+	}
+	p, err := newParser(*expr, names, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	cond, err := p.parseCondition()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid expression %q", *expr)
+	}
+	if err := p.expectEOF(); err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid expression %q", *expr)
+	}
+	return cond, p.used, nil
+}
+
+// parseProjectionExpression
+//
+//	@param expr
+//	@param names
+//	@return []path nil when expr is nil or blank
+//	@return map[string]bool placeholders used
+//	@return error
+func parseProjectionExpression(expr *string, names map[string]*string) ([]path, map[string]bool, error) {
+	if expr == nil || strings.TrimSpace(*expr) == "" {
+		return nil, map[string]bool{}, nil
+	}
+	p, err := newParser(*expr, names, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths, err := p.parseProjection()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid projection %q", *expr)
+	}
+	return paths, p.used, nil
+}
@@ -0,0 +1,1198 @@
+package dynamodbfake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const (
+	errCodeValidation string = "ValidationException"
+
+	maxBatchWriteItems    int = 25
+	maxBatchGetItems      int = 100
+	maxTransactWriteItems int = 100
+
+	cancellationCodeNone              string = "None"
+	cancellationCodeConditionalFailed string = "ConditionalCheckFailed"
+	conditionalFailedMessage          string = "The conditional request failed"
+)
+
+// table
+// items are keyed by the encoded primary key.
+type table struct {
+	description *dynamodb.TableDescription
+	hashKey     string
+	rangeKey    string
+	items       map[string]item
+}
+
+// Fake
+// in-process implementation of dynamodbiface.DynamoDBAPI for tests.
+// operations not overridden here panic through the nil embedded interface.
+type Fake struct {
+	dynamodbiface.DynamoDBAPI
+	mu     sync.Mutex
+	tables map[string]*table
+	// batchCapacity limits items processed by one batch request, 0 means no limit
+	batchCapacity int
+}
+
+// New
+//
+//	@return *Fake
+func New() *Fake {
+	return &Fake{
+		tables: make(map[string]*table),
+	}
+}
+
+// WithBatchCapacity
+// BatchGetItem and BatchWriteItem process at most capacity items of each request
+// and return the rest as unprocessed, like a throttled table
+//
+//	@receiver f
+//	@param capacity 0 means no limit
+//	@return *Fake
+func (f *Fake) WithBatchCapacity(capacity int) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batchCapacity = capacity
+	return f
+}
+
+// hasCapacity
+// must be called with the lock held
+//
+//	@receiver f
+//	@param processed items already processed by the request
+//	@return bool
+func (f *Fake) hasCapacity(processed int) bool {
+	return f.batchCapacity <= 0 || processed < f.batchCapacity
+}
+
+// CreateTable
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.CreateTableOutput
+//	@return error
+func (f *Fake) CreateTable(input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	return f.CreateTableWithContext(context.Background(), input)
+}
+
+// CreateTableWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.CreateTableOutput
+//	@return error
+func (f *Fake) CreateTableWithContext(
+	ctx context.Context, input *dynamodb.CreateTableInput, opts ...request.Option,
+) (*dynamodb.CreateTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := aws.StringValue(input.TableName)
+	if _, ok := f.tables[name]; ok {
+		return nil, &dynamodb.ResourceInUseException{Message_: aws.String("Table already exists: " + name)}
+	}
+	t := &table{
+		items: make(map[string]item),
+	}
+	for _, key := range input.KeySchema {
+		switch aws.StringValue(key.KeyType) {
+		case dynamodb.KeyTypeHash:
+			t.hashKey = aws.StringValue(key.AttributeName)
+		case dynamodb.KeyTypeRange:
+			t.rangeKey = aws.StringValue(key.AttributeName)
+		}
+	}
+	if t.hashKey == "" {
+		return nil, validationError("no hash key in key schema of table: %s", name)
+	}
+	t.description = &dynamodb.TableDescription{
+		TableName:            aws.String(name),
+		TableStatus:          aws.String(dynamodb.TableStatusActive),
+		KeySchema:            input.KeySchema,
+		AttributeDefinitions: input.AttributeDefinitions,
+	}
+	f.tables[name] = t
+	return &dynamodb.CreateTableOutput{TableDescription: t.description}, nil
+}
+
+// DescribeTable
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.DescribeTableOutput
+//	@return error
+func (f *Fake) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	return f.DescribeTableWithContext(context.Background(), input)
+}
+
+// DescribeTableWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.DescribeTableOutput
+//	@return error
+func (f *Fake) DescribeTableWithContext(
+	ctx context.Context, input *dynamodb.DescribeTableInput, opts ...request.Option,
+) (*dynamodb.DescribeTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	description := *t.description
+	description.ItemCount = aws.Int64(int64(len(t.items)))
+	return &dynamodb.DescribeTableOutput{Table: &description}, nil
+}
+
+// DeleteTable
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.DeleteTableOutput
+//	@return error
+func (f *Fake) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamodb.DeleteTableOutput, error) {
+	return f.DeleteTableWithContext(context.Background(), input)
+}
+
+// DeleteTableWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.DeleteTableOutput
+//	@return error
+func (f *Fake) DeleteTableWithContext(
+	ctx context.Context, input *dynamodb.DeleteTableInput, opts ...request.Option,
+) (*dynamodb.DeleteTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(f.tables, aws.StringValue(input.TableName))
+	return &dynamodb.DeleteTableOutput{TableDescription: t.description}, nil
+}
+
+// GetItem
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.GetItemOutput
+//	@return error
+func (f *Fake) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return f.GetItemWithContext(context.Background(), input)
+}
+
+// GetItemWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.GetItemOutput
+//	@return error
+func (f *Fake) GetItemWithContext(
+	ctx context.Context, input *dynamodb.GetItemInput, opts ...request.Option,
+) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.encodeKey(input.Key, true)
+	if err != nil {
+		return nil, err
+	}
+	paths, used, err := parseProjectionExpression(input.ProjectionExpression, input.ExpressionAttributeNames)
+	if err != nil {
+		return nil, validationError("%s", err.Error())
+	}
+	if err := checkUnused(input.ExpressionAttributeNames, nil, used); err != nil {
+		return nil, err
+	}
+	return &dynamodb.GetItemOutput{Item: project(t.items[key], paths)}, nil
+}
+
+// PutItem
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.PutItemOutput
+//	@return error
+func (f *Fake) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	return f.PutItemWithContext(context.Background(), input)
+}
+
+// PutItemWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.PutItemOutput
+//	@return error
+func (f *Fake) PutItemWithContext(
+	ctx context.Context, input *dynamodb.PutItemInput, opts ...request.Option,
+) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.encodeKey(input.Item, false)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[key]
+	err = checkCondition(input.ConditionExpression, input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues, old)
+	if err != nil {
+		return nil, err
+	}
+	t.items[key] = copyItem(input.Item)
+	output := &dynamodb.PutItemOutput{}
+	if aws.StringValue(input.ReturnValues) == dynamodb.ReturnValueAllOld {
+		output.Attributes = old
+	}
+	return output, nil
+}
+
+// DeleteItem
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.DeleteItemOutput
+//	@return error
+func (f *Fake) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	return f.DeleteItemWithContext(context.Background(), input)
+}
+
+// DeleteItemWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.DeleteItemOutput
+//	@return error
+func (f *Fake) DeleteItemWithContext(
+	ctx context.Context, input *dynamodb.DeleteItemInput, opts ...request.Option,
+) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.encodeKey(input.Key, true)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[key]
+	err = checkCondition(input.ConditionExpression, input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues, old)
+	if err != nil {
+		return nil, err
+	}
+	delete(t.items, key)
+	output := &dynamodb.DeleteItemOutput{}
+	if aws.StringValue(input.ReturnValues) == dynamodb.ReturnValueAllOld {
+		output.Attributes = old
+	}
+	return output, nil
+}
+
+// UpdateItem
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.UpdateItemOutput
+//	@return error
+func (f *Fake) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	return f.UpdateItemWithContext(context.Background(), input)
+}
+
+// UpdateItemWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.UpdateItemOutput
+//	@return error
+func (f *Fake) UpdateItemWithContext(
+	ctx context.Context, input *dynamodb.UpdateItemInput, opts ...request.Option,
+) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.encodeKey(input.Key, true)
+	if err != nil {
+		return nil, err
+	}
+	old := t.items[key]
+	updated, changed, err := prepareUpdate(input.UpdateExpression, input.ConditionExpression,
+		input.ExpressionAttributeNames, input.ExpressionAttributeValues, old, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	t.items[key] = updated
+	return &dynamodb.UpdateItemOutput{
+		Attributes: returnValues(aws.StringValue(input.ReturnValues), old, updated, changed),
+	}, nil
+}
+
+// Query
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.QueryOutput
+//	@return error
+func (f *Fake) Query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return f.QueryWithContext(context.Background(), input)
+}
+
+// QueryWithContext
+// the key condition is evaluated like a condition over the items of the table.
+// indexes are not supported.
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.QueryOutput
+//	@return error
+func (f *Fake) QueryWithContext(
+	ctx context.Context, input *dynamodb.QueryInput, opts ...request.Option,
+) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if input.IndexName != nil {
+		return nil, validationError("indexes are not supported by the fake: %s", aws.StringValue(input.IndexName))
+	}
+	if input.KeyConditionExpression == nil {
+		return nil, validationError("KeyConditionExpression is required")
+	}
+	keyCond, used1, err := parseConditionExpression(input.KeyConditionExpression,
+		input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	if err != nil {
+		return nil, validationError("%s", err.Error())
+	}
+	if err := t.validateKeyCondition(keyCond); err != nil {
+		return nil, err
+	}
+	page, err := t.read(&readInput{
+		keyCond:           keyCond,
+		filterExpr:        input.FilterExpression,
+		projectionExpr:    input.ProjectionExpression,
+		names:             input.ExpressionAttributeNames,
+		values:            input.ExpressionAttributeValues,
+		exclusiveStartKey: input.ExclusiveStartKey,
+		limit:             input.Limit,
+		forward:           input.ScanIndexForward == nil || *input.ScanIndexForward,
+		selectCount:       aws.StringValue(input.Select) == dynamodb.SelectCount,
+		used:              used1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.QueryOutput{
+		Items:            page.items,
+		Count:            aws.Int64(page.count),
+		ScannedCount:     aws.Int64(page.scanned),
+		LastEvaluatedKey: page.lastKey,
+	}, nil
+}
+
+// Scan
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.ScanOutput
+//	@return error
+func (f *Fake) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	return f.ScanWithContext(context.Background(), input)
+}
+
+// ScanWithContext
+// items are returned in key order. indexes and parallel scans are not supported.
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.ScanOutput
+//	@return error
+func (f *Fake) ScanWithContext(
+	ctx context.Context, input *dynamodb.ScanInput, opts ...request.Option,
+) (*dynamodb.ScanOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.getTable(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if input.IndexName != nil || input.Segment != nil {
+		return nil, validationError("indexes and parallel scans are not supported by the fake")
+	}
+	page, err := t.read(&readInput{
+		filterExpr:        input.FilterExpression,
+		projectionExpr:    input.ProjectionExpression,
+		names:             input.ExpressionAttributeNames,
+		values:            input.ExpressionAttributeValues,
+		exclusiveStartKey: input.ExclusiveStartKey,
+		limit:             input.Limit,
+		forward:           true,
+		selectCount:       aws.StringValue(input.Select) == dynamodb.SelectCount,
+		used:              map[string]bool{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.ScanOutput{
+		Items:            page.items,
+		Count:            aws.Int64(page.count),
+		ScannedCount:     aws.Int64(page.scanned),
+		LastEvaluatedKey: page.lastKey,
+	}, nil
+}
+
+// BatchGetItem
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.BatchGetItemOutput
+//	@return error
+func (f *Fake) BatchGetItem(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+	return f.BatchGetItemWithContext(context.Background(), input)
+}
+
+// BatchGetItemWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.BatchGetItemOutput
+//	@return error
+func (f *Fake) BatchGetItemWithContext(
+	ctx context.Context, input *dynamodb.BatchGetItemInput, opts ...request.Option,
+) (*dynamodb.BatchGetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	total := 0
+	for _, keysAndAttrs := range input.RequestItems {
+		total += len(keysAndAttrs.Keys)
+	}
+	if total == 0 || total > maxBatchGetItems {
+		return nil, validationError("too many or no items requested for the BatchGetItem call: %d", total)
+	}
+	output := &dynamodb.BatchGetItemOutput{
+		Responses:       make(map[string][]map[string]*dynamodb.AttributeValue),
+		UnprocessedKeys: make(map[string]*dynamodb.KeysAndAttributes),
+	}
+	processed := 0
+	for tableName, keysAndAttrs := range input.RequestItems {
+		t, err := f.getTable(aws.String(tableName))
+		if err != nil {
+			return nil, err
+		}
+		paths, used, err := parseProjectionExpression(keysAndAttrs.ProjectionExpression,
+			keysAndAttrs.ExpressionAttributeNames)
+		if err != nil {
+			return nil, validationError("%s", err.Error())
+		}
+		if err := checkUnused(keysAndAttrs.ExpressionAttributeNames, nil, used); err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		items := []map[string]*dynamodb.AttributeValue{}
+		for _, k := range keysAndAttrs.Keys {
+			key, err := t.encodeKey(k, true)
+			if err != nil {
+				return nil, err
+			}
+			if seen[key] {
+				return nil, validationError("provided list of item keys contains duplicates")
+			}
+			seen[key] = true
+			if !f.hasCapacity(processed) {
+				unprocessed := output.UnprocessedKeys[tableName]
+				if unprocessed == nil {
+					unprocessed = &dynamodb.KeysAndAttributes{
+						ProjectionExpression:     keysAndAttrs.ProjectionExpression,
+						ExpressionAttributeNames: keysAndAttrs.ExpressionAttributeNames,
+					}
+					output.UnprocessedKeys[tableName] = unprocessed
+				}
+				unprocessed.Keys = append(unprocessed.Keys, copyItem(k))
+				continue
+			}
+			processed++
+			if it, ok := t.items[key]; ok {
+				items = append(items, project(it, paths))
+			}
+		}
+		output.Responses[tableName] = items
+	}
+	return output, nil
+}
+
+// BatchWriteItem
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.BatchWriteItemOutput
+//	@return error
+func (f *Fake) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	return f.BatchWriteItemWithContext(context.Background(), input)
+}
+
+// BatchWriteItemWithContext
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.BatchWriteItemOutput
+//	@return error
+func (f *Fake) BatchWriteItemWithContext(
+	ctx context.Context, input *dynamodb.BatchWriteItemInput, opts ...request.Option,
+) (*dynamodb.BatchWriteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	total := 0
+	for _, reqs := range input.RequestItems {
+		total += len(reqs)
+	}
+	if total == 0 || total > maxBatchWriteItems {
+		return nil, validationError("too many or no items requested for the BatchWriteItem call: %d", total)
+	}
+	// validate everything before writing, a batch with an invalid request writes nothing
+	type write struct {
+		t         *table
+		tableName string
+		key       string
+		item      item
+		req       *dynamodb.WriteRequest
+	}
+	writes := []*write{}
+	for tableName, reqs := range input.RequestItems {
+		t, err := f.getTable(aws.String(tableName))
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, req := range reqs {
+			w := &write{t: t, tableName: tableName, req: req}
+			switch {
+			case req.PutRequest != nil:
+				w.key, err = t.encodeKey(req.PutRequest.Item, false)
+				w.item = req.PutRequest.Item
+			case req.DeleteRequest != nil:
+				w.key, err = t.encodeKey(req.DeleteRequest.Key, true)
+			default:
+				err = validationError("write request has neither put nor delete request")
+			}
+			if err != nil {
+				return nil, err
+			}
+			if seen[w.key] {
+				return nil, validationError("provided list of item keys contains duplicates")
+			}
+			seen[w.key] = true
+			writes = append(writes, w)
+		}
+	}
+	output := &dynamodb.BatchWriteItemOutput{
+		UnprocessedItems: make(map[string][]*dynamodb.WriteRequest),
+	}
+	for i, w := range writes {
+		if !f.hasCapacity(i) {
+			output.UnprocessedItems[w.tableName] = append(output.UnprocessedItems[w.tableName], w.req)
+			continue
+		}
+		if w.item == nil {
+			delete(w.t.items, w.key)
+		} else {
+			w.t.items[w.key] = copyItem(w.item)
+		}
+	}
+	return output, nil
+}
+
+// TransactWriteItems
+//
+//	@receiver f
+//	@param input
+//	@return *dynamodb.TransactWriteItemsOutput
+//	@return error
+func (f *Fake) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	return f.TransactWriteItemsWithContext(context.Background(), input)
+}
+
+// TransactWriteItemsWithContext
+// every condition is checked before any write is applied.
+//
+//	@receiver f
+//	@param ctx
+//	@param input
+//	@param opts
+//	@return *dynamodb.TransactWriteItemsOutput
+//	@return error
+func (f *Fake) TransactWriteItemsWithContext(
+	ctx context.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option,
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(input.TransactItems) == 0 || len(input.TransactItems) > maxTransactWriteItems {
+		return nil, validationError("transaction must contain between 1 and %d items", maxTransactWriteItems)
+	}
+	type write struct {
+		t       *table
+		key     string
+		updated item
+		remove  bool
+	}
+	writes := []*write{}
+	reasons := make([]*dynamodb.CancellationReason, len(input.TransactItems))
+	failed := false
+	seen := make(map[string]bool)
+	for i, transactItem := range input.TransactItems {
+		w, err := f.prepareTransactItem(transactItem)
+		if err != nil {
+			return nil, err
+		}
+		if seen[w.tableName+"/"+w.key] {
+			return nil, validationError("transaction request cannot include multiple operations on one item")
+		}
+		seen[w.tableName+"/"+w.key] = true
+		reasons[i] = &dynamodb.CancellationReason{Code: aws.String(cancellationCodeNone)}
+		if w.conditionErr != nil {
+			failed = true
+			reasons[i] = &dynamodb.CancellationReason{
+				Code:    aws.String(cancellationCodeConditionalFailed),
+				Message: aws.String(conditionalFailedMessage),
+			}
+			continue
+		}
+		if w.check {
+			continue
+		}
+		writes = append(writes, &write{t: w.t, key: w.key, updated: w.updated, remove: w.remove})
+	}
+	if failed {
+		codes := make([]string, len(reasons))
+		for i, reason := range reasons {
+			codes[i] = aws.StringValue(reason.Code)
+		}
+		return nil, &dynamodb.TransactionCanceledException{
+			Message_: aws.String(fmt.Sprintf(
+				"Transaction cancelled, please refer cancellation reasons for specific reasons [%s]",
+				strings.Join(codes, ", "))),
+			CancellationReasons: reasons,
+		}
+	}
+	for _, w := range writes {
+		if w.remove {
+			delete(w.t.items, w.key)
+		} else {
+			w.t.items[w.key] = w.updated
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// preparedWrite one transact item checked against the current items.
+type preparedWrite struct {
+	t            *table
+	tableName    string
+	key          string
+	updated      item
+	remove       bool
+	check        bool
+	conditionErr error
+}
+
+// prepareTransactItem
+// must be called with the lock held
+//
+//	@receiver f
+//	@param transactItem
+//	@return *preparedWrite
+//	@return error validation errors, failed conditions are kept in the result
+func (f *Fake) prepareTransactItem(transactItem *dynamodb.TransactWriteItem) (*preparedWrite, error) {
+	switch {
+	case transactItem.Put != nil:
+		put := transactItem.Put
+		w, err := f.newPreparedWrite(put.TableName, put.Item, false)
+		if err != nil {
+			return nil, err
+		}
+		w.conditionErr = checkCondition(put.ConditionExpression, put.ExpressionAttributeNames,
+			put.ExpressionAttributeValues, w.t.items[w.key])
+		if err := asValidationError(w.conditionErr); err != nil {
+			return nil, err
+		}
+		w.updated = copyItem(put.Item)
+		return w, nil
+	case transactItem.Delete != nil:
+		del := transactItem.Delete
+		w, err := f.newPreparedWrite(del.TableName, del.Key, true)
+		if err != nil {
+			return nil, err
+		}
+		w.conditionErr = checkCondition(del.ConditionExpression, del.ExpressionAttributeNames,
+			del.ExpressionAttributeValues, w.t.items[w.key])
+		if err := asValidationError(w.conditionErr); err != nil {
+			return nil, err
+		}
+		w.remove = true
+		return w, nil
+	case transactItem.Update != nil:
+		update := transactItem.Update
+		w, err := f.newPreparedWrite(update.TableName, update.Key, true)
+		if err != nil {
+			return nil, err
+		}
+		w.updated, _, w.conditionErr = prepareUpdate(update.UpdateExpression, update.ConditionExpression,
+			update.ExpressionAttributeNames, update.ExpressionAttributeValues, w.t.items[w.key], update.Key)
+		if err := asValidationError(w.conditionErr); err != nil {
+			return nil, err
+		}
+		return w, nil
+	case transactItem.ConditionCheck != nil:
+		check := transactItem.ConditionCheck
+		w, err := f.newPreparedWrite(check.TableName, check.Key, true)
+		if err != nil {
+			return nil, err
+		}
+		if check.ConditionExpression == nil {
+			return nil, validationError("ConditionExpression is required by ConditionCheck")
+		}
+		w.conditionErr = checkCondition(check.ConditionExpression, check.ExpressionAttributeNames,
+			check.ExpressionAttributeValues, w.t.items[w.key])
+		if err := asValidationError(w.conditionErr); err != nil {
+			return nil, err
+		}
+		w.check = true
+		return w, nil
+	default:
+		return nil, validationError("transact item has no operation")
+	}
+}
+
+func (f *Fake) newPreparedWrite(tableName *string, it item, keyOnly bool) (*preparedWrite, error) {
+	t, err := f.getTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := t.encodeKey(it, keyOnly)
+	if err != nil {
+		return nil, err
+	}
+	return &preparedWrite{t: t, tableName: aws.StringValue(tableName), key: key}, nil
+}
+
+// getTable
+// must be called with the lock held
+//
+//	@receiver f
+//	@param name
+//	@return *table
+//	@return error
+func (f *Fake) getTable(name *string) (*table, error) {
+	t, ok := f.tables[aws.StringValue(name)]
+	if !ok {
+		return nil, &dynamodb.ResourceNotFoundException{
+			Message_: aws.String("Cannot do operations on a non-existent table: " + aws.StringValue(name)),
+		}
+	}
+	return t, nil
+}
+
+// encodeKey
+//
+//	@receiver t
+//	@param it item or key
+//	@param keyOnly the map must contain the key attributes only
+//	@return string
+//	@return error
+func (t *table) encodeKey(it item, keyOnly bool) (string, error) {
+	hash := it[t.hashKey]
+	if hash == nil || !isKeyType(hash) {
+		return "", validationError("the provided key element does not match the schema: %s", t.hashKey)
+	}
+	key := typeOf(hash) + ":" + keyValue(hash)
+	expectedLen := 1
+	if t.rangeKey != "" {
+		r := it[t.rangeKey]
+		if r == nil || !isKeyType(r) {
+			return "", validationError("the provided key element does not match the schema: %s", t.rangeKey)
+		}
+		key += "|" + typeOf(r) + ":" + keyValue(r)
+		expectedLen = 2
+	}
+	if keyOnly && len(it) != expectedLen {
+		return "", validationError("the provided key element does not match the schema")
+	}
+	return key, nil
+}
+
+// keyOf
+//
+//	@receiver t
+//	@param it
+//	@return item
+func (t *table) keyOf(it item) item {
+	key := item{t.hashKey: copyValue(it[t.hashKey])}
+	if t.rangeKey != "" {
+		key[t.rangeKey] = copyValue(it[t.rangeKey])
+	}
+	return key
+}
+
+// validateKeyCondition
+// the key condition may only use key attributes and must compare the hash key by equality
+//
+//	@receiver t
+//	@param cond
+//	@return error
+func (t *table) validateKeyCondition(cond *condition) error {
+	hashFound := false
+	var walk func(c *condition) error
+	walk = func(c *condition) error {
+		switch c.op {
+		case "AND":
+			for _, child := range c.children {
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "OR", "NOT", "IN", "<>", funcAttributeExists, funcAttributeNotExists, funcAttributeType, funcContains:
+			return validationError("invalid operator used in KeyConditionExpression: %s", c.op)
+		}
+		name := c.operands[0].path.topName()
+		if len(c.operands[0].path) != 1 || (name != t.hashKey && name != t.rangeKey) {
+			return validationError("query key condition not supported: %s", name)
+		}
+		if name == t.hashKey {
+			if c.op != "=" {
+				return validationError("query key condition not supported on hash key: %s", c.op)
+			}
+			hashFound = true
+		}
+		return nil
+	}
+	if err := walk(cond); err != nil {
+		return err
+	}
+	if !hashFound {
+		return validationError("query condition missed key schema element: %s", t.hashKey)
+	}
+	return nil
+}
+
+// readInput shared options of Query and Scan.
+type readInput struct {
+	keyCond           *condition
+	filterExpr        *string
+	projectionExpr    *string
+	names             map[string]*string
+	values            map[string]*dynamodb.AttributeValue
+	exclusiveStartKey item
+	limit             *int64
+	forward           bool
+	selectCount       bool
+	used              map[string]bool
+}
+
+type readPage struct {
+	items   []map[string]*dynamodb.AttributeValue
+	count   int64
+	scanned int64
+	lastKey item
+}
+
+// read
+// limit counts evaluated items before the filter is applied, as dynamodb does
+//
+//	@receiver t
+//	@param input
+//	@return *readPage
+//	@return error
+func (t *table) read(input *readInput) (*readPage, error) {
+	filter, used2, err := parseConditionExpression(input.filterExpr, input.names, input.values)
+	if err != nil {
+		return nil, validationError("%s", err.Error())
+	}
+	paths, used3, err := parseProjectionExpression(input.projectionExpr, input.names)
+	if err != nil {
+		return nil, validationError("%s", err.Error())
+	}
+	used := mergeUsed(input.used, used2, used3)
+	if err := checkUnused(input.names, input.values, used); err != nil {
+		return nil, err
+	}
+	keys := t.sortedKeys(input.forward)
+	start := 0
+	if input.exclusiveStartKey != nil {
+		startKey, err := t.encodeKey(input.exclusiveStartKey, true)
+		if err != nil {
+			return nil, err
+		}
+		start = len(keys)
+		for i, key := range keys {
+			if key == startKey {
+				start = i + 1
+				break
+			}
+		}
+	}
+	page := &readPage{
+		items: []map[string]*dynamodb.AttributeValue{},
+	}
+	for _, key := range keys[start:] {
+		it := t.items[key]
+		matched, err := evaluate(input.keyCond, it)
+		if err != nil {
+			return nil, validationError("%s", err.Error())
+		}
+		if !matched {
+			continue
+		}
+		page.scanned++
+		passed, err := evaluate(filter, it)
+		if err != nil {
+			return nil, validationError("%s", err.Error())
+		}
+		if passed {
+			page.count++
+			if !input.selectCount {
+				page.items = append(page.items, project(it, paths))
+			}
+		}
+		if input.limit != nil && page.scanned >= *input.limit {
+			page.lastKey = t.keyOf(it)
+			break
+		}
+	}
+	return page, nil
+}
+
+// sortedKeys
+// order by hash key then range key
+//
+//	@receiver t
+//	@param forward
+//	@return []string
+func (t *table) sortedKeys(forward bool) []string {
+	keys := make([]string, 0, len(t.items))
+	for key := range t.items {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := t.items[keys[i]], t.items[keys[j]]
+		result, _ := compare(a[t.hashKey], b[t.hashKey])
+		if result == 0 && t.rangeKey != "" {
+			result, _ = compare(a[t.rangeKey], b[t.rangeKey])
+		}
+		if forward {
+			return result < 0
+		}
+		return result > 0
+	})
+	return keys
+}
+
+// prepareUpdate
+//
+//	@return item updated item
+//	@return map[string]bool changed attributes
+//	@return error ConditionalCheckFailedException, validation errors
+func prepareUpdate(
+	updateExpr *string,
+	conditionExpr *string,
+	names map[string]*string,
+	values map[string]*dynamodb.AttributeValue,
+	old item,
+	key item,
+) (item, map[string]bool, error) {
+	actions, used1, err := parseUpdateExpression(updateExpr, names, values)
+	if err != nil {
+		return nil, nil, validationError("%s", err.Error())
+	}
+	cond, used2, err := parseConditionExpression(conditionExpr, names, values)
+	if err != nil {
+		return nil, nil, validationError("%s", err.Error())
+	}
+	if err := checkUnused(names, values, mergeUsed(used1, used2)); err != nil {
+		return nil, nil, err
+	}
+	matched, err := evaluate(cond, old)
+	if err != nil {
+		return nil, nil, validationError("%s", err.Error())
+	}
+	if !matched {
+		return nil, nil, &dynamodb.ConditionalCheckFailedException{Message_: aws.String(conditionalFailedMessage)}
+	}
+	updated, changed, err := applyUpdate(old, key, actions)
+	if err != nil {
+		return nil, nil, validationError("%s", err.Error())
+	}
+	return updated, changed, nil
+}
+
+// checkCondition
+//
+//	@return error ConditionalCheckFailedException, validation errors
+func checkCondition(
+	expr *string,
+	names map[string]*string,
+	values map[string]*dynamodb.AttributeValue,
+	current item,
+) error {
+	cond, used, err := parseConditionExpression(expr, names, values)
+	if err != nil {
+		return validationError("%s", err.Error())
+	}
+	if err := checkUnused(names, values, used); err != nil {
+		return err
+	}
+	matched, err := evaluate(cond, current)
+	if err != nil {
+		return validationError("%s", err.Error())
+	}
+	if !matched {
+		return &dynamodb.ConditionalCheckFailedException{Message_: aws.String(conditionalFailedMessage)}
+	}
+	return nil
+}
+
+// asValidationError
+// keep validation errors, a failed condition is not an error of the request
+//
+//	@param err
+//	@return error
+func asValidationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, failed := err.(*dynamodb.ConditionalCheckFailedException); failed { //nolint:errorlint
+		return nil
+	}
+	return err
+}
+
+// returnValues
+//
+//	@param returnValue
+//	@param old
+//	@param updated
+//	@param changed
+//	@return item
+func returnValues(returnValue string, old item, updated item, changed map[string]bool) item {
+	switch returnValue {
+	case dynamodb.ReturnValueAllOld:
+		return old
+	case dynamodb.ReturnValueAllNew:
+		return copyItem(updated)
+	case dynamodb.ReturnValueUpdatedOld:
+		return pick(old, changed)
+	case dynamodb.ReturnValueUpdatedNew:
+		return pick(updated, changed)
+	default:
+		return nil
+	}
+}
+
+func pick(it item, names map[string]bool) item {
+	if it == nil {
+		return nil
+	}
+	result := make(item)
+	for name := range names {
+		if v, ok := it[name]; ok {
+			result[name] = copyValue(v)
+		}
+	}
+	return result
+}
+
+// checkUnused
+// dynamodb rejects placeholders that are defined but not used
+//
+//	@return error
+func checkUnused(names map[string]*string, values map[string]*dynamodb.AttributeValue, used map[string]bool) error {
+	for name := range names {
+		if !used[name] {
+			return validationError("value provided in ExpressionAttributeNames unused in expressions: %s", name)
+		}
+	}
+	for value := range values {
+		if !used[value] {
+			return validationError("value provided in ExpressionAttributeValues unused in expressions: %s", value)
+		}
+	}
+	return nil
+}
+
+func mergeUsed(useds ...map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	for _, used := range useds {
+		for k := range used {
+			result[k] = true
+		}
+	}
+	return result
+}
+
+func isKeyType(v *dynamodb.AttributeValue) bool {
+	typ := typeOf(v)
+	return typ == typeS || typ == typeN || typ == typeB
+}
+
+func keyValue(v *dynamodb.AttributeValue) string {
+	switch typeOf(v) {
+	case typeS:
+		return *v.S
+	case typeN:
+		return *v.N
+	default:
+		return fmt.Sprintf("%x", v.B)
+	}
+}
+
+func validationError(format string, args ...any) error {
+	return awserr.New(errCodeValidation, fmt.Sprintf(format, args...), nil)
+}
@@ -0,0 +1,242 @@
+package dynamodbfake_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/repository/dynamodbfake"
+)
+
+const testTableName string = "test_table"
+
+func newTestFake(t *testing.T) *dynamodbfake.Fake {
+	fake := dynamodbfake.New()
+	_, err := fake.CreateTable(&dynamodb.CreateTableInput{
+		TableName: aws.String(testTableName),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+			{AttributeName: aws.String("sk"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			{AttributeName: aws.String("sk"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+		},
+		BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+	})
+	require.NoError(t, err, "create table should succeed")
+	return fake
+}
+
+func testItem(pk string, sk string, count string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"pk":    {S: aws.String(pk)},
+		"sk":    {S: aws.String(sk)},
+		"count": {N: aws.String(count)},
+	}
+}
+
+func testKey(pk string, sk string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"pk": {S: aws.String(pk)},
+		"sk": {S: aws.String(sk)},
+	}
+}
+
+func putTestItems(t *testing.T, fake *dynamodbfake.Fake, items ...map[string]*dynamodb.AttributeValue) {
+	for _, it := range items {
+		_, err := fake.PutItem(&dynamodb.PutItemInput{
+			TableName: aws.String(testTableName),
+			Item:      it,
+		})
+		require.NoError(t, err, "put item should succeed")
+	}
+}
+
+func TestFakeGetItemWithMissingTableReturnResourceNotFound(t *testing.T) {
+	require := require.New(t)
+	fake := newTestFake(t)
+	_, err := fake.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String("missing"),
+		Key:       testKey("a", "1"),
+	})
+	require.Error(err, "get from missing table should fail")
+	var notFound *dynamodb.ResourceNotFoundException
+	require.ErrorAs(err, &notFound, "error should be resource not found")
+}
+
+func TestFakePutItemWithFailedConditionReturnConditionalCheckFailed(t *testing.T) {
+	require := require.New(t)
+	fake := newTestFake(t)
+	putTestItems(t, fake, testItem("a", "1", "1"))
+	_, err := fake.PutItem(&dynamodb.PutItemInput{
+		TableName:                aws.String(testTableName),
+		Item:                     testItem("a", "1", "2"),
+		ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+		ExpressionAttributeNames: map[string]*string{"#pk": aws.String("pk")},
+	})
+	var failed *dynamodb.ConditionalCheckFailedException
+	require.ErrorAs(err, &failed, "put over existing item should fail the condition")
+	out, err := fake.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(testTableName),
+		Key:       testKey("a", "1"),
+	})
+	require.NoError(err, "get item should succeed")
+	require.Equal("1", aws.StringValue(out.Item["count"].N), "item should not change")
+}
+
+func TestFakePutItemWithUnusedValueReturnValidationError(t *testing.T) {
+	require := require.New(t)
+	fake := newTestFake(t)
+	_, err := fake.PutItem(&dynamodb.PutItemInput{
+		TableName:           aws.String(testTableName),
+		Item:                testItem("a", "1", "1"),
+		ConditionExpression: aws.String("attribute_not_exists(pk)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":unused": {S: aws.String("x")},
+		},
+	})
+	var aerr awserr.Error
+	require.ErrorAs(err, &aerr, "error should be an aws error")
+	require.Equal("ValidationException", aerr.Code(), "unused placeholder should be rejected")
+}
+
+func TestFakeUpdateItemWithExpressionReturnAllNew(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	fake := newTestFake(t)
+	putTestItems(t, fake, testItem("a", "1", "5"))
+	out, err := fake.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:           aws.String(testTableName),
+		Key:                 testKey("a", "1"),
+		UpdateExpression:    aws.String("SET #count = #count + :inc, tags = :tags REMOVE missing ADD seen :one"),
+		ConditionExpression: aws.String("#count BETWEEN :low AND :high"),
+		ExpressionAttributeNames: map[string]*string{
+			"#count": aws.String("count"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":inc":  {N: aws.String("2")},
+			":one":  {N: aws.String("1")},
+			":low":  {N: aws.String("1")},
+			":high": {N: aws.String("10")},
+			":tags": {SS: []*string{aws.String("x"), aws.String("y")}},
+		},
+		ReturnValues: aws.String(dynamodb.ReturnValueAllNew),
+	})
+	require.NoError(err, "update item should succeed")
+	assert.Equal("7", aws.StringValue(out.Attributes["count"].N), "count should be incremented")
+	assert.Equal("1", aws.StringValue(out.Attributes["seen"].N), "ADD should create the number")
+	assert.Len(out.Attributes["tags"].SS, 2, "tags should be set")
+}
+
+func TestFakeUpdateItemWithKeyAttributeReturnValidationError(t *testing.T) {
+	require := require.New(t)
+	fake := newTestFake(t)
+	putTestItems(t, fake, testItem("a", "1", "5"))
+	_, err := fake.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String(testTableName),
+		Key:                       testKey("a", "1"),
+		UpdateExpression:          aws.String("SET sk = :sk"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":sk": {S: aws.String("2")}},
+	})
+	var aerr awserr.Error
+	require.ErrorAs(err, &aerr, "error should be an aws error")
+	require.Equal("ValidationException", aerr.Code(), "key attribute should not be updated")
+}
+
+func TestFakeQueryWithKeyConditionReturnSortedPage(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	fake := newTestFake(t)
+	putTestItems(t, fake,
+		testItem("a", "3", "3"), testItem("a", "1", "1"), testItem("a", "2", "2"),
+		testItem("b", "1", "9"),
+	)
+	out, err := fake.QueryWithContext(context.TODO(), &dynamodb.QueryInput{
+		TableName:              aws.String(testTableName),
+		KeyConditionExpression: aws.String("pk = :pk AND sk >= :sk"),
+		FilterExpression:       aws.String("#count <> :skip"),
+		ExpressionAttributeNames: map[string]*string{
+			"#count": aws.String("count"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk":   {S: aws.String("a")},
+			":sk":   {S: aws.String("1")},
+			":skip": {N: aws.String("2")},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int64(2),
+	})
+	require.NoError(err, "query should succeed")
+	require.Len(out.Items, 1, "filter should apply after limit")
+	assert.Equal("3", aws.StringValue(out.Items[0]["sk"].S), "items should be in descending order")
+	assert.Equal(int64(2), aws.Int64Value(out.ScannedCount), "scanned count should include filtered items")
+	require.NotNil(out.LastEvaluatedKey, "last evaluated key should be set when limited")
+	assert.Equal("2", aws.StringValue(out.LastEvaluatedKey["sk"].S), "last evaluated key should be the last scanned item")
+}
+
+func TestFakeQueryWithoutHashKeyReturnValidationError(t *testing.T) {
+	require := require.New(t)
+	fake := newTestFake(t)
+	_, err := fake.Query(&dynamodb.QueryInput{
+		TableName:                 aws.String(testTableName),
+		KeyConditionExpression:    aws.String("sk = :sk"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":sk": {S: aws.String("1")}},
+	})
+	var aerr awserr.Error
+	require.ErrorAs(err, &aerr, "error should be an aws error")
+	require.Equal("ValidationException", aerr.Code(), "query should require the hash key")
+}
+
+func TestFakeBatchWriteAndGetWithItemsReturnItems(t *testing.T) {
+	require := require.New(t)
+	fake := newTestFake(t)
+	_, err := fake.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]*dynamodb.WriteRequest{
+			testTableName: {
+				{PutRequest: &dynamodb.PutRequest{Item: testItem("a", "1", "1")}},
+				{PutRequest: &dynamodb.PutRequest{Item: testItem("a", "2", "2")}},
+			},
+		},
+	})
+	require.NoError(err, "batch write should succeed")
+	out, err := fake.BatchGetItem(&dynamodb.BatchGetItemInput{
+		RequestItems: map[string]*dynamodb.KeysAndAttributes{
+			testTableName: {Keys: []map[string]*dynamodb.AttributeValue{testKey("a", "1"), testKey("a", "2"), testKey("a", "9")}},
+		},
+	})
+	require.NoError(err, "batch get should succeed")
+	require.Len(out.Responses[testTableName], 2, "only existing items should be returned")
+}
+
+func TestFakeTransactWriteItemsWithFailedConditionReturnReasons(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	fake := newTestFake(t)
+	putTestItems(t, fake, testItem("a", "1", "1"))
+	_, err := fake.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Put: &dynamodb.Put{TableName: aws.String(testTableName), Item: testItem("a", "2", "2")}},
+			{ConditionCheck: &dynamodb.ConditionCheck{
+				TableName:           aws.String(testTableName),
+				Key:                 testKey("a", "1"),
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			}},
+		},
+	})
+	var canceled *dynamodb.TransactionCanceledException
+	require.ErrorAs(err, &canceled, "transaction should be canceled")
+	require.Len(canceled.CancellationReasons, 2, "one reason per item")
+	assert.Equal("None", aws.StringValue(canceled.CancellationReasons[0].Code), "first item should not fail")
+	assert.Equal("ConditionalCheckFailed", aws.StringValue(canceled.CancellationReasons[1].Code), "second item should fail")
+	out, err := fake.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(testTableName),
+		Key:       testKey("a", "2"),
+	})
+	require.NoError(err, "get item should succeed")
+	assert.Empty(out.Item, "nothing should be written")
+}
@@ -0,0 +1,411 @@
+package dynamodbfake
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+const (
+	updateSet    string = "SET"
+	updateRemove string = "REMOVE"
+	updateAdd    string = "ADD"
+	updateDelete string = "DELETE"
+)
+
+// updateAction one action of an update expression.
+type updateAction struct {
+	kind  string
+	path  path
+	value *operand
+}
+
+// parseUpdateExpression
+//
+//	update := (SET set (',' set)* | REMOVE path (',' path)* | ADD path value (',' ...)* | DELETE path value (',' ...)*)+
+//	@param expr
+//	@param names
+//	@param values
+//	@return []*updateAction
+//	@return map[string]bool placeholders used
+//	@return error
+func parseUpdateExpression(
+	expr *string,
+	names map[string]*string,
+	values map[string]*dynamodb.AttributeValue,
+) ([]*updateAction, map[string]bool, error) {
+	if expr == nil || strings.TrimSpace(*expr) == "" {
+		return nil, map[string]bool{}, nil
+	}
+	p, err := newParser(*expr, names, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	actions := []*updateAction{}
+	seen := make(map[string]bool)
+	for p.peek().kind != tokenEOF {
+		t := p.next()
+		kind := strings.ToUpper(t.text)
+		if t.kind != tokenIdent || (kind != updateSet && kind != updateRemove && kind != updateAdd && kind != updateDelete) {
+			return nil, nil, errors.Errorf("invalid update expression %q: unexpected token %q", *expr, t.text)
+		}
+		if seen[kind] {
+			return nil, nil, errors.Errorf("invalid update expression %q: clause %s appears more than once", *expr, kind)
+		}
+		seen[kind] = true
+		for {
+			action, err := p.parseUpdateAction(kind)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid update expression %q", *expr)
+			}
+			actions = append(actions, action)
+			if !p.isSymbol(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := validateUpdatePaths(actions); err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid update expression %q", *expr)
+	}
+	return actions, p.used, nil
+}
+
+// parseUpdateAction
+//
+//	@receiver p
+//	@param kind
+//	@return *updateAction
+//	@return error
+func (p *parser) parseUpdateAction(kind string) (*updateAction, error) {
+	pth, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	action := &updateAction{kind: kind, path: pth}
+	switch kind {
+	case updateRemove:
+		return action, nil
+	case updateSet:
+		if err := p.expectSymbol("="); err != nil {
+			return nil, err
+		}
+		left, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if p.isSymbol("+") || p.isSymbol("-") {
+			arith := p.next().text
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			left = &operand{arith: arith, args: []*operand{left, right}}
+		}
+		action.value = left
+	default:
+		value, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if value.value == nil {
+			return nil, errors.Errorf("%s requires a value placeholder", kind)
+		}
+		action.value = value
+	}
+	return action, nil
+}
+
+// validateUpdatePaths
+// the same path cannot be changed by two actions
+//
+//	@param actions
+//	@return error
+func validateUpdatePaths(actions []*updateAction) error {
+	paths := make(map[string]bool)
+	for _, action := range actions {
+		key := action.path.String()
+		if paths[key] {
+			return errors.Errorf("two document paths overlap: %s", key)
+		}
+		paths[key] = true
+	}
+	return nil
+}
+
+// applyUpdate
+// all values are evaluated against the item before the update
+//
+//	@param old nil when the item does not exist
+//	@param key
+//	@param actions
+//	@return item
+//	@return map[string]bool top level attributes changed
+//	@return error
+func applyUpdate(old item, key item, actions []*updateAction) (item, map[string]bool, error) {
+	updated := copyItem(old)
+	if updated == nil {
+		updated = copyItem(key)
+	}
+	values := make([]*dynamodb.AttributeValue, len(actions))
+	for i, action := range actions {
+		if action.value == nil {
+			continue
+		}
+		v, err := resolveOperand(old, action.value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if v == nil {
+			return nil, nil, errors.Errorf("the provided expression refers to an attribute that does not exist in the item: %s",
+				action.path.String())
+		}
+		values[i] = v
+	}
+	changed := make(map[string]bool)
+	for i, action := range actions {
+		if _, isKey := key[action.path.topName()]; isKey {
+			return nil, nil, errors.Errorf("cannot update attribute %s. this attribute is part of the key", action.path.topName())
+		}
+		changed[action.path.topName()] = true
+		var err error
+		switch action.kind {
+		case updateSet:
+			err = setPath(updated, action.path, copyValue(values[i]), false)
+		case updateRemove:
+			removePath(updated, action.path)
+		case updateAdd:
+			err = addPath(updated, action.path, values[i])
+		case updateDelete:
+			err = deleteFromSetPath(updated, action.path, values[i])
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return updated, changed, nil
+}
+
+// setPath
+//
+//	@param it
+//	@param p
+//	@param v
+//	@param create create missing enclosing maps and lists, used by projections
+//	@return error
+func setPath(it item, p path, v *dynamodb.AttributeValue, create bool) error {
+	if len(p) == 1 {
+		it[p[0].name] = v
+		return nil
+	}
+	parent := it[p[0].name]
+	if parent == nil {
+		if !create {
+			return errors.Errorf("the document path provided in the update expression is invalid for update: %s", p.String())
+		}
+		parent = newContainer(p[1])
+		it[p[0].name] = parent
+	}
+	for i := 1; i < len(p)-1; i++ {
+		child := childOf(parent, p[i])
+		if child == nil {
+			if !create {
+				return errors.Errorf("the document path provided in the update expression is invalid for update: %s", p.String())
+			}
+			child = newContainer(p[i+1])
+			if err := setChild(parent, p[i], child, create); err != nil {
+				return err
+			}
+		}
+		parent = child
+	}
+	return setChild(parent, p[len(p)-1], v, create)
+}
+
+func newContainer(next pathElem) *dynamodb.AttributeValue {
+	if next.isIdx {
+		return &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}}
+	}
+	return &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{}}
+}
+
+func childOf(parent *dynamodb.AttributeValue, e pathElem) *dynamodb.AttributeValue {
+	if e.isIdx {
+		if parent.L == nil || e.index >= len(parent.L) {
+			return nil
+		}
+		return parent.L[e.index]
+	}
+	if parent.M == nil {
+		return nil
+	}
+	return parent.M[e.name]
+}
+
+func setChild(parent *dynamodb.AttributeValue, e pathElem, v *dynamodb.AttributeValue, create bool) error {
+	if !e.isIdx {
+		if parent.M == nil {
+			return errors.New("the document path provided in the update expression is invalid for update")
+		}
+		parent.M[e.name] = v
+		return nil
+	}
+	if parent.L == nil {
+		return errors.New("the document path provided in the update expression is invalid for update")
+	}
+	if e.index < len(parent.L) && !create {
+		parent.L[e.index] = v
+		return nil
+	}
+	// an index beyond the end appends, as dynamodb does
+	parent.L = append(parent.L, v)
+	return nil
+}
+
+// removePath
+//
+//	@param it
+//	@param p
+func removePath(it item, p path) {
+	if len(p) == 1 {
+		delete(it, p[0].name)
+		return
+	}
+	parent := resolvePath(it, p[:len(p)-1])
+	if parent == nil {
+		return
+	}
+	last := p[len(p)-1]
+	if !last.isIdx {
+		if parent.M != nil {
+			delete(parent.M, last.name)
+		}
+		return
+	}
+	if parent.L != nil && last.index < len(parent.L) {
+		parent.L = append(parent.L[:last.index], parent.L[last.index+1:]...)
+	}
+}
+
+// addPath
+// add a number or union a set
+//
+//	@param it
+//	@param p
+//	@param v
+//	@return error
+func addPath(it item, p path, v *dynamodb.AttributeValue) error {
+	current := resolvePath(it, p)
+	if current == nil {
+		return setPath(it, p, copyValue(v), false)
+	}
+	if typeOf(current) != typeOf(v) {
+		return errors.New("an operand in the update expression has an incorrect data type")
+	}
+	switch typeOf(v) {
+	case typeN:
+		x, _ := new(big.Float).SetString(*current.N)
+		y, _ := new(big.Float).SetString(*v.N)
+		if x == nil || y == nil {
+			return errors.New("invalid number")
+		}
+		current.N = aws.String(formatNumber(x.Add(x, y)))
+	case typeSS:
+		for _, s := range v.SS {
+			if !containsString(current.SS, aws.StringValue(s)) {
+				current.SS = append(current.SS, aws.String(aws.StringValue(s)))
+			}
+		}
+	case typeNS:
+		for _, n := range v.NS {
+			if !containsNumber(current.NS, aws.StringValue(n)) {
+				current.NS = append(current.NS, aws.String(aws.StringValue(n)))
+			}
+		}
+	case typeBS:
+		for _, b := range v.BS {
+			if !containsBytes(current.BS, b) {
+				current.BS = append(current.BS, append([]byte{}, b...))
+			}
+		}
+	default:
+		return errors.New("ADD action is only supported for numbers and sets")
+	}
+	return nil
+}
+
+// deleteFromSetPath
+//
+//	@param it
+//	@param p
+//	@param v
+//	@return error
+func deleteFromSetPath(it item, p path, v *dynamodb.AttributeValue) error {
+	current := resolvePath(it, p)
+	if current == nil {
+		return nil
+	}
+	if typeOf(current) != typeOf(v) {
+		return errors.New("an operand in the update expression has an incorrect data type")
+	}
+	switch typeOf(v) {
+	case typeSS:
+		left := []*string{}
+		for _, s := range current.SS {
+			if !containsString(v.SS, aws.StringValue(s)) {
+				left = append(left, s)
+			}
+		}
+		current.SS = left
+		if len(left) == 0 {
+			removePath(it, p)
+		}
+	case typeNS:
+		left := []*string{}
+		for _, n := range current.NS {
+			if !containsNumber(v.NS, aws.StringValue(n)) {
+				left = append(left, n)
+			}
+		}
+		current.NS = left
+		if len(left) == 0 {
+			removePath(it, p)
+		}
+	case typeBS:
+		left := [][]byte{}
+		for _, b := range current.BS {
+			if !containsBytes(v.BS, b) {
+				left = append(left, b)
+			}
+		}
+		current.BS = left
+		if len(left) == 0 {
+			removePath(it, p)
+		}
+	default:
+		return errors.New("DELETE action is only supported for sets")
+	}
+	return nil
+}
+
+func containsNumber(values []*string, n string) bool {
+	for _, v := range values {
+		if compareNumber(aws.StringValue(v), n) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBytes(values [][]byte, b []byte) bool {
+	for _, v := range values {
+		if bytes.Equal(v, b) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	jobPKPrefix string = "job#"
+	jobSK       string = "job"
+	// fieldJobClaimExpireAt the claim of a running job in epoch milliseconds, the time string does not compare in order
+	fieldJobClaimExpireAt string = "claimExpireAt"
+)
+
+// JobDynamodbRepo
+// implements domain.JobRepository, a job is an item under job#<id>.
+type JobDynamodbRepo struct {
+	tableName string
+	client    dynamodbiface.DynamoDBAPI
+	// retention jobs are purged after it since they were created, kept when it is 0
+	retention time.Duration
+}
+
+// NewJobDynamodbRepo
+//
+//	@param tableName
+//	@param client
+//	@return *JobDynamodbRepo
+func NewJobDynamodbRepo(tableName string, client dynamodbiface.DynamoDBAPI) *JobDynamodbRepo {
+	return &JobDynamodbRepo{
+		tableName: tableName,
+		client:    client,
+	}
+}
+
+// WithRetention
+//
+//	@receiver repo
+//	@param retention jobs are purged by the table ttl after it since they were created
+//	@return *JobDynamodbRepo
+func (repo *JobDynamodbRepo) WithRetention(retention time.Duration) *JobDynamodbRepo {
+	repo.retention = retention
+	return repo
+}
+
+// CreateJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param job
+//	@return error
+func (repo *JobDynamodbRepo) CreateJob(ctx context.Context, job *domain.Job) error {
+	return repo.putJob(ctx, job, "attribute_not_exists(#pk)", nil, nil, domain.ErrConditionFailed)
+}
+
+// UpdateJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param job
+//	@return error
+func (repo *JobDynamodbRepo) UpdateJob(ctx context.Context, job *domain.Job) error {
+	return repo.putJob(ctx, job, "attribute_exists(#pk)", nil, nil, domain.ErrNotFound)
+}
+
+// ClaimJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param job
+//	@param now
+//	@return error
+func (repo *JobDynamodbRepo) ClaimJob(ctx context.Context, job *domain.Job, now time.Time) error {
+	names := map[string]*string{
+		"#state":         aws.String("state"),
+		"#claimExpireAt": aws.String(fieldJobClaimExpireAt),
+	}
+	values := map[string]*dynamodb.AttributeValue{
+		":pending": {S: aws.String(string(domain.JobPending))},
+		":running": {S: aws.String(string(domain.JobRunning))},
+		":now":     {N: aws.String(strconv.FormatInt(now.UnixMilli(), 10))},
+	}
+	condition := "attribute_exists(#pk) AND (#state = :pending OR (#state = :running AND #claimExpireAt < :now))"
+	return repo.putJob(ctx, job, condition, names, values, domain.ErrConditionFailed)
+}
+
+// GetJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return *domain.Job
+//	@return error
+func (repo *JobDynamodbRepo) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	data, err := repo.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(repo.tableName),
+		Key:            toJobDBKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "get db job error. table: %s, id: %s", repo.tableName, id)
+	}
+	if len(data.Item) == 0 {
+		return nil, errors.Wrapf(domain.ErrNotFound, "job id: %s", id)
+	}
+	job := &domain.Job{}
+	err = dynamodbattribute.UnmarshalMap(data.Item, job)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "unmarshal db job error. id: %s", id)
+	}
+	return job, nil
+}
+
+// putJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param job
+//	@param condition it must refer to #pk, unused names are rejected by DynamoDB
+//	@param names of the condition besides #pk
+//	@param values of the condition
+//	@param conditionErr returned when the condition fails
+//	@return error
+func (repo *JobDynamodbRepo) putJob(
+	ctx context.Context,
+	job *domain.Job,
+	condition string,
+	names map[string]*string,
+	values map[string]*dynamodb.AttributeValue,
+	conditionErr error,
+) error {
+	if job == nil || len(job.ID) == 0 {
+		return nil
+	}
+	item, err := dynamodbattribute.MarshalMap(job)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "marshal job error. id: %s", job.ID)
+	}
+	for name, value := range toJobDBKey(job.ID) {
+		item[name] = value
+	}
+	if !job.ClaimedUntil.IsZero() {
+		claimExpireAt := strconv.FormatInt(job.ClaimedUntil.UnixMilli(), 10)
+		item[fieldJobClaimExpireAt] = &dynamodb.AttributeValue{N: aws.String(claimExpireAt)}
+	}
+	if repo.retention > 0 {
+		expireAt := job.CreatedAt.Add(repo.retention).Unix()
+		item[FieldDummyExpireAt] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expireAt, 10))}
+	}
+	conditionNames := map[string]*string{"#pk": aws.String(FieldDummyPK)}
+	for name, value := range names {
+		conditionNames[name] = value
+	}
+	_, err = repo.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(repo.tableName),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  conditionNames,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if isAwsErrorCode(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+			return errors.Wrapf(conditionErr, "put db job. table: %s, id: %s", repo.tableName, job.ID)
+		}
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "put db job error. table: %s, id: %s", repo.tableName, job.ID)
+	}
+	logger.Debug("put job to db. id: %s, state: %s", job.ID, job.State)
+	return nil
+}
+
+// toJobDBKey
+//
+//	@param id
+//	@return map
+func toJobDBKey(id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(jobPKPrefix + id)},
+		FieldDummySK: {S: aws.String(jobSK)},
+	}
+}
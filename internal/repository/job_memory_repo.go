@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// JobMemoryRepo
+// implements domain.JobRepository in memory, safe for concurrent use.
+type JobMemoryRepo struct {
+	mu   sync.RWMutex
+	jobs map[string]*domain.Job
+}
+
+// NewJobMemoryRepo
+//
+//	@return *JobMemoryRepo
+func NewJobMemoryRepo() *JobMemoryRepo {
+	return &JobMemoryRepo{
+		jobs: make(map[string]*domain.Job),
+	}
+}
+
+// CreateJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param job
+//	@return error
+func (repo *JobMemoryRepo) CreateJob(ctx context.Context, job *domain.Job) error {
+	if job == nil || len(job.ID) == 0 {
+		return nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.jobs[job.ID]; ok {
+		return errors.Wrapf(domain.ErrConditionFailed, "job exists. id: %s", job.ID)
+	}
+	repo.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// GetJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param id
+//	@return *domain.Job
+//	@return error
+func (repo *JobMemoryRepo) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	job, ok := repo.jobs[id]
+	if !ok {
+		return nil, errors.Wrapf(domain.ErrNotFound, "job id: %s", id)
+	}
+	return copyJob(job), nil
+}
+
+// UpdateJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param job
+//	@return error
+func (repo *JobMemoryRepo) UpdateJob(ctx context.Context, job *domain.Job) error {
+	if job == nil {
+		return nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.jobs[job.ID]; !ok {
+		return errors.Wrapf(domain.ErrNotFound, "job id: %s", job.ID)
+	}
+	repo.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// ClaimJob
+//
+//	@receiver repo
+//	@param ctx
+//	@param job
+//	@param now
+//	@return error
+func (repo *JobMemoryRepo) ClaimJob(ctx context.Context, job *domain.Job, now time.Time) error {
+	if job == nil {
+		return nil
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, ok := repo.jobs[job.ID]
+	if !ok || !stored.IsClaimable(now) {
+		return errors.Wrapf(domain.ErrConditionFailed, "job is not claimable. id: %s", job.ID)
+	}
+	repo.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// copyJob
+// stored jobs are not shared with callers
+//
+//	@param job
+//	@return *domain.Job
+func copyJob(job *domain.Job) *domain.Job {
+	if job == nil {
+		return nil
+	}
+	copied := *job
+	copied.Input = append(json.RawMessage(nil), job.Input...)
+	copied.Result = append(json.RawMessage(nil), job.Result...)
+	if job.Caller != nil {
+		caller := *job.Caller
+		copied.Caller = &caller
+	}
+	return &copied
+}
@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+)
+
+func TestJobMemoryRepoContract(t *testing.T) {
+	repositorytest.RunJobRepositoryContract(t, func(t *testing.T) domain.JobRepository {
+		return repository.NewJobMemoryRepo()
+	})
+}
+
+func TestJobDynamodbRepoContract(t *testing.T) {
+	repositorytest.RunJobRepositoryContract(t, func(t *testing.T) domain.JobRepository {
+		return repository.NewJobDynamodbRepo(dummyTableName, ddb.client).WithRetention(time.Hour)
+	})
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+const (
+	loginAttemptPKPrefix      string = "loginattempt#"
+	loginAttemptSK            string = "loginattempt"
+	fieldLoginKey             string = "key"
+	fieldLoginFailures        string = "failures"
+	fieldLoginLastFailedAt    string = "lastFailedAt"
+	fieldLoginPrevFailedAt    string = "previousFailedAt"
+	fieldLoginExpiresAt       string = "expiresAt"
+	loginAttemptRecordRetries int    = 3
+)
+
+// LoginAttemptDynamodbStore
+// implements authentication.LoginAttemptStore.
+// the ttl is in seconds, so an attempt may outlive its ExpiresAt by less than a second on increments.
+type LoginAttemptDynamodbStore struct {
+	tableName string
+	client    dynamodbiface.DynamoDBAPI
+	now       func() time.Time
+}
+
+// NewLoginAttemptDynamodbStore
+//
+//	@param tableName
+//	@param client
+//	@return *LoginAttemptDynamodbStore
+func NewLoginAttemptDynamodbStore(tableName string, client dynamodbiface.DynamoDBAPI) *LoginAttemptDynamodbStore {
+	return &LoginAttemptDynamodbStore{
+		tableName: tableName,
+		client:    client,
+		now:       time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *LoginAttemptDynamodbStore
+func (s *LoginAttemptDynamodbStore) WithClock(now func() time.Time) *LoginAttemptDynamodbStore {
+	s.now = now
+	return s
+}
+
+// RecordFailure
+// an unexpired record is incremented in place, an expired one is replaced by a record of 1 failure.
+// both are conditional, a race between them is retried. the previous failure time is copied by the update itself,
+// as its operands are the attributes before the update.
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@param expiresAt
+//	@return *authentication.LoginAttempts
+//	@return error
+func (s *LoginAttemptDynamodbStore) RecordFailure(
+	ctx context.Context,
+	key string,
+	expiresAt time.Time,
+) (*authentication.LoginAttempts, error) {
+	for i := 0; i < loginAttemptRecordRetries; i++ {
+		curr := s.now()
+		attempts, err := s.increment(ctx, key, curr, expiresAt)
+		if err == nil {
+			return attempts, nil
+		}
+		if !isAwsErrorCode(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+			rootErr := errors.New(err.Error())
+			return nil, errors.Wrapf(rootErr, "update db login attempts error. table: %s, key: %s", s.tableName, key)
+		}
+		attempts, err = s.restart(ctx, key, curr, expiresAt)
+		if err == nil {
+			return attempts, nil
+		}
+		if !isAwsErrorCode(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+			rootErr := errors.New(err.Error())
+			return nil, errors.Wrapf(rootErr, "put db login attempts error. table: %s, key: %s", s.tableName, key)
+		}
+	}
+	return nil, errors.Errorf("too many conflicts on recording login failure. table: %s, key: %s", s.tableName, key)
+}
+
+// Get
+// the ttl of DynamoDB purges items lazily, so the expiration is checked here too
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@return *authentication.LoginAttempts
+//	@return error
+func (s *LoginAttemptDynamodbStore) Get(ctx context.Context, key string) (*authentication.LoginAttempts, error) {
+	data, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            toLoginAttemptDBKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "get db login attempts error. table: %s, key: %s", s.tableName, key)
+	}
+	if len(data.Item) == 0 {
+		return nil, nil
+	}
+	attempts, err := toLoginAttemptsEntity(data.Item)
+	if err != nil {
+		return nil, err
+	}
+	if !attempts.ExpiresAt.After(s.now()) {
+		return nil, nil
+	}
+	return attempts, nil
+}
+
+// Reset
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@return error
+func (s *LoginAttemptDynamodbStore) Reset(ctx context.Context, key string) error {
+	_, err := s.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       toLoginAttemptDBKey(key),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "delete db login attempts error. table: %s, key: %s", s.tableName, key)
+	}
+	return nil
+}
+
+// increment
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@param curr
+//	@param expiresAt
+//	@return *authentication.LoginAttempts
+//	@return error ConditionalCheckFailedException when the record has expired
+func (s *LoginAttemptDynamodbStore) increment(
+	ctx context.Context,
+	key string,
+	curr time.Time,
+	expiresAt time.Time,
+) (*authentication.LoginAttempts, error) {
+	values, err := toLoginAttemptTimeValues(curr, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	noFailedAt, err := dynamodbattribute.Marshal(time.Time{})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "marshal zero time error")
+	}
+	values[":noFailedAt"] = noFailedAt
+	values[":one"] = &dynamodb.AttributeValue{N: aws.String("1")}
+	values[":key"] = &dynamodb.AttributeValue{S: aws.String(key)}
+	values[":now"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(curr.Unix(), 10))}
+	data, err := s.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       toLoginAttemptDBKey(key),
+		UpdateExpression: aws.String("SET #key = :key, " +
+			"#previousFailedAt = if_not_exists(#lastFailedAt, :noFailedAt), #lastFailedAt = :lastFailedAt, " +
+			"#expiresAt = :expiresAt, #expireAt = :expireAt ADD #failures :one"),
+		ConditionExpression: aws.String("attribute_not_exists(#pk) OR #expireAt > :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#pk":               aws.String(FieldDummyPK),
+			"#key":              aws.String(fieldLoginKey),
+			"#failures":         aws.String(fieldLoginFailures),
+			"#lastFailedAt":     aws.String(fieldLoginLastFailedAt),
+			"#previousFailedAt": aws.String(fieldLoginPrevFailedAt),
+			"#expiresAt":        aws.String(fieldLoginExpiresAt),
+			"#expireAt":         aws.String(FieldDummyExpireAt),
+		},
+		ExpressionAttributeValues: values,
+		ReturnValues:              aws.String(dynamodb.ReturnValueAllNew),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toLoginAttemptsEntity(data.Attributes)
+}
+
+// restart
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@param curr
+//	@param expiresAt
+//	@return *authentication.LoginAttempts
+//	@return error ConditionalCheckFailedException when the record was renewed meanwhile
+func (s *LoginAttemptDynamodbStore) restart(
+	ctx context.Context,
+	key string,
+	curr time.Time,
+	expiresAt time.Time,
+) (*authentication.LoginAttempts, error) {
+	attempts := &authentication.LoginAttempts{
+		Key:          key,
+		Failures:     1,
+		LastFailedAt: curr,
+		ExpiresAt:    expiresAt,
+	}
+	item, err := dynamodbattribute.MarshalMap(attempts)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "marshal login attempts error. key: %s", key)
+	}
+	for name, value := range toLoginAttemptDBKey(key) {
+		item[name] = value
+	}
+	item[FieldDummyExpireAt] = toExpireAtValue(expiresAt)
+	_, err = s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#pk) OR #expireAt <= :now"),
+		ExpressionAttributeNames: map[string]*string{
+			"#pk":       aws.String(FieldDummyPK),
+			"#expireAt": aws.String(FieldDummyExpireAt),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(strconv.FormatInt(curr.Unix(), 10))},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// toLoginAttemptTimeValues
+//
+//	@param lastFailedAt
+//	@param expiresAt
+//	@return map
+//	@return error
+func toLoginAttemptTimeValues(
+	lastFailedAt time.Time,
+	expiresAt time.Time,
+) (map[string]*dynamodb.AttributeValue, error) {
+	lastFailedAtValue, err := dynamodbattribute.Marshal(lastFailedAt)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "marshal lastFailedAt error")
+	}
+	expiresAtValue, err := dynamodbattribute.Marshal(expiresAt)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrap(rootErr, "marshal expiresAt error")
+	}
+	return map[string]*dynamodb.AttributeValue{
+		":lastFailedAt": lastFailedAtValue,
+		":expiresAt":    expiresAtValue,
+		":expireAt":     toExpireAtValue(expiresAt),
+	}, nil
+}
+
+// toLoginAttemptsEntity
+//
+//	@param item
+//	@return *authentication.LoginAttempts
+//	@return error
+func toLoginAttemptsEntity(item map[string]*dynamodb.AttributeValue) (*authentication.LoginAttempts, error) {
+	attempts := &authentication.LoginAttempts{}
+	err := dynamodbattribute.UnmarshalMap(item, attempts)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "unmarshal db login attempts error. pk: %s",
+			aws.StringValue(item[FieldDummyPK].S))
+	}
+	return attempts, nil
+}
+
+// toLoginAttemptDBKey
+//
+//	@param key
+//	@return map
+func toLoginAttemptDBKey(key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(loginAttemptPKPrefix + key)},
+		FieldDummySK: {S: aws.String(loginAttemptSK)},
+	}
+}
@@ -0,0 +1,17 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestLoginAttemptDynamodbStoreContract(t *testing.T) {
+	repositorytest.RunLoginAttemptStoreContract(t,
+		func(t *testing.T, now func() time.Time) authentication.LoginAttemptStore {
+			return repository.NewLoginAttemptDynamodbStore(dummyTableName, ddb.client).WithClock(now)
+		})
+}
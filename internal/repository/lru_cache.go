@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// lruEntry.
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// lruCache
+// bounded least recently used cache with per entry expiry, safe for concurrent use.
+type lruCache[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	now      func() time.Time
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+// newLruCache
+//
+//	@param capacity max entries, the least recently used entry is evicted when full
+//	@param now clock, time.Now when nil
+//	@return *lruCache[V]
+func newLruCache[V any](capacity int, now func() time.Time) *lruCache[V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &lruCache[V]{
+		capacity: capacity,
+		now:      now,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get
+//
+//	@receiver c
+//	@param key
+//	@return V
+//	@return bool false when missing or expired
+func (c *lruCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry, _ := elem.Value.(*lruEntry[V])
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set
+//
+//	@receiver c
+//	@param key
+//	@param value
+//	@param ttl
+//	@return bool true when another entry was evicted
+func (c *lruCache[V]) set(key string, value V, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := c.now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry, _ := elem.Value.(*lruEntry[V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return false
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() <= c.capacity {
+		return false
+	}
+	c.removeElement(c.order.Back())
+	return true
+}
+
+// remove
+//
+//	@receiver c
+//	@param key
+func (c *lruCache[V]) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// len
+//
+//	@receiver c
+//	@return int entries including expired ones not yet removed
+func (c *lruCache[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// removeElement
+// must be called with the lock held
+//
+//	@receiver c
+//	@param elem
+func (c *lruCache[V]) removeElement(elem *list.Element) {
+	entry, _ := elem.Value.(*lruEntry[V])
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+}
+
+// flightCall an in-flight load shared by concurrent callers.
+type flightCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// flightGroup
+// collapses concurrent loads of the same key into one call.
+type flightGroup[V any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[V]
+}
+
+// do
+// fn runs with a context detached from the cancellation of the caller starting it, so the callers sharing it
+// do not fail when that caller goes away. every caller stops waiting when its own ctx is done.
+//
+//	@receiver g
+//	@param ctx
+//	@param key
+//	@param fn
+//	@return V
+//	@return bool true when the result came from a call started by another caller
+//	@return error
+func (g *flightGroup[V]) do(
+	ctx context.Context,
+	key string,
+	fn func(ctx context.Context) (V, error),
+) (V, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[V])
+	}
+	call, shared := g.calls[key]
+	if !shared {
+		call = &flightCall[V]{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.run(detachedContext{parent: ctx}, key, call, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, shared, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, shared, ctx.Err()
+	}
+}
+
+// run
+//
+//	@receiver g
+//	@param ctx
+//	@param key
+//	@param call
+//	@param fn
+func (g *flightGroup[V]) run(
+	ctx context.Context,
+	key string,
+	call *flightCall[V],
+	fn func(ctx context.Context) (V, error),
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = errors.Errorf("load of %s panicked: %v", key, r)
+		}
+		g.mu.Lock()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = fn(ctx)
+}
+
+// forget
+// later callers start a new call instead of joining the in-flight one
+//
+//	@receiver g
+//	@param key
+func (g *flightGroup[V]) forget(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.calls, key)
+}
+
+// detachedContext
+// keeps the values of parent without its deadline and cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+// Deadline
+//
+//	@receiver c
+//	@return time.Time
+//	@return bool
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done
+//
+//	@receiver c
+//	@return <-chan struct{} nil, it is never done
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+// Err
+//
+//	@receiver c
+//	@return error
+func (c detachedContext) Err() error {
+	return nil
+}
+
+// Value
+//
+//	@receiver c
+//	@param key
+//	@return any
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
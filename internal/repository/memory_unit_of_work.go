@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+// memoryCommitMu serializes commits, so locking several memory stores in one commit cannot deadlock.
+//
+//nolint:gochecknoglobals
+var memoryCommitMu sync.Mutex
+
+type memoryUnitOfWorkContextKey struct{}
+
+// memoryTxOp one write of a memory unit of work.
+type memoryTxOp struct {
+	locker sync.Locker
+	desc   string
+	// check runs with the locker held and before any op is applied
+	check func() error
+	apply func()
+}
+
+// MemoryUnitOfWork
+// collects writes of several memory repositories and applies them all or none.
+type MemoryUnitOfWork struct {
+	mu          sync.Mutex
+	ops         []*memoryTxOp
+	afterCommit []func()
+}
+
+// NewMemoryUnitOfWork
+//
+//	@return *MemoryUnitOfWork
+func NewMemoryUnitOfWork() *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{
+		ops: []*memoryTxOp{},
+	}
+}
+
+// MemoryUnitOfWorkFromContext
+//
+//	@param ctx
+//	@return *MemoryUnitOfWork nil when the context is not inside a memory transaction
+func MemoryUnitOfWorkFromContext(ctx context.Context) *MemoryUnitOfWork {
+	if ctx == nil {
+		return nil
+	}
+	uow, ok := ctx.Value(memoryUnitOfWorkContextKey{}).(*MemoryUnitOfWork)
+	if !ok {
+		return nil
+	}
+	return uow
+}
+
+// add
+//
+//	@receiver u
+//	@param op
+func (u *MemoryUnitOfWork) add(op *memoryTxOp) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.ops = append(u.ops, op)
+}
+
+// AfterCommit
+// fn runs once the transaction is committed, it never runs when the transaction fails
+//
+//	@receiver u
+//	@param fn
+func (u *MemoryUnitOfWork) AfterCommit(fn func()) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.afterCommit = append(u.afterCommit, fn)
+}
+
+// committed
+//
+//	@receiver u
+func (u *MemoryUnitOfWork) committed() {
+	u.mu.Lock()
+	fns := u.afterCommit
+	u.afterCommit = nil
+	u.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// commit
+//
+//	@receiver u
+//	@return error *TransactCanceledError when any check fails
+func (u *MemoryUnitOfWork) commit() error {
+	u.mu.Lock()
+	ops := make([]*memoryTxOp, len(u.ops))
+	copy(ops, u.ops)
+	u.mu.Unlock()
+	if len(ops) == 0 {
+		return nil
+	}
+	if len(ops) > MaxTransactItems {
+		return errors.Wrapf(ErrTooManyTransactItems, "items: %d, max: %d", len(ops), MaxTransactItems)
+	}
+	memoryCommitMu.Lock()
+	defer memoryCommitMu.Unlock()
+	locked := make(map[sync.Locker]bool)
+	for _, op := range ops {
+		if !locked[op.locker] {
+			op.locker.Lock()
+			locked[op.locker] = true
+		}
+	}
+	defer func() {
+		for locker := range locked {
+			locker.Unlock()
+		}
+	}()
+	canceled := &TransactCanceledError{
+		Items: []*TransactItemError{},
+	}
+	for i, op := range ops {
+		if op.check == nil {
+			continue
+		}
+		if err := op.check(); err != nil {
+			canceled.Items = append(canceled.Items, &TransactItemError{
+				Index:   i,
+				Desc:    op.desc,
+				Code:    errorToCancellationCode(err),
+				Message: err.Error(),
+				Err:     errors.Cause(err),
+			})
+		}
+	}
+	if len(canceled.Items) > 0 {
+		return errors.WithStack(canceled)
+	}
+	for _, op := range ops {
+		op.apply()
+	}
+	logger.Debug("memory transaction committed. items: %d", len(ops))
+	return nil
+}
+
+// MemoryTransactor implements domain.Transactor for memory repositories.
+type MemoryTransactor struct{}
+
+// NewMemoryTransactor
+//
+//	@return *MemoryTransactor
+func NewMemoryTransactor() *MemoryTransactor {
+	return &MemoryTransactor{}
+}
+
+// WithinTransaction
+//
+//	@receiver t
+//	@param ctx
+//	@param fn
+//	@return error
+func (t *MemoryTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if MemoryUnitOfWorkFromContext(ctx) != nil {
+		// join the outer unit of work, it is committed by the outer call
+		return fn(ctx)
+	}
+	uow := NewMemoryUnitOfWork()
+	err := fn(context.WithValue(ctx, memoryUnitOfWorkContextKey{}, uow))
+	if err != nil {
+		return err
+	}
+	err = uow.commit()
+	if err != nil {
+		return err
+	}
+	uow.committed()
+	return nil
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+const (
+	refreshTokenPKPrefix  string = "refresh#"
+	refreshTokenSK        string = "refresh"
+	refreshFamilyPKPrefix string = "refreshfamily#"
+	refreshFamilySK       string = "refreshfamily"
+	fieldRefreshUsedAt    string = "usedAt"
+)
+
+// RefreshTokenDynamodbStore
+// implements authentication.RefreshTokenStore, a token and a revoked family are items of their own.
+type RefreshTokenDynamodbStore struct {
+	tableName string
+	client    dynamodbiface.DynamoDBAPI
+	now       func() time.Time
+}
+
+// NewRefreshTokenDynamodbStore
+//
+//	@param tableName
+//	@param client
+//	@return *RefreshTokenDynamodbStore
+func NewRefreshTokenDynamodbStore(tableName string, client dynamodbiface.DynamoDBAPI) *RefreshTokenDynamodbStore {
+	return &RefreshTokenDynamodbStore{
+		tableName: tableName,
+		client:    client,
+		now:       time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *RefreshTokenDynamodbStore
+func (s *RefreshTokenDynamodbStore) WithClock(now func() time.Time) *RefreshTokenDynamodbStore {
+	s.now = now
+	return s
+}
+
+// Save
+//
+//	@receiver s
+//	@param ctx
+//	@param token
+//	@return error
+func (s *RefreshTokenDynamodbStore) Save(ctx context.Context, token *authentication.RefreshToken) error {
+	if token == nil || token.Hash == "" {
+		return errors.Wrap(authentication.ErrInvalidRefreshToken, "no hash found")
+	}
+	item, err := dynamodbattribute.MarshalMap(token)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "marshal refresh token error. family: %s", token.FamilyID)
+	}
+	for name, value := range toRefreshTokenDBKey(token.Hash) {
+		item[name] = value
+	}
+	item[FieldDummyExpireAt] = toExpireAtValue(token.ExpiresAt)
+	_, err = s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "put db refresh token error. table: %s, family: %s", s.tableName, token.FamilyID)
+	}
+	logger.Debug("put refresh token to db. family: %s, expiresAt: %s", token.FamilyID, token.ExpiresAt)
+	return nil
+}
+
+// Get
+// the ttl of DynamoDB purges items lazily, so the expiration is checked here too
+//
+//	@receiver s
+//	@param ctx
+//	@param hash
+//	@return *authentication.RefreshToken
+//	@return error
+func (s *RefreshTokenDynamodbStore) Get(ctx context.Context, hash string) (*authentication.RefreshToken, error) {
+	data, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            toRefreshTokenDBKey(hash),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "get db refresh token error. table: %s, hash: %s", s.tableName, hash)
+	}
+	if len(data.Item) == 0 {
+		return nil, nil
+	}
+	token := &authentication.RefreshToken{}
+	err = dynamodbattribute.UnmarshalMap(data.Item, token)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "unmarshal db refresh token error. hash: %s", hash)
+	}
+	if !token.ExpiresAt.After(s.now()) {
+		return nil, nil
+	}
+	return token, nil
+}
+
+// MarkUsed
+// the condition makes only one of concurrent calls succeed
+//
+//	@receiver s
+//	@param ctx
+//	@param hash
+//	@param usedAt
+//	@return error
+func (s *RefreshTokenDynamodbStore) MarkUsed(ctx context.Context, hash string, usedAt time.Time) error {
+	value, err := dynamodbattribute.Marshal(usedAt)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "marshal usedAt error. hash: %s", hash)
+	}
+	_, err = s.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.tableName),
+		Key:                 toRefreshTokenDBKey(hash),
+		UpdateExpression:    aws.String("SET #usedAt = :usedAt"),
+		ConditionExpression: aws.String("attribute_exists(#pk) AND attribute_not_exists(#usedAt)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#pk":     aws.String(FieldDummyPK),
+			"#usedAt": aws.String(fieldRefreshUsedAt),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":usedAt": value},
+	})
+	if err == nil {
+		return nil
+	}
+	if !isAwsErrorCode(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "update db refresh token error. table: %s, hash: %s", s.tableName, hash)
+	}
+	token, err := s.Get(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return errors.Wrapf(authentication.ErrInvalidRefreshToken, "hash: %s", hash)
+	}
+	return errors.Wrapf(authentication.ErrReusedRefreshToken, "hash: %s", hash)
+}
+
+// RevokeFamily
+//
+//	@receiver s
+//	@param ctx
+//	@param familyID
+//	@param expireAt
+//	@return error
+func (s *RefreshTokenDynamodbStore) RevokeFamily(ctx context.Context, familyID string, expireAt time.Time) error {
+	item := toRefreshFamilyDBKey(familyID)
+	item[FieldDummyExpireAt] = toExpireAtValue(expireAt)
+	_, err := s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "put db refresh family error. table: %s, family: %s", s.tableName, familyID)
+	}
+	logger.Debug("put revoked refresh family to db. family: %s, expireAt: %s", familyID, expireAt)
+	return nil
+}
+
+// IsFamilyRevoked
+//
+//	@receiver s
+//	@param ctx
+//	@param familyID
+//	@return bool
+//	@return error
+func (s *RefreshTokenDynamodbStore) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	data, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            toRefreshFamilyDBKey(familyID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return false, errors.Wrapf(rootErr, "get db refresh family error. table: %s, family: %s", s.tableName, familyID)
+	}
+	value, ok := data.Item[FieldDummyExpireAt]
+	if !ok || value.N == nil {
+		return false, nil
+	}
+	expireAt, err := strconv.ParseInt(*value.N, 10, 64)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return false, errors.Wrapf(rootErr, "invalid db refresh family expireAt. family: %s", familyID)
+	}
+	return expireAt > s.now().Unix(), nil
+}
+
+// toExpireAtValue
+// the ttl is in seconds, round it up to never purge an item too early
+//
+//	@param expireAt
+//	@return *dynamodb.AttributeValue
+func toExpireAtValue(expireAt time.Time) *dynamodb.AttributeValue {
+	expireAtSec := expireAt.Add(time.Second - time.Nanosecond).Unix()
+	return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expireAtSec, 10))}
+}
+
+// toRefreshTokenDBKey
+//
+//	@param hash
+//	@return map
+func toRefreshTokenDBKey(hash string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(refreshTokenPKPrefix + hash)},
+		FieldDummySK: {S: aws.String(refreshTokenSK)},
+	}
+}
+
+// toRefreshFamilyDBKey
+//
+//	@param familyID
+//	@return map
+func toRefreshFamilyDBKey(familyID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(refreshFamilyPKPrefix + familyID)},
+		FieldDummySK: {S: aws.String(refreshFamilySK)},
+	}
+}
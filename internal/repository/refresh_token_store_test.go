@@ -0,0 +1,16 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestRefreshTokenDynamodbStoreContract(t *testing.T) {
+	repositorytest.RunRefreshTokenStoreContract(t, func(t *testing.T, now func() time.Time) authentication.RefreshTokenStore {
+		return repository.NewRefreshTokenDynamodbStore(dummyTableName, ddb.client).WithClock(now)
+	})
+}
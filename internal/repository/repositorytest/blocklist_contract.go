@@ -0,0 +1,151 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+// BlocklistStoreFactory
+// build the store under test, it must read the time from now.
+type BlocklistStoreFactory func(t *testing.T, now func() time.Time) authentication.BlocklistStore
+
+// testClock a manual clock shared by the store and the case.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// RunBlocklistStoreContract
+// every implementation of authentication.BlocklistStore must pass these cases.
+//
+//	@param t
+//	@param factory
+func RunBlocklistStoreContract(t *testing.T, factory BlocklistStoreFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, store authentication.BlocklistStore, clock *testClock)
+	}{
+		{"BlockWithKeyThenItIsBlocked", testBlockWithKeyThenItIsBlocked},
+		{"IsBlockedWithUnknownKeyReturnFalse", testIsBlockedWithUnknownKeyReturnFalse},
+		{"BlockWithExpiredTimeSkipIt", testBlockWithExpiredTimeSkipIt},
+		{"IsBlockedWithExpiredKeyReturnFalse", testIsBlockedWithExpiredKeyReturnFalse},
+		{"BlockWithConcurrentCallsBlockAll", testBlockWithConcurrentCallsBlockAll},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+			testCase.fn(t, factory(t, clock.Now), clock)
+		})
+	}
+}
+
+// blocklistTestKey
+// keys are unique per case, the stores may be shared by cases.
+//
+//	@param t
+//	@param token
+//	@return string
+func blocklistTestKey(t *testing.T, token string) string {
+	return authentication.BlocklistKey(t.Name() + token)
+}
+
+func testBlockWithKeyThenItIsBlocked(t *testing.T, store authentication.BlocklistStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "failed to block key"
+	key := blocklistTestKey(t, "token_1")
+
+	err1 := store.Block(context.TODO(), key, clock.Now().Add(time.Minute))
+	blocked, err2 := store.IsBlocked(context.TODO(), key)
+
+	assert.Nil(err1, msg, "found block error")
+	assert.Nil(err2, msg, "found is blocked error")
+	assert.True(blocked, msg, "key is not blocked")
+}
+
+func testIsBlockedWithUnknownKeyReturnFalse(t *testing.T, store authentication.BlocklistStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "unknown key is blocked"
+
+	blocked, err := store.IsBlocked(context.TODO(), blocklistTestKey(t, "unknown"))
+
+	assert.Nil(err, msg, "found is blocked error")
+	assert.False(blocked, msg, "key is blocked")
+}
+
+func testBlockWithExpiredTimeSkipIt(t *testing.T, store authentication.BlocklistStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "expired key is blocked"
+	key := blocklistTestKey(t, "token_1")
+
+	err1 := store.Block(context.TODO(), key, clock.Now().Add(-time.Second))
+	blocked, err2 := store.IsBlocked(context.TODO(), key)
+
+	assert.Nil(err1, msg, "found block error")
+	assert.Nil(err2, msg, "found is blocked error")
+	assert.False(blocked, msg, "key is blocked")
+}
+
+func testIsBlockedWithExpiredKeyReturnFalse(t *testing.T, store authentication.BlocklistStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "key is blocked after it expired"
+	key := blocklistTestKey(t, "token_1")
+	err := store.Block(context.TODO(), key, clock.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	clock.Add(time.Minute + time.Second)
+	blocked, err := store.IsBlocked(context.TODO(), key)
+
+	assert.Nil(err, msg, "found is blocked error")
+	assert.False(blocked, msg, "key is blocked")
+}
+
+func testBlockWithConcurrentCallsBlockAll(t *testing.T, store authentication.BlocklistStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "failed to block keys concurrently"
+	count := 20
+	errs := make(chan error, count*2)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		key := blocklistTestKey(t, fmt.Sprintf("token_%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.Block(context.TODO(), key, clock.Now().Add(time.Minute))
+			_, err := store.IsBlocked(context.TODO(), key)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.Nil(err, msg, "found error")
+	}
+	for i := 0; i < count; i++ {
+		blocked, err := store.IsBlocked(context.TODO(), blocklistTestKey(t, fmt.Sprintf("token_%d", i)))
+		assert.Nil(err, msg, "found is blocked error")
+		assert.True(blocked, msg, "key is not blocked: %d", i)
+	}
+}
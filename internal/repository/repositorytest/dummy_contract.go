@@ -0,0 +1,640 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+const (
+	concurrentWriters int = 16
+	// batchItems spans several chunks of batch reads and more items than the concurrency of batch writes
+	batchItems int = 130
+)
+
+// DummyRepositoryFactory
+// build the repository under test and the transactor its writes join.
+type DummyRepositoryFactory func(t *testing.T) (domain.DummyRepository, domain.Transactor)
+
+// RunDummyRepositoryContract
+// every implementation of domain.DummyRepository must pass these cases to behave the same.
+//
+//	@param t
+//	@param factory
+func RunDummyRepositoryContract(t *testing.T, factory DummyRepositoryFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, repo domain.DummyRepository, transactor domain.Transactor)
+	}{
+		{"GetByIDWithBlankIDReturnNil", testGetByIDWithBlankIDReturnNil},
+		{"GetByIDWithMissingIDReturnNil", testGetByIDWithMissingIDReturnNil},
+		{"InsertWithEntityReturnEntity", testInsertWithEntityReturnEntity},
+		{"InsertWithNilReturnNil", testInsertWithNilReturnNil},
+		{"InsertWithExistingIDOverwrite", testInsertWithExistingIDOverwrite},
+		{"InsertWithOtherCreationKeepStoredCreation", testInsertWithOtherCreationKeepStoredCreation},
+		{"InsertWithUnmetConditionKeepEntity", testInsertWithUnmetConditionKeepEntity},
+		{"InsertWithConditionAndMissingIDCreate", testInsertWithConditionAndMissingIDCreate},
+		{"DeleteByIDWithIDReturnDeletedEntity", testDeleteByIDWithIDReturnDeletedEntity},
+		{"DeleteByIDWithMissingIDReturnNotFound", testDeleteByIDWithMissingIDReturnNotFound},
+		{"DeleteByIDWithUnmetConditionKeepEntity", testDeleteByIDWithUnmetConditionKeepEntity},
+		{"DeleteByIDWithMetConditionReturnDeletedEntity", testDeleteByIDWithMetConditionReturnDeletedEntity},
+		{"TransactionWithWritesCommitTogether", testTransactionWithWritesCommitTogether},
+		{"TransactionWithFnErrorWriteNothing", testTransactionWithFnErrorWriteNothing},
+		{"TransactionWithUnmetConditionWriteNothing", testTransactionWithUnmetConditionWriteNothing},
+		{"InsertConcurrentlyKeepAllEntities", testInsertConcurrentlyKeepAllEntities},
+		{"BatchInsertWithEntitiesStoreAll", testBatchInsertWithEntitiesStoreAll},
+		{"BatchInsertWithInvalidItemsReportPerItem", testBatchInsertWithInvalidItemsReportPerItem},
+		{"BatchGetByIDsWithIDsReturnInOrder", testBatchGetByIDsWithIDsReturnInOrder},
+		{"BatchDeleteByIDsWithIDsDeleteAll", testBatchDeleteByIDsWithIDsDeleteAll},
+		{"BatchWritesWithUnmetConditionReportPerItem", testBatchWritesWithUnmetConditionReportPerItem},
+		{"BatchInsertInTransactionWithFnErrorWriteNothing", testBatchInsertInTransactionWithFnErrorWriteNothing},
+		{"WritesWithChangeRecordHistory", testWritesWithChangeRecordHistory},
+		{"BatchWritesWithChangeRecordHistory", testBatchWritesWithChangeRecordHistory},
+		{"TransactionWithFnErrorRecordNoHistory", testTransactionWithFnErrorRecordNoHistory},
+		{"InsertWithDeletedIDContinueHistory", testInsertWithDeletedIDContinueHistory},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, transactor := factory(t)
+			testCase.fn(t, repo, transactor)
+		})
+	}
+}
+
+// NewDummy
+// build an entity with random id
+//
+//	@return *domain.Dummy
+func NewDummy() *domain.Dummy {
+	random := uuid.New().String()
+	now := time.Now().UTC()
+	return &domain.Dummy{
+		ID:        random,
+		Name:      fmt.Sprintf("test_name_%s", random),
+		SomeAttr:  fmt.Sprintf("test_some_attr_%s", random),
+		CreatedAt: now,
+		CreatedBy: fmt.Sprintf("test_creator_%s", random),
+		UpdatedAt: now,
+		UpdatedBy: fmt.Sprintf("test_updater_%s", random),
+	}
+}
+
+// NewReplacement
+// build an entity replacing origin, a random one of its id and creation fields
+//
+//	@param origin
+//	@return *domain.Dummy
+func NewReplacement(origin *domain.Dummy) *domain.Dummy {
+	replacement := NewDummy()
+	replacement.ID = origin.ID
+	replacement.CreatedAt = origin.CreatedAt
+	replacement.CreatedBy = origin.CreatedBy
+	return replacement
+}
+
+func mustInsert(t *testing.T, repo domain.DummyRepository, dummy *domain.Dummy) {
+	t.Helper()
+	_, err := repo.Insert(context.TODO(), dummy)
+	if err != nil {
+		t.Fatalf("error happened when preparing necesarry data, %v", err)
+	}
+}
+
+func mustGet(t *testing.T, repo domain.DummyRepository, id string) *domain.Dummy {
+	t.Helper()
+	loaded, err := repo.GetByID(context.TODO(), id)
+	if err != nil {
+		t.Fatalf("error happened when load data, %v", err)
+	}
+	return loaded
+}
+
+func testGetByIDWithBlankIDReturnNil(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "get by blank id returned entity"
+
+	actual, err := repo.GetByID(context.TODO(), "")
+
+	assert.Nil(err, msg, "found error")
+	assert.Nil(actual, msg, "returned entity")
+}
+
+func testGetByIDWithMissingIDReturnNil(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "get by missing id returned entity"
+
+	actual, err := repo.GetByID(context.TODO(), uuid.New().String())
+
+	assert.Nil(err, msg, "found error")
+	assert.Nil(actual, msg, "returned entity")
+}
+
+func testInsertWithEntityReturnEntity(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to insert valid entity"
+	expected := NewDummy()
+
+	actual, err := repo.Insert(context.TODO(), expected)
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong returned entity")
+	assert.Equal(expected, mustGet(t, repo, expected.ID), msg, "wrong stored entity")
+}
+
+func testInsertWithNilReturnNil(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "insert nil entity returned entity"
+
+	actual, err := repo.Insert(context.TODO(), nil)
+
+	assert.Nil(err, msg, "found error")
+	assert.Nil(actual, msg, "returned entity")
+}
+
+func testInsertWithExistingIDOverwrite(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to overwrite entity"
+	origin := NewDummy()
+	mustInsert(t, repo, origin)
+	expected := NewReplacement(origin)
+
+	_, err := repo.Insert(context.TODO(), expected)
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(expected, mustGet(t, repo, origin.ID), msg, "wrong stored entity")
+}
+
+func testInsertWithOtherCreationKeepStoredCreation(
+	t *testing.T,
+	repo domain.DummyRepository,
+	_ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "replace changed creation fields"
+	origin := NewDummy()
+	mustInsert(t, repo, origin)
+	// a replacement built from a stale read takes it for a new item
+	replacement := NewDummy()
+	replacement.ID = origin.ID
+	expected := NewReplacement(origin)
+	expected.Name = replacement.Name
+	expected.SomeAttr = replacement.SomeAttr
+	expected.UpdatedAt = replacement.UpdatedAt
+	expected.UpdatedBy = replacement.UpdatedBy
+
+	actual, err := repo.Insert(context.TODO(), replacement)
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong returned entity")
+	assert.Equal(expected, mustGet(t, repo, origin.ID), msg, "wrong stored entity")
+	batched := NewDummy()
+	batched.ID = origin.ID
+	results, err := repo.BatchInsert(context.TODO(), []*domain.Dummy{batched})
+	assert.Nil(err, msg, "found error")
+	assert.Nil(results[0].Err, msg, "found item error")
+	stored := mustGet(t, repo, origin.ID)
+	assert.Equal(batched.Name, stored.Name, msg, "batch didn't replace entity")
+	assert.Equal(origin.CreatedAt, stored.CreatedAt, msg, "wrong batch creation time")
+	assert.Equal(origin.CreatedBy, stored.CreatedBy, msg, "wrong batch creator")
+	assert.Equal(stored, results[0].Dummy, msg, "wrong batch result")
+}
+
+func testInsertWithUnmetConditionKeepEntity(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "insert with unmet condition didn't fail"
+	expected := NewDummy()
+	mustInsert(t, repo, expected)
+	replacement := NewDummy()
+	replacement.ID = expected.ID
+
+	actual, err := repo.Insert(context.TODO(), replacement, domain.WithExpectedAttr("name", "other_name"))
+
+	assert.ErrorIs(err, domain.ErrConditionFailed, msg, "wrong error")
+	assert.Nil(actual, msg, "returned entity")
+	assert.Equal(expected, mustGet(t, repo, expected.ID), msg, "entity not kept")
+}
+
+func testInsertWithConditionAndMissingIDCreate(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to create entity with condition"
+	expected := NewDummy()
+
+	actual, err := repo.Insert(context.TODO(), expected, domain.WithExpectedAttr("name", "other_name"))
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong returned entity")
+	assert.Equal(expected, mustGet(t, repo, expected.ID), msg, "wrong stored entity")
+}
+
+func testDeleteByIDWithIDReturnDeletedEntity(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to delete entity by valid id"
+	expected := NewDummy()
+	mustInsert(t, repo, expected)
+
+	actual, err := repo.DeleteByID(context.TODO(), expected.ID)
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong deleted entity")
+	assert.Nil(mustGet(t, repo, expected.ID), msg, "entity left")
+}
+
+func testDeleteByIDWithMissingIDReturnNotFound(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "delete by missing id didn't fail"
+
+	actual, err := repo.DeleteByID(context.TODO(), uuid.New().String())
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+	assert.Nil(actual, msg, "returned entity")
+}
+
+func testDeleteByIDWithUnmetConditionKeepEntity(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "delete with unmet condition didn't fail"
+	expected := NewDummy()
+	mustInsert(t, repo, expected)
+
+	actual, err := repo.DeleteByID(context.TODO(), expected.ID, domain.WithExpectedAttr("name", "other_name"))
+
+	assert.ErrorIs(err, domain.ErrConditionFailed, msg, "wrong error")
+	assert.Nil(actual, msg, "returned entity")
+	assert.Equal(expected, mustGet(t, repo, expected.ID), msg, "entity not kept")
+}
+
+func testDeleteByIDWithMetConditionReturnDeletedEntity(
+	t *testing.T, repo domain.DummyRepository, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "failed to delete entity with met condition"
+	expected := NewDummy()
+	mustInsert(t, repo, expected)
+
+	actual, err := repo.DeleteByID(context.TODO(), expected.ID, domain.WithExpectedAttr("name", expected.Name))
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(expected, actual, msg, "wrong deleted entity")
+	assert.Nil(mustGet(t, repo, expected.ID), msg, "entity left")
+}
+
+func testTransactionWithWritesCommitTogether(t *testing.T, repo domain.DummyRepository, transactor domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to write entities in one transaction"
+	existing := NewDummy()
+	mustInsert(t, repo, existing)
+	created := NewDummy()
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, created)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, existing.ID)
+		return err
+	})
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(created, mustGet(t, repo, created.ID), msg, "entity not inserted")
+	assert.Nil(mustGet(t, repo, existing.ID), msg, "entity not deleted")
+}
+
+func testTransactionWithFnErrorWriteNothing(t *testing.T, repo domain.DummyRepository, transactor domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed transaction wrote entities"
+	created := NewDummy()
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, created)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, uuid.New().String())
+		return err
+	})
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+	assert.Nil(mustGet(t, repo, created.ID), msg, "entity inserted")
+}
+
+func testTransactionWithUnmetConditionWriteNothing(
+	t *testing.T, repo domain.DummyRepository, transactor domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "canceled transaction wrote entities"
+	existing := NewDummy()
+	mustInsert(t, repo, existing)
+	created := NewDummy()
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, created)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, existing.ID, domain.WithExpectedAttr("name", "other_name"))
+		return err
+	})
+
+	assert.ErrorIs(err, domain.ErrTransactionCanceled, msg, "not canceled")
+	assert.ErrorIs(err, domain.ErrConditionFailed, msg, "wrong error")
+	assert.Nil(mustGet(t, repo, created.ID), msg, "entity inserted")
+	assert.Equal(existing, mustGet(t, repo, existing.ID), msg, "entity deleted")
+}
+
+func testInsertConcurrentlyKeepAllEntities(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to insert entities concurrently"
+	entities := make([]*domain.Dummy, concurrentWriters)
+	for i := range entities {
+		entities[i] = NewDummy()
+	}
+	errs := make([]error, concurrentWriters)
+	wg := sync.WaitGroup{}
+	for i := range entities {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = repo.Insert(context.TODO(), entities[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, entity := range entities {
+		assert.Nil(errs[i], msg, "found error")
+		assert.Equal(entity, mustGet(t, repo, entity.ID), msg, "wrong stored entity")
+	}
+}
+
+func newDummies(count int) []*domain.Dummy {
+	entities := make([]*domain.Dummy, count)
+	for i := range entities {
+		entities[i] = NewDummy()
+	}
+	return entities
+}
+
+func testBatchInsertWithEntitiesStoreAll(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to batch insert entities"
+	entities := newDummies(batchItems)
+
+	results, err := repo.BatchInsert(context.TODO(), entities)
+
+	assert.Nil(err, msg, "found error")
+	assert.Len(results, len(entities), msg, "wrong result count")
+	for i, entity := range entities {
+		assert.Equal(entity.ID, results[i].ID, msg, "wrong result order")
+		assert.Nil(results[i].Err, msg, "found item error")
+		assert.Equal(entity, mustGet(t, repo, entity.ID), msg, "wrong stored entity")
+	}
+}
+
+func testBatchInsertWithInvalidItemsReportPerItem(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "batch insert didn't report invalid items"
+	valid := NewDummy()
+	repeated := NewDummy()
+	repeated.ID = valid.ID
+	blank := NewDummy()
+	blank.ID = ""
+
+	results, err := repo.BatchInsert(context.TODO(), []*domain.Dummy{valid, nil, blank, repeated})
+
+	assert.Nil(err, msg, "found error")
+	assert.Len(results, 4, msg, "wrong result count")
+	assert.Nil(results[0].Err, msg, "valid item failed")
+	assert.ErrorIs(results[1].Err, domain.ErrBatchItemInvalid, msg, "nil item not reported")
+	assert.ErrorIs(results[2].Err, domain.ErrBatchItemInvalid, msg, "blank id not reported")
+	assert.ErrorIs(results[3].Err, domain.ErrBatchItemInvalid, msg, "repeated id not reported")
+	assert.Equal(valid, mustGet(t, repo, valid.ID), msg, "wrong stored entity")
+}
+
+func testBatchGetByIDsWithIDsReturnInOrder(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to batch get entities"
+	entities := newDummies(batchItems)
+	for _, entity := range entities {
+		mustInsert(t, repo, entity)
+	}
+	missingID := uuid.New().String()
+	ids := []string{missingID}
+	for i := len(entities) - 1; i >= 0; i-- {
+		ids = append(ids, entities[i].ID)
+	}
+	ids = append(ids, entities[0].ID)
+
+	results, err := repo.BatchGetByIDs(context.TODO(), ids)
+
+	assert.Nil(err, msg, "found error")
+	assert.Len(results, len(ids), msg, "wrong result count")
+	assert.Equal(missingID, results[0].ID, msg, "wrong missing id")
+	assert.Nil(results[0].Dummy, msg, "missing id returned entity")
+	assert.Nil(results[0].Err, msg, "missing id failed")
+	for i, id := range ids[1:] {
+		assert.Nil(results[i+1].Err, msg, "found item error")
+		assert.Equal(id, results[i+1].ID, msg, "wrong result order")
+		assert.NotNil(results[i+1].Dummy, msg, "entity not found")
+		assert.Equal(id, results[i+1].Dummy.ID, msg, "wrong entity")
+	}
+}
+
+func testBatchDeleteByIDsWithIDsDeleteAll(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to batch delete entities"
+	entities := newDummies(batchItems)
+	for _, entity := range entities {
+		mustInsert(t, repo, entity)
+	}
+	ids := []string{uuid.New().String()}
+	for _, entity := range entities {
+		ids = append(ids, entity.ID)
+	}
+
+	results, err := repo.BatchDeleteByIDs(context.TODO(), ids)
+
+	assert.Nil(err, msg, "found error")
+	assert.Len(results, len(ids), msg, "wrong result count")
+	assert.ErrorIs(results[0].Err, domain.ErrNotFound, msg, "missing id not reported")
+	for i, id := range ids {
+		assert.Equal(id, results[i].ID, msg, "wrong result order")
+		if i > 0 {
+			assert.Nil(results[i].Err, msg, "found item error")
+		}
+		assert.Nil(mustGet(t, repo, id), msg, "entity left")
+	}
+}
+
+func testBatchWritesWithUnmetConditionReportPerItem(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "batch writes didn't report unmet conditions"
+	met := NewDummy()
+	unmet := NewDummy()
+	unmet.Name = "other_name"
+	mustInsert(t, repo, met)
+	mustInsert(t, repo, unmet)
+	metReplacement := NewDummy()
+	metReplacement.ID = met.ID
+	unmetReplacement := NewDummy()
+	unmetReplacement.ID = unmet.ID
+	condition := domain.WithExpectedAttr("name", met.Name)
+
+	inserted, err := repo.BatchInsert(context.TODO(), []*domain.Dummy{metReplacement, unmetReplacement}, condition)
+	assert.Nil(err, msg, "found error")
+	assert.Nil(inserted[0].Err, msg, "item meeting condition failed")
+	assert.ErrorIs(inserted[1].Err, domain.ErrConditionFailed, msg, "wrong item error of insert")
+	assert.Nil(inserted[1].Dummy, msg, "failed item returned entity")
+	assert.Equal(unmet, mustGet(t, repo, unmet.ID), msg, "entity not kept by insert")
+
+	deleted, err := repo.BatchDeleteByIDs(context.TODO(), []string{met.ID, unmet.ID},
+		domain.WithExpectedAttr("name", metReplacement.Name))
+	assert.Nil(err, msg, "found error")
+	assert.Nil(deleted[0].Err, msg, "item meeting condition failed")
+	assert.ErrorIs(deleted[1].Err, domain.ErrConditionFailed, msg, "wrong item error of delete")
+	assert.Nil(mustGet(t, repo, met.ID), msg, "entity left")
+	assert.Equal(unmet, mustGet(t, repo, unmet.ID), msg, "entity not kept by delete")
+}
+
+func testBatchInsertInTransactionWithFnErrorWriteNothing(
+	t *testing.T, repo domain.DummyRepository, transactor domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "failed transaction wrote batch entities"
+	entities := newDummies(3)
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.BatchInsert(ctx, entities)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, uuid.New().String())
+		return err
+	})
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+	for _, entity := range entities {
+		assert.Nil(mustGet(t, repo, entity.ID), msg, "entity inserted")
+	}
+}
+
+func testWritesWithChangeRecordHistory(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to record history of writes"
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	created := NewDummy()
+	updated := NewReplacement(created)
+
+	_, err := repo.Insert(domain.WithChange(context.TODO(), "creator", at), created)
+	assert.Nil(err, msg, "found error")
+	_, err = repo.Insert(domain.WithChange(context.TODO(), "updater", at.Add(time.Minute)), updated)
+	assert.Nil(err, msg, "found error")
+	_, err = repo.DeleteByID(domain.WithChange(context.TODO(), "deleter", at.Add(time.Hour)), created.ID)
+	assert.Nil(err, msg, "found error")
+	records, err := repo.ListHistory(context.TODO(), created.ID)
+
+	assert.Nil(err, msg, "found error")
+	assert.Len(records, 3, msg, "wrong record count")
+	expected := []struct {
+		change   domain.DummyChange
+		by       string
+		at       time.Time
+		snapshot *domain.Dummy
+	}{
+		{domain.DummyChangeCreated, "creator", at, created},
+		{domain.DummyChangeUpdated, "updater", at.Add(time.Minute), updated},
+		{domain.DummyChangeDeleted, "deleter", at.Add(time.Hour), updated},
+	}
+	for i, record := range records {
+		assert.Equal(created.ID, record.ID, msg, "wrong id")
+		assert.Equal(int64(i+1), record.Version, msg, "wrong version")
+		assert.Equal(expected[i].change, record.Change, msg, "wrong change")
+		assert.Equal(expected[i].by, record.ChangedBy, msg, "wrong actor")
+		assert.Equal(expected[i].at, record.ChangedAt, msg, "wrong time")
+		assert.Equal(expected[i].snapshot, record.Snapshot, msg, "wrong snapshot")
+	}
+	version, err := repo.GetHistoryVersion(context.TODO(), created.ID, 2)
+	assert.Nil(err, msg, "found error")
+	assert.Equal(records[1], version, msg, "wrong record of version")
+	asOf, err := repo.GetHistoryAsOf(context.TODO(), created.ID, at.Add(30*time.Minute))
+	assert.Nil(err, msg, "found error")
+	assert.Equal(records[1], asOf, msg, "wrong record as of time")
+	before, err := repo.GetHistoryAsOf(context.TODO(), created.ID, at.Add(-time.Second))
+	assert.Nil(err, msg, "found error")
+	assert.Nil(before, msg, "record found before the first change")
+}
+
+func testBatchWritesWithChangeRecordHistory(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to record history of batch writes"
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	entities := newDummies(batchItems)
+	ids := make([]string, len(entities))
+	for i, entity := range entities {
+		ids[i] = entity.ID
+	}
+
+	_, err := repo.BatchInsert(domain.WithChange(context.TODO(), "creator", at), entities)
+	assert.Nil(err, msg, "found error")
+	_, err = repo.BatchDeleteByIDs(domain.WithChange(context.TODO(), "deleter", at.Add(time.Hour)), ids)
+	assert.Nil(err, msg, "found error")
+
+	for i, id := range ids {
+		records, err := repo.ListHistory(context.TODO(), id)
+		assert.Nil(err, msg, "found error")
+		assert.Len(records, 2, msg, "wrong record count")
+		assert.Equal(domain.DummyChangeCreated, records[0].Change, msg, "wrong first change")
+		assert.Equal("creator", records[0].ChangedBy, msg, "wrong first actor")
+		assert.Equal(entities[i], records[0].Snapshot, msg, "wrong first snapshot")
+		assert.Equal(domain.DummyChangeDeleted, records[1].Change, msg, "wrong second change")
+		assert.Equal(int64(2), records[1].Version, msg, "wrong second version")
+	}
+}
+
+func testTransactionWithFnErrorRecordNoHistory(
+	t *testing.T, repo domain.DummyRepository, transactor domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "failed transaction recorded history"
+	created := NewDummy()
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, created)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, uuid.New().String())
+		return err
+	})
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+	records, err := repo.ListHistory(context.TODO(), created.ID)
+	assert.Nil(err, msg, "found error")
+	assert.Empty(records, msg, "history recorded")
+}
+
+func testInsertWithDeletedIDContinueHistory(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to insert an entity deleted before"
+	deleted := NewDummy()
+	created := NewDummy()
+	created.ID = deleted.ID
+	mustInsert(t, repo, deleted)
+	_, err := repo.DeleteByID(context.TODO(), deleted.ID)
+	assert.Nil(err, msg, "found error of delete")
+
+	_, err = repo.Insert(context.TODO(), created)
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(created, mustGet(t, repo, created.ID), msg, "wrong entity")
+	records, err := repo.ListHistory(context.TODO(), created.ID)
+	assert.Nil(err, msg, "found error")
+	assert.Len(records, 3, msg, "wrong record count")
+	assert.Equal(int64(3), records[2].Version, msg, "wrong version")
+	assert.Equal(domain.DummyChangeCreated, records[2].Change, msg, "wrong change")
+}
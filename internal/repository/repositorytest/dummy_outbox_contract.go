@@ -0,0 +1,225 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// outboxListLimit large enough to list every event written by a case.
+const outboxListLimit int = 100
+
+// DummyOutboxFactory
+// build the repository under test, the outbox its events are written to, and the transactor its writes join.
+type DummyOutboxFactory func(t *testing.T) (domain.DummyRepository, domain.DummyOutbox, domain.Transactor)
+
+// RunDummyOutboxContract
+// every implementation of domain.DummyRepository writing events to a domain.DummyOutbox must pass these cases.
+// the outbox may be shared by the cases, every case empties it first.
+//
+//	@param t
+//	@param factory
+func RunDummyOutboxContract(t *testing.T, factory DummyOutboxFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, repo domain.DummyRepository, outbox domain.DummyOutbox, transactor domain.Transactor)
+	}{
+		{"WritesWithEventsAppendOutbox", testWritesWithEventsAppendOutbox},
+		{"WritesWithoutEventsAppendNothing", testWritesWithoutEventsAppendNothing},
+		{"BatchWritesWithEventsAppendPerItem", testBatchWritesWithEventsAppendPerItem},
+		{"TransactionWithFnErrorAppendNothing", testTransactionWithFnErrorAppendNothing},
+		{"DeleteOutboxWithEventRemoveIt", testDeleteOutboxWithEventRemoveIt},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, outbox, transactor := factory(t)
+			drainOutbox(t, outbox)
+			testCase.fn(t, repo, outbox, transactor)
+		})
+	}
+}
+
+// drainOutbox
+//
+//	@param t
+//	@param outbox
+func drainOutbox(t *testing.T, outbox domain.DummyOutbox) {
+	t.Helper()
+	for {
+		events, err := outbox.ListOutbox(context.TODO(), outboxListLimit)
+		if err != nil {
+			t.Fatalf("error happened when listing outbox, %v", err)
+		}
+		if len(events) == 0 {
+			return
+		}
+		for _, event := range events {
+			err = outbox.DeleteOutbox(context.TODO(), event)
+			if err != nil {
+				t.Fatalf("error happened when deleting outbox, %v", err)
+			}
+		}
+	}
+}
+
+// mustListOutbox
+//
+//	@param t
+//	@param outbox
+//	@return []*domain.DummyEvent
+func mustListOutbox(t *testing.T, outbox domain.DummyOutbox) []*domain.DummyEvent {
+	t.Helper()
+	events, err := outbox.ListOutbox(context.TODO(), outboxListLimit)
+	if err != nil {
+		t.Fatalf("error happened when listing outbox, %v", err)
+	}
+	return events
+}
+
+// withEvent
+//
+//	@param id
+//	@param at
+//	@return context.Context
+//	@return *domain.DummyEvent
+func withEvent(id string, at time.Time) (context.Context, *domain.DummyEvent) {
+	event := domain.NewDummyEvent(id, "tester", at)
+	ctx := domain.WithChange(context.TODO(), "tester", at)
+	return domain.WithDummyEvents(ctx, event), event
+}
+
+func testWritesWithEventsAppendOutbox(
+	t *testing.T, repo domain.DummyRepository, outbox domain.DummyOutbox, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "failed to append events of writes"
+	at := time.Now().UTC()
+	created := NewDummy()
+	updated := NewReplacement(created)
+
+	ctx, createdEvent := withEvent(created.ID, at)
+	_, err := repo.Insert(ctx, created)
+	assert.Nil(err, msg, "found error")
+	ctx, updatedEvent := withEvent(created.ID, at.Add(time.Minute))
+	_, err = repo.Insert(ctx, updated)
+	assert.Nil(err, msg, "found error")
+	ctx, deletedEvent := withEvent(created.ID, at.Add(time.Hour))
+	_, err = repo.DeleteByID(ctx, created.ID)
+	assert.Nil(err, msg, "found error")
+	events := mustListOutbox(t, outbox)
+
+	assert.Len(events, 3, msg, "wrong event count")
+	expected := []struct {
+		event     *domain.DummyEvent
+		eventType domain.DummyEventType
+		data      *domain.Dummy
+	}{
+		{createdEvent, domain.DummyCreated, created},
+		{updatedEvent, domain.DummyUpdated, updated},
+		{deletedEvent, domain.DummyDeleted, updated},
+	}
+	for i, event := range events {
+		assert.Equal(expected[i].event.ID, event.ID, msg, "wrong event id")
+		assert.Equal(expected[i].eventType, event.Type, msg, "wrong event type")
+		assert.Equal(domain.DummyEventSource, event.Source, msg, "wrong source")
+		assert.Equal(created.ID, event.AggregateID, msg, "wrong aggregate id")
+		assert.Equal(int64(i+1), event.AggregateVersion, msg, "wrong aggregate version")
+		assert.Equal(expected[i].event.OccurredAt, event.OccurredAt, msg, "wrong time")
+		assert.Equal("tester", event.Actor, msg, "wrong actor")
+		assert.Equal(expected[i].data, event.Data, msg, "wrong data")
+	}
+}
+
+func testWritesWithoutEventsAppendNothing(
+	t *testing.T, repo domain.DummyRepository, outbox domain.DummyOutbox, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "appended events not recorded"
+	dummy := NewDummy()
+	ctx, _ := withEvent(uuid.New().String(), time.Now().UTC())
+
+	_, err := repo.Insert(ctx, dummy)
+	assert.Nil(err, msg, "found error")
+	_, err = repo.DeleteByID(context.TODO(), dummy.ID)
+	assert.Nil(err, msg, "found error")
+
+	assert.Empty(mustListOutbox(t, outbox), msg, "outbox not empty")
+}
+
+func testBatchWritesWithEventsAppendPerItem(
+	t *testing.T, repo domain.DummyRepository, outbox domain.DummyOutbox, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "failed to append events of batch writes"
+	at := time.Now().UTC()
+	dummies := newDummies(2)
+	ctx := domain.WithDummyEvents(context.TODO(),
+		domain.NewDummyEvent(dummies[0].ID, "tester", at),
+		domain.NewDummyEvent(dummies[1].ID, "tester", at.Add(time.Second)),
+	)
+
+	results, err := repo.BatchInsert(ctx, dummies)
+	assert.Nil(err, msg, "found error")
+	for _, result := range results {
+		assert.Nil(result.Err, msg, "found item error")
+	}
+	events := mustListOutbox(t, outbox)
+
+	assert.Len(events, 2, msg, "wrong event count")
+	ids := []string{events[0].AggregateID, events[1].AggregateID}
+	assert.ElementsMatch([]string{dummies[0].ID, dummies[1].ID}, ids, msg, "wrong aggregate ids")
+}
+
+func testTransactionWithFnErrorAppendNothing(
+	t *testing.T, repo domain.DummyRepository, outbox domain.DummyOutbox, transactor domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "failed transaction appended events"
+	dummy := NewDummy()
+	ctx, _ := withEvent(dummy.ID, time.Now().UTC())
+
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := repo.Insert(ctx, dummy)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, uuid.New().String())
+		return err
+	})
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+	assert.Empty(mustListOutbox(t, outbox), msg, "outbox not empty")
+}
+
+func testDeleteOutboxWithEventRemoveIt(
+	t *testing.T, repo domain.DummyRepository, outbox domain.DummyOutbox, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "failed to delete event from outbox"
+	at := time.Now().UTC()
+	first := NewDummy()
+	second := NewDummy()
+	ctx, _ := withEvent(first.ID, at)
+	_, err := repo.Insert(ctx, first)
+	assert.Nil(err, msg, "found error")
+	ctx, _ = withEvent(second.ID, at.Add(time.Second))
+	_, err = repo.Insert(ctx, second)
+	assert.Nil(err, msg, "found error")
+	events := mustListOutbox(t, outbox)
+	assert.Len(events, 2, msg, "wrong event count")
+
+	err = outbox.DeleteOutbox(context.TODO(), events[0])
+	assert.Nil(err, msg, "found error")
+	err = outbox.DeleteOutbox(context.TODO(), events[0])
+	assert.Nil(err, msg, "found error of deleting twice")
+
+	left := mustListOutbox(t, outbox)
+	assert.Len(left, 1, msg, "wrong event count after delete")
+	assert.Equal(events[1], left[0], msg, "wrong event left")
+}
@@ -0,0 +1,200 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// softDeleteRetention retention of the repositories built for the soft delete cases.
+const softDeleteRetention time.Duration = time.Hour
+
+// DummySoftDeleteFactory
+// build the repository under test keeping deleted entities for the retention, and the transactor its writes join.
+type DummySoftDeleteFactory func(t *testing.T, retention time.Duration) (domain.DummyRepository, domain.Transactor)
+
+// RunDummySoftDeleteContract
+// every implementation of domain.DummyRepository supporting soft delete must pass these cases.
+//
+//	@param t
+//	@param factory
+func RunDummySoftDeleteContract(t *testing.T, factory DummySoftDeleteFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, repo domain.DummyRepository, transactor domain.Transactor)
+	}{
+		{"DeleteByIDWithSoftDeleteHideEntity", testDeleteByIDWithSoftDeleteHideEntity},
+		{"RestoreByIDWithDeletedIDReturnEntity", testRestoreByIDWithDeletedIDReturnEntity},
+		{"RestoreByIDWithExpiredRetentionReturnNotFound", testRestoreByIDWithExpiredRetentionReturnNotFound},
+		{"RestoreByIDWithLiveIDReturnNotFound", testRestoreByIDWithLiveIDReturnNotFound},
+		{"RestoreByIDWithUnmetConditionKeepDeleted", testRestoreByIDWithUnmetConditionKeepDeleted},
+		{"InsertWithDeletedIDCreateEntity", testInsertWithDeletedIDCreateEntity},
+		{"InsertWithDeletedIDOfUnmetConditionKeepDeleted", testInsertWithDeletedIDOfUnmetConditionKeepDeleted},
+		{"TransactionWithFnErrorKeepDeleted", testTransactionWithFnErrorKeepDeleted},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, transactor := factory(t, softDeleteRetention)
+			testCase.fn(t, repo, transactor)
+		})
+	}
+}
+
+// mustSoftDelete
+//
+//	@param t
+//	@param repo
+//	@param at time of the delete
+//	@return *domain.Dummy the deleted entity
+func mustSoftDelete(t *testing.T, repo domain.DummyRepository, at time.Time) *domain.Dummy {
+	t.Helper()
+	dummy := NewDummy()
+	mustInsert(t, repo, dummy)
+	_, err := repo.DeleteByID(domain.WithChange(context.TODO(), "deleter", at), dummy.ID)
+	if err != nil {
+		t.Fatalf("error happened when deleting data, %v", err)
+	}
+	return dummy
+}
+
+func testDeleteByIDWithSoftDeleteHideEntity(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "soft deleted entity is visible"
+	dummy := mustSoftDelete(t, repo, time.Now().UTC())
+
+	results, err := repo.BatchGetByIDs(context.TODO(), []string{dummy.ID})
+	assert.Nil(err, msg, "found error")
+	assert.Nil(results[0].Dummy, msg, "entity of batch get")
+	assert.Nil(mustGet(t, repo, dummy.ID), msg, "entity of get")
+	_, err = repo.DeleteByID(context.TODO(), dummy.ID)
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "deleted twice")
+}
+
+func testRestoreByIDWithDeletedIDReturnEntity(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to restore soft deleted entity"
+	at := time.Now().UTC()
+	dummy := mustSoftDelete(t, repo, at)
+
+	restored, err := repo.RestoreByID(domain.WithChange(context.TODO(), "restorer", at.Add(time.Minute)), dummy.ID)
+
+	assert.Nil(err, msg, "found error")
+	assert.Equal(dummy, restored, msg, "wrong restored entity")
+	assert.Equal(dummy, mustGet(t, repo, dummy.ID), msg, "wrong entity after restore")
+	records, err := repo.ListHistory(context.TODO(), dummy.ID)
+	assert.Nil(err, msg, "found error")
+	assert.Len(records, 3, msg, "wrong record count")
+	assert.Equal(domain.DummyChangeRestored, records[2].Change, msg, "wrong change")
+	assert.Equal("restorer", records[2].ChangedBy, msg, "wrong actor")
+	assert.Equal(int64(3), records[2].Version, msg, "wrong version")
+	assert.Equal(dummy, records[2].Snapshot, msg, "wrong snapshot")
+}
+
+func testRestoreByIDWithExpiredRetentionReturnNotFound(
+	t *testing.T, repo domain.DummyRepository, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "restored entity past its retention"
+	at := time.Now().UTC()
+	dummy := mustSoftDelete(t, repo, at)
+
+	restored, err := repo.RestoreByID(domain.WithChange(context.TODO(), "restorer", at.Add(softDeleteRetention)), dummy.ID)
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+	assert.Nil(restored, msg, "restored entity")
+	assert.Nil(mustGet(t, repo, dummy.ID), msg, "entity visible")
+}
+
+func testRestoreByIDWithLiveIDReturnNotFound(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "restored entity not deleted"
+	dummy := NewDummy()
+	mustInsert(t, repo, dummy)
+
+	_, err := repo.RestoreByID(context.TODO(), dummy.ID)
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error of live entity")
+	_, err = repo.RestoreByID(context.TODO(), uuid.New().String())
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error of missing entity")
+}
+
+func testRestoreByIDWithUnmetConditionKeepDeleted(
+	t *testing.T, repo domain.DummyRepository, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "restored entity not meeting the condition"
+	dummy := mustSoftDelete(t, repo, time.Now().UTC())
+
+	_, err := repo.RestoreByID(context.TODO(), dummy.ID, domain.WithExpectedAttr("createdBy", "other_user"))
+
+	assert.ErrorIs(err, domain.ErrConditionFailed, msg, "wrong error")
+	assert.Nil(mustGet(t, repo, dummy.ID), msg, "entity visible")
+	restored, err := repo.RestoreByID(context.TODO(), dummy.ID, domain.WithExpectedAttr("createdBy", dummy.CreatedBy))
+	assert.Nil(err, msg, "found error of met condition")
+	assert.Equal(dummy, restored, msg, "wrong restored entity")
+}
+
+func testInsertWithDeletedIDCreateEntity(t *testing.T, repo domain.DummyRepository, _ domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed to insert over soft deleted entity"
+	deleted := mustSoftDelete(t, repo, time.Now().UTC())
+	created := NewDummy()
+	created.ID = deleted.ID
+
+	mustInsert(t, repo, created)
+
+	assert.Equal(created, mustGet(t, repo, created.ID), msg, "wrong entity")
+	records, err := repo.ListHistory(context.TODO(), created.ID)
+	assert.Nil(err, msg, "found error")
+	assert.Len(records, 3, msg, "wrong record count")
+	assert.Equal(domain.DummyChangeCreated, records[2].Change, msg, "wrong change")
+	_, err = repo.RestoreByID(context.TODO(), created.ID)
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "restored replaced entity")
+}
+
+func testInsertWithDeletedIDOfUnmetConditionKeepDeleted(
+	t *testing.T, repo domain.DummyRepository, _ domain.Transactor,
+) {
+	assert := require.New(t)
+	msg := "inserted over soft deleted entity not meeting the condition"
+	deleted := mustSoftDelete(t, repo, time.Now().UTC())
+	created := NewDummy()
+	created.ID = deleted.ID
+	created.CreatedBy = "other_user"
+
+	actual, err := repo.Insert(context.TODO(), created, domain.WithExpectedAttr("createdBy", "other_user"))
+	results, batchErr := repo.BatchInsert(context.TODO(), []*domain.Dummy{created},
+		domain.WithExpectedAttr("createdBy", "other_user"))
+
+	assert.ErrorIs(err, domain.ErrConditionFailed, msg, "wrong error")
+	assert.Nil(actual, msg, "returned entity")
+	assert.Nil(batchErr, msg, "found batch error")
+	assert.ErrorIs(results[0].Err, domain.ErrConditionFailed, msg, "wrong error of batch insert")
+	assert.Nil(mustGet(t, repo, deleted.ID), msg, "entity visible")
+	restored, err := repo.RestoreByID(context.TODO(), deleted.ID, domain.WithExpectedAttr("createdBy", deleted.CreatedBy))
+	assert.Nil(err, msg, "found restore error")
+	assert.Equal(deleted, restored, msg, "wrong restored entity")
+}
+
+func testTransactionWithFnErrorKeepDeleted(t *testing.T, repo domain.DummyRepository, transactor domain.Transactor) {
+	assert := require.New(t)
+	msg := "failed transaction restored entity"
+	dummy := mustSoftDelete(t, repo, time.Now().UTC())
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		_, err := repo.RestoreByID(ctx, dummy.ID)
+		if err != nil {
+			return err
+		}
+		_, err = repo.DeleteByID(ctx, uuid.New().String())
+		return err
+	})
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+	assert.Nil(mustGet(t, repo, dummy.ID), msg, "entity visible")
+}
@@ -0,0 +1,184 @@
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// JobRepositoryFactory
+// build the repository under test.
+type JobRepositoryFactory func(t *testing.T) domain.JobRepository
+
+// RunJobRepositoryContract
+// every implementation of domain.JobRepository must pass these cases.
+//
+//	@param t
+//	@param factory
+func RunJobRepositoryContract(t *testing.T, factory JobRepositoryFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, repo domain.JobRepository)
+	}{
+		{"CreateJobWithNewIDThenGetIt", testCreateJobWithNewIDThenGetIt},
+		{"CreateJobWithExistingIDReturnConditionFailed", testCreateJobWithExistingIDReturnConditionFailed},
+		{"GetJobWithMissingIDReturnNotFound", testGetJobWithMissingIDReturnNotFound},
+		{"UpdateJobWithJobReplaceIt", testUpdateJobWithJobReplaceIt},
+		{"UpdateJobWithMissingIDReturnNotFound", testUpdateJobWithMissingIDReturnNotFound},
+		{"ClaimJobWithPendingJobReplaceItOnce", testClaimJobWithPendingJobReplaceItOnce},
+		{"ClaimJobWithExpiredClaimReplaceIt", testClaimJobWithExpiredClaimReplaceIt},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.fn(t, factory(t))
+		})
+	}
+}
+
+func newTestJob() *domain.Job {
+	at := time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC)
+	return &domain.Job{
+		ID:        uuid.New().String(),
+		Type:      "test",
+		State:     domain.JobPending,
+		Owner:     "user_1",
+		Caller:    &domain.JobCaller{UserID: "user_1", PermissionBit: 2},
+		Input:     json.RawMessage(`{"ids":["a","b"]}`),
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+}
+
+func testCreateJobWithNewIDThenGetIt(t *testing.T, repo domain.JobRepository) {
+	assert := require.New(t)
+	msg := "failed to create job"
+	job := newTestJob()
+
+	err1 := repo.CreateJob(context.TODO(), job)
+	actual, err2 := repo.GetJob(context.TODO(), job.ID)
+
+	assert.Nil(err1, msg, "found create error")
+	assert.Nil(err2, msg, "found get error")
+	assert.Equal(job, actual, msg, "wrong job")
+}
+
+func testCreateJobWithExistingIDReturnConditionFailed(t *testing.T, repo domain.JobRepository) {
+	assert := require.New(t)
+	msg := "create job with existing id didn't fail"
+	job := newTestJob()
+	err := repo.CreateJob(context.TODO(), job)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	err = repo.CreateJob(context.TODO(), job)
+
+	assert.ErrorIs(err, domain.ErrConditionFailed, msg, "wrong error")
+}
+
+func testGetJobWithMissingIDReturnNotFound(t *testing.T, repo domain.JobRepository) {
+	assert := require.New(t)
+	msg := "get job with missing id didn't fail"
+
+	actual, err := repo.GetJob(context.TODO(), uuid.New().String())
+
+	assert.Nil(actual, msg, "returned job")
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+}
+
+func testUpdateJobWithJobReplaceIt(t *testing.T, repo domain.JobRepository) {
+	assert := require.New(t)
+	msg := "failed to update job"
+	job := newTestJob()
+	err := repo.CreateJob(context.TODO(), job)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+	job.State = domain.JobSucceeded
+	job.Progress = domain.JobProgress{Done: 2, Total: 2}
+	job.Result = json.RawMessage(`{"count":2}`)
+	job.UpdatedAt = job.UpdatedAt.Add(time.Minute)
+
+	err1 := repo.UpdateJob(context.TODO(), job)
+	actual, err2 := repo.GetJob(context.TODO(), job.ID)
+
+	assert.Nil(err1, msg, "found update error")
+	assert.Nil(err2, msg, "found get error")
+	assert.Equal(job, actual, msg, "wrong job")
+}
+
+func testUpdateJobWithMissingIDReturnNotFound(t *testing.T, repo domain.JobRepository) {
+	assert := require.New(t)
+	msg := "update job with missing id didn't fail"
+
+	err := repo.UpdateJob(context.TODO(), newTestJob())
+
+	assert.ErrorIs(err, domain.ErrNotFound, msg, "wrong error")
+}
+
+func testClaimJobWithPendingJobReplaceItOnce(t *testing.T, repo domain.JobRepository) {
+	assert := require.New(t)
+	msg := "failed to claim job once"
+	job := newTestJob()
+	err := repo.CreateJob(context.TODO(), job)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+	now := job.UpdatedAt.Add(time.Minute)
+	job.State = domain.JobRunning
+	job.ClaimedUntil = now.Add(10 * time.Minute)
+	job.UpdatedAt = now
+
+	err1 := repo.ClaimJob(context.TODO(), job, now)
+	err2 := repo.ClaimJob(context.TODO(), job, now)
+	err3 := repo.ClaimJob(context.TODO(), newTestJob(), now)
+	actual, err4 := repo.GetJob(context.TODO(), job.ID)
+
+	assert.Nil(err1, msg, "found claim error")
+	assert.ErrorIs(err2, domain.ErrConditionFailed, msg, "claimed running job")
+	assert.ErrorIs(err3, domain.ErrConditionFailed, msg, "claimed missing job")
+	assert.Nil(err4, msg, "found get error")
+	assert.Equal(job, actual, msg, "wrong job")
+}
+
+func testClaimJobWithExpiredClaimReplaceIt(t *testing.T, repo domain.JobRepository) {
+	assert := require.New(t)
+	msg := "failed to claim job left by a worker"
+	job := newTestJob()
+	job.State = domain.JobRunning
+	job.ClaimedUntil = job.UpdatedAt.Add(10 * time.Minute)
+	err := repo.CreateJob(context.TODO(), job)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+	now := job.ClaimedUntil.Add(time.Millisecond)
+	claimed := newTestJob()
+	claimed.ID = job.ID
+	claimed.State = domain.JobRunning
+	claimed.ClaimedUntil = now.Add(10 * time.Minute)
+	claimed.UpdatedAt = now
+	finished := newTestJob()
+	finished.State = domain.JobSucceeded
+	err = repo.CreateJob(context.TODO(), finished)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	err1 := repo.ClaimJob(context.TODO(), claimed, job.ClaimedUntil)
+	err2 := repo.ClaimJob(context.TODO(), claimed, now)
+	err3 := repo.ClaimJob(context.TODO(), finished, now.Add(time.Hour))
+	actual, err4 := repo.GetJob(context.TODO(), job.ID)
+
+	assert.ErrorIs(err1, domain.ErrConditionFailed, msg, "claimed job before its claim expired")
+	assert.Nil(err2, msg, "found claim error")
+	assert.ErrorIs(err3, domain.ErrConditionFailed, msg, "claimed finished job")
+	assert.Nil(err4, msg, "found get error")
+	assert.Equal(claimed, actual, msg, "wrong job")
+}
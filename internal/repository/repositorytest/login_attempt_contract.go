@@ -0,0 +1,153 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+// LoginAttemptStoreFactory
+// build the store under test, it must read the time from now.
+type LoginAttemptStoreFactory func(t *testing.T, now func() time.Time) authentication.LoginAttemptStore
+
+// RunLoginAttemptStoreContract
+// every implementation of authentication.LoginAttemptStore must pass these cases.
+//
+//	@param t
+//	@param factory
+func RunLoginAttemptStoreContract(t *testing.T, factory LoginAttemptStoreFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, store authentication.LoginAttemptStore, clock *testClock)
+	}{
+		{"RecordFailureWithFailuresThenGetCount", testRecordFailureWithFailuresThenGetCount},
+		{"RecordFailureWithConcurrentCallsCountAll", testRecordFailureWithConcurrentCallsCountAll},
+		{"RecordFailureWithExpiredFailuresRestartCount", testRecordFailureWithExpiredFailuresRestartCount},
+		{"GetWithNoFailureReturnNil", testGetWithNoFailureReturnNil},
+		{"ResetWithFailuresThenGetNil", testResetWithFailuresThenGetNil},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+			testCase.fn(t, factory(t, clock.Now), clock)
+		})
+	}
+}
+
+func testRecordFailureWithFailuresThenGetCount(
+	t *testing.T, store authentication.LoginAttemptStore, clock *testClock,
+) {
+	assert := require.New(t)
+	msg := "failed to record login failures"
+	key := "user#" + t.Name()
+	expiresAt := clock.Now().Add(time.Hour)
+	firstAt := clock.Now()
+
+	first, err1 := store.RecordFailure(context.TODO(), key, expiresAt)
+	clock.Add(time.Minute)
+	second, err2 := store.RecordFailure(context.TODO(), key, expiresAt.Add(time.Minute))
+	found, err3 := store.Get(context.TODO(), key)
+
+	assert.Nil(err1, msg, "found first record error")
+	assert.Nil(err2, msg, "found second record error")
+	assert.Nil(err3, msg, "found get error")
+	assert.Equal(1, first.Failures, msg, "failures of first record")
+	assert.Equal(2, second.Failures, msg, "failures of second record")
+	assert.True(first.PreviousFailedAt.IsZero(), msg, "previousFailedAt of first record")
+	assert.True(firstAt.Equal(second.PreviousFailedAt), msg, "previousFailedAt of second record")
+	assert.NotNil(found, msg, "attempts are not found")
+	assert.Equal(key, found.Key, msg, "key")
+	assert.Equal(2, found.Failures, msg, "failures")
+	assert.True(clock.Now().Equal(found.LastFailedAt), msg, "lastFailedAt")
+	assert.True(expiresAt.Add(time.Minute).Equal(found.ExpiresAt), msg, "expiresAt")
+}
+
+func testRecordFailureWithConcurrentCallsCountAll(
+	t *testing.T, store authentication.LoginAttemptStore, clock *testClock,
+) {
+	assert := require.New(t)
+	msg := "failed to record login failures concurrently"
+	key := "ip#" + t.Name()
+	expiresAt := clock.Now().Add(time.Hour)
+	count := 20
+	errs := make(chan error, count)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.RecordFailure(context.TODO(), key, expiresAt)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.Nil(err, msg, "found record error")
+	}
+	found, err := store.Get(context.TODO(), key)
+	assert.Nil(err, msg, "found get error")
+	assert.NotNil(found, msg, "attempts are not found")
+	assert.Equal(count, found.Failures, msg, "failures of %d records", count)
+}
+
+func testRecordFailureWithExpiredFailuresRestartCount(
+	t *testing.T, store authentication.LoginAttemptStore, clock *testClock,
+) {
+	assert := require.New(t)
+	msg := "expired login failures are counted"
+	key := "user#" + t.Name()
+	for i := 0; i < 3; i++ {
+		_, err := store.RecordFailure(context.TODO(), key, clock.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+		}
+	}
+
+	clock.Add(time.Minute)
+	expired, err1 := store.Get(context.TODO(), key)
+	attempts, err2 := store.RecordFailure(context.TODO(), key, clock.Now().Add(time.Minute))
+
+	assert.Nil(err1, msg, "found get error")
+	assert.Nil(expired, msg, "found expired attempts")
+	assert.Nil(err2, msg, "found record error")
+	assert.Equal(1, attempts.Failures, msg, "failures")
+	assert.True(attempts.PreviousFailedAt.IsZero(), msg, "previousFailedAt of expired failures")
+	assert.True(clock.Now().Add(time.Minute).Equal(attempts.ExpiresAt), msg, "expiresAt")
+}
+
+func testGetWithNoFailureReturnNil(t *testing.T, store authentication.LoginAttemptStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "attempts of unknown key are found"
+
+	found, err := store.Get(context.TODO(), "user#"+t.Name())
+
+	assert.Nil(err, msg, "found get error")
+	assert.Nil(found, msg, "found attempts")
+}
+
+func testResetWithFailuresThenGetNil(t *testing.T, store authentication.LoginAttemptStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "reset attempts are found"
+	key := "user#" + t.Name()
+	_, err := store.RecordFailure(context.TODO(), key, clock.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	err1 := store.Reset(context.TODO(), key)
+	found, err2 := store.Get(context.TODO(), key)
+	err3 := store.Reset(context.TODO(), key)
+
+	assert.Nil(err1, msg, "found reset error")
+	assert.Nil(err2, msg, "found get error")
+	assert.Nil(found, msg, "found attempts")
+	assert.Nil(err3, msg, "found error of resetting it again")
+}
@@ -0,0 +1,185 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+// RefreshTokenStoreFactory
+// build the store under test, it must read the time from now.
+type RefreshTokenStoreFactory func(t *testing.T, now func() time.Time) authentication.RefreshTokenStore
+
+// RunRefreshTokenStoreContract
+// every implementation of authentication.RefreshTokenStore must pass these cases.
+//
+//	@param t
+//	@param factory
+func RunRefreshTokenStoreContract(t *testing.T, factory RefreshTokenStoreFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, store authentication.RefreshTokenStore, clock *testClock)
+	}{
+		{"SaveWithTokenThenGetIt", testSaveWithTokenThenGetIt},
+		{"GetWithUnknownHashReturnNil", testGetWithUnknownHashReturnNil},
+		{"GetWithExpiredTokenReturnNil", testGetWithExpiredTokenReturnNil},
+		{"MarkUsedWithUsedTokenReturnReused", testMarkUsedWithUsedTokenReturnReused},
+		{"MarkUsedWithUnknownHashReturnInvalid", testMarkUsedWithUnknownHashReturnInvalid},
+		{"MarkUsedWithConcurrentCallsSucceedOnce", testMarkUsedWithConcurrentCallsSucceedOnce},
+		{"RevokeFamilyThenItIsRevokedUntilExpireAt", testRevokeFamilyThenItIsRevokedUntilExpireAt},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+			testCase.fn(t, factory(t, clock.Now), clock)
+		})
+	}
+}
+
+// newTestRefreshToken
+// hashes and families are unique per case, the stores may be shared by cases.
+//
+//	@param t
+//	@param clock
+//	@return *authentication.RefreshToken
+func newTestRefreshToken(t *testing.T, clock *testClock) *authentication.RefreshToken {
+	return &authentication.RefreshToken{
+		Hash:      authentication.RefreshTokenHash(t.Name()),
+		FamilyID:  "family#" + t.Name(),
+		UserID:    "user_1",
+		IssuedAt:  clock.Now(),
+		ExpiresAt: clock.Now().Add(time.Hour),
+	}
+}
+
+func testSaveWithTokenThenGetIt(t *testing.T, store authentication.RefreshTokenStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "failed to save refresh token"
+	token := newTestRefreshToken(t, clock)
+
+	err1 := store.Save(context.TODO(), token)
+	found, err2 := store.Get(context.TODO(), token.Hash)
+
+	assert.Nil(err1, msg, "found save error")
+	assert.Nil(err2, msg, "found get error")
+	assert.NotNil(found, msg, "token is not found")
+	assert.Equal(token.FamilyID, found.FamilyID, msg, "family")
+	assert.Equal(token.UserID, found.UserID, msg, "user id")
+	assert.True(token.IssuedAt.Equal(found.IssuedAt), msg, "issuedAt")
+	assert.True(token.ExpiresAt.Equal(found.ExpiresAt), msg, "expiresAt")
+	assert.Nil(found.UsedAt, msg, "new token is used")
+}
+
+func testGetWithUnknownHashReturnNil(t *testing.T, store authentication.RefreshTokenStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "unknown refresh token is found"
+
+	found, err := store.Get(context.TODO(), authentication.RefreshTokenHash(t.Name()))
+
+	assert.Nil(err, msg, "found get error")
+	assert.Nil(found, msg, "found token")
+}
+
+func testGetWithExpiredTokenReturnNil(t *testing.T, store authentication.RefreshTokenStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "expired refresh token is found"
+	token := newTestRefreshToken(t, clock)
+	err := store.Save(context.TODO(), token)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	clock.Add(time.Hour + time.Second)
+	found, err := store.Get(context.TODO(), token.Hash)
+
+	assert.Nil(err, msg, "found get error")
+	assert.Nil(found, msg, "found token")
+}
+
+func testMarkUsedWithUsedTokenReturnReused(t *testing.T, store authentication.RefreshTokenStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "used refresh token is used again"
+	token := newTestRefreshToken(t, clock)
+	err := store.Save(context.TODO(), token)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	err1 := store.MarkUsed(context.TODO(), token.Hash, clock.Now())
+	found, err2 := store.Get(context.TODO(), token.Hash)
+	err3 := store.MarkUsed(context.TODO(), token.Hash, clock.Now())
+
+	assert.Nil(err1, msg, "found first mark used error")
+	assert.Nil(err2, msg, "found get error")
+	assert.NotNil(found.UsedAt, msg, "usedAt is not saved")
+	assert.True(clock.Now().Equal(*found.UsedAt), msg, "usedAt")
+	assert.True(errors.Is(err3, authentication.ErrReusedRefreshToken), msg, "error type")
+}
+
+func testMarkUsedWithUnknownHashReturnInvalid(t *testing.T, store authentication.RefreshTokenStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "unknown refresh token is used"
+
+	err := store.MarkUsed(context.TODO(), authentication.RefreshTokenHash(t.Name()), clock.Now())
+
+	assert.True(errors.Is(err, authentication.ErrInvalidRefreshToken), msg, "error type")
+}
+
+func testMarkUsedWithConcurrentCallsSucceedOnce(t *testing.T, store authentication.RefreshTokenStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "refresh token is used by concurrent calls"
+	token := newTestRefreshToken(t, clock)
+	err := store.Save(context.TODO(), token)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+	count := 20
+	errs := make(chan error, count)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.MarkUsed(context.TODO(), token.Hash, clock.Now())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.True(errors.Is(err, authentication.ErrReusedRefreshToken), msg, "error type: %v", err)
+	}
+	assert.Equal(1, succeeded, msg, "succeeded calls")
+}
+
+func testRevokeFamilyThenItIsRevokedUntilExpireAt(t *testing.T, store authentication.RefreshTokenStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "failed to revoke refresh token family"
+	familyID := "family#" + t.Name()
+
+	revoked1, err1 := store.IsFamilyRevoked(context.TODO(), familyID)
+	err2 := store.RevokeFamily(context.TODO(), familyID, clock.Now().Add(time.Hour))
+	revoked2, err3 := store.IsFamilyRevoked(context.TODO(), familyID)
+	clock.Add(time.Hour + time.Second)
+	revoked3, err4 := store.IsFamilyRevoked(context.TODO(), familyID)
+
+	assert.Nil(err1, msg, "found first is revoked error")
+	assert.False(revoked1, msg, "family is revoked before revoking it")
+	assert.Nil(err2, msg, "found revoke error")
+	assert.Nil(err3, msg, "found second is revoked error")
+	assert.True(revoked2, msg, "family is not revoked")
+	assert.Nil(err4, msg, "found third is revoked error")
+	assert.False(revoked3, msg, "family is revoked after expireAt")
+}
@@ -0,0 +1,188 @@
+package repositorytest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+// SessionStoreFactory
+// build the store under test, it must read the time from now.
+type SessionStoreFactory func(t *testing.T, now func() time.Time) authentication.SessionStore
+
+// RunSessionStoreContract
+// every implementation of authentication.SessionStore must pass these cases.
+//
+//	@param t
+//	@param factory
+func RunSessionStoreContract(t *testing.T, factory SessionStoreFactory) {
+	t.Helper()
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, store authentication.SessionStore, clock *testClock)
+	}{
+		{"SaveWithSessionThenGetIt", testSaveWithSessionThenGetIt},
+		{"GetWithExpiredSessionReturnNil", testGetWithExpiredSessionReturnNil},
+		{"ListWithSessionsReturnUnexpiredOnesInIssuedOrder", testListWithSessionsReturnUnexpiredOnesInIssuedOrder},
+		{"DeleteWithSessionThenItIsNotFound", testDeleteWithSessionThenItIsNotFound},
+		{"UserEpochWithNoIncrementReturnZero", testUserEpochWithNoIncrementReturnZero},
+		{"IncrementUserEpochWithConcurrentCallsCountAll", testIncrementUserEpochWithConcurrentCallsCountAll},
+	}
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}
+			testCase.fn(t, factory(t, clock.Now), clock)
+		})
+	}
+}
+
+// newTestSession
+// users are unique per case, the stores may be shared by cases.
+//
+//	@param t
+//	@param clock
+//	@param id
+//	@return *authentication.Session
+func newTestSession(t *testing.T, clock *testClock, id string) *authentication.Session {
+	return &authentication.Session{
+		ID:             id,
+		UserID:         "user#" + t.Name(),
+		Device:         "Mozilla/5.0",
+		IP:             "192.0.2.1",
+		IssuedAt:       clock.Now(),
+		RefreshedAt:    clock.Now(),
+		ExpiresAt:      clock.Now().Add(time.Hour),
+		TokenID:        "jti_" + id,
+		TokenKey:       authentication.BlocklistKey(id),
+		TokenExpiresAt: clock.Now().Add(time.Minute),
+	}
+}
+
+func testSaveWithSessionThenGetIt(t *testing.T, store authentication.SessionStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "failed to save session"
+	session := newTestSession(t, clock, "session_1")
+
+	err1 := store.Save(context.TODO(), session)
+	found, err2 := store.Get(context.TODO(), session.UserID, session.ID)
+
+	assert.Nil(err1, msg, "found save error")
+	assert.Nil(err2, msg, "found get error")
+	assert.NotNil(found, msg, "session is not found")
+	assert.Equal(session.Device, found.Device, msg, "device")
+	assert.Equal(session.IP, found.IP, msg, "ip")
+	assert.Equal(session.TokenID, found.TokenID, msg, "token id")
+	assert.Equal(session.TokenKey, found.TokenKey, msg, "token key")
+	assert.True(session.IssuedAt.Equal(found.IssuedAt), msg, "issuedAt")
+	assert.True(session.ExpiresAt.Equal(found.ExpiresAt), msg, "expiresAt")
+	assert.True(session.TokenExpiresAt.Equal(found.TokenExpiresAt), msg, "tokenExpiresAt")
+}
+
+func testGetWithExpiredSessionReturnNil(t *testing.T, store authentication.SessionStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "expired session is found"
+	session := newTestSession(t, clock, "session_1")
+	err := store.Save(context.TODO(), session)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	clock.Add(time.Hour + time.Second)
+	found, err := store.Get(context.TODO(), session.UserID, session.ID)
+
+	assert.Nil(err, msg, "found get error")
+	assert.Nil(found, msg, "found session")
+}
+
+func testListWithSessionsReturnUnexpiredOnesInIssuedOrder(
+	t *testing.T, store authentication.SessionStore, clock *testClock,
+) {
+	assert := require.New(t)
+	msg := "failed to list sessions"
+	expired := newTestSession(t, clock, "session_0")
+	expired.ExpiresAt = clock.Now().Add(time.Second)
+	later := newTestSession(t, clock, "session_1")
+	later.IssuedAt = clock.Now().Add(time.Minute)
+	earlier := newTestSession(t, clock, "session_2")
+	other := newTestSession(t, clock, "session_3")
+	other.UserID = "other#" + t.Name()
+	for _, session := range []*authentication.Session{expired, later, earlier, other} {
+		err := store.Save(context.TODO(), session)
+		if err != nil {
+			t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+		}
+	}
+
+	clock.Add(time.Second)
+	sessions, err := store.List(context.TODO(), later.UserID)
+
+	assert.Nil(err, msg, "found list error")
+	assert.Len(sessions, 2, msg, "sessions")
+	assert.Equal(earlier.ID, sessions[0].ID, msg, "first session")
+	assert.Equal(later.ID, sessions[1].ID, msg, "second session")
+}
+
+func testDeleteWithSessionThenItIsNotFound(t *testing.T, store authentication.SessionStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "deleted session is found"
+	session := newTestSession(t, clock, "session_1")
+	err := store.Save(context.TODO(), session)
+	if err != nil {
+		t.Fatalf("%s. error happened when preparing necesarry data, %v", msg, err)
+	}
+
+	err1 := store.Delete(context.TODO(), session.UserID, session.ID)
+	found, err2 := store.Get(context.TODO(), session.UserID, session.ID)
+	sessions, err3 := store.List(context.TODO(), session.UserID)
+	err4 := store.Delete(context.TODO(), session.UserID, session.ID)
+
+	assert.Nil(err1, msg, "found delete error")
+	assert.Nil(err2, msg, "found get error")
+	assert.Nil(found, msg, "found session")
+	assert.Nil(err3, msg, "found list error")
+	assert.Empty(sessions, msg, "listed sessions")
+	assert.Nil(err4, msg, "found error of deleting it again")
+}
+
+func testUserEpochWithNoIncrementReturnZero(t *testing.T, store authentication.SessionStore, clock *testClock) {
+	assert := require.New(t)
+	msg := "epoch of new user is not zero"
+
+	epoch, err := store.UserEpoch(context.TODO(), "user#"+t.Name())
+
+	assert.Nil(err, msg, "found epoch error")
+	assert.Equal(int64(0), epoch, msg, "epoch")
+}
+
+func testIncrementUserEpochWithConcurrentCallsCountAll(
+	t *testing.T, store authentication.SessionStore, clock *testClock,
+) {
+	assert := require.New(t)
+	msg := "failed to increment epoch concurrently"
+	userID := "user#" + t.Name()
+	count := 20
+	errs := make(chan error, count)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.IncrementUserEpoch(context.TODO(), userID)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.Nil(err, msg, "found increment error")
+	}
+	epoch, err := store.UserEpoch(context.TODO(), userID)
+	assert.Nil(err, msg, "found epoch error")
+	assert.Equal(int64(count), epoch, msg, "epoch of %d increments", count)
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+const (
+	sessionPKPrefix string = "session#"
+	sessionSKPrefix string = "session#"
+	sessionEpochSK  string = "epoch"
+	fieldUserEpoch  string = "epoch"
+)
+
+// SessionDynamodbStore
+// implements authentication.SessionStore, the sessions and the epoch of a user share a partition.
+// the sessions are purged by the ttl of the table, the epochs are kept.
+type SessionDynamodbStore struct {
+	tableName string
+	client    dynamodbiface.DynamoDBAPI
+	now       func() time.Time
+}
+
+// NewSessionDynamodbStore
+//
+//	@param tableName
+//	@param client
+//	@return *SessionDynamodbStore
+func NewSessionDynamodbStore(tableName string, client dynamodbiface.DynamoDBAPI) *SessionDynamodbStore {
+	return &SessionDynamodbStore{
+		tableName: tableName,
+		client:    client,
+		now:       time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *SessionDynamodbStore
+func (s *SessionDynamodbStore) WithClock(now func() time.Time) *SessionDynamodbStore {
+	s.now = now
+	return s
+}
+
+// Save
+//
+//	@receiver s
+//	@param ctx
+//	@param session
+//	@return error
+func (s *SessionDynamodbStore) Save(ctx context.Context, session *authentication.Session) error {
+	if session == nil || session.ID == "" || session.UserID == "" {
+		return errors.Wrap(authentication.ErrSessionNotFound, "no session id or user id found")
+	}
+	item, err := dynamodbattribute.MarshalMap(session)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "marshal session error. id: %s", session.ID)
+	}
+	for name, value := range toSessionDBKey(session.UserID, session.ID) {
+		item[name] = value
+	}
+	item[FieldDummyExpireAt] = toExpireAtValue(session.ExpiresAt)
+	_, err = s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "put db session error. table: %s, id: %s", s.tableName, session.ID)
+	}
+	logger.Debug("put session to db. user_id: %s, id: %s", session.UserID, session.ID)
+	return nil
+}
+
+// Get
+// the ttl of DynamoDB purges items lazily, so the expiration is checked here too
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@param id
+//	@return *authentication.Session
+//	@return error
+func (s *SessionDynamodbStore) Get(ctx context.Context, userID string, id string) (*authentication.Session, error) {
+	data, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            toSessionDBKey(userID, id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "get db session error. table: %s, id: %s", s.tableName, id)
+	}
+	if len(data.Item) == 0 {
+		return nil, nil
+	}
+	session, err := toSessionEntity(data.Item)
+	if err != nil {
+		return nil, err
+	}
+	if !session.ExpiresAt.After(s.now()) {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// List
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@return []*authentication.Session
+//	@return error
+func (s *SessionDynamodbStore) List(ctx context.Context, userID string) ([]*authentication.Session, error) {
+	sessions := []*authentication.Session{}
+	curr := s.now()
+	input := &dynamodb.QueryInput{
+		TableName:                aws.String(s.tableName),
+		KeyConditionExpression:   aws.String("#pk = :pk AND begins_with(#sk, :sk)"),
+		ExpressionAttributeNames: map[string]*string{"#pk": aws.String(FieldDummyPK), "#sk": aws.String(FieldDummySK)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk": {S: aws.String(sessionPKPrefix + userID)},
+			":sk": {S: aws.String(sessionSKPrefix)},
+		},
+		ConsistentRead: aws.Bool(true),
+	}
+	for {
+		data, err := s.client.QueryWithContext(ctx, input)
+		if err != nil {
+			rootErr := errors.New(err.Error())
+			return nil, errors.Wrapf(rootErr, "query db sessions error. table: %s, user_id: %s", s.tableName, userID)
+		}
+		for _, item := range data.Items {
+			session, err := toSessionEntity(item)
+			if err != nil {
+				return nil, err
+			}
+			if session.ExpiresAt.After(curr) {
+				sessions = append(sessions, session)
+			}
+		}
+		if len(data.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = data.LastEvaluatedKey
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].IssuedAt.Before(sessions[j].IssuedAt)
+	})
+	return sessions, nil
+}
+
+// Delete
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@param id
+//	@return error
+func (s *SessionDynamodbStore) Delete(ctx context.Context, userID string, id string) error {
+	_, err := s.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       toSessionDBKey(userID, id),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "delete db session error. table: %s, id: %s", s.tableName, id)
+	}
+	logger.Debug("delete session from db. user_id: %s, id: %s", userID, id)
+	return nil
+}
+
+// UserEpoch
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@return int64
+//	@return error
+func (s *SessionDynamodbStore) UserEpoch(ctx context.Context, userID string) (int64, error) {
+	data, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            toUserEpochDBKey(userID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return 0, errors.Wrapf(rootErr, "get db user epoch error. table: %s, user_id: %s", s.tableName, userID)
+	}
+	return toUserEpoch(data.Item, userID)
+}
+
+// IncrementUserEpoch
+// ADD starts from 0 when there is no epoch yet
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@return int64
+//	@return error
+func (s *SessionDynamodbStore) IncrementUserEpoch(ctx context.Context, userID string) (int64, error) {
+	data, err := s.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       toUserEpochDBKey(userID),
+		UpdateExpression:          aws.String("ADD #epoch :one"),
+		ExpressionAttributeNames:  map[string]*string{"#epoch": aws.String(fieldUserEpoch)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":one": {N: aws.String("1")}},
+		ReturnValues:              aws.String(dynamodb.ReturnValueUpdatedNew),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return 0, errors.Wrapf(rootErr, "update db user epoch error. table: %s, user_id: %s", s.tableName, userID)
+	}
+	return toUserEpoch(data.Attributes, userID)
+}
+
+// toSessionEntity
+//
+//	@param item
+//	@return *authentication.Session
+//	@return error
+func toSessionEntity(item map[string]*dynamodb.AttributeValue) (*authentication.Session, error) {
+	session := &authentication.Session{}
+	err := dynamodbattribute.UnmarshalMap(item, session)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "unmarshal db session error. pk: %s, sk: %s",
+			aws.StringValue(item[FieldDummyPK].S), aws.StringValue(item[FieldDummySK].S))
+	}
+	return session, nil
+}
+
+// toUserEpoch
+//
+//	@param item
+//	@param userID
+//	@return int64 0 when there is no epoch
+//	@return error
+func toUserEpoch(item map[string]*dynamodb.AttributeValue, userID string) (int64, error) {
+	value, ok := item[fieldUserEpoch]
+	if !ok || value.N == nil {
+		return 0, nil
+	}
+	epoch, err := strconv.ParseInt(*value.N, 10, 64)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return 0, errors.Wrapf(rootErr, "invalid db user epoch. user_id: %s", userID)
+	}
+	return epoch, nil
+}
+
+// toSessionDBKey
+//
+//	@param userID
+//	@param id
+//	@return map
+func toSessionDBKey(userID string, id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(sessionPKPrefix + userID)},
+		FieldDummySK: {S: aws.String(sessionSKPrefix + id)},
+	}
+}
+
+// toUserEpochDBKey
+//
+//	@param userID
+//	@return map
+func toUserEpochDBKey(userID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		FieldDummyPK: {S: aws.String(sessionPKPrefix + userID)},
+		FieldDummySK: {S: aws.String(sessionEpochSK)},
+	}
+}
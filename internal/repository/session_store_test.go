@@ -0,0 +1,16 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"local.com/go-clean-lambda/internal/repository"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestSessionDynamodbStoreContract(t *testing.T) {
+	repositorytest.RunSessionStoreContract(t, func(t *testing.T, now func() time.Time) authentication.SessionStore {
+		return repository.NewSessionDynamodbStore(dummyTableName, ddb.client).WithClock(now)
+	})
+}
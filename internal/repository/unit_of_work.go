@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	nativeerr "errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	// MaxTransactItems limit of actions in one TransactWriteItems request.
+	MaxTransactItems int = 100
+
+	cancellationCodeNone string = "None"
+)
+
+var (
+	ErrTooManyTransactItems error = nativeerr.New("too many items in one transaction")
+	ErrThrottled            error = nativeerr.New("request throttled")
+
+	// reasons are listed in the message when the error has no structured reasons
+	// e.g. "Transaction cancelled, please refer cancellation reasons for specific reasons [None, ConditionalCheckFailed]".
+	cancellationReasonsPattern = regexp.MustCompile(`\[([A-Za-z, ]+)\]`)
+)
+
+type unitOfWorkContextKey struct{}
+
+// UnitOfWork
+// collects writes of several repositories and commits them in one TransactWriteItems request.
+type UnitOfWork struct {
+	mu          sync.Mutex
+	items       []*dynamodb.TransactWriteItem
+	descs       []string
+	afterCommit []func()
+}
+
+// NewUnitOfWork
+//
+//	@return *UnitOfWork
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{
+		items: []*dynamodb.TransactWriteItem{},
+		descs: []string{},
+	}
+}
+
+// WithUnitOfWork
+//
+//	@param ctx
+//	@param uow
+//	@return context.Context
+func WithUnitOfWork(ctx context.Context, uow *UnitOfWork) context.Context {
+	return context.WithValue(ctx, unitOfWorkContextKey{}, uow)
+}
+
+// UnitOfWorkFromContext
+//
+//	@param ctx
+//	@return *UnitOfWork nil when the context is not inside a transaction
+func UnitOfWorkFromContext(ctx context.Context) *UnitOfWork {
+	if ctx == nil {
+		return nil
+	}
+	uow, ok := ctx.Value(unitOfWorkContextKey{}).(*UnitOfWork)
+	if !ok {
+		return nil
+	}
+	return uow
+}
+
+// Put
+//
+//	@receiver u
+//	@param put
+//	@param desc describes the item in errors and logs
+func (u *UnitOfWork) Put(put *dynamodb.Put, desc string) {
+	u.add(&dynamodb.TransactWriteItem{Put: put}, desc)
+}
+
+// Update
+//
+//	@receiver u
+//	@param update
+//	@param desc describes the item in errors and logs
+func (u *UnitOfWork) Update(update *dynamodb.Update, desc string) {
+	u.add(&dynamodb.TransactWriteItem{Update: update}, desc)
+}
+
+// Delete
+//
+//	@receiver u
+//	@param del
+//	@param desc describes the item in errors and logs
+func (u *UnitOfWork) Delete(del *dynamodb.Delete, desc string) {
+	u.add(&dynamodb.TransactWriteItem{Delete: del}, desc)
+}
+
+// ConditionCheck
+//
+//	@receiver u
+//	@param check
+//	@param desc describes the item in errors and logs
+func (u *UnitOfWork) ConditionCheck(check *dynamodb.ConditionCheck, desc string) {
+	u.add(&dynamodb.TransactWriteItem{ConditionCheck: check}, desc)
+}
+
+// AfterCommit
+// fn runs once the transaction is committed, it never runs when the transaction fails
+//
+//	@receiver u
+//	@param fn
+func (u *UnitOfWork) AfterCommit(fn func()) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.afterCommit = append(u.afterCommit, fn)
+}
+
+// committed
+//
+//	@receiver u
+func (u *UnitOfWork) committed() {
+	u.mu.Lock()
+	fns := u.afterCommit
+	u.afterCommit = nil
+	u.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// Len
+//
+//	@receiver u
+//	@return int
+func (u *UnitOfWork) Len() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.items)
+}
+
+// add
+//
+//	@receiver u
+//	@param item
+//	@param desc
+func (u *UnitOfWork) add(item *dynamodb.TransactWriteItem, desc string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.items = append(u.items, item)
+	u.descs = append(u.descs, desc)
+}
+
+// snapshot
+//
+//	@receiver u
+//	@return []*dynamodb.TransactWriteItem
+//	@return []string
+func (u *UnitOfWork) snapshot() ([]*dynamodb.TransactWriteItem, []string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	items := make([]*dynamodb.TransactWriteItem, len(u.items))
+	copy(items, u.items)
+	descs := make([]string, len(u.descs))
+	copy(descs, u.descs)
+	return items, descs
+}
+
+// TransactItemError
+// reason why one item of a canceled transaction failed.
+type TransactItemError struct {
+	Index   int
+	Desc    string
+	Code    string
+	Message string
+	Err     error
+}
+
+// Error
+//
+//	@receiver e
+//	@return string
+func (e *TransactItemError) Error() string {
+	return fmt.Sprintf("transact item %d (%s) failed. code: %s, message: %s", e.Index, e.Desc, e.Code, e.Message)
+}
+
+// Unwrap
+//
+//	@receiver e
+//	@return error
+func (e *TransactItemError) Unwrap() error {
+	return e.Err
+}
+
+// TransactCanceledError
+// decoded TransactionCanceledException, only failed items are listed.
+type TransactCanceledError struct {
+	Items []*TransactItemError
+}
+
+// Error
+//
+//	@receiver e
+//	@return string
+func (e *TransactCanceledError) Error() string {
+	msgs := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		msgs = append(msgs, item.Error())
+	}
+	return fmt.Sprintf("%s: [%s]", domain.ErrTransactionCanceled.Error(), strings.Join(msgs, "; "))
+}
+
+// Is
+// matches domain.ErrTransactionCanceled and the error of any failed item
+//
+//	@receiver e
+//	@param target
+//	@return bool
+func (e *TransactCanceledError) Is(target error) bool {
+	if target == domain.ErrTransactionCanceled {
+		return true
+	}
+	for _, item := range e.Items {
+		if errors.Is(item.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// DynamodbTransactor implements domain.Transactor.
+type DynamodbTransactor struct {
+	client dynamodbiface.DynamoDBAPI
+}
+
+// NewDynamodbTransactor
+//
+//	@param client
+//	@return *DynamodbTransactor
+func NewDynamodbTransactor(client dynamodbiface.DynamoDBAPI) *DynamodbTransactor {
+	return &DynamodbTransactor{
+		client: client,
+	}
+}
+
+// WithinTransaction
+//
+//	@receiver t
+//	@param ctx
+//	@param fn
+//	@return error
+func (t *DynamodbTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if UnitOfWorkFromContext(ctx) != nil {
+		// join the outer unit of work, it is committed by the outer call
+		return fn(ctx)
+	}
+	uow := NewUnitOfWork()
+	err := fn(WithUnitOfWork(ctx, uow))
+	if err != nil {
+		logger.Debug("transaction discarded. items: %d", uow.Len())
+		return err
+	}
+	err = t.Commit(ctx, uow)
+	if err != nil {
+		return err
+	}
+	uow.committed()
+	return nil
+}
+
+// Commit
+//
+//	@receiver t
+//	@param ctx
+//	@param uow
+//	@return error ErrTooManyTransactItems, *TransactCanceledError and others
+func (t *DynamodbTransactor) Commit(ctx context.Context, uow *UnitOfWork) error {
+	items, descs := uow.snapshot()
+	if len(items) == 0 {
+		return nil
+	}
+	if len(items) > MaxTransactItems {
+		return errors.Wrapf(ErrTooManyTransactItems, "items: %d, max: %d", len(items), MaxTransactItems)
+	}
+	_, err := t.client.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		var canceled *dynamodb.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return errors.WithStack(decodeTransactionCanceled(canceled, descs))
+		}
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "transact write items error. items: %s", strings.Join(descs, ", "))
+	}
+	logger.Debug("transaction committed. items: %s", strings.Join(descs, ", "))
+	return nil
+}
+
+// decodeTransactionCanceled
+//
+//	@param canceled
+//	@param descs descriptions of the items in request order
+//	@return *TransactCanceledError
+func decodeTransactionCanceled(canceled *dynamodb.TransactionCanceledException, descs []string) *TransactCanceledError {
+	reasons := canceled.CancellationReasons
+	if len(reasons) == 0 {
+		reasons = parseCancellationReasons(canceled.Message())
+	}
+	result := &TransactCanceledError{
+		Items: []*TransactItemError{},
+	}
+	for i, reason := range reasons {
+		code := aws.StringValue(reason.Code)
+		if code == "" || code == cancellationCodeNone {
+			continue
+		}
+		desc := ""
+		if i < len(descs) {
+			desc = descs[i]
+		}
+		result.Items = append(result.Items, &TransactItemError{
+			Index:   i,
+			Desc:    desc,
+			Code:    code,
+			Message: aws.StringValue(reason.Message),
+			Err:     cancellationCodeToError(code),
+		})
+	}
+	return result
+}
+
+// parseCancellationReasons
+//
+//	@param message
+//	@return []*dynamodb.CancellationReason
+func parseCancellationReasons(message string) []*dynamodb.CancellationReason {
+	matches := cancellationReasonsPattern.FindStringSubmatch(message)
+	if len(matches) < 2 {
+		return nil
+	}
+	reasons := []*dynamodb.CancellationReason{}
+	for _, code := range strings.Split(matches[1], ",") {
+		reasons = append(reasons, &dynamodb.CancellationReason{
+			Code: aws.String(strings.TrimSpace(code)),
+		})
+	}
+	return reasons
+}
+
+// cancellationCodeToError
+//
+//	@param code
+//	@return error
+func cancellationCodeToError(code string) error {
+	switch code {
+	case "ConditionalCheckFailed":
+		return domain.ErrConditionFailed
+	case "TransactionConflict":
+		return domain.ErrTransactionConflict
+	case "ProvisionedThroughputExceeded", "ThrottlingError", "RequestLimitExceeded":
+		return ErrThrottled
+	default:
+		return domain.ErrTransactionCanceled
+	}
+}
+
+// errorToCancellationCode
+//
+//	@param err
+//	@return string
+func errorToCancellationCode(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrConditionFailed):
+		return "ConditionalCheckFailed"
+	case errors.Is(err, domain.ErrTransactionConflict):
+		return "TransactionConflict"
+	case errors.Is(err, ErrThrottled):
+		return "ThrottlingError"
+	default:
+		return "ValidationError"
+	}
+}
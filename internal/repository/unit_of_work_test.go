@@ -0,0 +1,155 @@
+package repository_test
+
+import (
+	"context"
+	nativeerr "errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/stretchr/testify/require"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/repository"
+)
+
+var errFnFailed error = nativeerr.New("mocked fn error")
+
+// transactStubClient records TransactWriteItems requests and answers with a preset error.
+type transactStubClient struct {
+	dynamodbiface.DynamoDBAPI
+	inputs []*dynamodb.TransactWriteItemsInput
+	err    error
+}
+
+func (c *transactStubClient) TransactWriteItemsWithContext(
+	ctx context.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option,
+) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.inputs = append(c.inputs, input)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func buildStubPut(id string) *dynamodb.Put {
+	return &dynamodb.Put{
+		TableName: aws.String("stub_table"),
+		Item: map[string]*dynamodb.AttributeValue{
+			repository.FieldDummyPK: {S: aws.String("test")},
+			repository.FieldDummySK: {S: aws.String(id)},
+		},
+	}
+}
+
+func TestTransactorWithWritesCommitOnce(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to commit unit of work"
+	client := &transactStubClient{}
+	transactor := repository.NewDynamodbTransactor(client)
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		repository.UnitOfWorkFromContext(ctx).Put(buildStubPut("id1"), "put id1")
+		// nested call joins the outer unit of work
+		return transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			repository.UnitOfWorkFromContext(ctx).Put(buildStubPut("id2"), "put id2")
+			return nil
+		})
+	})
+
+	assert.Nil(err, msg, "found error")
+	assert.Len(client.inputs, 1, msg, "wrong request count")
+	assert.Len(client.inputs[0].TransactItems, 2, msg, "wrong item count")
+}
+
+func TestTransactorWithFnErrorDiscardWrites(t *testing.T) {
+	assert := require.New(t)
+	msg := "unit of work not discarded"
+	client := &transactStubClient{}
+	transactor := repository.NewDynamodbTransactor(client)
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		repository.UnitOfWorkFromContext(ctx).Put(buildStubPut("id1"), "put id1")
+		return errFnFailed
+	})
+
+	assert.ErrorIs(err, errFnFailed, msg, "wrong error")
+	assert.Len(client.inputs, 0, msg, "request sent")
+}
+
+func TestTransactorWithTooManyItemsReturnError(t *testing.T) {
+	assert := require.New(t)
+	msg := "oversized unit of work didn't fail"
+	client := &transactStubClient{}
+	transactor := repository.NewDynamodbTransactor(client)
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		for i := 0; i <= repository.MaxTransactItems; i++ {
+			repository.UnitOfWorkFromContext(ctx).Put(buildStubPut("id"), "put id")
+		}
+		return nil
+	})
+
+	assert.ErrorIs(err, repository.ErrTooManyTransactItems, msg, "wrong error")
+	assert.Len(client.inputs, 0, msg, "request sent")
+}
+
+func TestTransactorWithCanceledReturnItemErrors(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to decode cancellation reasons"
+	client := &transactStubClient{
+		err: &dynamodb.TransactionCanceledException{
+			Message_: aws.String("Transaction cancelled"),
+			CancellationReasons: []*dynamodb.CancellationReason{
+				{Code: aws.String("None")},
+				{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")},
+				{Code: aws.String("TransactionConflict")},
+			},
+		},
+	}
+	transactor := repository.NewDynamodbTransactor(client)
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		uow := repository.UnitOfWorkFromContext(ctx)
+		uow.Put(buildStubPut("id1"), "put id1")
+		uow.Put(buildStubPut("id2"), "put id2")
+		uow.Put(buildStubPut("id3"), "put id3")
+		return nil
+	})
+
+	var canceled *repository.TransactCanceledError
+	assert.ErrorAs(err, &canceled, msg, "wrong error type")
+	assert.ErrorIs(err, domain.ErrTransactionCanceled, msg, "not canceled")
+	assert.ErrorIs(err, domain.ErrConditionFailed, msg, "no condition failure")
+	assert.ErrorIs(err, domain.ErrTransactionConflict, msg, "no conflict")
+	assert.Len(canceled.Items, 2, msg, "wrong failed item count")
+	assert.Equal(1, canceled.Items[0].Index, msg, "wrong item index")
+	assert.Equal("put id2", canceled.Items[0].Desc, msg, "wrong item desc")
+	assert.Equal(2, canceled.Items[1].Index, msg, "wrong item index")
+}
+
+func TestTransactorWithReasonsInMessageReturnItemErrors(t *testing.T) {
+	assert := require.New(t)
+	msg := "failed to decode cancellation reasons from message"
+	client := &transactStubClient{
+		err: &dynamodb.TransactionCanceledException{
+			Message_: aws.String(
+				"Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None]"),
+		},
+	}
+	transactor := repository.NewDynamodbTransactor(client)
+
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		uow := repository.UnitOfWorkFromContext(ctx)
+		uow.Put(buildStubPut("id1"), "put id1")
+		uow.Put(buildStubPut("id2"), "put id2")
+		return nil
+	})
+
+	var canceled *repository.TransactCanceledError
+	assert.ErrorAs(err, &canceled, msg, "wrong error type")
+	assert.Len(canceled.Items, 1, msg, "wrong failed item count")
+	assert.Equal(0, canceled.Items[0].Index, msg, "wrong item index")
+	assert.ErrorIs(canceled.Items[0], domain.ErrConditionFailed, msg, "wrong item error")
+}
@@ -0,0 +1,401 @@
+package account
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	nativeerr "errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmArgon2id string = "argon2id"
+	PasswordAlgorithmBcrypt   string = "bcrypt"
+	// bcryptMaxPasswordBytes bcrypt ignores the bytes after them, so longer passwords are rejected
+	bcryptMaxPasswordBytes int = 72
+)
+
+var ErrUnsupportedPasswordHash error = nativeerr.New("unsupported password hash")
+
+// phcEncoding base64 of the PHC string format, without padding
+var phcEncoding = base64.RawStdEncoding
+
+type PasswordHasher interface {
+	// Hash
+	//  @param password
+	//  @return string the hash in the PHC string format, carrying the algorithm, its parameters and the salt
+	//  @return error
+	Hash(password string) (string, error)
+
+	// Verify
+	// compare in constant time
+	//  @param password
+	//  @param encoded
+	//  @return bool
+	//  @return error ErrUnsupportedPasswordHash when encoded is not of the hasher, and others
+	Verify(password string, encoded string) (bool, error)
+
+	// Supports
+	//  @param encoded
+	//  @return bool true when encoded is of the algorithm of the hasher
+	Supports(encoded string) bool
+
+	// NeedsRehash
+	//  @param encoded a hash supported by the hasher
+	//  @return bool true when encoded was hashed with other parameters than the current ones
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idParams
+// the parameters of argon2id, they are written in every hash.
+type Argon2idParams struct {
+	// Memory KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams
+// the minimum recommended by OWASP.
+//
+//	@return Argon2idParams
+func DefaultArgon2idParams() Argon2idParams {
+	return Argon2idParams{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Argon2idHasher
+// implements PasswordHasher by argon2id, hashes are $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher
+//
+//	@param params
+//	@return *Argon2idHasher
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{
+		params: params,
+	}
+}
+
+// Hash
+//
+//	@receiver h
+//	@param password
+//	@return string
+//	@return error
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return "", errors.Wrap(rootErr, "failed to generate salt")
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism,
+		h.params.KeyLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", PasswordAlgorithmArgon2id, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key)), nil
+}
+
+// Verify
+// the hash is verified with its own parameters, which may be older than the current ones
+//
+//	@receiver h
+//	@param password
+//	@param encoded
+//	@return bool
+//	@return error
+func (h *Argon2idHasher) Verify(password string, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+		params.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// Supports
+//
+//	@receiver h
+//	@param encoded
+//	@return bool
+func (h *Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$"+PasswordAlgorithmArgon2id+"$")
+}
+
+// NeedsRehash
+//
+//	@receiver h
+//	@param encoded
+//	@return bool
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params != h.params
+}
+
+// decodeArgon2id
+//
+//	@param encoded
+//	@return Argon2idParams
+//	@return []byte salt
+//	@return []byte key
+//	@return error ErrUnsupportedPasswordHash
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	params := Argon2idParams{}
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return params, nil, nil, errors.Wrap(ErrUnsupportedPasswordHash, "not argon2id")
+	}
+	version := 0
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, errors.Wrapf(ErrUnsupportedPasswordHash, "argon2id version: %s", parts[2])
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errors.Wrapf(ErrUnsupportedPasswordHash, "argon2id parameters: %s", parts[3])
+	}
+	salt, err := phcEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errors.Wrap(ErrUnsupportedPasswordHash, "argon2id salt")
+	}
+	key, err := phcEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.Wrap(ErrUnsupportedPasswordHash, "argon2id key")
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// BcryptHasher
+// implements PasswordHasher by bcrypt, hashes are $2a$<cost>$<salt and key>.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher
+//
+//	@param cost bcrypt.DefaultCost when it is out of the range of bcrypt
+//	@return *BcryptHasher
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{
+		cost: cost,
+	}
+}
+
+// Hash
+//
+//	@receiver h
+//	@param password
+//	@return string
+//	@return error ErrInvalidPassword when it is longer than 72 bytes, and others
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxPasswordBytes {
+		return "", errors.Wrapf(ErrInvalidPassword, "password must be at most %d bytes", bcryptMaxPasswordBytes)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return "", errors.Wrap(rootErr, "failed to hash password by bcrypt")
+	}
+	return string(hashed), nil
+}
+
+// Verify
+// bcrypt compares in constant time
+//
+//	@receiver h
+//	@param password
+//	@param encoded
+//	@return bool
+//	@return error
+func (h *BcryptHasher) Verify(password string, encoded string) (bool, error) {
+	if !h.Supports(encoded) {
+		return false, errors.Wrap(ErrUnsupportedPasswordHash, "not bcrypt")
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(ErrUnsupportedPasswordHash, "invalid bcrypt hash. %s", err.Error())
+	}
+	return true, nil
+}
+
+// Supports
+//
+//	@receiver h
+//	@param encoded
+//	@return bool
+func (h *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash
+//
+//	@receiver h
+//	@param encoded
+//	@return bool
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost
+}
+
+// LegacySHA256Hasher
+// verifies the unsalted sha256 hex hashes stored before the passwords were hashed by PasswordHasher.
+// it never hashes, so every hash of it is rehashed on login.
+type LegacySHA256Hasher struct{}
+
+// Hash
+//
+//	@receiver h
+//	@param password
+//	@return string
+//	@return error ErrUnsupportedPasswordHash always
+func (h LegacySHA256Hasher) Hash(password string) (string, error) {
+	return "", errors.Wrap(ErrUnsupportedPasswordHash, "sha256 is only verified")
+}
+
+// Verify
+//
+//	@receiver h
+//	@param password
+//	@param encoded
+//	@return bool
+//	@return error
+func (h LegacySHA256Hasher) Verify(password string, encoded string) (bool, error) {
+	if !h.Supports(encoded) {
+		return false, errors.Wrap(ErrUnsupportedPasswordHash, "not sha256")
+	}
+	hashed := sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(hashed[:])), []byte(encoded)) == 1, nil
+}
+
+// Supports
+//
+//	@receiver h
+//	@param encoded
+//	@return bool
+func (h LegacySHA256Hasher) Supports(encoded string) bool {
+	if len(encoded) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
+
+// NeedsRehash
+//
+//	@receiver h
+//	@param encoded
+//	@return bool
+func (h LegacySHA256Hasher) NeedsRehash(encoded string) bool {
+	return true
+}
+
+// PasswordEncoder
+// hashes by the current hasher, and verifies the hashes of the current and the old hashers.
+type PasswordEncoder struct {
+	hasher PasswordHasher
+	// legacy hashes of them are verified and rehashed by hasher
+	legacy []PasswordHasher
+	// dummy verified when there is no hash, so unknown users take as long as known ones
+	dummy     string
+	dummyOnce sync.Once
+}
+
+// NewPasswordEncoder
+//
+//	@param hasher hashes new passwords
+//	@param legacy verify the hashes of the old algorithms
+//	@return *PasswordEncoder
+func NewPasswordEncoder(hasher PasswordHasher, legacy ...PasswordHasher) *PasswordEncoder {
+	return &PasswordEncoder{
+		hasher: hasher,
+		legacy: legacy,
+	}
+}
+
+// NewPasswordEncoderOf
+// the hashes of the other algorithm and of the old format are verified, and rehashed on login
+//
+//	@param algorithm PasswordAlgorithmArgon2id or PasswordAlgorithmBcrypt
+//	@param params the parameters of argon2id
+//	@param bcryptCost the cost of bcrypt
+//	@return *PasswordEncoder
+func NewPasswordEncoderOf(algorithm string, params Argon2idParams, bcryptCost int) *PasswordEncoder {
+	argon2idHasher := NewArgon2idHasher(params)
+	bcryptHasher := NewBcryptHasher(bcryptCost)
+	if algorithm == PasswordAlgorithmBcrypt {
+		return NewPasswordEncoder(bcryptHasher, argon2idHasher, LegacySHA256Hasher{})
+	}
+	return NewPasswordEncoder(argon2idHasher, bcryptHasher, LegacySHA256Hasher{})
+}
+
+// Hash
+//
+//	@receiver e
+//	@param password
+//	@return string
+//	@return error
+func (e *PasswordEncoder) Hash(password string) (string, error) {
+	return e.hasher.Hash(password)
+}
+
+// Verify
+//
+//	@receiver e
+//	@param password
+//	@param encoded blank when the user is not found, it is never verified
+//	@return bool
+//	@return bool true when the password is verified and encoded should be replaced by a new hash of it
+//	@return error ErrUnsupportedPasswordHash and others
+func (e *PasswordEncoder) Verify(password string, encoded string) (bool, bool, error) {
+	if encoded == "" {
+		e.dummyOnce.Do(func() {
+			e.dummy, _ = e.hasher.Hash("dummy password")
+		})
+		_, _ = e.hasher.Verify(password, e.dummy)
+		return false, false, nil
+	}
+	for _, hasher := range append([]PasswordHasher{e.hasher}, e.legacy...) {
+		if !hasher.Supports(encoded) {
+			continue
+		}
+		verified, err := hasher.Verify(password, encoded)
+		if err != nil || !verified {
+			return false, false, err
+		}
+		return true, hasher != e.hasher || hasher.NeedsRehash(encoded), nil
+	}
+	return false, false, errors.WithStack(ErrUnsupportedPasswordHash)
+}
@@ -0,0 +1,129 @@
+package account_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"local.com/go-clean-lambda/internal/sdk/account"
+)
+
+// newTestArgon2idParams
+// cheap parameters to keep the tests fast
+//
+//	@return account.Argon2idParams
+func newTestArgon2idParams() account.Argon2idParams {
+	params := account.DefaultArgon2idParams()
+	params.Memory = 1024
+	params.Iterations = 1
+	return params
+}
+
+func TestArgon2idHasherWithHashReturnSaltedPHCString(t *testing.T) {
+	hasher := account.NewArgon2idHasher(newTestArgon2idParams())
+
+	hash1, err1 := hasher.Hash("correct horse")
+	hash2, err2 := hasher.Hash("correct horse")
+	verified, err3 := hasher.Verify("correct horse", hash1)
+	wrong, err4 := hasher.Verify("wrong horse", hash1)
+
+	msg := "argon2id hash is not a salted PHC string"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found first hash error")
+	assertions.Nil(err2, msg, "found second hash error")
+	assertions.True(strings.HasPrefix(hash1, "$argon2id$v=19$m=1024,t=1,p=1$"), msg, "hash: %s", hash1)
+	assertions.Len(strings.Split(hash1, "$"), 6, msg, "parts of hash")
+	assertions.NotEqual(hash1, hash2, msg, "same hash of same password")
+	assertions.Nil(err3, msg, "found verify error")
+	assertions.True(verified, msg, "password is not verified")
+	assertions.Nil(err4, msg, "found verify error of wrong password")
+	assertions.False(wrong, msg, "wrong password is verified")
+}
+
+func TestPasswordEncoderWithOldHashesRequireRehash(t *testing.T) {
+	params := newTestArgon2idParams()
+	oldParams := params
+	oldParams.Iterations = 2
+	oldArgon2id, err := account.NewArgon2idHasher(oldParams).Hash("correct horse")
+	if err != nil {
+		t.Fatalf("error happened when hashing by argon2id, %v", err)
+	}
+	oldBcrypt, err := account.NewBcryptHasher(bcrypt.MinCost).Hash("correct horse")
+	if err != nil {
+		t.Fatalf("error happened when hashing by bcrypt, %v", err)
+	}
+	current, err := account.NewArgon2idHasher(params).Hash("correct horse")
+	if err != nil {
+		t.Fatalf("error happened when hashing by argon2id, %v", err)
+	}
+	legacy := "d97fd9f5d1bfab7ee6a8f13553ab22ac0c0b9ba2a1ad0d1ab1ea9de6d5ff9a01"
+	encoder := account.NewPasswordEncoderOf(account.PasswordAlgorithmArgon2id, params, bcrypt.MinCost)
+
+	rehashes := map[string]bool{}
+	for name, encoded := range map[string]string{
+		"old argon2id": oldArgon2id,
+		"bcrypt":       oldBcrypt,
+		"current":      current,
+	} {
+		verified, rehash, err := encoder.Verify("correct horse", encoded)
+		if err != nil || !verified {
+			t.Fatalf("%s hash is not verified, %v", name, err)
+		}
+		rehashes[name] = rehash
+	}
+	_, _, err1 := encoder.Verify("correct horse", "$unknown$hash")
+	verified, _, err2 := encoder.Verify("correct horse", legacy)
+
+	msg := "old hashes are not rehashed"
+	assertions := assert.New(t)
+	assertions.True(rehashes["old argon2id"], msg, "hash of old parameters")
+	assertions.True(rehashes["bcrypt"], msg, "hash of other algorithm")
+	assertions.False(rehashes["current"], msg, "hash of current parameters")
+	assertions.True(errors.Is(err1, account.ErrUnsupportedPasswordHash), msg, "unknown hash")
+	assertions.Nil(err2, msg, "found verify error of legacy hash")
+	assertions.False(verified, msg, "legacy hash of other password is verified")
+}
+
+func TestPasswordPolicyWithWeakPasswordReturnEveryReason(t *testing.T) {
+	policy := account.DefaultPasswordPolicy()
+	policy.RequiredClasses = []string{account.PasswordClassDigit, account.PasswordClassUpper}
+
+	err1 := policy.Validate("alice", "alice")
+	err2 := policy.Validate("alice", "Correct horse 9")
+
+	msg := "password policy is not enforced"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err1, account.ErrInvalidPassword), msg, "error type")
+	assertions.Contains(err1.Error(), "at least 8 characters", msg, "length reason")
+	assertions.Contains(err1.Error(), "digit", msg, "digit reason")
+	assertions.Contains(err1.Error(), "upper", msg, "upper reason")
+	assertions.Contains(err1.Error(), "user id", msg, "user id reason")
+	assertions.Nil(err2, msg, "strong password is rejected")
+}
+
+func TestUserDummyClientWithDefaultUserRehashOnLogin(t *testing.T) {
+	client := account.NewUserDummmyClient().
+		WithPasswordEncoder(account.NewPasswordEncoderOf(
+			account.PasswordAlgorithmArgon2id, newTestArgon2idParams(), bcrypt.MinCost))
+
+	verified1, err1 := client.VerifyPassword(context.TODO(), account.User01ID, account.User01ID)
+	verified2, err2 := client.VerifyPassword(context.TODO(), account.User01ID, account.User01ID)
+	wrong, err3 := client.VerifyPassword(context.TODO(), account.User01ID, "wrong password")
+	unknown, err4 := client.VerifyPassword(context.TODO(), "unknown", account.User01ID)
+	err5 := client.RegisterUser(context.TODO(), &account.UserDto{UserID: "alice", Name: "alice"}, "short")
+
+	msg := "password of default user is not verified"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found verify error of legacy hash")
+	assertions.True(verified1, msg, "legacy hash")
+	assertions.Nil(err2, msg, "found verify error of rehashed password")
+	assertions.True(verified2, msg, "rehashed password")
+	assertions.Nil(err3, msg, "found verify error of wrong password")
+	assertions.False(wrong, msg, "wrong password is verified")
+	assertions.Nil(err4, msg, "found verify error of unknown user")
+	assertions.False(unknown, msg, "unknown user is verified")
+	assertions.True(errors.Is(err5, account.ErrInvalidPassword), msg, "weak password is registered")
+}
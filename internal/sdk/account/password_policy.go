@@ -0,0 +1,91 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	PasswordClassUpper  string = "upper"
+	PasswordClassLower  string = "lower"
+	PasswordClassDigit  string = "digit"
+	PasswordClassSymbol string = "symbol"
+)
+
+// passwordClasses tells whether a character is of a class
+var passwordClasses = map[string]func(r rune) bool{
+	PasswordClassUpper: unicode.IsUpper,
+	PasswordClassLower: unicode.IsLower,
+	PasswordClassDigit: unicode.IsDigit,
+	PasswordClassSymbol: func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r)
+	},
+}
+
+// IsPasswordClass
+//
+//	@param class
+//	@return bool true when it is one of PasswordClassUpper, PasswordClassLower, PasswordClassDigit and
+//	PasswordClassSymbol
+func IsPasswordClass(class string) bool {
+	_, ok := passwordClasses[class]
+	return ok
+}
+
+// PasswordPolicy
+// the rules new passwords must follow, the lengths are in characters.
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength no limit when it is 0
+	MaxLength int
+	// RequiredClasses the password must have a character of each of them
+	RequiredClasses []string
+	// AllowUserID allow the password to contain the user id
+	AllowUserID bool
+}
+
+// DefaultPasswordPolicy
+// length matters more than classes, as NIST SP 800-63B
+//
+//	@return *PasswordPolicy
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:       8,
+		MaxLength:       64,
+		RequiredClasses: []string{},
+	}
+}
+
+// Validate
+//
+//	@receiver p
+//	@param userID
+//	@param password
+//	@return error ErrInvalidPassword with every rule the password breaks
+func (p *PasswordPolicy) Validate(userID string, password string) error {
+	reasons := []string{}
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		reasons = append(reasons, fmt.Sprintf("password must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		reasons = append(reasons, fmt.Sprintf("password must be at most %d characters", p.MaxLength))
+	}
+	for _, class := range p.RequiredClasses {
+		isClass, ok := passwordClasses[class]
+		if ok && strings.IndexFunc(password, isClass) < 0 {
+			reasons = append(reasons, fmt.Sprintf("password must contain a %s character", class))
+		}
+	}
+	if !p.AllowUserID && userID != "" && strings.Contains(strings.ToLower(password), strings.ToLower(userID)) {
+		reasons = append(reasons, "password must not contain the user id")
+	}
+	if len(reasons) > 0 {
+		return errors.Wrap(ErrInvalidPassword, strings.Join(reasons, ", "))
+	}
+	return nil
+}
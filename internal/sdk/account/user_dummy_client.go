@@ -4,8 +4,11 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"sync"
 
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"local.com/go-clean-lambda/internal/logger"
 )
 
 const (
@@ -19,8 +22,12 @@ const (
 )
 
 type UserDummyClient struct {
+	mu   sync.RWMutex
 	umap map[string]*UserDto
 	pmap map[string]string
+	// encoder hashes new passwords, the old hashes are rehashed by it on login
+	encoder *PasswordEncoder
+	policy  *PasswordPolicy
 }
 
 func NewUserDummmyClient() *UserDummyClient {
@@ -33,6 +40,7 @@ func NewUserDummmyClient() *UserDummyClient {
 		User0123ID: {UserID: User0123ID, Name: User0123ID},
 		UserRootID: {UserID: UserRootID, Name: UserRootID},
 	}
+	// the default users keep the hashes of the old format, they are rehashed on their first login
 	defaultPasswordMap := map[string]string{
 		User0ID:    encodePasssword(User0ID),
 		User01ID:   encodePasssword(User01ID),
@@ -43,15 +51,39 @@ func NewUserDummmyClient() *UserDummyClient {
 		UserRootID: encodePasssword(UserRootID),
 	}
 	return &UserDummyClient{
-		umap: defaultUserMap,
-		pmap: defaultPasswordMap,
+		umap:    defaultUserMap,
+		pmap:    defaultPasswordMap,
+		encoder: NewPasswordEncoderOf(PasswordAlgorithmArgon2id, DefaultArgon2idParams(), bcrypt.DefaultCost),
+		policy:  DefaultPasswordPolicy(),
 	}
 }
 
+// WithPasswordEncoder
+//
+//	@receiver c
+//	@param encoder
+//	@return *UserDummyClient
+func (c *UserDummyClient) WithPasswordEncoder(encoder *PasswordEncoder) *UserDummyClient {
+	c.encoder = encoder
+	return c
+}
+
+// WithPasswordPolicy
+//
+//	@receiver c
+//	@param policy
+//	@return *UserDummyClient
+func (c *UserDummyClient) WithPasswordPolicy(policy *PasswordPolicy) *UserDummyClient {
+	c.policy = policy
+	return c
+}
+
 func (c *UserDummyClient) GetUser(ctx context.Context, userID string) (*UserDto, error) {
 	if userID == "" {
 		return nil, errors.WithStack(ErrInvalidUserID)
 	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.umap[userID], nil
 }
 
@@ -59,24 +91,62 @@ func (c *UserDummyClient) RegisterUser(ctx context.Context, user *UserDto, passw
 	if user == nil || user.UserID == "" || user.Name == "" {
 		return errors.WithStack(ErrInvalidUserInfo)
 	}
-	if password == "" {
-		return errors.WithStack(ErrInvalidPassword)
+	err := c.policy.Validate(user.UserID, password)
+	if err != nil {
+		return err
+	}
+	hashed, err := c.encoder.Hash(password)
+	if err != nil {
+		return errors.Wrapf(err, "failed to hash password. user_id: %s", user.UserID)
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.umap[user.UserID] = user
-	c.pmap[user.UserID] = encodePasssword(password)
+	c.pmap[user.UserID] = hashed
 	return nil
 }
 
+// VerifyPassword
+// a password verified by an old hash is hashed again by the current parameters
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@param password
+//	@return bool
+//	@return error
 func (c *UserDummyClient) VerifyPassword(ctx context.Context, userID string, password string) (bool, error) {
 	if userID == "" {
 		return false, errors.WithStack(ErrInvalidUserID)
 	}
-	p1 := c.pmap[userID]
-	p2 := encodePasssword(password)
-	return p1 == p2, nil
+	c.mu.RLock()
+	encoded := c.pmap[userID]
+	c.mu.RUnlock()
+	verified, rehash, err := c.encoder.Verify(password, encoded)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to verify password. user_id: %s", userID)
+	}
+	if !verified || !rehash {
+		return verified, nil
+	}
+	hashed, err := c.encoder.Hash(password)
+	if err != nil {
+		// the user still logs in, the hash is replaced next time
+		logger.Error("failed to rehash password. user_id: %s", err, userID)
+		return true, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// another login or register may have replaced it meanwhile
+	if c.pmap[userID] == encoded {
+		c.pmap[userID] = hashed
+		logger.Info("password is rehashed. user_id: %s", userID)
+	}
+	return true, nil
 }
 
 // encodePasssword
+// the unsalted sha256 of the old format, verified by LegacySHA256Hasher
 //
 //	@param password
 //	@return string
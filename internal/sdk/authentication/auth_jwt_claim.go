@@ -1,5 +1,11 @@
 package authentication
 
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 const (
 	JwtHeader       string = "Authorization"
 	JwtHeaderPrefix string = "Bearer "
@@ -28,20 +34,74 @@ func (c *UserContext) DeepCopy() UserContext {
 	}
 }
 
-// AuthJwtClaim struct saves jwt claims.
+// legacyNanoThreshold the exp of old tokens is in nanoseconds, no time in seconds reaches it before year 5000.
+const legacyNanoThreshold int64 = 1e11
+
+// AuthJwtClaim struct saves jwt claims, the times are in seconds as RFC 7519.
 type AuthJwtClaim struct {
 	User      *UserContext `json:"userContext"`
-	Issuer    string       `json:"iss"`
-	Audience  string       `json:"aud"`
-	Subject   string       `json:"sub"`
-	IssuesAt  int64        `json:"issAt"`
+	Issuer    string       `json:"iss,omitempty"`
+	Audience  string       `json:"aud,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+	NotBefore int64        `json:"nbf,omitempty"`
 	ExpiresAt int64        `json:"exp"`
+	// SessionID sid of the session which issued the token
+	SessionID string `json:"sid,omitempty"`
+	// Epoch the epoch of the user when the token was issued, the token is revoked when the epoch is incremented
+	Epoch int64 `json:"epoch,omitempty"`
+	// LegacyIssuesAt issue time in nanoseconds of the tokens issued before RFC 7519 was followed
+	LegacyIssuesAt int64 `json:"issAt,omitempty"`
 }
 
 // Valid necessary function to implement jwt.Claims
 //
 //	@receiver c
-//	@return error always return <value>nil</value>. check detail after claim is returned by jwt package
+//	@return error ErrInvalidClaim, ErrExpiredClaim. issuer and audience are checked by the client
 func (c *AuthJwtClaim) Valid() error {
+	return c.ValidAt(time.Now(), 0)
+}
+
+// ValidAt
+// check the times of the claim
+//
+//	@receiver c
+//	@param now
+//	@param leeway the clock skew allowed between the issuer and the verifier
+//	@return error ErrInvalidClaim, ErrExpiredClaim
+func (c *AuthJwtClaim) ValidAt(now time.Time, leeway time.Duration) error {
+	if c.User == nil || c.ExpiresAt <= 0 {
+		return errors.WithStack(ErrInvalidClaim)
+	}
+	if !now.Before(c.ExpiresTime().Add(leeway)) {
+		return errors.WithStack(ErrExpiredClaim)
+	}
+	if c.NotBefore > 0 && now.Add(leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return errors.Wrapf(ErrInvalidClaim, "not valid before: %d", c.NotBefore)
+	}
+	if c.IssuedAt > 0 && now.Add(leeway).Before(time.Unix(c.IssuedAt, 0)) {
+		return errors.Wrapf(ErrInvalidClaim, "issued in the future: %d", c.IssuedAt)
+	}
 	return nil
 }
+
+// IsLegacy
+// the claim is issued before RFC 7519 was followed, its exp is in nanoseconds
+//
+//	@receiver c
+//	@return bool
+func (c *AuthJwtClaim) IsLegacy() bool {
+	return c.LegacyIssuesAt > 0 || c.ExpiresAt >= legacyNanoThreshold
+}
+
+// ExpiresTime
+//
+//	@receiver c
+//	@return time.Time
+func (c *AuthJwtClaim) ExpiresTime() time.Time {
+	if c.ExpiresAt >= legacyNanoThreshold {
+		return time.Unix(0, c.ExpiresAt)
+	}
+	return time.Unix(c.ExpiresAt, 0)
+}
@@ -11,6 +11,7 @@ type AuthContextKey string
 const (
 	UserContextKey AuthContextKey = "user_context"
 	UserIDKey      AuthContextKey = "user_id"
+	SessionIDKey   AuthContextKey = "session_id"
 	ExpireDuration time.Duration  = time.Minute * 30
 )
 
@@ -42,4 +43,10 @@ type AuthJwtClient interface {
 	//  @return *AuthJwtClaim
 	//  @return error ErrBadClient, ErrInvalidJwt, ErrInvalidClaim, ErrExpiredClaim, ErrBlockedClaim and others
 	Verify(ctx context.Context, tokenStr string) (*AuthJwtClaim, error)
+
+	// JWKS
+	//  @param ctx
+	//  @return *JSONWebKeySet the public keys which verify issued tokens
+	//  @return error
+	JWKS(ctx context.Context) (*JSONWebKeySet, error)
 }
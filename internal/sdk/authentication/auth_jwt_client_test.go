@@ -0,0 +1,193 @@
+package authentication_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+const (
+	testIssuer   string = "test.gocleanlambda"
+	testAudience string = "gocleanlambda"
+)
+
+func newTestJwtClient(ssmClient *SSMMock, clock *testClock) *authentication.AuthJwtDuummyClient {
+	return authentication.NewAuthJwtDummyClient(testPublicKeyParam, testPrivateKeyParam, ssmClient).
+		WithIssuer(testIssuer, testAudience).
+		WithClock(clock.Now)
+}
+
+func newTestClaim() *authentication.AuthJwtClaim {
+	return &authentication.AuthJwtClaim{
+		User: &authentication.UserContext{UserID: "user_1"},
+	}
+}
+
+func TestAuthJwtClientWithIssueReturnStandardClaims(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	client := newTestJwtClient(NewSSMMock(t), clock)
+
+	token, err := client.Issue(context.TODO(), newTestClaim())
+
+	msg := "issued token is not RFC 7519 compliant"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found issue error")
+	parts := strings.Split(token, ".")
+	assertions.Len(parts, 3, msg, "token parts")
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assertions.Nil(err, msg, "invalid payload encoding")
+	claims := map[string]interface{}{}
+	assertions.Nil(json.Unmarshal(payload, &claims), msg, "invalid payload")
+	assertions.Equal(testIssuer, claims["iss"], msg, "iss")
+	assertions.Equal(testAudience, claims["aud"], msg, "aud")
+	assertions.Equal("user_1", claims["sub"], msg, "sub")
+	assertions.NotEmpty(claims["jti"], msg, "jti")
+	assertions.Equal(float64(clock.Now().Unix()), claims["iat"], msg, "iat in seconds")
+	assertions.Equal(float64(clock.Now().Unix()), claims["nbf"], msg, "nbf in seconds")
+	assertions.Equal(float64(clock.Now().Add(authentication.ExpireDuration).Unix()), claims["exp"], msg, "exp in seconds")
+	assertions.NotContains(claims, "issAt", msg, "legacy issAt")
+}
+
+func TestAuthJwtClientWithOtherAudienceReturnInvalidClaim(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	issuer := newTestJwtClient(ssmClient, clock).WithIssuer(testIssuer, "other_app")
+	token, err := issuer.Issue(context.TODO(), newTestClaim())
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+
+	claim, err := newTestJwtClient(ssmClient, clock).Verify(context.TODO(), token)
+
+	msg := "token for other audience is accepted"
+	assertions := assert.New(t)
+	assertions.Nil(claim, msg, "returned claim")
+	assertions.ErrorIs(err, authentication.ErrInvalidClaim, msg, "error type")
+}
+
+func TestAuthJwtClientWithLeewayAcceptSkewedTimes(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	issuerClock := &testClock{now: time.Now()}
+	token, err := newTestJwtClient(ssmClient, issuerClock).Issue(context.TODO(), newTestClaim())
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+	verifierClock := &testClock{now: issuerClock.Now().Add(-10 * time.Second)}
+	verifier := newTestJwtClient(ssmClient, verifierClock).WithLeeway(30 * time.Second)
+
+	_, err1 := verifier.Verify(context.TODO(), token)
+	verifierClock.Add(authentication.ExpireDuration + 30*time.Second)
+	_, err2 := verifier.Verify(context.TODO(), token)
+	verifierClock.Add(20 * time.Second)
+	_, err3 := verifier.Verify(context.TODO(), token)
+
+	msg := "leeway is not applied"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "token issued by a clock ahead is rejected")
+	assertions.Nil(err2, msg, "token expired within leeway is rejected")
+	assertions.ErrorIs(err3, authentication.ErrExpiredClaim, msg, "token expired beyond leeway is accepted")
+}
+
+func TestAuthJwtClientWithLegacyTokenAcceptUntilCutoff(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(ssmClient.Get(testPrivateKeyParam)))
+	if err != nil {
+		t.Fatalf("error happened when parsing private key, %v", err)
+	}
+	// the format issued before RFC 7519 was followed
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"userContext": map[string]interface{}{"userId": "user_1"},
+		"issAt":       clock.Now().UnixNano(),
+		"exp":         clock.Now().Add(authentication.ExpireDuration).UnixNano(),
+	}).SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("error happened when signing legacy jwt, %v", err)
+	}
+
+	cutoff := clock.Now().Add(time.Minute)
+	migrating := newTestJwtClient(ssmClient, clock).WithLegacyTokens(cutoff)
+
+	claim, err1 := migrating.Verify(context.TODO(), token)
+	_, err2 := newTestJwtClient(ssmClient, clock).Verify(context.TODO(), token)
+	clock.Add(time.Minute)
+	_, err3 := migrating.Verify(context.TODO(), token)
+	clock.Add(authentication.ExpireDuration)
+	_, err4 := newTestJwtClient(ssmClient, clock).WithLegacyTokens(clock.Now().Add(time.Hour)).
+		Verify(context.TODO(), token)
+
+	msg := "legacy token is not accepted until cutoff"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found verify error")
+	assertions.Equal("user_1", claim.User.UserID, msg, "user of claim")
+	assertions.True(claim.IsLegacy(), msg, "claim is not legacy")
+	assertions.ErrorIs(err2, authentication.ErrInvalidClaim, msg, "legacy token without cutoff")
+	assertions.ErrorIs(err3, authentication.ErrInvalidClaim, msg, "legacy token after cutoff")
+	assertions.ErrorIs(err4, authentication.ErrExpiredClaim, msg, "expired legacy token")
+}
+
+func TestAuthJwtClientWithRotatedKeysVerifyTokensOfBothKeys(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	oldPublicKey := ssmClient.Get(testPublicKeyParam)
+	oldToken, err := newTestJwtClient(ssmClient, clock).Issue(context.TODO(), newTestClaim())
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+	newPrivateKey, newPublicKey := newTestKeyPair(t)
+	// overlap: both public keys are active, the new key signs
+	ssmClient.Set(testPublicKeyParam, oldPublicKey+newPublicKey)
+	ssmClient.Set(testPrivateKeyParam, newPrivateKey)
+	overlapClient := newTestJwtClient(ssmClient, clock)
+	newToken, err := overlapClient.Issue(context.TODO(), newTestClaim())
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+
+	_, err1 := overlapClient.Verify(context.TODO(), oldToken)
+	_, err2 := overlapClient.Verify(context.TODO(), newToken)
+	jwks, err3 := overlapClient.JWKS(context.TODO())
+	// the old key is retired
+	ssmClient.Set(testPublicKeyParam, newPublicKey)
+	retiredClient := newTestJwtClient(ssmClient, clock)
+	_, err4 := retiredClient.Verify(context.TODO(), oldToken)
+	_, err5 := retiredClient.Verify(context.TODO(), newToken)
+
+	msg := "tokens are not verified during key rotation"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "token of old key during overlap")
+	assertions.Nil(err2, msg, "token of new key during overlap")
+	assertions.Nil(err3, msg, "found jwks error")
+	assertions.Len(jwks.Keys, 2, msg, "published keys during overlap")
+	assertions.Equal(tokenKeyID(t, oldToken), jwks.Keys[0].KeyID, msg, "kid of old key")
+	assertions.Equal(tokenKeyID(t, newToken), jwks.Keys[1].KeyID, msg, "kid of new key")
+	assertions.Equal("RS256", jwks.Keys[1].Algorithm, msg, "alg of published key")
+	assertions.ErrorIs(err4, authentication.ErrInvalidJwt, msg, "token of retired key")
+	assertions.Nil(err5, msg, "token of new key after retirement")
+}
+
+// tokenKeyID
+//
+//	@param t
+//	@param token
+//	@return string kid in the header of the token
+func tokenKeyID(t *testing.T, token string) string {
+	t.Helper()
+	header, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if err != nil {
+		t.Fatalf("error happened when decoding jwt header, %v", err)
+	}
+	values := map[string]string{}
+	err = json.Unmarshal(header, &values)
+	if err != nil {
+		t.Fatalf("error happened when unmarshaling jwt header, %v", err)
+	}
+	return values[authentication.JwtHeaderKeyID]
+}
@@ -4,18 +4,32 @@ import (
 	"context"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
 type AuthJwtDuummyClient struct {
-	publicKeyParam  string
-	privateKeyParam string
-	ssmClient       ssmiface.SSMAPI
-	blockMap        map[string]int64
+	publicKeyParam string
+	keys           KeyProvider
+	blocklist      BlocklistStore
+	// sessions the epochs of the users and the sessions of the tokens are read from it, not checked when it is nil
+	sessions SessionStore
+	// issuer set as iss of issued tokens and required in verified ones, not checked when it is blank
+	issuer string
+	// audience set as aud of issued tokens and required in verified ones, not checked when it is blank
+	audience string
+	// leeway clock skew allowed when the times of a claim are checked
+	leeway time.Duration
+	// legacyUntil old tokens which have no iss and aud, and exp in nanoseconds, are accepted before it, never when
+	// it is the zero time
+	legacyUntil time.Time
+	// algorithm signs issued tokens
+	algorithm string
+	// allowedAlgorithms verified tokens must be signed by one of them
+	allowedAlgorithms []string
+	now               func() time.Time
 }
 
 // NewAuthJwtDummyClient
@@ -30,31 +44,147 @@ func NewAuthJwtDummyClient(
 	ssmClient ssmiface.SSMAPI,
 ) *AuthJwtDuummyClient {
 	return &AuthJwtDuummyClient{
-		publicKeyParam:  publicKeyParam,
-		privateKeyParam: privateKeyParam,
-		ssmClient:       ssmClient,
-		blockMap:        make(map[string]int64),
+		publicKeyParam:    publicKeyParam,
+		keys:              NewSSMKeyProvider(publicKeyParam, privateKeyParam, ssmClient),
+		blocklist:         NewBlocklistMemoryStore(),
+		algorithm:         AlgorithmRS256,
+		allowedAlgorithms: []string{AlgorithmRS256},
+		now:               time.Now,
 	}
 }
 
+// WithAlgorithms
+// the signing key must be of the algorithm
+//
+//	@receiver c
+//	@param algorithm signs issued tokens
+//	@param allowedAlgorithms verified tokens must be signed by one of them, only the algorithm when it is empty
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithAlgorithms(algorithm string, allowedAlgorithms []string) *AuthJwtDuummyClient {
+	c.algorithm = algorithm
+	c.allowedAlgorithms = allowedAlgorithms
+	if len(allowedAlgorithms) == 0 {
+		c.allowedAlgorithms = []string{algorithm}
+	}
+	return c
+}
+
+// WithIssuer
+//
+//	@receiver c
+//	@param issuer
+//	@param audience
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithIssuer(issuer string, audience string) *AuthJwtDuummyClient {
+	c.issuer = issuer
+	c.audience = audience
+	return c
+}
+
+// WithLeeway
+//
+//	@receiver c
+//	@param leeway
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithLeeway(leeway time.Duration) *AuthJwtDuummyClient {
+	c.leeway = leeway
+	return c
+}
+
+// WithLegacyTokens
+// old tokens are rejected by default, accept them during a migration until the last of them expired. they expire in
+// ExpireDuration since they were issued
+//
+//	@receiver c
+//	@param until old tokens are accepted before it
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithLegacyTokens(until time.Time) *AuthJwtDuummyClient {
+	c.legacyUntil = until
+	return c
+}
+
+// WithSessions
+// issued tokens carry the epoch of the user, and verified ones are revoked once it is incremented or once their
+// session is revoked
+//
+//	@receiver c
+//	@param sessions
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithSessions(sessions SessionStore) *AuthJwtDuummyClient {
+	c.sessions = sessions
+	return c
+}
+
+// WithClock
+//
+//	@receiver c
+//	@param now
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithClock(now func() time.Time) *AuthJwtDuummyClient {
+	c.now = now
+	return c
+}
+
+// WithKeyProvider
+// the default provider caches the keys of ssm for DefaultKeyCacheTTL
+//
+//	@receiver c
+//	@param keys
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithKeyProvider(keys KeyProvider) *AuthJwtDuummyClient {
+	c.keys = keys
+	return c
+}
+
+// WithBlocklist
+// blocked tokens are only seen by the process with the default memory store, share a store between instances
+//
+//	@receiver c
+//	@param blocklist
+//	@return *AuthJwtDuummyClient
+func (c *AuthJwtDuummyClient) WithBlocklist(blocklist BlocklistStore) *AuthJwtDuummyClient {
+	c.blocklist = blocklist
+	return c
+}
+
 func (c *AuthJwtDuummyClient) Issue(ctx context.Context, claim *AuthJwtClaim) (string, error) {
 	if claim == nil {
 		return "", errors.WithStack(ErrInvalidClaim)
 	}
-	privateKey, err := c.getSSMParam(ctx, c.privateKeyParam)
+	method, ok := signingMethods[c.algorithm]
+	if !ok {
+		return "", errors.Wrapf(ErrBadClient, "unsupported algorithm: %s", c.algorithm)
+	}
+	signingKey, err := c.keys.SigningKey(ctx)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get private key")
+		return "", errors.Wrap(err, "failed to get signing key")
+	}
+	if signingKey.Algorithm != c.algorithm {
+		return "", errors.Wrapf(
+			ErrBadClient,
+			"signing key is for %s, not %s. kid: %s",
+			signingKey.Algorithm, c.algorithm, signingKey.ID)
+	}
+	if claim.User == nil {
+		return "", errors.Wrap(ErrInvalidClaim, "no user")
 	}
-	rsakey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKey))
+	epoch, err := c.userEpoch(ctx, claim.User.UserID)
 	if err != nil {
-		rootErr := errors.New(err.Error())
-		return "", errors.Wrap(rootErr, "failed to parse private key")
+		return "", err
 	}
-	curr := time.Now()
-	claim.IssuesAt = curr.UnixNano()
-	claim.ExpiresAt = curr.Add(ExpireDuration).UnixNano()
-	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
-	jwt, err := t.SignedString(rsakey)
+	curr := c.now()
+	claim.Issuer = c.issuer
+	claim.Audience = c.audience
+	claim.Subject = claim.User.UserID
+	claim.ID = uuid.New().String()
+	claim.IssuedAt = curr.Unix()
+	claim.NotBefore = curr.Unix()
+	claim.ExpiresAt = curr.Add(ExpireDuration).Unix()
+	claim.LegacyIssuesAt = 0
+	claim.Epoch = epoch
+	t := jwt.NewWithClaims(method, claim)
+	t.Header[JwtHeaderKeyID] = signingKey.ID
+	jwt, err := t.SignedString(signingKey.Key)
 	if err != nil {
 		rootErr := errors.New(err.Error())
 		return "", errors.Wrap(rootErr, "failed to issue jwt")
@@ -66,7 +196,7 @@ func (c *AuthJwtDuummyClient) Verify(ctx context.Context, tokenStr string) (*Aut
 	if len(tokenStr) == 0 {
 		return nil, errors.WithStack(ErrInvalidJwt)
 	}
-	blocked, err := c.isBlocked(tokenStr)
+	blocked, err := c.isBlocked(ctx, tokenStr)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to check block status")
 	}
@@ -77,11 +207,27 @@ func (c *AuthJwtDuummyClient) Verify(ctx context.Context, tokenStr string) (*Aut
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to prase jwt as claim")
 	}
-	if claim == nil || claim.User == nil {
+	if claim == nil {
 		return nil, errors.WithStack(ErrInvalidClaim)
 	}
-	if claim.ExpiresAt <= time.Now().UnixNano() {
-		return nil, errors.WithStack(ErrExpiredClaim)
+	err = claim.ValidAt(c.now(), c.leeway)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid claim. jti: %s", claim.ID)
+	}
+	err = c.checkIssuer(claim, c.now())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid claim. jti: %s", claim.ID)
+	}
+	epoch, err := c.userEpoch(ctx, claim.User.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Epoch < epoch {
+		return nil, errors.Wrapf(ErrBlockedClaim, "revoked by user epoch: %d. jti: %s", epoch, claim.ID)
+	}
+	err = c.checkSession(ctx, claim)
+	if err != nil {
+		return nil, err
 	}
 	return claim, nil
 }
@@ -97,34 +243,132 @@ func (c *AuthJwtDuummyClient) Block(ctx context.Context, tokenStr string) error
 	if claim == nil {
 		return errors.Wrapf(ErrInvalidJwt, "token: %s", tokenStr)
 	}
-	curr := time.Now().UnixNano()
-	if claim.ExpiresAt <= curr {
+	expireAt := claim.ExpiresTime().Add(c.leeway)
+	if !expireAt.After(c.now()) {
 		// already expired, no need to block it
 		return nil
 	}
-	c.blockMap[tokenStr] = claim.ExpiresAt
+	if c.blocklist == nil {
+		return errors.Wrap(ErrBadClient, "no blocklist store found")
+	}
+	err = c.blocklist.Block(ctx, BlocklistKey(tokenStr), expireAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to block token")
+	}
+	return nil
+}
+
+// checkIssuer
+// legacy tokens have no issuer and audience
+//
+//	@receiver c
+//	@param claim
+//	@param curr
+//	@return error ErrInvalidClaim
+func (c *AuthJwtDuummyClient) checkIssuer(claim *AuthJwtClaim, curr time.Time) error {
+	if claim.IsLegacy() {
+		if !curr.Before(c.legacyUntil) {
+			return errors.Wrapf(ErrInvalidClaim, "legacy token is not accepted. until: %s", c.legacyUntil)
+		}
+		return nil
+	}
+	if len(c.issuer) > 0 && claim.Issuer != c.issuer {
+		return errors.Wrapf(ErrInvalidClaim, "unexpected issuer: %s", claim.Issuer)
+	}
+	if len(c.audience) > 0 && claim.Audience != c.audience {
+		return errors.Wrapf(ErrInvalidClaim, "unexpected audience: %s", claim.Audience)
+	}
+	return nil
+}
+
+// userEpoch
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@return int64 0 without the session store
+//	@return error
+func (c *AuthJwtDuummyClient) userEpoch(ctx context.Context, userID string) (int64, error) {
+	if c.sessions == nil {
+		return 0, nil
+	}
+	epoch, err := c.sessions.UserEpoch(ctx, userID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get user epoch. user_id: %s", userID)
+	}
+	return epoch, nil
+}
+
+// checkSession
+// every token of a session is revoked with it, not only the latest one blocked by the session client
+//
+//	@receiver c
+//	@param ctx
+//	@param claim
+//	@return error ErrBlockedClaim when the session is revoked or expired, nil for tokens without a session
+func (c *AuthJwtDuummyClient) checkSession(ctx context.Context, claim *AuthJwtClaim) error {
+	if c.sessions == nil || claim.SessionID == "" {
+		return nil
+	}
+	session, err := c.sessions.Get(ctx, claim.User.UserID, claim.SessionID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get session. user_id: %s, sid: %s", claim.User.UserID, claim.SessionID)
+	}
+	if session == nil {
+		return errors.Wrapf(ErrBlockedClaim, "session is revoked. sid: %s, jti: %s", claim.SessionID, claim.ID)
+	}
 	return nil
 }
 
 // isBlocked
 //
 //	@receiver c
+//	@param ctx
 //	@param tokenStr
 //	@return bool
 //	@return error
-func (c *AuthJwtDuummyClient) isBlocked(tokenStr string) (bool, error) {
+func (c *AuthJwtDuummyClient) isBlocked(ctx context.Context, tokenStr string) (bool, error) {
 	if tokenStr == "" {
 		return false, errors.WithStack(ErrInvalidJwt)
 	}
-	expireAt, ok := c.blockMap[tokenStr]
-	if !ok {
-		return false, nil
+	if c.blocklist == nil {
+		return false, errors.Wrap(ErrBadClient, "no blocklist store found")
 	}
-	curr := time.Now().UnixNano()
-	return expireAt > curr, nil
+	blocked, err := c.blocklist.IsBlocked(ctx, BlocklistKey(tokenStr))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get token from blocklist")
+	}
+	return blocked, nil
+}
+
+// JWKS
+// the public keys which verify the tokens, for the services which verify them by themselves
+//
+//	@receiver c
+//	@param ctx
+//	@return *JSONWebKeySet
+//	@return error
+func (c *AuthJwtDuummyClient) JWKS(ctx context.Context) (*JSONWebKeySet, error) {
+	keys, err := c.keys.VerificationKeys(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get verification keys")
+	}
+	set := &JSONWebKeySet{
+		Keys: make([]*JSONWebKey, 0, len(keys)),
+	}
+	for _, key := range keys {
+		jwk, err := NewJSONWebKey(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to build jwk. kid: %s", key.ID)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
 }
 
 // parseJwt
+// the claim is validated by the client, so that the leeway is applied.
+// the algorithm in the header must be allowed and be the one of the key, the key must never be used by other algorithms
 //
 //	@receiver c
 //	@param ctx
@@ -132,24 +376,49 @@ func (c *AuthJwtDuummyClient) isBlocked(tokenStr string) (bool, error) {
 //	@return *AuthJwtClaim
 //	@return error
 func (c *AuthJwtDuummyClient) parseJwt(ctx context.Context, tokenStr string) (*AuthJwtClaim, error) {
-	publicKey, err := c.getSSMParam(ctx, c.publicKeyParam)
+	keys, err := c.keys.VerificationKeys(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get public key")
+		return nil, errors.Wrap(err, "failed to get verification keys")
+	}
+	parser := &jwt.Parser{
+		ValidMethods:         c.allowedAlgorithms,
+		SkipClaimsValidation: true,
+	}
+	unverified, _, err := parser.ParseUnverified(tokenStr, &AuthJwtClaim{})
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidJwt, "failed to parse token: %s, %s", tokenStr, err.Error())
+	}
+	alg := unverified.Method.Alg()
+	if !c.isAllowedAlgorithm(alg) {
+		return nil, errors.Wrapf(ErrInvalidJwt, "algorithm is not allowed: %s", alg)
+	}
+	kid, _ := unverified.Header[JwtHeaderKeyID].(string)
+	candidates := findVerificationKeys(keys, kid, alg)
+	if len(candidates) == 0 {
+		return nil, errors.Wrapf(ErrInvalidJwt, "no key found. kid: %s, alg: %s", kid, alg)
+	}
+	var token *jwt.Token
+	for _, candidate := range candidates {
+		key := candidate
+		token, err = parser.ParseWithClaims(
+			tokenStr,
+			&AuthJwtClaim{},
+			func(t *jwt.Token) (interface{}, error) {
+				if t.Method.Alg() != key.Algorithm {
+					return nil, errors.Wrapf(ErrInvalidJwt, "key is for %s, not %s", key.Algorithm, t.Method.Alg())
+				}
+				return key.Key, nil
+			},
+		)
+		if err == nil {
+			break
+		}
 	}
-	token, err := jwt.ParseWithClaims(
-		tokenStr,
-		&AuthJwtClaim{},
-		func(t *jwt.Token) (interface{}, error) {
-			//nolint:wrapcheck
-			return jwt.ParseRSAPublicKeyFromPEM([]byte(publicKey))
-		},
-	)
 	if err != nil {
-		rootErr := errors.New(err.Error())
 		return nil, errors.Wrapf(
-			rootErr,
-			"failed to parse token: %s, by public secret key: %s",
-			tokenStr, c.publicKeyParam)
+			ErrInvalidJwt,
+			"failed to parse token: %s, by public key param: %s, kid: %s, %s",
+			tokenStr, c.publicKeyParam, kid, err.Error())
 	}
 	claim, ok := token.Claims.(*AuthJwtClaim)
 	if !ok {
@@ -158,31 +427,36 @@ func (c *AuthJwtDuummyClient) parseJwt(ctx context.Context, tokenStr string) (*A
 	return claim, nil
 }
 
-// getSSMParam
+// isAllowedAlgorithm
 //
 //	@receiver c
-//	@param ctx
-//	@param paramName
-//	@return string
-//	@return error
-func (c *AuthJwtDuummyClient) getSSMParam(ctx context.Context, paramName string) (string, error) {
-	if paramName == "" {
-		return "", errors.Wrapf(ErrInvalidJwt, "invalid key param name: %s", paramName)
-	}
-	if c.ssmClient == nil {
-		return "", errors.Wrap(ErrBadClient, "no ssm client found")
-	}
-	input := &ssm.GetParameterInput{
-		Name:           aws.String(paramName),
-		WithDecryption: aws.Bool(false),
-	}
-	output, err := c.ssmClient.GetParameterWithContext(ctx, input)
-	if err != nil {
-		return "", errors.Wrap(ErrInvalidJwt, err.Error())
+//	@param alg
+//	@return bool
+func (c *AuthJwtDuummyClient) isAllowedAlgorithm(alg string) bool {
+	for _, allowed := range c.allowedAlgorithms {
+		if allowed == alg {
+			return true
+		}
 	}
-	if output == nil || output.Parameter == nil || output.Parameter.Value == nil {
-		return "", errors.Wrap(ErrInvalidJwt, "no ssm param found")
+	return false
+}
+
+// findVerificationKeys
+// tokens issued before kid was added are tried with every key of the algorithm
+//
+//	@param keys
+//	@param kid
+//	@param alg
+//	@return []*VerificationKey
+func findVerificationKeys(keys []*VerificationKey, kid string, alg string) []*VerificationKey {
+	found := []*VerificationKey{}
+	for _, key := range keys {
+		if key.Algorithm != alg {
+			continue
+		}
+		if len(kid) == 0 || key.ID == kid {
+			found = append(found, key)
+		}
 	}
-	value := *output.Parameter.Value
-	return value, nil
+	return found
 }
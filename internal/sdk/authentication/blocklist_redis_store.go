@@ -0,0 +1,103 @@
+package authentication
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// blocklistRedisPrefix prefix of the blocklist keys in redis.
+const blocklistRedisPrefix string = "blocklist:"
+
+// RedisClient
+// the commands of redis used by the blocklist, a go-redis client is adapted to it by a few lines.
+type RedisClient interface {
+	// Set
+	// SET key value PX expiration
+	//  @param ctx
+	//  @param key
+	//  @param value
+	//  @param expiration
+	//  @return error
+	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+
+	// Exists
+	// EXISTS key
+	//  @param ctx
+	//  @param key
+	//  @return bool
+	//  @return error
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// BlocklistRedisStore
+// implements BlocklistStore by redis, the keys are evicted by their expiration.
+type BlocklistRedisStore struct {
+	client RedisClient
+	now    func() time.Time
+}
+
+// NewBlocklistRedisStore
+//
+//	@param client
+//	@return *BlocklistRedisStore
+func NewBlocklistRedisStore(client RedisClient) *BlocklistRedisStore {
+	return &BlocklistRedisStore{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *BlocklistRedisStore
+func (s *BlocklistRedisStore) WithClock(now func() time.Time) *BlocklistRedisStore {
+	s.now = now
+	return s
+}
+
+// Block
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@param expireAt
+//	@return error
+func (s *BlocklistRedisStore) Block(ctx context.Context, key string, expireAt time.Time) error {
+	if s.client == nil {
+		return errors.Wrap(ErrBadClient, "no redis client found")
+	}
+	ttl := expireAt.Sub(s.now())
+	if ttl <= 0 {
+		// already expired, no need to block it
+		return nil
+	}
+	err := s.client.Set(ctx, blocklistRedisPrefix+key, "1", ttl)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "failed to add key in redis: %s", key)
+	}
+	return nil
+}
+
+// IsBlocked
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@return bool
+//	@return error
+func (s *BlocklistRedisStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	if s.client == nil {
+		return false, errors.Wrap(ErrBadClient, "no redis client found")
+	}
+	exists, err := s.client.Exists(ctx, blocklistRedisPrefix+key)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return false, errors.Wrapf(rootErr, "failed to get key from redis: %s", key)
+	}
+	return exists, nil
+}
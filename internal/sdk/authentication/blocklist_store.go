@@ -0,0 +1,128 @@
+package authentication
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// blocklistPurgeInterval expired entries of the memory store are purged at most once in it.
+const blocklistPurgeInterval time.Duration = time.Minute
+
+// BlocklistStore
+// saves blocked tokens until they expire, it must be safe for concurrent use.
+type BlocklistStore interface {
+	// Block
+	//  @param ctx
+	//  @param key
+	//  @param expireAt the key is not blocked after it
+	//  @return error
+	Block(ctx context.Context, key string, expireAt time.Time) error
+
+	// IsBlocked
+	//  @param ctx
+	//  @param key
+	//  @return bool
+	//  @return error
+	IsBlocked(ctx context.Context, key string) (bool, error)
+}
+
+// BlocklistKey
+// tokens are blocked by their hash to keep the keys short
+//
+//	@param tokenStr
+//	@return string
+func BlocklistKey(tokenStr string) string {
+	sum := sha256.Sum256([]byte(tokenStr))
+	return hex.EncodeToString(sum[:])
+}
+
+// BlocklistMemoryStore
+// implements BlocklistStore in memory, it is only shared by the goroutines of a process.
+type BlocklistMemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	nextPurge time.Time
+	now       func() time.Time
+}
+
+// NewBlocklistMemoryStore
+//
+//	@return *BlocklistMemoryStore
+func NewBlocklistMemoryStore() *BlocklistMemoryStore {
+	return &BlocklistMemoryStore{
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *BlocklistMemoryStore
+func (s *BlocklistMemoryStore) WithClock(now func() time.Time) *BlocklistMemoryStore {
+	s.now = now
+	return s
+}
+
+// Block
+// expired entries are purged here, so the map only holds the tokens which are still valid
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@param expireAt
+//	@return error
+func (s *BlocklistMemoryStore) Block(ctx context.Context, key string, expireAt time.Time) error {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !curr.Before(s.nextPurge) {
+		s.purge(curr)
+		s.nextPurge = curr.Add(blocklistPurgeInterval)
+	}
+	if !expireAt.After(curr) {
+		// already expired, no need to block it
+		return nil
+	}
+	s.entries[key] = expireAt
+	return nil
+}
+
+// IsBlocked
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@return bool
+//	@return error
+func (s *BlocklistMemoryStore) IsBlocked(ctx context.Context, key string) (bool, error) {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expireAt, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if !expireAt.After(curr) {
+		delete(s.entries, key)
+		return false, nil
+	}
+	return true, nil
+}
+
+// purge
+// must be called with the lock held
+//
+//	@receiver s
+//	@param curr
+func (s *BlocklistMemoryStore) purge(curr time.Time) {
+	for key, expireAt := range s.entries {
+		if !expireAt.After(curr) {
+			delete(s.entries, key)
+		}
+	}
+}
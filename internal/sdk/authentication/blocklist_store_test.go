@@ -0,0 +1,117 @@
+package authentication_test
+
+import (
+	"context"
+	nativeerr "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestBlocklistMemoryStoreContract(t *testing.T) {
+	repositorytest.RunBlocklistStoreContract(t, func(t *testing.T, now func() time.Time) authentication.BlocklistStore {
+		return authentication.NewBlocklistMemoryStore().WithClock(now)
+	})
+}
+
+func TestBlocklistRedisStoreContract(t *testing.T) {
+	repositorytest.RunBlocklistStoreContract(t, func(t *testing.T, now func() time.Time) authentication.BlocklistStore {
+		return authentication.NewBlocklistRedisStore(NewRedisStandIn(now)).WithClock(now)
+	})
+}
+
+func TestAuthJwtRedisClientWithBlockOnOtherInstanceReturnBlocked(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	redisClient := NewRedisStandIn(time.Now)
+	instance1 := authentication.NewAuthJwtRedisClient(testPublicKeyParam, testPrivateKeyParam, ssmClient, redisClient)
+	instance2 := authentication.NewAuthJwtRedisClient(testPublicKeyParam, testPrivateKeyParam, ssmClient, redisClient)
+	token, err := instance1.Issue(context.TODO(), &authentication.AuthJwtClaim{
+		User: &authentication.UserContext{UserID: "user_1"},
+	})
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+
+	claim, err1 := instance2.Verify(context.TODO(), token)
+	err2 := instance1.Block(context.TODO(), token)
+	_, err3 := instance2.Verify(context.TODO(), token)
+
+	msg := "token blocked by one instance is valid on another"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found verify error")
+	assertions.Equal("user_1", claim.User.UserID, msg, "user of claim")
+	assertions.Nil(err2, msg, "found block error")
+	assertions.True(errors.Is(err3, authentication.ErrBlockedClaim), msg, "error type")
+}
+
+func TestAuthJwtDummyClientWithBrokenBlocklistReturnError(t *testing.T) {
+	client := authentication.NewAuthJwtDummyClient(testPublicKeyParam, testPrivateKeyParam, NewSSMMock(t)).
+		WithBlocklist(authentication.NewBlocklistRedisStore(&RedisStandIn{err: errRedisDown}))
+	token, err := client.Issue(context.TODO(), &authentication.AuthJwtClaim{
+		User: &authentication.UserContext{UserID: "user_1"},
+	})
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+
+	claim, err := client.Verify(context.TODO(), token)
+
+	msg := "token is valid without the blocklist"
+	assertions := assert.New(t)
+	assertions.Nil(claim, msg, "returned claim")
+	assertions.NotNil(err, msg, "no error")
+	assertions.False(errors.Is(err, authentication.ErrBlockedClaim), msg, "error type")
+}
+
+var errRedisDown error = nativeerr.New("redis is down")
+
+// RedisStandIn
+// an in-process redis which implements authentication.RedisClient.
+type RedisStandIn struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+	err     error
+}
+
+func NewRedisStandIn(now func() time.Time) *RedisStandIn {
+	return &RedisStandIn{
+		entries: make(map[string]time.Time),
+		now:     now,
+	}
+}
+
+func (r *RedisStandIn) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	if r.err != nil {
+		return r.err
+	}
+	if expiration <= 0 {
+		return nativeerr.New("ERR invalid expire time in 'set' command")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[key] = r.now().Add(expiration)
+	return nil
+}
+
+func (r *RedisStandIn) Exists(ctx context.Context, key string) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expireAt, ok := r.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if !expireAt.After(r.now()) {
+		delete(r.entries, key)
+		return false, nil
+	}
+	return true, nil
+}
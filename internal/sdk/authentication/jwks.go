@@ -0,0 +1,112 @@
+package authentication
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// JwtHeaderKeyID header of issued tokens which names the key verifying them.
+const JwtHeaderKeyID string = "kid"
+
+// JSONWebKey a public key of RFC 7517.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet keys of the /.well-known/jwks.json.
+type JSONWebKeySet struct {
+	Keys []*JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKey
+//
+//	@param key
+//	@return *JSONWebKey
+//	@return error
+func NewJSONWebKey(key *VerificationKey) (*JSONWebKey, error) {
+	members, err := jwkMembers(key.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONWebKey{
+		KeyType:   members["kty"],
+		Use:       "sig",
+		Algorithm: key.Algorithm,
+		KeyID:     key.ID,
+		Curve:     members["crv"],
+		N:         members["n"],
+		E:         members["e"],
+		X:         members["x"],
+		Y:         members["y"],
+	}, nil
+}
+
+// KeyID
+// the JWK thumbprint of RFC 7638, a key has the same id wherever it is loaded
+//
+//	@param key
+//	@return string
+//	@return error
+func KeyID(key crypto.PublicKey) (string, error) {
+	members, err := jwkMembers(key)
+	if err != nil {
+		return "", err
+	}
+	// maps are marshaled in the lexicographic order of their keys as the thumbprint requires
+	data, err := json.Marshal(members)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return "", errors.Wrap(rootErr, "marshal jwk members error")
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// jwkMembers
+// the required members of a public key
+//
+//	@param key
+//	@return map
+//	@return error ErrInvalidJwt for the keys of no supported algorithm
+func jwkMembers(key crypto.PublicKey) (map[string]string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return map[string]string{
+			"kty": "EC",
+			"crv": k.Curve.Params().Name,
+			"x":   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			"y":   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return map[string]string{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	default:
+		return nil, errors.Wrapf(ErrInvalidJwt, "unsupported key type: %T", key)
+	}
+}
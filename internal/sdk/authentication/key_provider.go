@@ -0,0 +1,314 @@
+package authentication
+
+import (
+	"context"
+	"crypto"
+	"encoding/pem"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	// DefaultKeyCacheTTL keys are refreshed in background after it.
+	DefaultKeyCacheTTL time.Duration = time.Minute * 15
+	// keyRefreshRetryInterval a failed refresh is retried after it, the stale key is served meanwhile.
+	keyRefreshRetryInterval time.Duration = time.Second * 10
+	// keyRefreshTimeout a background refresh doesn't wait ssm longer than it.
+	keyRefreshTimeout time.Duration = time.Second * 5
+)
+
+// SigningKey the key which signs issued tokens, its ID is set as kid in their header.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.Signer
+}
+
+// VerificationKey a key which verifies the tokens of its ID and algorithm.
+type VerificationKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+// KeyProvider
+// provides the keys to issue and verify jwt, it must be safe for concurrent use.
+type KeyProvider interface {
+	// SigningKey
+	//  @param ctx
+	//  @return *SigningKey the current key
+	//  @return error ErrBadClient, ErrInvalidJwt and others
+	SigningKey(ctx context.Context) (*SigningKey, error)
+
+	// VerificationKeys
+	//  @param ctx
+	//  @return []*VerificationKey the keys which are active, the old and the new ones overlap during a rotation
+	//  @return error ErrBadClient, ErrInvalidJwt and others
+	VerificationKeys(ctx context.Context) ([]*VerificationKey, error)
+}
+
+// keyParser parses a pem value of ssm.
+type keyParser func(value string) (interface{}, error)
+
+// cachedKey a parsed key and the state of its refresh.
+type cachedKey struct {
+	key        interface{}
+	fetchedAt  time.Time
+	retryAt    time.Time
+	refreshing bool
+}
+
+// SSMKeyProvider
+// implements KeyProvider by ssm parameters, the parsed keys are cached and refreshed in background.
+// a cached key is served even if ssm is unavailable, only the first fetch of a key waits for ssm.
+// the private key param holds the pem of the signing key, the public key param holds the pem of every active key.
+// the ids of the keys are their thumbprints, and the algorithms are decided by their types.
+type SSMKeyProvider struct {
+	publicKeyParam  string
+	privateKeyParam string
+	ssmClient       ssmiface.SSMAPI
+	ttl             time.Duration
+	now             func() time.Time
+	mu              sync.Mutex
+	keys            map[string]*cachedKey
+}
+
+// NewSSMKeyProvider
+//
+//	@param publicKeyParam
+//	@param privateKeyParam
+//	@param ssmClient
+//	@return *SSMKeyProvider
+func NewSSMKeyProvider(
+	publicKeyParam string,
+	privateKeyParam string,
+	ssmClient ssmiface.SSMAPI,
+) *SSMKeyProvider {
+	return &SSMKeyProvider{
+		publicKeyParam:  publicKeyParam,
+		privateKeyParam: privateKeyParam,
+		ssmClient:       ssmClient,
+		ttl:             DefaultKeyCacheTTL,
+		now:             time.Now,
+		keys:            make(map[string]*cachedKey),
+	}
+}
+
+// WithTTL
+//
+//	@receiver p
+//	@param ttl keys are refreshed in background after it
+//	@return *SSMKeyProvider
+func (p *SSMKeyProvider) WithTTL(ttl time.Duration) *SSMKeyProvider {
+	p.ttl = ttl
+	return p
+}
+
+// WithClock
+//
+//	@receiver p
+//	@param now
+//	@return *SSMKeyProvider
+func (p *SSMKeyProvider) WithClock(now func() time.Time) *SSMKeyProvider {
+	p.now = now
+	return p
+}
+
+// SigningKey
+//
+//	@receiver p
+//	@param ctx
+//	@return *SigningKey
+//	@return error
+func (p *SSMKeyProvider) SigningKey(ctx context.Context) (*SigningKey, error) {
+	key, err := p.get(ctx, p.privateKeyParam, parseSigningKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get private key")
+	}
+	signingKey, ok := key.(*SigningKey)
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidJwt, "not a signing key. param: %s", p.privateKeyParam)
+	}
+	return signingKey, nil
+}
+
+// VerificationKeys
+//
+//	@receiver p
+//	@param ctx
+//	@return []*VerificationKey
+//	@return error
+func (p *SSMKeyProvider) VerificationKeys(ctx context.Context) ([]*VerificationKey, error) {
+	key, err := p.get(ctx, p.publicKeyParam, parseVerificationKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get public keys")
+	}
+	keys, ok := key.([]*VerificationKey)
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidJwt, "not verification keys. param: %s", p.publicKeyParam)
+	}
+	return keys, nil
+}
+
+// get
+// serve the cached key and refresh it in background when it is older than the ttl
+//
+//	@receiver p
+//	@param ctx
+//	@param paramName
+//	@param parse
+//	@return interface{}
+//	@return error
+func (p *SSMKeyProvider) get(ctx context.Context, paramName string, parse keyParser) (interface{}, error) {
+	p.mu.Lock()
+	cached, ok := p.keys[paramName]
+	if ok {
+		curr := p.now()
+		if !cached.refreshing && curr.Sub(cached.fetchedAt) >= p.ttl && !curr.Before(cached.retryAt) {
+			cached.refreshing = true
+			go p.refresh(paramName, parse)
+		}
+		key := cached.key
+		p.mu.Unlock()
+		return key, nil
+	}
+	p.mu.Unlock()
+	// concurrent first calls may fetch the same key, the last one is kept
+	key, err := p.fetch(ctx, paramName, parse)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[paramName] = &cachedKey{
+		key:       key,
+		fetchedAt: p.now(),
+	}
+	return key, nil
+}
+
+// refresh
+// runs in background, the stale key is kept when it fails
+//
+//	@receiver p
+//	@param paramName
+//	@param parse
+func (p *SSMKeyProvider) refresh(paramName string, parse keyParser) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyRefreshTimeout)
+	defer cancel()
+	key, err := p.fetch(ctx, paramName, parse)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cached := p.keys[paramName]
+	cached.refreshing = false
+	if err != nil {
+		cached.retryAt = p.now().Add(keyRefreshRetryInterval)
+		logger.Warn("failed to refresh key, serve the stale one. param: %s, error: %s", paramName, err.Error())
+		return
+	}
+	cached.key = key
+	cached.fetchedAt = p.now()
+	logger.Debug("key refreshed. param: %s", paramName)
+}
+
+// fetch
+//
+//	@receiver p
+//	@param ctx
+//	@param paramName
+//	@param parse
+//	@return interface{}
+//	@return error
+func (p *SSMKeyProvider) fetch(ctx context.Context, paramName string, parse keyParser) (interface{}, error) {
+	if paramName == "" {
+		return nil, errors.Wrapf(ErrInvalidJwt, "invalid key param name: %s", paramName)
+	}
+	if p.ssmClient == nil {
+		return nil, errors.Wrap(ErrBadClient, "no ssm client found")
+	}
+	input := &ssm.GetParameterInput{
+		Name:           aws.String(paramName),
+		WithDecryption: aws.Bool(true),
+	}
+	output, err := p.ssmClient.GetParameterWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidJwt, err.Error())
+	}
+	if output == nil || output.Parameter == nil || output.Parameter.Value == nil {
+		return nil, errors.Wrap(ErrInvalidJwt, "no ssm param found")
+	}
+	key, err := parse(*output.Parameter.Value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse key of param: %s", paramName)
+	}
+	return key, nil
+}
+
+// parseSigningKey
+//
+//	@param value pem of a private key
+//	@return interface{} *SigningKey
+//	@return error
+func parseSigningKey(value string) (interface{}, error) {
+	key, err := parsePrivateKeyPEM([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+	alg, err := publicKeyAlgorithm(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	id, err := KeyID(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{
+		ID:        id,
+		Algorithm: alg,
+		Key:       key,
+	}, nil
+}
+
+// parseVerificationKeys
+//
+//	@param value pem blocks of public keys
+//	@return interface{} []*VerificationKey
+//	@return error
+func parseVerificationKeys(value string) (interface{}, error) {
+	keys := []*VerificationKey{}
+	rest := []byte(value)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		key, err := parsePublicKeyBlock(block)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid public key at %d", len(keys))
+		}
+		alg, err := publicKeyAlgorithm(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid public key at %d", len(keys))
+		}
+		id, err := KeyID(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid public key at %d", len(keys))
+		}
+		keys = append(keys, &VerificationKey{
+			ID:        id,
+			Algorithm: alg,
+			Key:       key,
+		})
+	}
+	if len(keys) == 0 {
+		return nil, errors.Wrap(ErrInvalidJwt, "no public key found")
+	}
+	return keys, nil
+}
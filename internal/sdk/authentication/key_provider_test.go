@@ -0,0 +1,236 @@
+package authentication_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	nativeerr "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+const (
+	testPublicKeyParam  string = "public_key"
+	testPrivateKeyParam string = "private_key"
+)
+
+var errSSMThrottled error = nativeerr.New("ThrottlingException: rate exceeded")
+
+func TestSSMKeyProviderWithCachedKeyFetchOnce(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	p := authentication.NewSSMKeyProvider(testPublicKeyParam, testPrivateKeyParam, ssmClient)
+
+	keys1, err1 := p.VerificationKeys(context.TODO())
+	keys2, err2 := p.VerificationKeys(context.TODO())
+	signingKey, err3 := p.SigningKey(context.TODO())
+
+	msg := "cached key is fetched again"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found first error")
+	assertions.Nil(err2, msg, "found second error")
+	assertions.Nil(err3, msg, "found private key error")
+	assertions.Len(keys1, 1, msg, "verification keys")
+	assertions.Same(keys1[0], keys2[0], msg, "key is parsed again")
+	assertions.Equal(signingKey.Key.Public(), keys1[0].Key, msg, "key pair")
+	assertions.Equal(signingKey.ID, keys1[0].ID, msg, "key id")
+	assertions.Equal(2, ssmClient.Calls(), msg, "ssm calls")
+	assertions.True(ssmClient.decrypted, msg, "fetched without decryption")
+}
+
+func TestSSMKeyProviderWithExpiredKeyRefreshInBackground(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	p := authentication.NewSSMKeyProvider(testPublicKeyParam, testPrivateKeyParam, ssmClient).
+		WithTTL(time.Minute).
+		WithClock(clock.Now)
+	stale, err := p.VerificationKeys(context.TODO())
+	if err != nil {
+		t.Fatalf("error happened when fetching key, %v", err)
+	}
+	ssmClient.Rotate(t)
+
+	clock.Add(time.Minute)
+	served, err1 := p.VerificationKeys(context.TODO())
+	refreshed := assert.Eventually(t, func() bool {
+		keys, _ := p.VerificationKeys(context.TODO())
+		return keys[0].ID != stale[0].ID
+	}, time.Second, time.Millisecond)
+
+	msg := "expired key is not refreshed in background"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found error")
+	assertions.Same(stale[0], served[0], msg, "stale key is not served while refreshing")
+	assertions.True(refreshed, msg, "key is not refreshed")
+	assertions.Equal(2, ssmClient.Calls(), msg, "ssm calls")
+}
+
+func TestSSMKeyProviderWithUnavailableSSMServeStaleKey(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	p := authentication.NewSSMKeyProvider(testPublicKeyParam, testPrivateKeyParam, ssmClient).
+		WithTTL(time.Minute).
+		WithClock(clock.Now)
+	stale, err := p.VerificationKeys(context.TODO())
+	if err != nil {
+		t.Fatalf("error happened when fetching key, %v", err)
+	}
+	ssmClient.SetError(errSSMThrottled)
+
+	clock.Add(time.Minute)
+	_, _ = p.VerificationKeys(context.TODO())
+	attempted := assert.Eventually(t, func() bool {
+		return ssmClient.Calls() == 2
+	}, time.Second, time.Millisecond)
+	served, err1 := p.VerificationKeys(context.TODO())
+	served2, err2 := p.VerificationKeys(context.TODO())
+
+	msg := "stale key is not served when ssm is unavailable"
+	assertions := assert.New(t)
+	assertions.True(attempted, msg, "refresh is not attempted")
+	assertions.Nil(err1, msg, "found error")
+	assertions.Nil(err2, msg, "found second error")
+	assertions.Same(stale[0], served[0], msg, "served key")
+	assertions.Same(stale[0], served2[0], msg, "second served key")
+	assertions.Equal(2, ssmClient.Calls(), msg, "failed refresh is retried at once")
+}
+
+func TestSSMKeyProviderWithoutCachedKeyReturnError(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	ssmClient.SetError(errSSMThrottled)
+	p := authentication.NewSSMKeyProvider(testPublicKeyParam, testPrivateKeyParam, ssmClient)
+
+	key, err := p.SigningKey(context.TODO())
+
+	msg := "key is returned without ssm"
+	assertions := assert.New(t)
+	assertions.Nil(key, msg, "returned key")
+	assertions.ErrorIs(err, authentication.ErrInvalidJwt, msg, "error type")
+}
+
+// testClock a manual clock.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SSMMock
+// implements ssmiface.SSMAPI with a generated rsa key pair.
+type SSMMock struct {
+	ssmiface.SSMAPI
+	mu        sync.Mutex
+	store     map[string]string
+	calls     int
+	decrypted bool
+	err       error
+}
+
+func NewSSMMock(t *testing.T) *SSMMock {
+	t.Helper()
+	s := &SSMMock{
+		store: make(map[string]string),
+	}
+	s.Rotate(t)
+	return s
+}
+
+// Rotate
+// replace the key pair by a new one.
+func (s *SSMMock) Rotate(t *testing.T) {
+	t.Helper()
+	privateKey, publicKey := newTestKeyPair(t)
+	s.Set(testPrivateKeyParam, privateKey)
+	s.Set(testPublicKeyParam, publicKey)
+}
+
+func (s *SSMMock) Set(name string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store[name] = value
+}
+
+func (s *SSMMock) Get(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store[name]
+}
+
+func (s *SSMMock) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *SSMMock) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *SSMMock) GetParameterWithContext(
+	ctx context.Context, input *ssm.GetParameterInput, opts ...request.Option,
+) (*ssm.GetParameterOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	s.decrypted = aws.BoolValue(input.WithDecryption)
+	if s.err != nil {
+		return nil, s.err
+	}
+	val, ok := s.store[*input.Name]
+	if !ok {
+		return nil, nativeerr.New("ParameterNotFound")
+	}
+	return &ssm.GetParameterOutput{
+		Parameter: &ssm.Parameter{
+			Value: aws.String(val),
+		},
+	}, nil
+}
+
+// newTestKeyPair
+//
+//	@param t
+//	@return string pem of the private key
+//	@return string pem of the public key
+func newTestKeyPair(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error happened when generating rsa key, %v", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error happened when marshaling rsa public key, %v", err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	publicPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKey,
+	})
+	return string(privatePEM), string(publicPEM)
+}
@@ -0,0 +1,460 @@
+package authentication
+
+import (
+	"context"
+	nativeerr "errors"
+	"time"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	loginAttemptUserKeyPrefix string = "user#"
+	loginAttemptIPKeyPrefix   string = "ip#"
+
+	LoginEventLocked    string = "login_locked"
+	LoginEventIPBlocked string = "login_ip_blocked"
+)
+
+var (
+	ErrLoginLocked    error = nativeerr.New("login is locked")
+	ErrLoginThrottled error = nativeerr.New("login is throttled")
+)
+
+// LoginAttempts
+// the failed logins of a user or of an ip, they are forgotten at ExpiresAt.
+type LoginAttempts struct {
+	Key          string    `json:"key"`
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"lastFailedAt"`
+	// PreviousFailedAt LastFailedAt before the latest failure, the zero time when it is the first one
+	PreviousFailedAt time.Time `json:"previousFailedAt"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+}
+
+// LoginAttemptStore
+// counts the failed logins by keys until they expire. it must be safe for concurrent use.
+type LoginAttemptStore interface {
+	// RecordFailure
+	// increment the failures of the key atomically, the count restarts from 1 when the record has expired
+	//  @param ctx
+	//  @param key
+	//  @param expiresAt the record is forgotten at it
+	//  @return *LoginAttempts the record after the increment, with the time of the failure before it
+	//  @return error
+	RecordFailure(ctx context.Context, key string, expiresAt time.Time) (*LoginAttempts, error)
+
+	// Get
+	//  @param ctx
+	//  @param key
+	//  @return *LoginAttempts nil when it is not found or expired
+	//  @return error
+	Get(ctx context.Context, key string) (*LoginAttempts, error)
+
+	// Reset
+	//  @param ctx
+	//  @param key
+	//  @return error
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginAttemptPolicy
+// a threshold of 0 disables its rule.
+type LoginAttemptPolicy struct {
+	// DelayThreshold failures of a user before the logins of it are delayed
+	DelayThreshold int
+	// BaseDelay the first delay, it doubles on each further failure
+	BaseDelay time.Duration
+	// MaxDelay the delay stops doubling at it
+	MaxDelay time.Duration
+	// LockoutThreshold failures of a user before it is locked out
+	LockoutThreshold int
+	// LockoutDuration the user is unlocked after it since the last failure
+	LockoutDuration time.Duration
+	// IPThreshold attempts from an ip, of any users and successful or not, before the logins from it are blocked
+	// until the window ends
+	IPThreshold int
+	// Window the failures are forgotten after it since the last failure
+	Window time.Duration
+}
+
+// DefaultLoginAttemptPolicy
+//
+//	@return *LoginAttemptPolicy
+func DefaultLoginAttemptPolicy() *LoginAttemptPolicy {
+	return &LoginAttemptPolicy{
+		DelayThreshold:   3,
+		BaseDelay:        time.Second,
+		MaxDelay:         30 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		IPThreshold:      50,
+		Window:           15 * time.Minute,
+	}
+}
+
+// delay
+//
+//	@receiver p
+//	@param failures
+//	@return time.Duration the delay before the next login of a user, 0 when it is not delayed
+func (p *LoginAttemptPolicy) delay(failures int) time.Duration {
+	if p.DelayThreshold <= 0 || failures < p.DelayThreshold {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.DelayThreshold; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// LoginAuditEvent
+// a lockout of a user or a block of an ip.
+type LoginAuditEvent struct {
+	Type     string    `json:"type"`
+	UserID   string    `json:"userId,omitempty"`
+	IP       string    `json:"ip,omitempty"`
+	Failures int       `json:"failures"`
+	Until    time.Time `json:"until"`
+	At       time.Time `json:"at"`
+}
+
+type LoginAuditor interface {
+	// Audit
+	// it must not fail the login
+	//  @param ctx
+	//  @param event
+	Audit(ctx context.Context, event *LoginAuditEvent)
+}
+
+// LoginLogAuditor
+// implements LoginAuditor by the log.
+type LoginLogAuditor struct{}
+
+// Audit
+//
+//	@receiver a
+//	@param ctx
+//	@param event
+func (a *LoginLogAuditor) Audit(ctx context.Context, event *LoginAuditEvent) {
+	logger.Warn("login audit event: %s", logger.Pretty(event))
+}
+
+// LoginAttempt
+// a login let through by LoginGuard.Attempt, it is counted as a failure of the user until it succeeds.
+type LoginAttempt struct {
+	UserID string
+	IP     string
+	// UserAttempts the failures of the user counting the attempt, nil without a user id
+	UserAttempts *LoginAttempts
+}
+
+type LoginGuard interface {
+	// Attempt
+	// call it before the password is verified, the attempt is counted at once so that the logins in parallel
+	// can not pass the limits together
+	//  @param ctx
+	//  @param userID
+	//  @param ip
+	//  @return *LoginAttempt
+	//  @return time.Duration the time to wait before retrying when it is rejected
+	//  @return error ErrLoginLocked, ErrLoginThrottled and others
+	Attempt(ctx context.Context, userID string, ip string) (*LoginAttempt, time.Duration, error)
+
+	// Fail
+	// call it when the password is wrong
+	//  @param ctx
+	//  @param attempt
+	//  @return error
+	Fail(ctx context.Context, attempt *LoginAttempt) error
+
+	// Succeed
+	// call it when the password is verified, the failures of the user are forgotten
+	//  @param ctx
+	//  @param attempt
+	//  @return error
+	Succeed(ctx context.Context, attempt *LoginAttempt) error
+}
+
+// LoginAttemptGuard
+// implements LoginGuard by a LoginAttemptStore, the failures are counted by user and by ip.
+// the users are delayed progressively and then locked out, the ips are blocked. all of them are released
+// automatically when their failures expire.
+type LoginAttemptGuard struct {
+	store   LoginAttemptStore
+	policy  *LoginAttemptPolicy
+	auditor LoginAuditor
+	now     func() time.Time
+}
+
+// NewLoginAttemptGuard
+//
+//	@param store
+//	@param policy
+//	@return *LoginAttemptGuard
+func NewLoginAttemptGuard(store LoginAttemptStore, policy *LoginAttemptPolicy) *LoginAttemptGuard {
+	return &LoginAttemptGuard{
+		store:   store,
+		policy:  policy,
+		auditor: &LoginLogAuditor{},
+		now:     time.Now,
+	}
+}
+
+// WithAuditor
+//
+//	@receiver g
+//	@param auditor
+//	@return *LoginAttemptGuard
+func (g *LoginAttemptGuard) WithAuditor(auditor LoginAuditor) *LoginAttemptGuard {
+	g.auditor = auditor
+	return g
+}
+
+// WithClock
+//
+//	@receiver g
+//	@param now
+//	@return *LoginAttemptGuard
+func (g *LoginAttemptGuard) WithClock(now func() time.Time) *LoginAttemptGuard {
+	g.now = now
+	return g
+}
+
+// Attempt
+// a login rejected by the recorded failures is not counted, it must not postpone the release. the logins passing
+// that check together are decided one by one by the failures counted before each of them, the ip first.
+//
+//	@receiver g
+//	@param ctx
+//	@param userID
+//	@param ip
+//	@return *LoginAttempt
+//	@return time.Duration
+//	@return error
+func (g *LoginAttemptGuard) Attempt(
+	ctx context.Context,
+	userID string,
+	ip string,
+) (*LoginAttempt, time.Duration, error) {
+	curr := g.now()
+	retryAfter, err := g.check(ctx, userID, ip, curr)
+	if err != nil {
+		return nil, retryAfter, err
+	}
+	retryAfter, err = g.attemptIP(ctx, userID, ip, curr)
+	if err != nil {
+		return nil, retryAfter, err
+	}
+	attempt := &LoginAttempt{UserID: userID, IP: ip}
+	if userID == "" {
+		return attempt, 0, nil
+	}
+	attempts, err := g.store.RecordFailure(ctx, LoginAttemptUserKey(userID), curr.Add(g.userWindow()))
+	if err != nil {
+		return nil, 0, err
+	}
+	_, err = g.rejectUser(userID, attempts.Failures-1, attempts.PreviousFailedAt, curr)
+	if err != nil {
+		// the failure counted meanwhile postpones the release
+		if wait, current := g.rejectUser(userID, attempts.Failures, attempts.LastFailedAt, curr); current != nil {
+			return nil, wait, current
+		}
+		return nil, 0, err
+	}
+	attempt.UserAttempts = attempts
+	return attempt, 0, nil
+}
+
+// Fail
+// the failure is counted by Attempt, every lockout of the user is audited when it starts
+//
+//	@receiver g
+//	@param ctx
+//	@param attempt
+//	@return error
+func (g *LoginAttemptGuard) Fail(ctx context.Context, attempt *LoginAttempt) error {
+	if attempt == nil {
+		return nil
+	}
+	curr := g.now()
+	if attempts := attempt.UserAttempts; attempts != nil && g.policy.LockoutThreshold > 0 {
+		// the failures keep counting above the threshold after a lockout shorter than the window, the lockout
+		// starts again unless the user was still locked out before the failure
+		wasLocked := attempts.Failures-1 >= g.policy.LockoutThreshold &&
+			attempts.PreviousFailedAt.Add(g.policy.LockoutDuration).After(attempts.LastFailedAt)
+		if attempts.Failures >= g.policy.LockoutThreshold && !wasLocked {
+			g.auditor.Audit(ctx, &LoginAuditEvent{
+				Type:     LoginEventLocked,
+				UserID:   attempt.UserID,
+				IP:       attempt.IP,
+				Failures: attempts.Failures,
+				Until:    g.lockedUntil(attempts),
+				At:       curr,
+			})
+		}
+	}
+	return nil
+}
+
+// Succeed
+// the attempts of the ip are kept, a valid account must not unblock the guesses on the others
+//
+//	@receiver g
+//	@param ctx
+//	@param attempt
+//	@return error
+func (g *LoginAttemptGuard) Succeed(ctx context.Context, attempt *LoginAttempt) error {
+	if attempt == nil || attempt.UserID == "" {
+		return nil
+	}
+	return g.store.Reset(ctx, LoginAttemptUserKey(attempt.UserID))
+}
+
+// attemptIP
+// count the attempt of the ip, the block is audited when the count reaches the threshold as it lasts as long as
+// the attempts
+//
+//	@receiver g
+//	@param ctx
+//	@param userID
+//	@param ip
+//	@param curr
+//	@return time.Duration
+//	@return error ErrLoginThrottled when the attempts counted meanwhile reached the threshold
+func (g *LoginAttemptGuard) attemptIP(
+	ctx context.Context,
+	userID string,
+	ip string,
+	curr time.Time,
+) (time.Duration, error) {
+	if ip == "" || g.policy.IPThreshold <= 0 {
+		return 0, nil
+	}
+	attempts, err := g.store.RecordFailure(ctx, LoginAttemptIPKey(ip), curr.Add(g.policy.Window))
+	if err != nil {
+		return 0, err
+	}
+	if attempts.Failures > g.policy.IPThreshold {
+		return attempts.ExpiresAt.Sub(curr), errors.Wrapf(ErrLoginThrottled, "ip: %s, until: %s", ip,
+			attempts.ExpiresAt)
+	}
+	if attempts.Failures == g.policy.IPThreshold {
+		g.auditor.Audit(ctx, &LoginAuditEvent{
+			Type:     LoginEventIPBlocked,
+			UserID:   userID,
+			IP:       ip,
+			Failures: attempts.Failures,
+			Until:    attempts.ExpiresAt,
+			At:       curr,
+		})
+	}
+	return 0, nil
+}
+
+// check
+//
+//	@receiver g
+//	@param ctx
+//	@param userID
+//	@param ip
+//	@param curr
+//	@return time.Duration
+//	@return error
+func (g *LoginAttemptGuard) check(
+	ctx context.Context,
+	userID string,
+	ip string,
+	curr time.Time,
+) (time.Duration, error) {
+	if userID != "" {
+		attempts, err := g.store.Get(ctx, LoginAttemptUserKey(userID))
+		if err != nil {
+			return 0, err
+		}
+		if attempts != nil {
+			if retryAfter, rejected := g.rejectUser(userID, attempts.Failures, attempts.LastFailedAt, curr); rejected != nil {
+				return retryAfter, rejected
+			}
+		}
+	}
+	if ip != "" && g.policy.IPThreshold > 0 {
+		attempts, err := g.store.Get(ctx, LoginAttemptIPKey(ip))
+		if err != nil {
+			return 0, err
+		}
+		if attempts != nil && attempts.Failures >= g.policy.IPThreshold && attempts.ExpiresAt.After(curr) {
+			return attempts.ExpiresAt.Sub(curr), errors.Wrapf(ErrLoginThrottled, "ip: %s, until: %s", ip,
+				attempts.ExpiresAt)
+		}
+	}
+	return 0, nil
+}
+
+// rejectUser
+//
+//	@receiver g
+//	@param userID
+//	@param failures of the user
+//	@param lastFailedAt of the failures
+//	@param curr
+//	@return time.Duration
+//	@return error ErrLoginLocked, ErrLoginThrottled, nil when the user may login
+func (g *LoginAttemptGuard) rejectUser(
+	userID string,
+	failures int,
+	lastFailedAt time.Time,
+	curr time.Time,
+) (time.Duration, error) {
+	if until := g.lockedUntil(&LoginAttempts{Failures: failures, LastFailedAt: lastFailedAt}); until.After(curr) {
+		return until.Sub(curr), errors.Wrapf(ErrLoginLocked, "user_id: %s, until: %s", userID, until)
+	}
+	if until := lastFailedAt.Add(g.policy.delay(failures)); until.After(curr) {
+		return until.Sub(curr), errors.Wrapf(ErrLoginThrottled, "user_id: %s, until: %s", userID, until)
+	}
+	return 0, nil
+}
+
+// userWindow
+//
+//	@receiver g
+//	@return time.Duration the failures of a user are kept for it, at least as long as the lockout
+func (g *LoginAttemptGuard) userWindow() time.Duration {
+	if g.policy.LockoutDuration > g.policy.Window {
+		return g.policy.LockoutDuration
+	}
+	return g.policy.Window
+}
+
+// lockedUntil
+//
+//	@receiver g
+//	@param attempts
+//	@return time.Time the zero time when the user is not locked out
+func (g *LoginAttemptGuard) lockedUntil(attempts *LoginAttempts) time.Time {
+	if g.policy.LockoutThreshold <= 0 || attempts.Failures < g.policy.LockoutThreshold {
+		return time.Time{}
+	}
+	return attempts.LastFailedAt.Add(g.policy.LockoutDuration)
+}
+
+// LoginAttemptUserKey
+//
+//	@param userID
+//	@return string
+func LoginAttemptUserKey(userID string) string {
+	return loginAttemptUserKeyPrefix + userID
+}
+
+// LoginAttemptIPKey
+//
+//	@param ip
+//	@return string
+func LoginAttemptIPKey(ip string) string {
+	return loginAttemptIPKeyPrefix + ip
+}
@@ -0,0 +1,115 @@
+package authentication
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LoginAttemptMemoryStore
+// implements LoginAttemptStore in memory, it is only shared by the goroutines of a process.
+type LoginAttemptMemoryStore struct {
+	mu        sync.Mutex
+	attempts  map[string]*LoginAttempts
+	nextPurge time.Time
+	now       func() time.Time
+}
+
+// NewLoginAttemptMemoryStore
+//
+//	@return *LoginAttemptMemoryStore
+func NewLoginAttemptMemoryStore() *LoginAttemptMemoryStore {
+	return &LoginAttemptMemoryStore{
+		attempts: make(map[string]*LoginAttempts),
+		now:      time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *LoginAttemptMemoryStore
+func (s *LoginAttemptMemoryStore) WithClock(now func() time.Time) *LoginAttemptMemoryStore {
+	s.now = now
+	return s
+}
+
+// RecordFailure
+// the expired records are purged here, at most once in blocklistPurgeInterval
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@param expiresAt
+//	@return *LoginAttempts
+//	@return error
+func (s *LoginAttemptMemoryStore) RecordFailure(
+	ctx context.Context,
+	key string,
+	expiresAt time.Time,
+) (*LoginAttempts, error) {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !curr.Before(s.nextPurge) {
+		s.purge(curr)
+		s.nextPurge = curr.Add(blocklistPurgeInterval)
+	}
+	attempts, ok := s.attempts[key]
+	if !ok || !attempts.ExpiresAt.After(curr) {
+		attempts = &LoginAttempts{Key: key}
+		s.attempts[key] = attempts
+	}
+	attempts.Failures++
+	attempts.PreviousFailedAt = attempts.LastFailedAt
+	attempts.LastFailedAt = curr
+	attempts.ExpiresAt = expiresAt
+	saved := *attempts
+	return &saved, nil
+}
+
+// Get
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@return *LoginAttempts a copy of the saved one
+//	@return error
+func (s *LoginAttemptMemoryStore) Get(ctx context.Context, key string) (*LoginAttempts, error) {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.attempts[key]
+	if !ok || !attempts.ExpiresAt.After(curr) {
+		return nil, nil
+	}
+	saved := *attempts
+	return &saved, nil
+}
+
+// Reset
+//
+//	@receiver s
+//	@param ctx
+//	@param key
+//	@return error
+func (s *LoginAttemptMemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// purge
+// must be called with the lock held
+//
+//	@receiver s
+//	@param curr
+func (s *LoginAttemptMemoryStore) purge(curr time.Time) {
+	for key, attempts := range s.attempts {
+		if !attempts.ExpiresAt.After(curr) {
+			delete(s.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package authentication_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestLoginAttemptMemoryStoreContract(t *testing.T) {
+	repositorytest.RunLoginAttemptStoreContract(t,
+		func(t *testing.T, now func() time.Time) authentication.LoginAttemptStore {
+			return authentication.NewLoginAttemptMemoryStore().WithClock(now)
+		})
+}
+
+func TestLoginAttemptGuardWithFailuresDelayThenLockUser(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	auditor := &loginAuditorStub{}
+	guard := authentication.NewLoginAttemptGuard(
+		authentication.NewLoginAttemptMemoryStore().WithClock(clock.Now),
+		&authentication.LoginAttemptPolicy{
+			DelayThreshold:   2,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Minute,
+			LockoutThreshold: 4,
+			LockoutDuration:  10 * time.Minute,
+			Window:           15 * time.Minute,
+		}).WithAuditor(auditor).WithClock(clock.Now)
+	// login with a wrong password
+	login := func(userID string) (time.Duration, error) {
+		attempt, retryAfter, err := guard.Attempt(context.TODO(), userID, "192.0.2.1")
+		if err != nil {
+			return retryAfter, err
+		}
+		err = guard.Fail(context.TODO(), attempt)
+		if err != nil {
+			t.Fatalf("error happened when failing login, %v", err)
+		}
+		return 0, nil
+	}
+
+	_, err1 := login("user_1")
+	_, err2 := login("user_1")
+	retry3, err3 := login("user_1")
+	clock.Add(time.Second)
+	_, err4 := login("user_1")
+	retry5, err5 := login("user_1")
+	clock.Add(2 * time.Second)
+	_, err6 := login("user_1")
+	retry7, err7 := login("user_1")
+	_, err8 := login("user_2")
+	clock.Add(10 * time.Minute)
+	_, err9 := login("user_1")
+
+	msg := "failed logins of user are not delayed and locked"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "first login is rejected")
+	assertions.Nil(err2, msg, "login below delay threshold is rejected")
+	assertions.True(errors.Is(err3, authentication.ErrLoginThrottled), msg, "login after second failure is not delayed")
+	assertions.Equal(time.Second, retry3, msg, "retry after second failure")
+	assertions.Nil(err4, msg, "login after delay is rejected")
+	assertions.True(errors.Is(err5, authentication.ErrLoginThrottled), msg, "login after third failure is not delayed")
+	assertions.Equal(2*time.Second, retry5, msg, "retry after third failure")
+	assertions.Nil(err6, msg, "login after doubled delay is rejected")
+	assertions.True(errors.Is(err7, authentication.ErrLoginLocked), msg, "login after fourth failure is not locked")
+	assertions.Equal(10*time.Minute, retry7, msg, "retry of lockout")
+	assertions.Nil(err8, msg, "other user is locked")
+	assertions.Nil(err9, msg, "user is not unlocked after lockout duration")
+	assertions.Len(auditor.events, 2, msg, "audit events of lockout and lockout again")
+	for _, event := range auditor.events {
+		assertions.Equal(authentication.LoginEventLocked, event.Type, msg, "type of audit event")
+		assertions.Equal("user_1", event.UserID, msg, "user of audit event")
+	}
+	assertions.Equal(4, auditor.events[0].Failures, msg, "failures of first lockout")
+	assertions.Equal(5, auditor.events[1].Failures, msg, "failures of lockout again")
+}
+
+func TestLoginAttemptGuardWithParallelAttemptsLetThroughThreshold(t *testing.T) {
+	guard := authentication.NewLoginAttemptGuard(authentication.NewLoginAttemptMemoryStore(),
+		&authentication.LoginAttemptPolicy{
+			LockoutThreshold: 3,
+			LockoutDuration:  10 * time.Minute,
+			Window:           15 * time.Minute,
+		}).WithAuditor(&loginAuditorStub{})
+	count := 20
+	attempts := make(chan *authentication.LoginAttempt, count)
+	errs := make(chan error, count)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt, _, err := guard.Attempt(context.TODO(), "user_1", "192.0.2.1")
+			if err != nil {
+				errs <- err
+				return
+			}
+			attempts <- attempt
+		}()
+	}
+	wg.Wait()
+	close(attempts)
+	close(errs)
+	passed := []*authentication.LoginAttempt{}
+	for attempt := range attempts {
+		passed = append(passed, attempt)
+	}
+
+	msg := "parallel logins pass the lockout threshold"
+	assertions := assert.New(t)
+	assertions.Len(passed, 3, msg, "logins let through")
+	for err := range errs {
+		assertions.True(errors.Is(err, authentication.ErrLoginLocked), msg, "error of rejected login")
+	}
+	err1 := guard.Succeed(context.TODO(), passed[0])
+	_, _, err2 := guard.Attempt(context.TODO(), "user_1", "192.0.2.1")
+	assertions.Nil(err1, msg, "found succeed error")
+	assertions.Nil(err2, msg, "user is not unlocked by successful login")
+}
+
+func TestLoginAttemptGuardWithParallelAttemptsFromIPLetThroughThreshold(t *testing.T) {
+	auditor := &loginAuditorStub{}
+	guard := authentication.NewLoginAttemptGuard(authentication.NewLoginAttemptMemoryStore(),
+		&authentication.LoginAttemptPolicy{
+			IPThreshold: 3,
+			Window:      15 * time.Minute,
+		}).WithAuditor(auditor)
+	count := 20
+	passed := make(chan string, count)
+	errs := make(chan error, count)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			_, _, err := guard.Attempt(context.TODO(), userID, "192.0.2.1")
+			if err != nil {
+				errs <- err
+				return
+			}
+			passed <- userID
+		}(fmt.Sprintf("user_%d", i))
+	}
+	wg.Wait()
+	close(passed)
+	close(errs)
+
+	msg := "parallel logins from ip pass the threshold"
+	assertions := assert.New(t)
+	assertions.Len(passed, 3, msg, "logins let through")
+	for err := range errs {
+		assertions.True(errors.Is(err, authentication.ErrLoginThrottled), msg, "error of rejected login")
+	}
+	assertions.Len(auditor.events, 1, msg, "audit events")
+}
+
+func TestLoginAttemptGuardWithFailuresFromIPBlockEveryUser(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	auditor := &loginAuditorStub{}
+	guard := authentication.NewLoginAttemptGuard(
+		authentication.NewLoginAttemptMemoryStore().WithClock(clock.Now),
+		&authentication.LoginAttemptPolicy{
+			IPThreshold: 3,
+			Window:      15 * time.Minute,
+		}).WithAuditor(auditor).WithClock(clock.Now)
+	for _, userID := range []string{"user_1", "user_2"} {
+		attempt, _, err := guard.Attempt(context.TODO(), userID, "192.0.2.1")
+		if err != nil {
+			t.Fatalf("error happened when attempting login, %v", err)
+		}
+		err = guard.Fail(context.TODO(), attempt)
+		if err != nil {
+			t.Fatalf("error happened when failing login, %v", err)
+		}
+	}
+	attempt, _, err := guard.Attempt(context.TODO(), "user_3", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("error happened when attempting login, %v", err)
+	}
+
+	err1 := guard.Succeed(context.TODO(), attempt)
+	_, retry2, err2 := guard.Attempt(context.TODO(), "user_4", "192.0.2.1")
+	_, _, err3 := guard.Attempt(context.TODO(), "user_4", "192.0.2.2")
+	clock.Add(15 * time.Minute)
+	_, _, err4 := guard.Attempt(context.TODO(), "user_4", "192.0.2.1")
+
+	msg := "logins from ip are not blocked"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found succeed error")
+	assertions.True(errors.Is(err2, authentication.ErrLoginThrottled), msg, "ip is not blocked")
+	assertions.Equal(15*time.Minute, retry2, msg, "retry of blocked ip")
+	assertions.Nil(err3, msg, "other ip is blocked")
+	assertions.Nil(err4, msg, "ip is not unblocked after window")
+	assertions.Len(auditor.events, 1, msg, "audit events")
+	assertions.Equal(authentication.LoginEventIPBlocked, auditor.events[0].Type, msg, "type of audit event")
+	assertions.Equal("192.0.2.1", auditor.events[0].IP, msg, "ip of audit event")
+}
+
+// loginAuditorStub
+// keeps the audited events.
+type loginAuditorStub struct {
+	mu     sync.Mutex
+	events []*authentication.LoginAuditEvent
+}
+
+func (a *loginAuditorStub) Audit(ctx context.Context, event *authentication.LoginAuditEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+}
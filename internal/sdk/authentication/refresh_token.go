@@ -0,0 +1,295 @@
+package authentication
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	nativeerr "errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+const (
+	// DefaultRefreshTokenTTL a refresh token expires in it since it was issued, every rotation issues a new one.
+	DefaultRefreshTokenTTL time.Duration = time.Hour * 24 * 30
+	// refreshTokenBytes random bytes of an opaque refresh token.
+	refreshTokenBytes int = 32
+)
+
+var (
+	ErrInvalidRefreshToken error = nativeerr.New("invalid refresh token")
+	ErrReusedRefreshToken  error = nativeerr.New("refresh token is reused")
+)
+
+// RefreshToken
+// the record of an issued refresh token, the token itself is never saved but its hash.
+// the tokens rotated from the same login are a family, they are revoked together.
+type RefreshToken struct {
+	Hash      string    `json:"hash"`
+	FamilyID  string    `json:"familyId"`
+	UserID    string    `json:"userId"`
+	IssuedAt  time.Time `json:"issuedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// UsedAt the token was rotated at it, a used token is never accepted again
+	UsedAt *time.Time `json:"usedAt,omitempty"`
+}
+
+// RefreshTokenStore
+// saves the records of refresh tokens until they expire, it must be safe for concurrent use.
+type RefreshTokenStore interface {
+	// Save
+	//  @param ctx
+	//  @param token
+	//  @return error
+	Save(ctx context.Context, token *RefreshToken) error
+
+	// Get
+	//  @param ctx
+	//  @param hash
+	//  @return *RefreshToken nil when it is not found or expired
+	//  @return error
+	Get(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// MarkUsed
+	// only one of concurrent calls for a token succeeds
+	//  @param ctx
+	//  @param hash
+	//  @param usedAt
+	//  @return error ErrReusedRefreshToken when it was used already, ErrInvalidRefreshToken when it is not found
+	MarkUsed(ctx context.Context, hash string, usedAt time.Time) error
+
+	// RevokeFamily
+	//  @param ctx
+	//  @param familyID
+	//  @param expireAt no token of the family is valid after it, the revocation can be forgotten then
+	//  @return error
+	RevokeFamily(ctx context.Context, familyID string, expireAt time.Time) error
+
+	// IsFamilyRevoked
+	//  @param ctx
+	//  @param familyID
+	//  @return bool
+	//  @return error
+	IsFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+}
+
+type RefreshTokenClient interface {
+	// Issue
+	// start a new family
+	//  @param ctx
+	//  @param userID
+	//  @return string the opaque refresh token
+	//  @return *RefreshToken
+	//  @return error
+	Issue(ctx context.Context, userID string) (string, *RefreshToken, error)
+
+	// Rotate
+	// the token is used up and a new one of the same family is issued
+	//  @param ctx
+	//  @param tokenStr
+	//  @return string the new refresh token
+	//  @return *RefreshToken the record of the new token
+	//  @return error ErrInvalidRefreshToken, ErrReusedRefreshToken and others
+	Rotate(ctx context.Context, tokenStr string) (string, *RefreshToken, error)
+
+	// Revoke
+	// revoke the family of the token
+	//  @param ctx
+	//  @param tokenStr
+	//  @return error ErrInvalidRefreshToken and others
+	Revoke(ctx context.Context, tokenStr string) error
+}
+
+// RefreshTokenHash
+// refresh tokens are saved by their hash, a leaked store never leaks usable tokens
+//
+//	@param tokenStr
+//	@return string
+func RefreshTokenHash(tokenStr string) string {
+	sum := sha256.Sum256([]byte(tokenStr))
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshTokenStoreClient
+// implements RefreshTokenClient by a RefreshTokenStore.
+// a used token which is presented again means it was stolen, so the whole family is revoked.
+type RefreshTokenStoreClient struct {
+	store RefreshTokenStore
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewRefreshTokenStoreClient
+//
+//	@param store
+//	@return *RefreshTokenStoreClient
+func NewRefreshTokenStoreClient(store RefreshTokenStore) *RefreshTokenStoreClient {
+	return &RefreshTokenStoreClient{
+		store: store,
+		ttl:   DefaultRefreshTokenTTL,
+		now:   time.Now,
+	}
+}
+
+// WithTTL
+//
+//	@receiver c
+//	@param ttl
+//	@return *RefreshTokenStoreClient
+func (c *RefreshTokenStoreClient) WithTTL(ttl time.Duration) *RefreshTokenStoreClient {
+	c.ttl = ttl
+	return c
+}
+
+// WithClock
+//
+//	@receiver c
+//	@param now
+//	@return *RefreshTokenStoreClient
+func (c *RefreshTokenStoreClient) WithClock(now func() time.Time) *RefreshTokenStoreClient {
+	c.now = now
+	return c
+}
+
+// Issue
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@return string
+//	@return *RefreshToken
+//	@return error
+func (c *RefreshTokenStoreClient) Issue(ctx context.Context, userID string) (string, *RefreshToken, error) {
+	if userID == "" {
+		return "", nil, errors.Wrap(ErrInvalidRefreshToken, "no user id found")
+	}
+	return c.issue(ctx, userID, uuid.NewString())
+}
+
+// Rotate
+//
+//	@receiver c
+//	@param ctx
+//	@param tokenStr
+//	@return string
+//	@return *RefreshToken
+//	@return error
+func (c *RefreshTokenStoreClient) Rotate(ctx context.Context, tokenStr string) (string, *RefreshToken, error) {
+	token, err := c.get(ctx, tokenStr)
+	if err != nil {
+		return "", nil, err
+	}
+	if token.UsedAt != nil {
+		return "", nil, c.revokeReused(ctx, token)
+	}
+	err = c.store.MarkUsed(ctx, token.Hash, c.now())
+	if errors.Is(err, ErrReusedRefreshToken) {
+		// used by a concurrent call
+		return "", nil, c.revokeReused(ctx, token)
+	}
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to use refresh token. family: %s", token.FamilyID)
+	}
+	return c.issue(ctx, token.UserID, token.FamilyID)
+}
+
+// Revoke
+//
+//	@receiver c
+//	@param ctx
+//	@param tokenStr
+//	@return error
+func (c *RefreshTokenStoreClient) Revoke(ctx context.Context, tokenStr string) error {
+	token, err := c.get(ctx, tokenStr)
+	if err != nil {
+		return err
+	}
+	err = c.store.RevokeFamily(ctx, token.FamilyID, c.now().Add(c.ttl))
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke refresh token family: %s", token.FamilyID)
+	}
+	return nil
+}
+
+// get
+// the token must be unexpired and of an unrevoked family
+//
+//	@receiver c
+//	@param ctx
+//	@param tokenStr
+//	@return *RefreshToken
+//	@return error
+func (c *RefreshTokenStoreClient) get(ctx context.Context, tokenStr string) (*RefreshToken, error) {
+	if tokenStr == "" {
+		return nil, errors.Wrap(ErrInvalidRefreshToken, "no refresh token found")
+	}
+	hash := RefreshTokenHash(tokenStr)
+	token, err := c.store.Get(ctx, hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get refresh token. hash: %s", hash)
+	}
+	if token == nil || !token.ExpiresAt.After(c.now()) {
+		return nil, errors.Wrapf(ErrInvalidRefreshToken, "unknown or expired. hash: %s", hash)
+	}
+	revoked, err := c.store.IsFamilyRevoked(ctx, token.FamilyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check refresh token family: %s", token.FamilyID)
+	}
+	if revoked {
+		return nil, errors.Wrapf(ErrInvalidRefreshToken, "revoked family: %s", token.FamilyID)
+	}
+	return token, nil
+}
+
+// issue
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@param familyID
+//	@return string
+//	@return *RefreshToken
+//	@return error
+func (c *RefreshTokenStoreClient) issue(ctx context.Context, userID string, familyID string) (string, *RefreshToken, error) {
+	buf := make([]byte, refreshTokenBytes)
+	_, err := rand.Read(buf)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return "", nil, errors.Wrap(rootErr, "failed to generate refresh token")
+	}
+	tokenStr := base64.RawURLEncoding.EncodeToString(buf)
+	curr := c.now()
+	token := &RefreshToken{
+		Hash:      RefreshTokenHash(tokenStr),
+		FamilyID:  familyID,
+		UserID:    userID,
+		IssuedAt:  curr,
+		ExpiresAt: curr.Add(c.ttl),
+	}
+	err = c.store.Save(ctx, token)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to save refresh token. family: %s", familyID)
+	}
+	return tokenStr, token, nil
+}
+
+// revokeReused
+// no token of the family is newer than now plus the ttl, so the revocation outlives all of them
+//
+//	@receiver c
+//	@param ctx
+//	@param token
+//	@return error ErrReusedRefreshToken unless the revocation failed
+func (c *RefreshTokenStoreClient) revokeReused(ctx context.Context, token *RefreshToken) error {
+	logger.Warn("refresh token is reused, revoke its family. user_id: %s, family: %s", token.UserID, token.FamilyID)
+	err := c.store.RevokeFamily(ctx, token.FamilyID, c.now().Add(c.ttl))
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke reused refresh token family: %s", token.FamilyID)
+	}
+	return errors.Wrapf(ErrReusedRefreshToken, "user_id: %s, family: %s", token.UserID, token.FamilyID)
+}
@@ -0,0 +1,150 @@
+package authentication
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RefreshTokenMemoryStore
+// implements RefreshTokenStore in memory, it is only shared by the goroutines of a process.
+type RefreshTokenMemoryStore struct {
+	mu        sync.Mutex
+	tokens    map[string]*RefreshToken
+	revoked   map[string]time.Time
+	nextPurge time.Time
+	now       func() time.Time
+}
+
+// NewRefreshTokenMemoryStore
+//
+//	@return *RefreshTokenMemoryStore
+func NewRefreshTokenMemoryStore() *RefreshTokenMemoryStore {
+	return &RefreshTokenMemoryStore{
+		tokens:  make(map[string]*RefreshToken),
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *RefreshTokenMemoryStore
+func (s *RefreshTokenMemoryStore) WithClock(now func() time.Time) *RefreshTokenMemoryStore {
+	s.now = now
+	return s
+}
+
+// Save
+// expired tokens and revocations are purged here
+//
+//	@receiver s
+//	@param ctx
+//	@param token
+//	@return error
+func (s *RefreshTokenMemoryStore) Save(ctx context.Context, token *RefreshToken) error {
+	if token == nil || token.Hash == "" {
+		return errors.Wrap(ErrInvalidRefreshToken, "no hash found")
+	}
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !curr.Before(s.nextPurge) {
+		s.purge(curr)
+		s.nextPurge = curr.Add(blocklistPurgeInterval)
+	}
+	saved := *token
+	s.tokens[token.Hash] = &saved
+	return nil
+}
+
+// Get
+//
+//	@receiver s
+//	@param ctx
+//	@param hash
+//	@return *RefreshToken a copy of the saved one
+//	@return error
+func (s *RefreshTokenMemoryStore) Get(ctx context.Context, hash string) (*RefreshToken, error) {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[hash]
+	if !ok || !token.ExpiresAt.After(curr) {
+		return nil, nil
+	}
+	found := *token
+	return &found, nil
+}
+
+// MarkUsed
+//
+//	@receiver s
+//	@param ctx
+//	@param hash
+//	@param usedAt
+//	@return error
+func (s *RefreshTokenMemoryStore) MarkUsed(ctx context.Context, hash string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[hash]
+	if !ok {
+		return errors.Wrapf(ErrInvalidRefreshToken, "hash: %s", hash)
+	}
+	if token.UsedAt != nil {
+		return errors.Wrapf(ErrReusedRefreshToken, "hash: %s", hash)
+	}
+	token.UsedAt = &usedAt
+	return nil
+}
+
+// RevokeFamily
+//
+//	@receiver s
+//	@param ctx
+//	@param familyID
+//	@param expireAt
+//	@return error
+func (s *RefreshTokenMemoryStore) RevokeFamily(ctx context.Context, familyID string, expireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[familyID] = expireAt
+	return nil
+}
+
+// IsFamilyRevoked
+//
+//	@receiver s
+//	@param ctx
+//	@param familyID
+//	@return bool
+//	@return error
+func (s *RefreshTokenMemoryStore) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expireAt, ok := s.revoked[familyID]
+	return ok && expireAt.After(curr), nil
+}
+
+// purge
+// must be called with the lock held
+//
+//	@receiver s
+//	@param curr
+func (s *RefreshTokenMemoryStore) purge(curr time.Time) {
+	for hash, token := range s.tokens {
+		if !token.ExpiresAt.After(curr) {
+			delete(s.tokens, hash)
+		}
+	}
+	for familyID, expireAt := range s.revoked {
+		if !expireAt.After(curr) {
+			delete(s.revoked, familyID)
+		}
+	}
+}
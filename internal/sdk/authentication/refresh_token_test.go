@@ -0,0 +1,102 @@
+package authentication_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestRefreshTokenMemoryStoreContract(t *testing.T) {
+	repositorytest.RunRefreshTokenStoreContract(t, func(t *testing.T, now func() time.Time) authentication.RefreshTokenStore {
+		return authentication.NewRefreshTokenMemoryStore().WithClock(now)
+	})
+}
+
+func newTestRefreshTokenClient(clock *testClock) *authentication.RefreshTokenStoreClient {
+	return authentication.NewRefreshTokenStoreClient(authentication.NewRefreshTokenMemoryStore().WithClock(clock.Now)).
+		WithTTL(time.Hour).
+		WithClock(clock.Now)
+}
+
+func TestRefreshTokenClientWithRotateReturnNewTokenOfSameFamily(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	client := newTestRefreshTokenClient(clock)
+	token1, issued, err := client.Issue(context.TODO(), "user_1")
+	if err != nil {
+		t.Fatalf("error happened when issuing refresh token, %v", err)
+	}
+
+	clock.Add(time.Minute)
+	token2, rotated, err1 := client.Rotate(context.TODO(), token1)
+	token3, _, err2 := client.Rotate(context.TODO(), token2)
+
+	msg := "refresh token is not rotated"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found first rotate error")
+	assertions.NotEqual(token1, token2, msg, "same token")
+	assertions.Equal(issued.FamilyID, rotated.FamilyID, msg, "family")
+	assertions.Equal("user_1", rotated.UserID, msg, "user id")
+	assertions.Equal(clock.Now().Add(time.Hour), rotated.ExpiresAt, msg, "expiresAt is not extended")
+	assertions.NotEqual(token2, rotated.Hash, msg, "token is saved as it is")
+	assertions.Nil(err2, msg, "found second rotate error")
+	assertions.NotEmpty(token3, msg, "third token")
+}
+
+func TestRefreshTokenClientWithReusedTokenRevokeFamily(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	client := newTestRefreshTokenClient(clock)
+	token1, _, err := client.Issue(context.TODO(), "user_1")
+	if err != nil {
+		t.Fatalf("error happened when issuing refresh token, %v", err)
+	}
+	otherToken, _, err := client.Issue(context.TODO(), "user_1")
+	if err != nil {
+		t.Fatalf("error happened when issuing refresh token, %v", err)
+	}
+	token2, _, err := client.Rotate(context.TODO(), token1)
+	if err != nil {
+		t.Fatalf("error happened when rotating refresh token, %v", err)
+	}
+
+	// token1 is replayed by an attacker who stole it
+	_, _, err1 := client.Rotate(context.TODO(), token1)
+	_, _, err2 := client.Rotate(context.TODO(), token2)
+	_, _, err3 := client.Rotate(context.TODO(), otherToken)
+
+	msg := "family of reused refresh token is not revoked"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err1, authentication.ErrReusedRefreshToken), msg, "error type of reused token")
+	assertions.True(errors.Is(err2, authentication.ErrInvalidRefreshToken), msg, "latest token of the family")
+	assertions.Nil(err3, msg, "token of other family is revoked")
+}
+
+func TestRefreshTokenClientWithExpiredOrRevokedTokenReturnInvalid(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	client := newTestRefreshTokenClient(clock)
+	token1, _, err := client.Issue(context.TODO(), "user_1")
+	if err != nil {
+		t.Fatalf("error happened when issuing refresh token, %v", err)
+	}
+	token2, _, err := client.Issue(context.TODO(), "user_1")
+	if err != nil {
+		t.Fatalf("error happened when issuing refresh token, %v", err)
+	}
+
+	err1 := client.Revoke(context.TODO(), token1)
+	_, _, err2 := client.Rotate(context.TODO(), token1)
+	clock.Add(time.Hour)
+	_, _, err3 := client.Rotate(context.TODO(), token2)
+	_, _, err4 := client.Rotate(context.TODO(), "unknown")
+
+	msg := "invalid refresh token is rotated"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found revoke error")
+	assertions.True(errors.Is(err2, authentication.ErrInvalidRefreshToken), msg, "revoked token")
+	assertions.True(errors.Is(err3, authentication.ErrInvalidRefreshToken), msg, "expired token")
+	assertions.True(errors.Is(err4, authentication.ErrInvalidRefreshToken), msg, "unknown token")
+}
@@ -0,0 +1,271 @@
+package authentication
+
+import (
+	"context"
+	nativeerr "errors"
+	"time"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+var ErrSessionNotFound error = nativeerr.New("session not found")
+
+// Session
+// a login of a user on a device, it lives as long as the refresh tokens rotated from the login.
+// its ID is the family of the refresh tokens and the sid of the access tokens.
+type Session struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"userId"`
+	Device      string    `json:"device"`
+	IP          string    `json:"ip"`
+	IssuedAt    time.Time `json:"issuedAt"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	// TokenID jti of the latest access token of the session
+	TokenID string `json:"tokenId"`
+	// TokenKey blocklist key of the latest access token, it is blocked when the session is revoked
+	TokenKey       string    `json:"tokenKey"`
+	TokenExpiresAt time.Time `json:"tokenExpiresAt"`
+}
+
+// SessionStore
+// saves the sessions of users until they expire, and the epoch of every user. it must be safe for concurrent use.
+type SessionStore interface {
+	// Save
+	// create or replace the session
+	//  @param ctx
+	//  @param session
+	//  @return error
+	Save(ctx context.Context, session *Session) error
+
+	// Get
+	//  @param ctx
+	//  @param userID
+	//  @param id
+	//  @return *Session nil when it is not found or expired
+	//  @return error
+	Get(ctx context.Context, userID string, id string) (*Session, error)
+
+	// List
+	//  @param ctx
+	//  @param userID
+	//  @return []*Session the unexpired sessions of the user in the order of IssuedAt
+	//  @return error
+	List(ctx context.Context, userID string) ([]*Session, error)
+
+	// Delete
+	//  @param ctx
+	//  @param userID
+	//  @param id
+	//  @return error
+	Delete(ctx context.Context, userID string, id string) error
+
+	// UserEpoch
+	//  @param ctx
+	//  @param userID
+	//  @return int64 0 until it is incremented
+	//  @return error
+	UserEpoch(ctx context.Context, userID string) (int64, error)
+
+	// IncrementUserEpoch
+	//  @param ctx
+	//  @param userID
+	//  @return int64 the new epoch
+	//  @return error
+	IncrementUserEpoch(ctx context.Context, userID string) (int64, error)
+}
+
+type SessionClient interface {
+	// Save
+	//  @param ctx
+	//  @param session
+	//  @return error
+	Save(ctx context.Context, session *Session) error
+
+	// Get
+	//  @param ctx
+	//  @param userID
+	//  @param id
+	//  @return *Session nil when it is not found
+	//  @return error
+	Get(ctx context.Context, userID string, id string) (*Session, error)
+
+	// List
+	//  @param ctx
+	//  @param userID
+	//  @return []*Session
+	//  @return error
+	List(ctx context.Context, userID string) ([]*Session, error)
+
+	// Revoke
+	// the latest access token and the refresh tokens of the session are revoked at once
+	//  @param ctx
+	//  @param userID
+	//  @param id
+	//  @return error ErrSessionNotFound and others
+	Revoke(ctx context.Context, userID string, id string) error
+
+	// RevokeAll
+	// every token issued to the user so far is revoked by the epoch of the user
+	//  @param ctx
+	//  @param userID
+	//  @return error
+	RevokeAll(ctx context.Context, userID string) error
+}
+
+// SessionStoreClient
+// implements SessionClient by a SessionStore, the tokens of revoked sessions are revoked by their stores.
+type SessionStoreClient struct {
+	sessions      SessionStore
+	blocklist     BlocklistStore
+	refreshTokens RefreshTokenStore
+	// leeway access tokens are blocked for it after they expired, as they are verified with it
+	leeway time.Duration
+}
+
+// NewSessionStoreClient
+//
+//	@param sessions
+//	@param blocklist blocks the latest access tokens of revoked sessions
+//	@param refreshTokens revokes the refresh tokens of revoked sessions
+//	@return *SessionStoreClient
+func NewSessionStoreClient(
+	sessions SessionStore,
+	blocklist BlocklistStore,
+	refreshTokens RefreshTokenStore,
+) *SessionStoreClient {
+	return &SessionStoreClient{
+		sessions:      sessions,
+		blocklist:     blocklist,
+		refreshTokens: refreshTokens,
+	}
+}
+
+// WithLeeway
+//
+//	@receiver c
+//	@param leeway the leeway of the jwt client
+//	@return *SessionStoreClient
+func (c *SessionStoreClient) WithLeeway(leeway time.Duration) *SessionStoreClient {
+	c.leeway = leeway
+	return c
+}
+
+// Save
+//
+//	@receiver c
+//	@param ctx
+//	@param session
+//	@return error
+func (c *SessionStoreClient) Save(ctx context.Context, session *Session) error {
+	if session == nil || session.ID == "" || session.UserID == "" {
+		return errors.Wrap(ErrSessionNotFound, "no session id or user id found")
+	}
+	err := c.sessions.Save(ctx, session)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save session. user_id: %s, id: %s", session.UserID, session.ID)
+	}
+	return nil
+}
+
+// Get
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@param id
+//	@return *Session
+//	@return error
+func (c *SessionStoreClient) Get(ctx context.Context, userID string, id string) (*Session, error) {
+	session, err := c.sessions.Get(ctx, userID, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get session. user_id: %s, id: %s", userID, id)
+	}
+	return session, nil
+}
+
+// List
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@return []*Session
+//	@return error
+func (c *SessionStoreClient) List(ctx context.Context, userID string) ([]*Session, error) {
+	sessions, err := c.sessions.List(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list sessions. user_id: %s", userID)
+	}
+	return sessions, nil
+}
+
+// Revoke
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@param id
+//	@return error
+func (c *SessionStoreClient) Revoke(ctx context.Context, userID string, id string) error {
+	session, err := c.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return errors.Wrapf(ErrSessionNotFound, "user_id: %s, id: %s", userID, id)
+	}
+	return c.revoke(ctx, session)
+}
+
+// RevokeAll
+// the epoch is incremented first, the access tokens are revoked by it even if a session fails to be revoked
+//
+//	@receiver c
+//	@param ctx
+//	@param userID
+//	@return error
+func (c *SessionStoreClient) RevokeAll(ctx context.Context, userID string) error {
+	epoch, err := c.sessions.IncrementUserEpoch(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to increment user epoch. user_id: %s", userID)
+	}
+	logger.Info("every token of the user is revoked. user_id: %s, epoch: %d", userID, epoch)
+	sessions, err := c.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		err = c.revoke(ctx, session)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revoke
+// the session is deleted after its tokens are revoked, so a failed revocation can be retried
+//
+//	@receiver c
+//	@param ctx
+//	@param session
+//	@return error
+func (c *SessionStoreClient) revoke(ctx context.Context, session *Session) error {
+	if session.TokenKey != "" {
+		err := c.blocklist.Block(ctx, session.TokenKey, session.TokenExpiresAt.Add(c.leeway))
+		if err != nil {
+			return errors.Wrapf(err, "failed to block access token of session. id: %s, jti: %s", session.ID, session.TokenID)
+		}
+	}
+	err := c.refreshTokens.RevokeFamily(ctx, session.ID, session.ExpiresAt)
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke refresh tokens of session. id: %s", session.ID)
+	}
+	err = c.sessions.Delete(ctx, session.UserID, session.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete session. user_id: %s, id: %s", session.UserID, session.ID)
+	}
+	logger.Info("session is revoked. user_id: %s, id: %s", session.UserID, session.ID)
+	return nil
+}
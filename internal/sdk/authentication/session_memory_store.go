@@ -0,0 +1,155 @@
+package authentication
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SessionMemoryStore
+// implements SessionStore in memory, it is only shared by the goroutines of a process.
+type SessionMemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]*Session
+	epochs   map[string]int64
+	now      func() time.Time
+}
+
+// NewSessionMemoryStore
+//
+//	@return *SessionMemoryStore
+func NewSessionMemoryStore() *SessionMemoryStore {
+	return &SessionMemoryStore{
+		sessions: make(map[string]map[string]*Session),
+		epochs:   make(map[string]int64),
+		now:      time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver s
+//	@param now
+//	@return *SessionMemoryStore
+func (s *SessionMemoryStore) WithClock(now func() time.Time) *SessionMemoryStore {
+	s.now = now
+	return s
+}
+
+// Save
+// the expired sessions of the user are purged here
+//
+//	@receiver s
+//	@param ctx
+//	@param session
+//	@return error
+func (s *SessionMemoryStore) Save(ctx context.Context, session *Session) error {
+	if session == nil || session.ID == "" || session.UserID == "" {
+		return errors.Wrap(ErrSessionNotFound, "no session id or user id found")
+	}
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userSessions, ok := s.sessions[session.UserID]
+	if !ok {
+		userSessions = make(map[string]*Session)
+		s.sessions[session.UserID] = userSessions
+	}
+	for id, saved := range userSessions {
+		if !saved.ExpiresAt.After(curr) {
+			delete(userSessions, id)
+		}
+	}
+	saved := *session
+	userSessions[session.ID] = &saved
+	return nil
+}
+
+// Get
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@param id
+//	@return *Session a copy of the saved one
+//	@return error
+func (s *SessionMemoryStore) Get(ctx context.Context, userID string, id string) (*Session, error) {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[userID][id]
+	if !ok || !session.ExpiresAt.After(curr) {
+		return nil, nil
+	}
+	found := *session
+	return &found, nil
+}
+
+// List
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@return []*Session
+//	@return error
+func (s *SessionMemoryStore) List(ctx context.Context, userID string) ([]*Session, error) {
+	curr := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := []*Session{}
+	for _, session := range s.sessions[userID] {
+		if !session.ExpiresAt.After(curr) {
+			continue
+		}
+		found := *session
+		sessions = append(sessions, &found)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].IssuedAt.Before(sessions[j].IssuedAt)
+	})
+	return sessions, nil
+}
+
+// Delete
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@param id
+//	@return error
+func (s *SessionMemoryStore) Delete(ctx context.Context, userID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions[userID], id)
+	return nil
+}
+
+// UserEpoch
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@return int64
+//	@return error
+func (s *SessionMemoryStore) UserEpoch(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.epochs[userID], nil
+}
+
+// IncrementUserEpoch
+//
+//	@receiver s
+//	@param ctx
+//	@param userID
+//	@return int64
+//	@return error
+func (s *SessionMemoryStore) IncrementUserEpoch(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epochs[userID]++
+	return s.epochs[userID], nil
+}
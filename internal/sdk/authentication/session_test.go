@@ -0,0 +1,139 @@
+package authentication_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/repository/repositorytest"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestSessionMemoryStoreContract(t *testing.T) {
+	repositorytest.RunSessionStoreContract(t, func(t *testing.T, now func() time.Time) authentication.SessionStore {
+		return authentication.NewSessionMemoryStore().WithClock(now)
+	})
+}
+
+func TestSessionClientWithRevokeBlockTokensOfSession(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	sessions := authentication.NewSessionMemoryStore().WithClock(clock.Now)
+	blocklist := authentication.NewBlocklistMemoryStore().WithClock(clock.Now)
+	refreshTokens := authentication.NewRefreshTokenMemoryStore().WithClock(clock.Now)
+	refreshClient := authentication.NewRefreshTokenStoreClient(refreshTokens).WithTTL(time.Hour).WithClock(clock.Now)
+	client := authentication.NewSessionStoreClient(sessions, blocklist, refreshTokens).WithLeeway(time.Minute)
+	refreshToken, token, err := refreshClient.Issue(context.TODO(), "user_1")
+	if err != nil {
+		t.Fatalf("error happened when issuing refresh token, %v", err)
+	}
+	err = client.Save(context.TODO(), &authentication.Session{
+		ID:             token.FamilyID,
+		UserID:         "user_1",
+		IssuedAt:       clock.Now(),
+		ExpiresAt:      token.ExpiresAt,
+		TokenKey:       authentication.BlocklistKey("jwt_1"),
+		TokenExpiresAt: clock.Now().Add(authentication.ExpireDuration),
+	})
+	if err != nil {
+		t.Fatalf("error happened when saving session, %v", err)
+	}
+
+	err1 := client.Revoke(context.TODO(), "user_1", token.FamilyID)
+	err2 := client.Revoke(context.TODO(), "user_1", token.FamilyID)
+	blocked, err3 := blocklist.IsBlocked(context.TODO(), authentication.BlocklistKey("jwt_1"))
+	_, _, err4 := refreshClient.Rotate(context.TODO(), refreshToken)
+	found, err5 := client.Get(context.TODO(), "user_1", token.FamilyID)
+
+	msg := "tokens of revoked session are not revoked"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found revoke error")
+	assertions.True(errors.Is(err2, authentication.ErrSessionNotFound), msg, "revoked session is revoked again")
+	assertions.Nil(err3, msg, "found blocklist error")
+	assertions.True(blocked, msg, "access token is not blocked")
+	assertions.True(errors.Is(err4, authentication.ErrInvalidRefreshToken), msg, "refresh token is rotated")
+	assertions.Nil(err5, msg, "found get error")
+	assertions.Nil(found, msg, "session is not deleted")
+}
+
+func TestSessionClientWithRevokeRejectOlderTokensOfSession(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	sessions := authentication.NewSessionMemoryStore().WithClock(clock.Now)
+	blocklist := authentication.NewBlocklistMemoryStore().WithClock(clock.Now)
+	client := authentication.NewSessionStoreClient(sessions, blocklist,
+		authentication.NewRefreshTokenMemoryStore().WithClock(clock.Now))
+	jwtClient := newTestJwtClient(NewSSMMock(t), clock).WithSessions(sessions).WithBlocklist(blocklist)
+	tokens := map[string]string{}
+	for _, name := range []string{"older", "latest", "other"} {
+		claim := newTestClaim()
+		claim.SessionID = "session_1"
+		if name == "other" {
+			claim.SessionID = "session_2"
+		}
+		token, err := jwtClient.Issue(context.TODO(), claim)
+		if err != nil {
+			t.Fatalf("error happened when issuing jwt, %v", err)
+		}
+		err = client.Save(context.TODO(), &authentication.Session{
+			ID:             claim.SessionID,
+			UserID:         "user_1",
+			IssuedAt:       clock.Now(),
+			ExpiresAt:      clock.Now().Add(time.Hour),
+			TokenKey:       authentication.BlocklistKey(token),
+			TokenExpiresAt: claim.ExpiresTime(),
+		})
+		if err != nil {
+			t.Fatalf("error happened when saving session, %v", err)
+		}
+		tokens[name] = token
+	}
+
+	err1 := client.Revoke(context.TODO(), "user_1", "session_1")
+	_, err2 := jwtClient.Verify(context.TODO(), tokens["older"])
+	_, err3 := jwtClient.Verify(context.TODO(), tokens["latest"])
+	_, err4 := jwtClient.Verify(context.TODO(), tokens["other"])
+
+	msg := "older tokens of revoked session are verified"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found revoke error")
+	assertions.True(errors.Is(err2, authentication.ErrBlockedClaim), msg, "older token is verified")
+	assertions.True(errors.Is(err3, authentication.ErrBlockedClaim), msg, "latest token is verified")
+	assertions.Nil(err4, msg, "token of other session is rejected")
+}
+
+func TestSessionClientWithRevokeAllRejectTokensIssuedBefore(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	sessions := authentication.NewSessionMemoryStore().WithClock(clock.Now)
+	client := authentication.NewSessionStoreClient(
+		sessions,
+		authentication.NewBlocklistMemoryStore().WithClock(clock.Now),
+		authentication.NewRefreshTokenMemoryStore().WithClock(clock.Now),
+	)
+	jwtClient := newTestJwtClient(NewSSMMock(t), clock).WithSessions(sessions)
+	before, err := jwtClient.Issue(context.TODO(), newTestClaim())
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+	otherClaim := newTestClaim()
+	otherClaim.User.UserID = "user_2"
+	other, err := jwtClient.Issue(context.TODO(), otherClaim)
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+
+	err1 := client.RevokeAll(context.TODO(), "user_1")
+	after, err2 := jwtClient.Issue(context.TODO(), newTestClaim())
+	_, err3 := jwtClient.Verify(context.TODO(), before)
+	claim, err4 := jwtClient.Verify(context.TODO(), after)
+	_, err5 := jwtClient.Verify(context.TODO(), other)
+
+	msg := "tokens are not revoked by user epoch"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found revoke all error")
+	assertions.Nil(err2, msg, "found issue error")
+	assertions.True(errors.Is(err3, authentication.ErrBlockedClaim), msg, "token issued before is verified")
+	assertions.Nil(err4, msg, "token issued after is rejected")
+	assertions.Equal(int64(1), claim.Epoch, msg, "epoch of token issued after")
+	assertions.Nil(err5, msg, "token of other user is rejected")
+}
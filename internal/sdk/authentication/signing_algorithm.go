@@ -0,0 +1,122 @@
+package authentication
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+)
+
+// the algorithms which sign and verify tokens, the algorithm of a key is decided by its type.
+const (
+	AlgorithmRS256 string = "RS256"
+	AlgorithmES256 string = "ES256"
+	AlgorithmEdDSA string = "EdDSA"
+)
+
+// signingMethods.
+var signingMethods = map[string]jwt.SigningMethod{
+	AlgorithmRS256: jwt.SigningMethodRS256,
+	AlgorithmES256: jwt.SigningMethodES256,
+	AlgorithmEdDSA: jwt.SigningMethodEdDSA,
+}
+
+// IsSupportedAlgorithm
+//
+//	@param alg
+//	@return bool
+func IsSupportedAlgorithm(alg string) bool {
+	_, ok := signingMethods[alg]
+	return ok
+}
+
+// publicKeyAlgorithm
+//
+//	@param key
+//	@return string
+//	@return error ErrInvalidJwt for the keys of no supported algorithm
+func publicKeyAlgorithm(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return AlgorithmRS256, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return "", errors.Wrapf(ErrInvalidJwt, "unsupported curve: %s", k.Curve.Params().Name)
+		}
+		return AlgorithmES256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEdDSA, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidJwt, "unsupported key type: %T", key)
+	}
+}
+
+// parsePrivateKeyPEM
+// pkcs1 and pkcs8 rsa keys, sec1 and pkcs8 ec keys, and pkcs8 ed25519 keys
+//
+//	@param data
+//	@return crypto.Signer
+//	@return error
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Wrap(ErrInvalidJwt, "no pem block found")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, errors.Wrapf(ErrInvalidJwt, "unsupported pem type: %s", block.Type)
+	}
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "invalid private key of pem type: %s", block.Type)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidJwt, "unsupported key type: %T", key)
+	}
+	return signer, nil
+}
+
+// parsePublicKeyBlock
+// pkix and pkcs1 public keys, and the public keys of certificates
+//
+//	@param block
+//	@return crypto.PublicKey
+//	@return error
+func parsePublicKeyBlock(block *pem.Block) (crypto.PublicKey, error) {
+	var key crypto.PublicKey
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, errors.Wrapf(ErrInvalidJwt, "unsupported pem type: %s", block.Type)
+	}
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return nil, errors.Wrapf(rootErr, "invalid public key of pem type: %s", block.Type)
+	}
+	return key, nil
+}
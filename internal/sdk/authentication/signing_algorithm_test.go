@@ -0,0 +1,228 @@
+package authentication_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+func TestAuthJwtClientWithES256AndEdDSAKeysVerifyIssuedTokens(t *testing.T) {
+	for _, alg := range []string{authentication.AlgorithmES256, authentication.AlgorithmEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			ssmClient := NewSSMMock(t)
+			privateKey, publicKey := newTestKeyPairOf(t, alg)
+			ssmClient.Set(testPrivateKeyParam, privateKey)
+			ssmClient.Set(testPublicKeyParam, publicKey)
+			client := newTestJwtClient(ssmClient, &testClock{now: time.Now()}).WithAlgorithms(alg, nil)
+
+			token, err1 := client.Issue(context.TODO(), newTestClaim())
+			claim, err2 := client.Verify(context.TODO(), token)
+			jwks, err3 := client.JWKS(context.TODO())
+
+			msg := "token of the algorithm is not verified"
+			assertions := assert.New(t)
+			assertions.Nil(err1, msg, "found issue error")
+			assertions.Nil(err2, msg, "found verify error")
+			assertions.Equal("user_1", claim.User.UserID, msg, "user of claim")
+			assertions.Equal(alg, tokenHeader(t, token, "alg"), msg, "alg of token")
+			assertions.Nil(err3, msg, "found jwks error")
+			assertions.Len(jwks.Keys, 1, msg, "published keys")
+			assertions.Equal(alg, jwks.Keys[0].Algorithm, msg, "alg of published key")
+			assertions.Equal(tokenKeyID(t, token), jwks.Keys[0].KeyID, msg, "kid of published key")
+			assertions.NotEmpty(jwks.Keys[0].X, msg, "x of published key")
+		})
+	}
+}
+
+func TestAuthJwtClientWithKeyOfOtherAlgorithmReturnBadClient(t *testing.T) {
+	client := newTestJwtClient(NewSSMMock(t), &testClock{now: time.Now()}).
+		WithAlgorithms(authentication.AlgorithmES256, nil)
+
+	token, err := client.Issue(context.TODO(), newTestClaim())
+
+	msg := "token is signed by a key of other algorithm"
+	assertions := assert.New(t)
+	assertions.Empty(token, msg, "issued token")
+	assertions.ErrorIs(err, authentication.ErrBadClient, msg, "error type")
+}
+
+func TestAuthJwtClientWithNoneAlgorithmReturnInvalidJwt(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	client := newTestJwtClient(NewSSMMock(t), clock)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, newTestMapClaims(clock)).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("error happened when signing jwt, %v", err)
+	}
+
+	claim, err := client.Verify(context.TODO(), token)
+
+	msg := "unsigned token is accepted"
+	assertions := assert.New(t)
+	assertions.Nil(claim, msg, "returned claim")
+	assertions.ErrorIs(err, authentication.ErrInvalidJwt, msg, "error type")
+}
+
+func TestAuthJwtClientWithHS256SignedByPublicKeyReturnInvalidJwt(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	client := newTestJwtClient(ssmClient, clock).
+		WithAlgorithms(authentication.AlgorithmRS256, []string{authentication.AlgorithmRS256, authentication.AlgorithmES256})
+	// the public key is no secret, a token signed by it as a hmac secret must never be accepted
+	t1 := jwt.NewWithClaims(jwt.SigningMethodHS256, newTestMapClaims(clock))
+	token, err := t1.SignedString([]byte(ssmClient.Get(testPublicKeyParam)))
+	if err != nil {
+		t.Fatalf("error happened when signing jwt, %v", err)
+	}
+
+	claim, err := client.Verify(context.TODO(), token)
+
+	msg := "algorithm confusion is not prevented"
+	assertions := assert.New(t)
+	assertions.Nil(claim, msg, "returned claim")
+	assertions.ErrorIs(err, authentication.ErrInvalidJwt, msg, "error type")
+}
+
+func TestAuthJwtClientWithNotAllowedAlgorithmReturnInvalidJwt(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	privateKey, publicKey := newTestKeyPairOf(t, authentication.AlgorithmES256)
+	ssmClient.Set(testPublicKeyParam, ssmClient.Get(testPublicKeyParam)+publicKey)
+	ssmClient.Set(testPrivateKeyParam, privateKey)
+	token, err := newTestJwtClient(ssmClient, clock).
+		WithAlgorithms(authentication.AlgorithmES256, nil).
+		Issue(context.TODO(), newTestClaim())
+	if err != nil {
+		t.Fatalf("error happened when issuing jwt, %v", err)
+	}
+
+	claim1, err1 := newTestJwtClient(ssmClient, clock).Verify(context.TODO(), token)
+	claim2, err2 := newTestJwtClient(ssmClient, clock).
+		WithAlgorithms(authentication.AlgorithmRS256, []string{authentication.AlgorithmRS256, authentication.AlgorithmES256}).
+		Verify(context.TODO(), token)
+
+	msg := "token of an algorithm not allowed is accepted"
+	assertions := assert.New(t)
+	assertions.Nil(claim1, msg, "returned claim")
+	assertions.ErrorIs(err1, authentication.ErrInvalidJwt, msg, "error type")
+	assertions.Nil(err2, msg, "token of an allowed algorithm is rejected")
+	assertions.Equal("user_1", claim2.User.UserID, msg, "user of claim")
+}
+
+func TestAuthJwtClientWithAlgorithmNotOfKeyReturnInvalidJwt(t *testing.T) {
+	ssmClient := NewSSMMock(t)
+	clock := &testClock{now: time.Now()}
+	rsaKeyID := signingKeyID(t, ssmClient)
+	_, publicKey := newTestKeyPairOf(t, authentication.AlgorithmES256)
+	ssmClient.Set(testPublicKeyParam, ssmClient.Get(testPublicKeyParam)+publicKey)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error happened when generating ec key, %v", err)
+	}
+	// an ES256 token which names the rsa key
+	t1 := jwt.NewWithClaims(jwt.SigningMethodES256, newTestMapClaims(clock))
+	t1.Header[authentication.JwtHeaderKeyID] = rsaKeyID
+	token, err := t1.SignedString(otherKey)
+	if err != nil {
+		t.Fatalf("error happened when signing jwt, %v", err)
+	}
+	client := newTestJwtClient(ssmClient, clock).
+		WithAlgorithms(authentication.AlgorithmRS256, []string{authentication.AlgorithmRS256, authentication.AlgorithmES256})
+
+	claim, err := client.Verify(context.TODO(), token)
+
+	msg := "key is used by other algorithm"
+	assertions := assert.New(t)
+	assertions.Nil(claim, msg, "returned claim")
+	assertions.ErrorIs(err, authentication.ErrInvalidJwt, msg, "error type")
+}
+
+// newTestMapClaims
+//
+//	@param clock
+//	@return jwt.MapClaims claims which are valid except the signature
+func newTestMapClaims(clock *testClock) jwt.MapClaims {
+	return jwt.MapClaims{
+		"userContext": map[string]interface{}{"userId": "user_1"},
+		"iss":         testIssuer,
+		"aud":         testAudience,
+		"iat":         clock.Now().Unix(),
+		"exp":         clock.Now().Add(authentication.ExpireDuration).Unix(),
+	}
+}
+
+// signingKeyID
+//
+//	@param t
+//	@param ssmClient
+//	@return string kid of the current signing key
+func signingKeyID(t *testing.T, ssmClient *SSMMock) string {
+	t.Helper()
+	signingKey, err := authentication.NewSSMKeyProvider(testPublicKeyParam, testPrivateKeyParam, ssmClient).
+		SigningKey(context.TODO())
+	if err != nil {
+		t.Fatalf("error happened when getting signing key, %v", err)
+	}
+	return signingKey.ID
+}
+
+// tokenHeader
+//
+//	@param t
+//	@param token
+//	@param name
+//	@return string the header of the token
+func tokenHeader(t *testing.T, token string, name string) string {
+	t.Helper()
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("error happened when parsing jwt, %v", err)
+	}
+	value, _ := parsed.Header[name].(string)
+	return value
+}
+
+// newTestKeyPairOf
+//
+//	@param t
+//	@param alg ES256 or EdDSA
+//	@return string pkcs8 pem of the private key
+//	@return string pem of the public key
+func newTestKeyPairOf(t *testing.T, alg string) (string, string) {
+	t.Helper()
+	var privateKey crypto.Signer
+	var err error
+	switch alg {
+	case authentication.AlgorithmES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case authentication.AlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported algorithm of test key: %s", alg)
+	}
+	if err != nil {
+		t.Fatalf("error happened when generating %s key, %v", alg, err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("error happened when marshaling %s private key, %v", alg, err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		t.Fatalf("error happened when marshaling %s public key, %v", alg, err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return string(privatePEM), string(publicPEM)
+}
@@ -10,10 +10,11 @@ import (
 
 // provided by app side.
 const (
-	AuthIndexApp         int = iota // index: 0
-	AuthIndexAppDummy               // index: 1
-	AuthIndexAppDummyNew            // index: 2
-	AuthIndexAppPing                // index: 3
+	AuthIndexApp           int = iota // index: 0
+	AuthIndexAppDummy                 // index: 1
+	AuthIndexAppDummyNew              // index: 2
+	AuthIndexAppPing                  // index: 3
+	AuthIndexAppDummyAdmin            // index: 4
 )
 
 type RoleDummyClient struct {
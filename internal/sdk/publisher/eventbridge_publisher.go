@@ -0,0 +1,69 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// EventBridgePublisher
+// implements domain.DummyEventPublisher by putting events to an event bus, the detail-type is the event type.
+type EventBridgePublisher struct {
+	busName string
+	client  eventbridgeiface.EventBridgeAPI
+}
+
+// NewEventBridgePublisher
+//
+//	@param busName
+//	@param client
+//	@return *EventBridgePublisher
+func NewEventBridgePublisher(busName string, client eventbridgeiface.EventBridgeAPI) *EventBridgePublisher {
+	return &EventBridgePublisher{
+		busName: busName,
+		client:  client,
+	}
+}
+
+// Publish
+//
+//	@receiver p
+//	@param ctx
+//	@param event
+//	@return error
+func (p *EventBridgePublisher) Publish(ctx context.Context, event *domain.DummyEvent) error {
+	if event == nil {
+		return nil
+	}
+	detail, err := json.Marshal(event)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "marshal event error. id: %s", event.ID)
+	}
+	output, err := p.client.PutEventsWithContext(ctx, &eventbridge.PutEventsInput{
+		Entries: []*eventbridge.PutEventsRequestEntry{
+			{
+				EventBusName: aws.String(p.busName),
+				Source:       aws.String(event.Source),
+				DetailType:   aws.String(string(event.Type)),
+				Detail:       aws.String(string(detail)),
+				Time:         aws.Time(event.OccurredAt),
+				Resources:    []*string{},
+			},
+		},
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "put event error. bus: %s, id: %s", p.busName, event.ID)
+	}
+	if aws.Int64Value(output.FailedEntryCount) > 0 && len(output.Entries) > 0 {
+		return errors.Errorf("put event failed. bus: %s, id: %s, code: %s, message: %s", p.busName, event.ID,
+			aws.StringValue(output.Entries[0].ErrorCode), aws.StringValue(output.Entries[0].ErrorMessage))
+	}
+	return nil
+}
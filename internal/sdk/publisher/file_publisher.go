@@ -0,0 +1,64 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// FilePublisher
+// implements domain.DummyEventPublisher by appending events to a local file as json lines.
+type FilePublisher struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFilePublisher
+//
+//	@param path the file is created when missing
+//	@return *FilePublisher
+func NewFilePublisher(path string) *FilePublisher {
+	return &FilePublisher{
+		path: path,
+	}
+}
+
+// Publish
+//
+//	@receiver p
+//	@param ctx
+//	@param event
+//	@return error
+func (p *FilePublisher) Publish(ctx context.Context, event *domain.DummyEvent) error {
+	if event == nil {
+		return nil
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "marshal event error. id: %s", event.ID)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	file, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "open event file error. path: %s", p.path)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		_ = file.Close()
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "write event file error. path: %s", p.path)
+	}
+	err = file.Close()
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "close event file error. path: %s", p.path)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package publisher
+
+import (
+	"context"
+	"sync"
+
+	"local.com/go-clean-lambda/internal/domain"
+)
+
+// MemoryPublisher
+// implements domain.DummyEventPublisher by keeping events in memory, safe for concurrent use.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []*domain.DummyEvent
+}
+
+// NewMemoryPublisher
+//
+//	@return *MemoryPublisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{
+		events: []*domain.DummyEvent{},
+	}
+}
+
+// Publish
+//
+//	@receiver p
+//	@param ctx
+//	@param event
+//	@return error
+func (p *MemoryPublisher) Publish(ctx context.Context, event *domain.DummyEvent) error {
+	if event == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	copied := *event
+	p.events = append(p.events, &copied)
+	return nil
+}
+
+// Events
+//
+//	@receiver p
+//	@return []*domain.DummyEvent published events in order
+func (p *MemoryPublisher) Events() []*domain.DummyEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := make([]*domain.DummyEvent, len(p.events))
+	copy(events, p.events)
+	return events
+}
@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+// channelRetryDelay delay before a failed job is enqueued again.
+const channelRetryDelay time.Duration = time.Second
+
+// ChannelJobQueue
+// implements domain.JobQueue with an in-process channel for local runs, jobs are lost when the process exits.
+type ChannelJobQueue struct {
+	jobs chan string
+}
+
+// NewChannelJobQueue
+//
+//	@param size jobs Enqueue keeps before it blocks
+//	@return *ChannelJobQueue
+func NewChannelJobQueue(size int) *ChannelJobQueue {
+	return &ChannelJobQueue{
+		jobs: make(chan string, size),
+	}
+}
+
+// Enqueue
+//
+//	@receiver q
+//	@param ctx
+//	@param jobID
+//	@return error
+func (q *ChannelJobQueue) Enqueue(ctx context.Context, jobID string) error {
+	select {
+	case q.jobs <- jobID:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrapf(errors.New(ctx.Err().Error()), "enqueue job canceled. id: %s", jobID)
+	}
+}
+
+// Consume
+// handle the jobs one by one until ctx is done, a failed job is enqueued again after a delay
+//
+//	@receiver q
+//	@param ctx
+//	@param handler
+func (q *ChannelJobQueue) Consume(ctx context.Context, handler func(ctx context.Context, jobID string) error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case jobID := <-q.jobs:
+			err := handler(ctx, jobID)
+			if err != nil {
+				logger.Error("failed to handle job, retry it later. id: %s", err, jobID)
+				go func(jobID string) {
+					select {
+					case <-time.After(channelRetryDelay):
+						_ = q.Enqueue(ctx, jobID)
+					case <-ctx.Done():
+					}
+				}(jobID)
+			}
+		}
+	}
+}
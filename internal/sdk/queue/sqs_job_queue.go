@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/pkg/errors"
+)
+
+// JobMessage body of the messages of job queues.
+type JobMessage struct {
+	JobID string `json:"jobId"`
+}
+
+// SQSJobQueue
+// implements domain.JobQueue by sending messages to a sqs queue.
+type SQSJobQueue struct {
+	queueName string
+	client    sqsiface.SQSAPI
+	mu        sync.Mutex
+	// queueURL resolved from the name by the first Enqueue
+	queueURL string
+}
+
+// NewSQSJobQueue
+//
+//	@param queueName
+//	@param client
+//	@return *SQSJobQueue
+func NewSQSJobQueue(queueName string, client sqsiface.SQSAPI) *SQSJobQueue {
+	return &SQSJobQueue{
+		queueName: queueName,
+		client:    client,
+	}
+}
+
+// Enqueue
+//
+//	@receiver q
+//	@param ctx
+//	@param jobID
+//	@return error
+func (q *SQSJobQueue) Enqueue(ctx context.Context, jobID string) error {
+	queueURL, err := q.getQueueURL(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(&JobMessage{JobID: jobID})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "marshal job message error. id: %s", jobID)
+	}
+	_, err = q.client.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueURL),
+		MessageBody: aws.String(string(body)),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return errors.Wrapf(rootErr, "send job message error. queue: %s, id: %s", q.queueName, jobID)
+	}
+	return nil
+}
+
+// getQueueURL
+//
+//	@receiver q
+//	@param ctx
+//	@return string
+//	@return error
+func (q *SQSJobQueue) getQueueURL(ctx context.Context) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.queueURL) > 0 {
+		return q.queueURL, nil
+	}
+	output, err := q.client.GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{
+		QueueName: aws.String(q.queueName),
+	})
+	if err != nil {
+		rootErr := errors.New(err.Error())
+		return "", errors.Wrapf(rootErr, "get queue url error. queue: %s", q.queueName)
+	}
+	q.queueURL = aws.StringValue(output.QueueUrl)
+	return q.queueURL, nil
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+)
+
+// defaultRelayBatchSize events read from the outbox at once.
+const defaultRelayBatchSize int = 25
+
+// DummyEventRelay
+// publishes the events of the outbox.
+type DummyEventRelay struct {
+	outbox    domain.DummyOutbox
+	publisher domain.DummyEventPublisher
+	batchSize int
+}
+
+// NewDummyEventRelay
+//
+//	@param outbox
+//	@param publisher
+//	@return *DummyEventRelay
+func NewDummyEventRelay(outbox domain.DummyOutbox, publisher domain.DummyEventPublisher) *DummyEventRelay {
+	return &DummyEventRelay{
+		outbox:    outbox,
+		publisher: publisher,
+		batchSize: defaultRelayBatchSize,
+	}
+}
+
+// WithBatchSize
+//
+//	@receiver relay
+//	@param size events read from the outbox at once
+//	@return *DummyEventRelay
+func (relay *DummyEventRelay) WithBatchSize(size int) *DummyEventRelay {
+	if size > 0 {
+		relay.batchSize = size
+	}
+	return relay
+}
+
+// Relay
+// publish pending events from the oldest one until the outbox is empty, an event is deleted after it is published.
+// it stops at the first failure so later events are not published before it, the next run publishes it again.
+//
+//	@receiver relay
+//	@param ctx
+//	@return int published events
+//	@return error
+func (relay *DummyEventRelay) Relay(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		events, err := relay.outbox.ListOutbox(ctx, relay.batchSize)
+		if err != nil {
+			return published, errors.Wrap(err, "list outbox error")
+		}
+		for _, event := range events {
+			err = relay.publisher.Publish(ctx, event)
+			if err != nil {
+				return published, errors.Wrapf(err, "publish event error. id: %s, type: %s", event.ID, event.Type)
+			}
+			err = relay.outbox.DeleteOutbox(ctx, event)
+			if err != nil {
+				// the event is published again by the next run, consumers deduplicate it by id
+				return published, errors.Wrapf(err, "delete published event error. id: %s", event.ID)
+			}
+			published++
+		}
+		if len(events) < relay.batchSize {
+			if published > 0 {
+				logger.Info("dummy events relayed. count: %d", published)
+			}
+			return published, nil
+		}
+	}
+}
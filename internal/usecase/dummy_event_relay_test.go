@@ -0,0 +1,99 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/sdk/publisher"
+	"local.com/go-clean-lambda/internal/usecase"
+)
+
+func TestDummyEventRelayWithPendingEventsPublishInOrder(t *testing.T) {
+	outbox := NewDummyMockOutbox(7)
+	pub := publisher.NewMemoryPublisher()
+	relay := usecase.NewDummyEventRelay(outbox, pub).WithBatchSize(3)
+
+	published, err := relay.Relay(context.TODO())
+
+	msg := "failed to relay events"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal(7, published, msg, "published count")
+	assertions.Empty(outbox.events, msg, "outbox not empty")
+	events := pub.Events()
+	assertions.Len(events, 7, msg, "published events")
+	for i, event := range events {
+		assertions.Equal(fmt.Sprintf("event_%d", i), event.ID, msg, "event order")
+	}
+}
+
+func TestDummyEventRelayWithPublishErrorKeepFailedEvents(t *testing.T) {
+	outbox := NewDummyMockOutbox(5)
+	pub := &failingPublisher{
+		MemoryPublisher: publisher.NewMemoryPublisher(),
+		failID:          "event_2",
+	}
+	relay := usecase.NewDummyEventRelay(outbox, pub).WithBatchSize(10)
+
+	published, err := relay.Relay(context.TODO())
+
+	msg := "relay didn't stop at the failed event"
+	assertions := assert.New(t)
+	assertions.ErrorIs(err, errBadPublish, msg, "error type")
+	assertions.Equal(2, published, msg, "published count")
+	assertions.Len(pub.Events(), 2, msg, "published events")
+	assertions.Len(outbox.events, 3, msg, "events left in outbox")
+	assertions.Equal("event_2", outbox.events[0].ID, msg, "first event left")
+}
+
+var errBadPublish = errors.New("mocked publish error")
+
+type failingPublisher struct {
+	*publisher.MemoryPublisher
+	failID string
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, event *domain.DummyEvent) error {
+	if event.ID == p.failID {
+		return errBadPublish
+	}
+	return p.MemoryPublisher.Publish(ctx, event)
+}
+
+type DummyMockOutbox struct {
+	events []*domain.DummyEvent
+}
+
+func NewDummyMockOutbox(count int) *DummyMockOutbox {
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := make([]*domain.DummyEvent, 0, count)
+	for i := 0; i < count; i++ {
+		event := domain.NewDummyEvent(fmt.Sprintf("id_%d", i), testOwnerID, at)
+		event.ID = fmt.Sprintf("event_%d", i)
+		event.Type = domain.DummyCreated
+		events = append(events, event)
+	}
+	return &DummyMockOutbox{events: events}
+}
+
+func (o *DummyMockOutbox) ListOutbox(ctx context.Context, limit int) ([]*domain.DummyEvent, error) {
+	if limit > len(o.events) {
+		limit = len(o.events)
+	}
+	return append([]*domain.DummyEvent{}, o.events[:limit]...), nil
+}
+
+func (o *DummyMockOutbox) DeleteOutbox(ctx context.Context, event *domain.DummyEvent) error {
+	for i, pending := range o.events {
+		if pending.ID == event.ID {
+			o.events = append(o.events[:i], o.events[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
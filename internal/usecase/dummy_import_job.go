@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// JobTypeDummyImport adds a list of DummyBo in batches.
+const JobTypeDummyImport string = "dummy-import"
+
+// DummyImportResult.
+type DummyImportResult struct {
+	Imported int                  `json:"imported"`
+	Failed   []*DummyImportFailed `json:"failed"`
+}
+
+// DummyImportFailed an item which is not imported.
+type DummyImportFailed struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// NewDummyImportJob
+// the items are added by AddBatch of MaxBatchSize, the progress is reported after every batch
+//
+//	@param uc
+//	@return JobExecutor input is a json array of DummyBo, the result is a DummyImportResult
+func NewDummyImportJob(uc *DummyUseCase) JobExecutor {
+	return func(ctx context.Context, input json.RawMessage, progress JobProgressFunc) (interface{}, error) {
+		bos := []*DummyBo{}
+		err := json.Unmarshal(input, &bos)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidInput, "unmarshal import input error: %s", err.Error())
+		}
+		result := &DummyImportResult{
+			Failed: []*DummyImportFailed{},
+		}
+		for start := 0; start < len(bos); start += MaxBatchSize {
+			end := start + MaxBatchSize
+			if end > len(bos) {
+				end = len(bos)
+			}
+			batchResults, err := uc.AddBatch(ctx, bos[start:end])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to import items from %d to %d", start, end)
+			}
+			for _, batchResult := range batchResults {
+				if batchResult.Err != nil {
+					result.Failed = append(result.Failed, &DummyImportFailed{
+						ID:    batchResult.ID,
+						Error: batchResult.Err.Error(),
+					})
+					continue
+				}
+				result.Imported++
+			}
+			err = progress(ctx, end, len(bos))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to report import progress")
+			}
+		}
+		return result, nil
+	}
+}
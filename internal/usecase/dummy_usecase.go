@@ -3,19 +3,40 @@ package usecase
 import (
 	"context"
 	nativeerr "errors"
+	"time"
 
 	"github.com/pkg/errors"
 	"local.com/go-clean-lambda/internal/domain"
 	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+	"local.com/go-clean-lambda/internal/sdk/authorization"
 )
 
-var ErrInvalidInput error = nativeerr.New("invalid input")
+// MaxBatchSize limit of items in one bulk call.
+const MaxBatchSize int = 1000
+
+// ownerAttr json field name of the owner of an item, used as a delete condition.
+const ownerAttr string = "createdBy"
+
+// endOfHistory reads the latest record of the history.
+var endOfHistory time.Time = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+var (
+	ErrInvalidInput error = nativeerr.New("invalid input")
+	// ErrForbidden the caller is neither the owner of the item nor an admin
+	ErrForbidden error = nativeerr.New("forbidden")
+)
 
 // DummyBo.
 type DummyBo struct {
 	ID   string
 	Name string
 	Attr string
+	// audit fields are set by DummyUseCase, input values are ignored
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt time.Time
+	UpdatedBy string
 }
 
 // IsValid
@@ -26,19 +47,94 @@ func (cbo *DummyBo) IsValid() bool {
 	return cbo != nil && len(cbo.ID) > 0 && len(cbo.Name) > 0
 }
 
+// DummyHistoryBo
+// one change of an item.
+type DummyHistoryBo struct {
+	ID        string
+	Version   int64
+	Change    string
+	ChangedAt time.Time
+	ChangedBy string
+	// Bo the item after the change, the removed item for a removal
+	Bo *DummyBo
+}
+
+// DummyBatchResultBo
+// result of one item of a bulk call, results are in the order of the input.
+type DummyBatchResultBo struct {
+	ID string
+	Bo *DummyBo
+	// Err is ErrInvalidInput, ErrForbidden, domain.ErrNotFound, domain.ErrBatchItemUnprocessed or others when the item
+	// failed
+	Err error
+}
+
 // DummyUseCase.
 type DummyUseCase struct {
-	dummyRepo domain.DummyRepository
+	dummyRepo  domain.DummyRepository
+	transactor domain.Transactor
+	now        func() time.Time
+	// adminBits permissions allowed to modify items of other users
+	adminBits []uint64
 }
 
 // NewDummyUseCase
 //
 //	@param dummyRepo
+//	@param transactor nil to run transactions without a unit of work
 //	@return *DummyUseCase
-func NewDummyUseCase(dummyRepo domain.DummyRepository) *DummyUseCase {
+func NewDummyUseCase(dummyRepo domain.DummyRepository, transactor domain.Transactor) *DummyUseCase {
 	return &DummyUseCase{
-		dummyRepo: dummyRepo,
+		dummyRepo:  dummyRepo,
+		transactor: transactor,
+		now:        time.Now,
+	}
+}
+
+// WithClock
+// replace the clock used for audit timestamps
+//
+//	@receiver uc
+//	@param now
+//	@return *DummyUseCase
+func (uc *DummyUseCase) WithClock(now func() time.Time) *DummyUseCase {
+	uc.now = now
+	return uc
+}
+
+// WithAdminPermission
+// holders of any of the bits may modify and remove items of other users, the super admin always may
+//
+//	@receiver uc
+//	@param bits
+//	@return *DummyUseCase
+func (uc *DummyUseCase) WithAdminPermission(bits ...uint64) *DummyUseCase {
+	uc.adminBits = []uint64{}
+	for _, bit := range bits {
+		// a zero bit is held by everyone
+		if bit != authorization.NonePermissionBit {
+			uc.adminBits = append(uc.adminBits, bit)
+		}
+	}
+	return uc
+}
+
+// Transaction
+// run fn inside a unit of work, repository writes with the context passed to fn are committed together.
+//
+//	@receiver uc
+//	@param ctx
+//	@param fn
+//	@return error
+func (uc *DummyUseCase) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if uc.transactor == nil {
+		return fn(ctx)
+	}
+	err := uc.transactor.WithinTransaction(ctx, fn)
+	if err != nil {
+		return errors.Wrap(err, "transaction failed")
 	}
+	return nil
 }
 
 // Get
@@ -65,30 +161,493 @@ func (uc *DummyUseCase) Get(ctx context.Context, id string) (*DummyBo, error) {
 //	@param ctx
 //	@param bo
 //	@return *DummyBo
-//	@return error
+//	@return error ErrInvalidInput, ErrForbidden when the caller may not replace the existing item, and others
 func (uc *DummyUseCase) Add(ctx context.Context, bo *DummyBo) (*DummyBo, error) {
 	if bo == nil || !bo.IsValid() {
 		return nil, errors.Wrapf(ErrInvalidInput, "invalid bo: %s", logger.Pretty(bo))
 	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. id: %s", bo.ID)
+	}
+	existing, err := uc.dummyRepo.GetByID(ctx, bo.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s", bo.ID)
+	}
+	if !uc.canModify(caller, existing) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, id: %s", caller.UserID, bo.ID)
+	}
+	now := uc.now().UTC()
 	entity := uc.buildEntity(bo)
-	entity, err := uc.dummyRepo.Insert(ctx, entity)
+	uc.stamp(ctx, entity, existing, now)
+	event := domain.NewDummyEvent(entity.ID, caller.UserID, now)
+	entity, err = uc.dummyRepo.Insert(withChange(ctx, caller.UserID, now, event), entity, uc.ownerOptions(caller)...)
+	if errors.Is(err, domain.ErrConditionFailed) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, id: %s", caller.UserID, bo.ID)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "repository error with bo: %s", logger.Pretty(bo))
 	}
 	return uc.buildBo(entity), nil
 }
 
+// Remove
+//
+//	@receiver uc
+//	@param ctx
+//	@param id
+//	@return *DummyBo the removed item
+//	@return error ErrInvalidInput, ErrForbidden, domain.ErrNotFound and others
 func (uc *DummyUseCase) Remove(ctx context.Context, id string) (*DummyBo, error) {
 	if len(id) == 0 {
 		return nil, errors.Wrap(ErrInvalidInput, "blank id")
 	}
-	entity, err := uc.dummyRepo.DeleteByID(ctx, id)
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. id: %s", id)
+	}
+	now := uc.now().UTC()
+	event := domain.NewDummyEvent(id, caller.UserID, now)
+	entity, err := uc.dummyRepo.DeleteByID(withChange(ctx, caller.UserID, now, event), id, uc.ownerOptions(caller)...)
+	if errors.Is(err, domain.ErrConditionFailed) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, id: %s", caller.UserID, id)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s", id)
+	}
+	return uc.buildBo(entity), nil
+}
+
+// RestoreDeleted
+// restore a removed item kept by the repository within its retention window
+//
+//	@receiver uc
+//	@param ctx
+//	@param id
+//	@return *DummyBo the restored item
+//	@return error ErrInvalidInput, ErrForbidden, domain.ErrNotFound and others
+func (uc *DummyUseCase) RestoreDeleted(ctx context.Context, id string) (*DummyBo, error) {
+	if len(id) == 0 {
+		return nil, errors.Wrap(ErrInvalidInput, "blank id")
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. id: %s", id)
+	}
+	now := uc.now().UTC()
+	event := domain.NewDummyEvent(id, caller.UserID, now)
+	entity, err := uc.dummyRepo.RestoreByID(withChange(ctx, caller.UserID, now, event), id, uc.ownerOptions(caller)...)
+	if errors.Is(err, domain.ErrConditionFailed) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, id: %s", caller.UserID, id)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s", id)
+	}
+	return uc.buildBo(entity), nil
+}
+
+// AddBatch
+// invalid items fail alone, the other items are still added
+//
+//	@receiver uc
+//	@param ctx
+//	@param bos
+//	@return []*DummyBatchResultBo one per bo
+//	@return error ErrInvalidInput when the batch is empty or too large, ErrForbidden without a caller, and others
+func (uc *DummyUseCase) AddBatch(ctx context.Context, bos []*DummyBo) ([]*DummyBatchResultBo, error) {
+	if len(bos) == 0 || len(bos) > MaxBatchSize {
+		return nil, errors.Wrapf(ErrInvalidInput, "batch size: %d, max: %d", len(bos), MaxBatchSize)
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrap(ErrForbidden, "no authenticated user")
+	}
+	results := make([]*DummyBatchResultBo, len(bos))
+	ids := []string{}
+	positions := []int{}
+	for i, bo := range bos {
+		results[i] = &DummyBatchResultBo{}
+		if bo != nil {
+			results[i].ID = bo.ID
+		}
+		if !bo.IsValid() {
+			results[i].Err = errors.Wrapf(ErrInvalidInput, "invalid bo at %d: %s", i, logger.Pretty(bo))
+			continue
+		}
+		ids = append(ids, bo.ID)
+		positions = append(positions, i)
+	}
+	if len(ids) == 0 {
+		return results, nil
+	}
+	existing, err := uc.dummyRepo.BatchGetByIDs(ctx, uniqueIDs(ids))
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with batch size: %d", len(ids))
+	}
+	existingByID := make(map[string]*domain.DummyBatchResult, len(existing))
+	for _, result := range existing {
+		existingByID[result.ID] = result
+	}
+	now := uc.now().UTC()
+	entities := []*domain.Dummy{}
+	events := []*domain.DummyEvent{}
+	entityPositions := []int{}
+	for _, i := range positions {
+		var current *domain.Dummy
+		if result := existingByID[bos[i].ID]; result != nil {
+			if result.Err != nil {
+				results[i].Err = result.Err
+				continue
+			}
+			current = result.Dummy
+		}
+		if !uc.canModify(caller, current) {
+			results[i].Err = errors.Wrapf(ErrForbidden, "user: %s, id: %s", caller.UserID, bos[i].ID)
+			continue
+		}
+		entity := uc.buildEntity(bos[i])
+		uc.stamp(ctx, entity, current, now)
+		entities = append(entities, entity)
+		events = append(events, domain.NewDummyEvent(entity.ID, caller.UserID, now))
+		entityPositions = append(entityPositions, i)
+	}
+	positions = entityPositions
+	if len(entities) == 0 {
+		return results, nil
+	}
+	repoResults, err := uc.dummyRepo.BatchInsert(withChange(ctx, caller.UserID, now, events...), entities,
+		uc.ownerOptions(caller)...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with batch size: %d", len(entities))
+	}
+	for i, repoResult := range repoResults {
+		results[positions[i]] = uc.buildBatchResultBo(repoResult)
+	}
+	return results, nil
+}
+
+// RemoveBatch
+// a missing id fails with domain.ErrNotFound, the owners are checked by the repository with the deletes
+//
+//	@receiver uc
+//	@param ctx
+//	@param ids
+//	@return []*DummyBatchResultBo one per id
+//	@return error ErrInvalidInput when the batch is empty or too large, ErrForbidden without a caller, and others
+func (uc *DummyUseCase) RemoveBatch(ctx context.Context, ids []string) ([]*DummyBatchResultBo, error) {
+	if len(ids) == 0 || len(ids) > MaxBatchSize {
+		return nil, errors.Wrapf(ErrInvalidInput, "batch size: %d, max: %d", len(ids), MaxBatchSize)
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrap(ErrForbidden, "no authenticated user")
+	}
+	results := make([]*DummyBatchResultBo, len(ids))
+	validIDs := []string{}
+	positions := []int{}
+	for i, id := range ids {
+		if len(id) == 0 {
+			results[i] = &DummyBatchResultBo{Err: errors.Wrapf(ErrInvalidInput, "blank id at %d", i)}
+			continue
+		}
+		validIDs = append(validIDs, id)
+		positions = append(positions, i)
+	}
+	if len(validIDs) == 0 {
+		return results, nil
+	}
+	now := uc.now().UTC()
+	events := make([]*domain.DummyEvent, 0, len(validIDs))
+	for _, id := range validIDs {
+		events = append(events, domain.NewDummyEvent(id, caller.UserID, now))
+	}
+	repoResults, err := uc.dummyRepo.BatchDeleteByIDs(withChange(ctx, caller.UserID, now, events...), validIDs,
+		uc.ownerOptions(caller)...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with batch size: %d", len(validIDs))
+	}
+	for i, repoResult := range repoResults {
+		results[positions[i]] = uc.buildBatchResultBo(repoResult)
+	}
+	return results, nil
+}
+
+// GetAsOf
+// the past of a deleted item is read only by its owner or an admin
+//
+//	@receiver uc
+//	@param ctx
+//	@param id
+//	@param asOf
+//	@return *DummyBo the item as it was at asOf, nil when it did not exist then
+//	@return error ErrInvalidInput, ErrForbidden and others
+func (uc *DummyUseCase) GetAsOf(ctx context.Context, id string, asOf time.Time) (*DummyBo, error) {
+	if len(id) == 0 {
+		return nil, errors.Wrap(ErrInvalidInput, "blank id")
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. id: %s", id)
+	}
+	latest, err := uc.dummyRepo.GetHistoryAsOf(ctx, id, endOfHistory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s", id)
+	}
+	if !uc.canReadHistory(caller, latest) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, deleted id: %s", caller.UserID, id)
+	}
+	record, err := uc.dummyRepo.GetHistoryAsOf(ctx, id, asOf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s, as of: %s", id, asOf)
+	}
+	if record == nil || record.Change == domain.DummyChangeDeleted {
+		return nil, nil
+	}
+	return uc.buildBo(record.Snapshot), nil
+}
+
+// ListHistory
+// the history of a deleted item is read only by its owner or an admin
+//
+//	@receiver uc
+//	@param ctx
+//	@param id
+//	@return []*DummyHistoryBo changes in version order
+//	@return error ErrInvalidInput, ErrForbidden and others
+func (uc *DummyUseCase) ListHistory(ctx context.Context, id string) ([]*DummyHistoryBo, error) {
+	if len(id) == 0 {
+		return nil, errors.Wrap(ErrInvalidInput, "blank id")
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. id: %s", id)
+	}
+	records, err := uc.dummyRepo.ListHistory(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s", id)
+	}
+	if len(records) > 0 && !uc.canReadHistory(caller, records[len(records)-1]) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, deleted id: %s", caller.UserID, id)
+	}
+	bos := make([]*DummyHistoryBo, 0, len(records))
+	for _, record := range records {
+		bos = append(bos, &DummyHistoryBo{
+			ID:        record.ID,
+			Version:   record.Version,
+			Change:    string(record.Change),
+			ChangedAt: record.ChangedAt,
+			ChangedBy: record.ChangedBy,
+			Bo:        uc.buildBo(record.Snapshot),
+		})
+	}
+	return bos, nil
+}
+
+// Restore
+// write the item as it was after the change of version, a removed item is added back.
+// the restore is recorded as a new version.
+//
+//	@receiver uc
+//	@param ctx
+//	@param id
+//	@param version
+//	@return *DummyBo the restored item
+//	@return error ErrInvalidInput, ErrForbidden, domain.ErrNotFound when the version is missing, and others
+func (uc *DummyUseCase) Restore(ctx context.Context, id string, version int64) (*DummyBo, error) {
+	if len(id) == 0 || version < 1 {
+		return nil, errors.Wrapf(ErrInvalidInput, "id: %s, version: %d", id, version)
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. id: %s", id)
+	}
+	record, err := uc.dummyRepo.GetHistoryVersion(ctx, id, version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s, version: %d", id, version)
+	}
+	if record == nil {
+		return nil, errors.Wrapf(domain.ErrNotFound, "no history. id: %s, version: %d", id, version)
+	}
+	if record.Change == domain.DummyChangeDeleted {
+		return nil, errors.Wrapf(ErrInvalidInput, "version removed the item. id: %s, version: %d", id, version)
+	}
+	current, err := uc.dummyRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, errors.Wrapf(err, "repository error with id: %s", id)
 	}
+	existing := current
+	if existing == nil {
+		// a removed item belongs to the owner it had
+		existing = record.Snapshot
+	}
+	if !uc.canModify(caller, existing) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, id: %s", caller.UserID, id)
+	}
+	now := uc.now().UTC()
+	entity := uc.buildEntity(uc.buildBo(record.Snapshot))
+	uc.stamp(ctx, entity, existing, now)
+	event := domain.NewDummyEvent(id, caller.UserID, now)
+	entity, err = uc.dummyRepo.Insert(withChange(ctx, caller.UserID, now, event), entity, uc.ownerOptions(caller)...)
+	if errors.Is(err, domain.ErrConditionFailed) {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, id: %s", caller.UserID, id)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with id: %s, version: %d", id, version)
+	}
+	logger.Info("dummy restored. id: %s, version: %d, user: %s", id, version, caller.UserID)
 	return uc.buildBo(entity), nil
 }
 
+// canModify
+//
+//	@receiver uc
+//	@param caller
+//	@param existing nil when the item is new
+//	@return bool true when the item is new, owned by the caller or the caller is an admin
+func (uc *DummyUseCase) canModify(caller authentication.UserContext, existing *domain.Dummy) bool {
+	return existing == nil || existing.CreatedBy == caller.UserID || uc.isAdmin(caller)
+}
+
+// canReadHistory
+// a deleted item, soft deleted or not, belongs to the owner it had, like RestoreDeleted
+//
+//	@receiver uc
+//	@param caller
+//	@param latest the latest record of the history, nil when there is none
+//	@return bool true when the item is not deleted, owned by the caller or the caller is an admin
+func (uc *DummyUseCase) canReadHistory(caller authentication.UserContext, latest *domain.DummyHistory) bool {
+	if latest == nil || latest.Change != domain.DummyChangeDeleted {
+		return true
+	}
+	return uc.canModify(caller, latest.Snapshot)
+}
+
+// isAdmin
+//
+//	@receiver uc
+//	@param caller
+//	@return bool
+func (uc *DummyUseCase) isAdmin(caller authentication.UserContext) bool {
+	if caller.PermissionBit == authorization.SuperAdminBit {
+		return true
+	}
+	if len(uc.adminBits) == 0 {
+		return false
+	}
+	ok, err := authorization.HasAuthority(caller.PermissionBit, uc.adminBits)
+	return err == nil && ok
+}
+
+// ownerOptions
+// the owner is checked by the repository with the write, so it cannot change after it was read
+//
+//	@receiver uc
+//	@param caller
+//	@return []domain.DeleteOption none for an admin
+func (uc *DummyUseCase) ownerOptions(caller authentication.UserContext) []domain.DeleteOption {
+	if uc.isAdmin(caller) {
+		return nil
+	}
+	return []domain.DeleteOption{domain.WithExpectedAttr(ownerAttr, caller.UserID)}
+}
+
+// stamp
+// set the audit fields of entity, the creation fields of an existing item are kept
+//
+//	@receiver uc
+//	@param ctx
+//	@param entity
+//	@param existing nil when the item is new
+//	@param now
+func (uc *DummyUseCase) stamp(ctx context.Context, entity *domain.Dummy, existing *domain.Dummy, now time.Time) {
+	userID := userIDFromContext(ctx)
+	entity.UpdatedAt = now
+	entity.UpdatedBy = userID
+	if existing == nil {
+		entity.CreatedAt = now
+		entity.CreatedBy = userID
+		return
+	}
+	entity.CreatedAt = existing.CreatedAt
+	entity.CreatedBy = existing.CreatedBy
+}
+
+// withChange
+// record who makes the changes and their events, the repository writes them with the changes
+//
+//	@param ctx
+//	@param by
+//	@param at
+//	@param events
+//	@return context.Context
+func withChange(ctx context.Context, by string, at time.Time, events ...*domain.DummyEvent) context.Context {
+	return domain.WithDummyEvents(domain.WithChange(ctx, by, at), events...)
+}
+
+// userIDFromContext
+//
+//	@param ctx
+//	@return string id of the authenticated user, empty when there is none
+func userIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(authentication.UserIDKey).(string)
+	return userID
+}
+
+// callerFromContext
+// the user context is set by the login middleware
+//
+//	@param ctx
+//	@return authentication.UserContext
+//	@return bool false when there is no authenticated user
+func callerFromContext(ctx context.Context) (authentication.UserContext, bool) {
+	caller, ok := ctx.Value(authentication.UserContextKey).(authentication.UserContext)
+	if !ok {
+		// only the user id is known, e.g. set by another entry than the login middleware
+		caller = authentication.UserContext{UserID: userIDFromContext(ctx)}
+	}
+	if len(caller.UserID) == 0 {
+		return authentication.UserContext{}, false
+	}
+	return caller, true
+}
+
+// uniqueIDs
+//
+//	@param ids
+//	@return []string ids without repeats, in the order of the first occurrence
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// buildBatchResultBo
+//
+//	@receiver uc
+//	@param result
+//	@return *DummyBatchResultBo
+func (uc *DummyUseCase) buildBatchResultBo(result *domain.DummyBatchResult) *DummyBatchResultBo {
+	bo := &DummyBatchResultBo{
+		ID:  result.ID,
+		Bo:  uc.buildBo(result.Dummy),
+		Err: result.Err,
+	}
+	if errors.Is(result.Err, domain.ErrBatchItemInvalid) {
+		bo.Err = errors.Wrap(ErrInvalidInput, result.Err.Error())
+	}
+	if errors.Is(result.Err, domain.ErrConditionFailed) {
+		bo.Err = errors.Wrap(ErrForbidden, result.Err.Error())
+	}
+	return bo
+}
+
 // buildEntity
 //
 //	@receiver uc
@@ -115,8 +674,12 @@ func (uc *DummyUseCase) buildBo(entity *domain.Dummy) *DummyBo {
 		return nil
 	}
 	return &DummyBo{
-		ID:   entity.ID,
-		Name: entity.Name,
-		Attr: entity.SomeAttr,
+		ID:        entity.ID,
+		Name:      entity.Name,
+		Attr:      entity.SomeAttr,
+		CreatedAt: entity.CreatedAt,
+		CreatedBy: entity.CreatedBy,
+		UpdatedAt: entity.UpdatedAt,
+		UpdatedBy: entity.UpdatedBy,
 	}
 }
@@ -4,25 +4,39 @@ import (
 	"context"
 	nativeerr "errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"local.com/go-clean-lambda/internal/domain"
 	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
 	"local.com/go-clean-lambda/internal/usecase"
 )
 
 const (
 	invalidDummyID string = "invalid_dummy_id"
+	testOwnerID    string = "test_owner"
+	testAdminBit   uint64 = 0b1000
 )
 
 var errBadRepositoryAction error = nativeerr.New("mocked repo error")
 
+// userCtx
+// context of an authenticated user as set by the login middleware
+func userCtx(userID string, permissionBit uint64) context.Context {
+	ctx := context.WithValue(context.TODO(), authentication.UserContextKey, authentication.UserContext{
+		UserID:        userID,
+		PermissionBit: permissionBit,
+	})
+	return context.WithValue(ctx, authentication.UserIDKey, userID)
+}
+
 func TestTraceableErrorLog(t *testing.T) {
 	items := []*domain.Dummy{}
 	repo := NewDummyMockRepository(items)
-	u := usecase.NewDummyUseCase(repo)
+	u := usecase.NewDummyUseCase(repo, nil)
 
 	_, err := u.Get(context.TODO(), invalidDummyID)
 
@@ -35,7 +49,7 @@ func TestTraceableErrorLog(t *testing.T) {
 func TestDummyGetWithBadRepoReturnError(t *testing.T) {
 	items := []*domain.Dummy{}
 	repo := NewDummyMockRepository(items)
-	u := usecase.NewDummyUseCase(repo)
+	u := usecase.NewDummyUseCase(repo, nil)
 
 	bo, err := u.Get(context.TODO(), invalidDummyID)
 
@@ -48,7 +62,7 @@ func TestDummyGetWithBadRepoReturnError(t *testing.T) {
 func TestDummyGetWithEmptyIDReturnError(t *testing.T) {
 	items := []*domain.Dummy{}
 	repo := NewDummyMockRepository(items)
-	u := usecase.NewDummyUseCase(repo)
+	u := usecase.NewDummyUseCase(repo, nil)
 
 	bo, err := u.Get(context.TODO(), "")
 	msg := "get with empty id didn't fail"
@@ -66,7 +80,7 @@ func TestDummyGetWithIDReturnBo(t *testing.T) {
 	}
 	items := []*domain.Dummy{item}
 	repo := NewDummyMockRepository(items)
-	u := usecase.NewDummyUseCase(repo)
+	u := usecase.NewDummyUseCase(repo, nil)
 
 	bo, err := u.Get(context.TODO(), item.ID)
 	msg := "failed to get dummy bo by id"
@@ -78,8 +92,437 @@ func TestDummyGetWithIDReturnBo(t *testing.T) {
 	assertions.Equal(item.SomeAttr, bo.Attr, msg, "attr")
 }
 
+func TestDummyRemoveWithMissingIDReturnNotFound(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	bo, err := u.Remove(userCtx(testOwnerID, 0), uuid.New().String())
+	msg := "remove with missing id didn't fail"
+	assertions := assert.New(t)
+	assertions.NotNil(err, msg, "error not found")
+	assertions.True(errors.Is(err, domain.ErrNotFound), msg, "error type")
+	assertions.Nil(bo, msg, "returned bo")
+}
+
+func TestDummyRemoveWithIDReturnRemovedBo(t *testing.T) {
+	item := &domain.Dummy{
+		ID:        uuid.New().String(),
+		Name:      "test_name",
+		SomeAttr:  "test_attr",
+		CreatedBy: testOwnerID,
+	}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	bo, err := u.Remove(userCtx(testOwnerID, 0), item.ID)
+	msg := "failed to remove dummy bo by id"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.NotNil(bo, msg, "nil bo")
+	assertions.Equal(item.ID, bo.ID, msg, "id")
+	assertions.Equal(item.Name, bo.Name, msg, "name")
+	left, _ := repo.GetByID(context.TODO(), item.ID)
+	assertions.Nil(left, msg, "item left in repo")
+}
+
+func TestDummyTransactionWithFnErrorReturnError(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	transactor := &DummyMockTransactor{}
+	u := usecase.NewDummyUseCase(repo, transactor)
+
+	err := u.Transaction(context.TODO(), func(ctx context.Context) error {
+		return errBadRepositoryAction
+	})
+	msg := "transaction with failed fn didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, errBadRepositoryAction), msg, "error type")
+	assertions.Equal(1, transactor.calls, msg, "transactor not used")
+	assertions.Equal(0, transactor.commits, msg, "committed")
+}
+
+func TestDummyTransactionWithFnDoneCommit(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	transactor := &DummyMockTransactor{}
+	u := usecase.NewDummyUseCase(repo, transactor)
+
+	err := u.Transaction(context.TODO(), func(ctx context.Context) error {
+		return nil
+	})
+	msg := "failed to run transaction"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal(1, transactor.commits, msg, "not committed")
+}
+
+func TestDummyAddBatchWithInvalidItemsReturnPerItemResults(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+	valid := &usecase.DummyBo{ID: uuid.New().String(), Name: "test_name", Attr: "test_attr"}
+	failing := &usecase.DummyBo{ID: invalidDummyID, Name: "test_name"}
+
+	results, err := u.AddBatch(userCtx(testOwnerID, 0), []*usecase.DummyBo{valid, {ID: "no_name"}, nil, failing})
+	msg := "failed to add batch"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Len(results, 4, msg, "result count")
+	assertions.Nil(results[0].Err, msg, "valid item failed")
+	assertions.Equal(valid.ID, results[0].Bo.ID, msg, "valid item bo")
+	assertions.True(errors.Is(results[1].Err, usecase.ErrInvalidInput), msg, "item without name")
+	assertions.Equal("no_name", results[1].ID, msg, "invalid item id")
+	assertions.True(errors.Is(results[2].Err, usecase.ErrInvalidInput), msg, "nil item")
+	assertions.True(errors.Is(results[3].Err, errBadRepositoryAction), msg, "repository item error")
+	stored, _ := repo.GetByID(context.TODO(), valid.ID)
+	assertions.NotNil(stored, msg, "valid item not stored")
+}
+
+func TestDummyAddBatchWithTooManyItemsReturnError(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	results, err := u.AddBatch(userCtx(testOwnerID, 0), make([]*usecase.DummyBo, usecase.MaxBatchSize+1))
+	msg := "add too large batch didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, usecase.ErrInvalidInput), msg, "error type")
+	assertions.Nil(results, msg, "returned results")
+}
+
+func TestDummyRemoveBatchWithIDsReturnPerItemResults(t *testing.T) {
+	item := &domain.Dummy{
+		ID:        uuid.New().String(),
+		Name:      "test_name",
+		SomeAttr:  "test_attr",
+		CreatedBy: testOwnerID,
+	}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	results, err := u.RemoveBatch(userCtx(testOwnerID, 0), []string{item.ID, "", uuid.New().String()})
+	msg := "failed to remove batch"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Len(results, 3, msg, "result count")
+	assertions.Nil(results[0].Err, msg, "existing id failed")
+	assertions.True(errors.Is(results[1].Err, usecase.ErrInvalidInput), msg, "blank id")
+	assertions.True(errors.Is(results[2].Err, domain.ErrNotFound), msg, "missing id")
+	left, _ := repo.GetByID(context.TODO(), item.ID)
+	assertions.Nil(left, msg, "item left in repo")
+}
+
+func TestDummyAddWithNewIDReturnAuditFields(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	u := usecase.NewDummyUseCase(repo, nil).WithClock(func() time.Time { return now })
+	ctx := context.WithValue(context.TODO(), authentication.UserIDKey, "creator")
+
+	bo, err := u.Add(ctx, &usecase.DummyBo{ID: uuid.New().String(), Name: "test_name"})
+	msg := "failed to add dummy with audit fields"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal(now, bo.CreatedAt, msg, "created at")
+	assertions.Equal("creator", bo.CreatedBy, msg, "created by")
+	assertions.Equal(now, bo.UpdatedAt, msg, "updated at")
+	assertions.Equal("creator", bo.UpdatedBy, msg, "updated by")
+}
+
+func TestDummyAddWithExistingIDKeepCreatedFields(t *testing.T) {
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	item := &domain.Dummy{
+		ID:        uuid.New().String(),
+		Name:      "test_name",
+		CreatedAt: created,
+		CreatedBy: "creator",
+		UpdatedAt: created,
+		UpdatedBy: "creator",
+	}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	now := created.Add(time.Hour)
+	u := usecase.NewDummyUseCase(repo, nil).
+		WithClock(func() time.Time { return now }).
+		WithAdminPermission(testAdminBit)
+	ctx := userCtx("updater", testAdminBit)
+
+	results, err := u.AddBatch(ctx, []*usecase.DummyBo{{ID: item.ID, Name: "new_name", CreatedBy: "ignored"}})
+	msg := "failed to update dummy audit fields"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Nil(results[0].Err, msg, "item error")
+	bo := results[0].Bo
+	assertions.Equal(created, bo.CreatedAt, msg, "created at")
+	assertions.Equal("creator", bo.CreatedBy, msg, "created by")
+	assertions.Equal(now, bo.UpdatedAt, msg, "updated at")
+	assertions.Equal("updater", bo.UpdatedBy, msg, "updated by")
+}
+
+func TestDummyAddWithOtherOwnerReturnForbidden(t *testing.T) {
+	item := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: testOwnerID}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil).WithAdminPermission(testAdminBit)
+
+	bo, err := u.Add(userCtx("other", 0), &usecase.DummyBo{ID: item.ID, Name: "new_name"})
+	msg := "add over item of other owner didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, usecase.ErrForbidden), msg, "error type")
+	assertions.Nil(bo, msg, "returned bo")
+	assertions.Equal("test_name", repo.dmap[item.ID].Name, msg, "item changed")
+}
+
+func TestDummyAddWithoutUserReturnForbidden(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	bo, err := u.Add(context.TODO(), &usecase.DummyBo{ID: uuid.New().String(), Name: "test_name"})
+	msg := "add without user didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, usecase.ErrForbidden), msg, "error type")
+	assertions.Nil(bo, msg, "returned bo")
+}
+
+func TestDummyRemoveWithOtherOwnerReturnForbidden(t *testing.T) {
+	item := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: testOwnerID}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil).WithAdminPermission(testAdminBit)
+
+	bo, err := u.Remove(userCtx("other", 0b0100), item.ID)
+	msg := "remove item of other owner didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, usecase.ErrForbidden), msg, "error type")
+	assertions.Nil(bo, msg, "returned bo")
+	assertions.NotNil(repo.dmap[item.ID], msg, "item removed")
+}
+
+func TestDummyRemoveWithAdminReturnRemovedBo(t *testing.T) {
+	item := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: testOwnerID}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil).WithAdminPermission(testAdminBit)
+
+	bo, err := u.Remove(userCtx("admin", testAdminBit|0b0001), item.ID)
+	msg := "failed to remove item by admin"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal(item.ID, bo.ID, msg, "id")
+	assertions.Nil(repo.dmap[item.ID], msg, "item left in repo")
+}
+
+func TestDummyRemoveBatchWithOtherOwnerReturnPerItemForbidden(t *testing.T) {
+	owned := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: testOwnerID}
+	other := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: "other"}
+	items := []*domain.Dummy{owned, other}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	results, err := u.RemoveBatch(userCtx(testOwnerID, 0), []string{owned.ID, other.ID})
+	msg := "failed to check owners of batch"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Nil(results[0].Err, msg, "owned item failed")
+	assertions.True(errors.Is(results[1].Err, usecase.ErrForbidden), msg, "item of other owner")
+	assertions.Nil(repo.dmap[owned.ID], msg, "owned item left in repo")
+	assertions.NotNil(repo.dmap[other.ID], msg, "item of other owner removed")
+}
+
+func TestDummyAddWithStaleReadOfOtherOwnerReturnForbidden(t *testing.T) {
+	item := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: testOwnerID}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	repo.stale = true
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	bo, err := u.Add(userCtx("other", 0), &usecase.DummyBo{ID: item.ID, Name: "new_name"})
+	results, batchErr := u.AddBatch(userCtx("other", 0), []*usecase.DummyBo{{ID: item.ID, Name: "new_name"}})
+	msg := "write over item of other owner read as missing didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, usecase.ErrForbidden), msg, "error type")
+	assertions.Nil(bo, msg, "returned bo")
+	assertions.Nil(batchErr, msg, "found batch error")
+	assertions.True(errors.Is(results[0].Err, usecase.ErrForbidden), msg, "batch item error type")
+	assertions.Equal("test_name", repo.dmap[item.ID].Name, msg, "item changed")
+	assertions.Equal(testOwnerID, repo.dmap[item.ID].CreatedBy, msg, "owner changed")
+}
+
+func TestDummyRemoveBatchWithStaleReadOfOtherOwnerReturnPerItemForbidden(t *testing.T) {
+	other := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: "other"}
+	items := []*domain.Dummy{other}
+	repo := NewDummyMockRepository(items)
+	repo.stale = true
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	results, err := u.RemoveBatch(userCtx(testOwnerID, 0), []string{other.ID})
+	msg := "remove of item of other owner read as missing didn't fail"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.True(errors.Is(results[0].Err, usecase.ErrForbidden), msg, "item error type")
+	assertions.NotNil(repo.dmap[other.ID], msg, "item of other owner removed")
+}
+
+func TestDummyRestoreWithRemovedVersionAddBack(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	u := usecase.NewDummyUseCase(repo, nil).WithClock(func() time.Time { return now })
+	ctx := userCtx(testOwnerID, 0)
+	id := uuid.New().String()
+	_, _ = u.Add(ctx, &usecase.DummyBo{ID: id, Name: "first_name"})
+	now = now.Add(time.Hour)
+	_, _ = u.Add(ctx, &usecase.DummyBo{ID: id, Name: "second_name"})
+	now = now.Add(time.Hour)
+	_, _ = u.Remove(ctx, id)
+	now = now.Add(time.Hour)
+
+	bo, err := u.Restore(ctx, id, 1)
+	msg := "failed to restore removed item"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Equal("first_name", bo.Name, msg, "restored name")
+	assertions.Equal(now.Add(-3*time.Hour), bo.CreatedAt, msg, "created at")
+	assertions.Equal(now, bo.UpdatedAt, msg, "updated at")
+	history, _ := u.ListHistory(ctx, id)
+	assertions.Len(history, 4, msg, "restore not recorded")
+	asOf, _ := u.GetAsOf(ctx, id, now.Add(-90*time.Minute))
+	assertions.Equal("second_name", asOf.Name, msg, "item as of time")
+	removed, _ := u.GetAsOf(ctx, id, now.Add(-30*time.Minute))
+	assertions.Nil(removed, msg, "removed item as of time")
+}
+
+func TestDummyRestoreWithRemovalVersionReturnError(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+	ctx := userCtx(testOwnerID, 0)
+	id := uuid.New().String()
+	_, _ = u.Add(ctx, &usecase.DummyBo{ID: id, Name: "test_name"})
+	_, _ = u.Remove(ctx, id)
+
+	bo, err := u.Restore(ctx, id, 2)
+	msg := "restore to removal didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, usecase.ErrInvalidInput), msg, "error type")
+	assertions.Nil(bo, msg, "returned bo")
+	_, err = u.Restore(ctx, id, 3)
+	assertions.True(errors.Is(err, domain.ErrNotFound), msg, "missing version error type")
+}
+
+func TestDummyRestoreDeletedWithOtherOwnerReturnForbidden(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	u := usecase.NewDummyUseCase(repo, nil)
+	id := uuid.New().String()
+	_, _ = u.Add(userCtx(testOwnerID, 0), &usecase.DummyBo{ID: id, Name: "test_name"})
+	_, _ = u.Remove(userCtx(testOwnerID, 0), id)
+
+	bo, err := u.RestoreDeleted(userCtx("other_user", 0), id)
+	msg := "restore of other user's item didn't fail"
+	assertions := assert.New(t)
+	assertions.True(errors.Is(err, usecase.ErrForbidden), msg, "error type")
+	assertions.Nil(bo, msg, "returned bo")
+	bo, err = u.RestoreDeleted(userCtx(testOwnerID, 0), id)
+	assertions.Nil(err, msg, "owner restore error")
+	assertions.Equal("test_name", bo.Name, msg, "restored name")
+}
+
+func TestDummyListHistoryWithRemovedItemOfOtherOwnerReturnForbidden(t *testing.T) {
+	repo := NewDummyMockRepository([]*domain.Dummy{})
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	u := usecase.NewDummyUseCase(repo, nil).
+		WithAdminPermission(testAdminBit).
+		WithClock(func() time.Time { return now })
+	id := uuid.New().String()
+	_, _ = u.Add(userCtx(testOwnerID, 0), &usecase.DummyBo{ID: id, Name: "test_name"})
+	live, err1 := u.ListHistory(userCtx("other_user", 0), id)
+	_, _ = u.Remove(userCtx(testOwnerID, 0), id)
+
+	history, err2 := u.ListHistory(userCtx("other_user", 0), id)
+	asOf, err3 := u.GetAsOf(userCtx("other_user", 0), id, now)
+	owned, err4 := u.ListHistory(userCtx(testOwnerID, 0), id)
+	admin, err5 := u.ListHistory(userCtx("admin", testAdminBit), id)
+
+	msg := "history of removed item is read by other user"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "history of live item error")
+	assertions.Len(live, 1, msg, "history of live item")
+	assertions.True(errors.Is(err2, usecase.ErrForbidden), msg, "history error type")
+	assertions.Nil(history, msg, "returned history")
+	assertions.True(errors.Is(err3, usecase.ErrForbidden), msg, "as of time error type")
+	assertions.Nil(asOf, msg, "returned bo")
+	assertions.Nil(err4, msg, "history of owner error")
+	assertions.Len(owned, 2, msg, "history of owner")
+	assertions.Nil(err5, msg, "history of admin error")
+	assertions.Len(admin, 2, msg, "history of admin")
+}
+
+func TestDummyAddAndRemoveRecordEvents(t *testing.T) {
+	items := []*domain.Dummy{}
+	repo := NewDummyMockRepository(items)
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	u := usecase.NewDummyUseCase(repo, nil).WithClock(func() time.Time { return now })
+	ctx := userCtx(testOwnerID, 0)
+	id := uuid.New().String()
+
+	_, _ = u.Add(ctx, &usecase.DummyBo{ID: id, Name: "first_name"})
+	_, _ = u.Add(ctx, &usecase.DummyBo{ID: id, Name: "second_name"})
+	_, _ = u.Remove(ctx, id)
+
+	msg := "wrong recorded events"
+	assertions := assert.New(t)
+	assertions.Len(repo.events, 3, msg, "event count")
+	for i, eventType := range []domain.DummyEventType{domain.DummyCreated, domain.DummyUpdated, domain.DummyDeleted} {
+		assertions.Equal(eventType, repo.events[i].Type, msg, "event type")
+		assertions.Equal(id, repo.events[i].AggregateID, msg, "aggregate id")
+		assertions.Equal(testOwnerID, repo.events[i].Actor, msg, "actor")
+		assertions.Equal(now, repo.events[i].OccurredAt, msg, "occurred at")
+		assertions.NotEmpty(repo.events[i].ID, msg, "event id")
+	}
+}
+
+func TestDummyAddWithStaleReadRecordEventOfChange(t *testing.T) {
+	item := &domain.Dummy{ID: uuid.New().String(), Name: "test_name", CreatedBy: testOwnerID}
+	items := []*domain.Dummy{item}
+	repo := NewDummyMockRepository(items)
+	repo.stale = true
+	u := usecase.NewDummyUseCase(repo, nil)
+
+	_, err := u.Add(userCtx(testOwnerID, 0), &usecase.DummyBo{ID: item.ID, Name: "new_name"})
+	msg := "event of item read as missing isn't the update"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found error")
+	assertions.Len(repo.events, 1, msg, "event count")
+	assertions.Equal(domain.DummyUpdated, repo.events[0].Type, msg, "event type")
+}
+
+type DummyMockTransactor struct {
+	calls   int
+	commits int
+}
+
+func (tx *DummyMockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx.calls++
+	err := fn(ctx)
+	if err != nil {
+		return err
+	}
+	tx.commits++
+	return nil
+}
+
 type DummyMockRepository struct {
-	dmap map[string]*domain.Dummy
+	// stale reads miss like a cache which has not seen the writes
+	stale   bool
+	dmap    map[string]*domain.Dummy
+	history map[string][]*domain.DummyHistory
+	deleted map[string]*domain.Dummy
+	events  []*domain.DummyEvent
 }
 
 func NewDummyMockRepository(entities []*domain.Dummy) *DummyMockRepository {
@@ -88,7 +531,9 @@ func NewDummyMockRepository(entities []*domain.Dummy) *DummyMockRepository {
 		dmap[entity.ID] = entity
 	}
 	return &DummyMockRepository{
-		dmap: dmap,
+		dmap:    dmap,
+		history: make(map[string][]*domain.DummyHistory),
+		deleted: make(map[string]*domain.Dummy),
 	}
 }
 
@@ -99,28 +544,161 @@ func (r *DummyMockRepository) GetByID(ctx context.Context, id string) (*domain.D
 	if id == invalidDummyID {
 		return nil, errors.Wrap(errBadRepositoryAction, "GetByID")
 	}
+	if r.stale {
+		return nil, nil
+	}
 	return r.dmap[id], nil
 }
 
-func (r *DummyMockRepository) Insert(ctx context.Context, dummy *domain.Dummy) (*domain.Dummy, error) {
+func (r *DummyMockRepository) Insert(
+	ctx context.Context,
+	dummy *domain.Dummy,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
 	if dummy == nil || dummy.ID == "" {
 		return nil, nil
 	}
 	if dummy.ID == invalidDummyID {
 		return nil, errors.Wrap(errBadRepositoryAction, "Insert")
 	}
+	change := domain.DummyChangeUpdated
+	if existing, ok := r.dmap[dummy.ID]; !ok {
+		change = domain.DummyChangeCreated
+	} else if owner, ok := domain.BuildDeleteOptions(opts...).ExpectedAttrs["createdBy"]; ok && owner != existing.CreatedBy {
+		return nil, errors.Wrap(domain.ErrConditionFailed, "Insert")
+	}
 	r.dmap[dummy.ID] = dummy
+	r.record(ctx, dummy, change)
 	return dummy, nil
 }
 
-func (r *DummyMockRepository) DeleteByID(ctx context.Context, id string) (*domain.Dummy, error) {
+func (r *DummyMockRepository) DeleteByID(
+	ctx context.Context,
+	id string,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
 	if id == "" {
 		return nil, nil
 	}
 	if id == invalidDummyID {
 		return nil, errors.Wrap(errBadRepositoryAction, "DeleteByID")
 	}
-	dummy := r.dmap[id]
+	dummy, ok := r.dmap[id]
+	if !ok {
+		return nil, errors.Wrap(domain.ErrNotFound, "DeleteByID")
+	}
+	options := domain.BuildDeleteOptions(opts...)
+	if owner, ok := options.ExpectedAttrs["createdBy"]; ok && owner != dummy.CreatedBy {
+		return nil, errors.Wrap(domain.ErrConditionFailed, "DeleteByID")
+	}
 	delete(r.dmap, id)
+	r.deleted[id] = dummy
+	r.record(ctx, dummy, domain.DummyChangeDeleted)
+	return dummy, nil
+}
+
+func (r *DummyMockRepository) RestoreByID(
+	ctx context.Context,
+	id string,
+	opts ...domain.DeleteOption,
+) (*domain.Dummy, error) {
+	dummy, ok := r.deleted[id]
+	if !ok {
+		return nil, errors.Wrap(domain.ErrNotFound, "RestoreByID")
+	}
+	options := domain.BuildDeleteOptions(opts...)
+	if owner, ok := options.ExpectedAttrs["createdBy"]; ok && owner != dummy.CreatedBy {
+		return nil, errors.Wrap(domain.ErrConditionFailed, "RestoreByID")
+	}
+	delete(r.deleted, id)
+	r.dmap[id] = dummy
+	r.record(ctx, dummy, domain.DummyChangeRestored)
 	return dummy, nil
 }
+
+func (r *DummyMockRepository) record(ctx context.Context, dummy *domain.Dummy, change domain.DummyChange) {
+	by, at := domain.ChangeFromContext(ctx)
+	snapshot := *dummy
+	r.history[dummy.ID] = append(r.history[dummy.ID], &domain.DummyHistory{
+		ID:        dummy.ID,
+		Version:   int64(len(r.history[dummy.ID]) + 1),
+		Change:    change,
+		ChangedAt: at,
+		ChangedBy: by,
+		Snapshot:  &snapshot,
+	})
+	if event := domain.DummyEventFromContext(ctx, dummy.ID); event != nil {
+		recorded := *event
+		recorded.Type = domain.DummyEventTypeOf(change)
+		r.events = append(r.events, &recorded)
+	}
+}
+
+func (r *DummyMockRepository) BatchGetByIDs(ctx context.Context, ids []string) ([]*domain.DummyBatchResult, error) {
+	results := []*domain.DummyBatchResult{}
+	for _, id := range ids {
+		dummy, err := r.GetByID(ctx, id)
+		results = append(results, &domain.DummyBatchResult{ID: id, Dummy: dummy, Err: err})
+	}
+	return results, nil
+}
+
+func (r *DummyMockRepository) BatchInsert(
+	ctx context.Context,
+	dummies []*domain.Dummy,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	results := []*domain.DummyBatchResult{}
+	for _, dummy := range dummies {
+		inserted, err := r.Insert(ctx, dummy, opts...)
+		result := &domain.DummyBatchResult{Dummy: inserted, Err: err}
+		if dummy != nil {
+			result.ID = dummy.ID
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (r *DummyMockRepository) BatchDeleteByIDs(
+	ctx context.Context,
+	ids []string,
+	opts ...domain.DeleteOption,
+) ([]*domain.DummyBatchResult, error) {
+	results := []*domain.DummyBatchResult{}
+	for _, id := range ids {
+		_, err := r.DeleteByID(ctx, id, opts...)
+		results = append(results, &domain.DummyBatchResult{ID: id, Err: err})
+	}
+	return results, nil
+}
+
+func (r *DummyMockRepository) ListHistory(ctx context.Context, id string) ([]*domain.DummyHistory, error) {
+	return r.history[id], nil
+}
+
+func (r *DummyMockRepository) GetHistoryVersion(
+	ctx context.Context,
+	id string,
+	version int64,
+) (*domain.DummyHistory, error) {
+	records := r.history[id]
+	if version < 1 || version > int64(len(records)) {
+		return nil, nil
+	}
+	return records[version-1], nil
+}
+
+func (r *DummyMockRepository) GetHistoryAsOf(
+	ctx context.Context,
+	id string,
+	asOf time.Time,
+) (*domain.DummyHistory, error) {
+	var found *domain.DummyHistory
+	for _, record := range r.history[id] {
+		if !record.ChangedAt.After(asOf) {
+			found = record
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/logger"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+)
+
+const (
+	// maxJobInputBytes keeps a job within the item size limit of DynamoDB.
+	maxJobInputBytes int = 300 << 10
+	// defaultJobClaimTTL the timeout of the worker lambda.
+	defaultJobClaimTTL time.Duration = 15 * time.Minute
+)
+
+// JobBo.
+type JobBo struct {
+	ID        string
+	Type      string
+	State     string
+	Owner     string
+	Done      int
+	Total     int
+	Result    json.RawMessage
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// JobProgressFunc
+// reports the progress of a running job.
+type JobProgressFunc func(ctx context.Context, done int, total int) error
+
+// JobExecutor
+// executes a job of a type, it runs as the owner of the job with the permissions of the submission.
+// a redelivered job is skipped while a worker has claimed it. the claim lasts the claim ttl since the job started or
+// reported its progress, so a job is executed again only when its worker stopped longer than that.
+//
+//	@param ctx
+//	@param input
+//	@param progress
+//	@return interface{} result marshaled as json
+//	@return error the job fails with it
+type JobExecutor func(ctx context.Context, input json.RawMessage, progress JobProgressFunc) (interface{}, error)
+
+// JobUseCase.
+type JobUseCase struct {
+	jobRepo   domain.JobRepository
+	queue     domain.JobQueue
+	executors map[string]JobExecutor
+	claimTTL  time.Duration
+	now       func() time.Time
+}
+
+// NewJobUseCase
+//
+//	@param jobRepo
+//	@param queue
+//	@return *JobUseCase
+func NewJobUseCase(jobRepo domain.JobRepository, queue domain.JobQueue) *JobUseCase {
+	return &JobUseCase{
+		jobRepo:   jobRepo,
+		queue:     queue,
+		executors: make(map[string]JobExecutor),
+		claimTTL:  defaultJobClaimTTL,
+		now:       time.Now,
+	}
+}
+
+// WithClock
+//
+//	@receiver uc
+//	@param now
+//	@return *JobUseCase
+func (uc *JobUseCase) WithClock(now func() time.Time) *JobUseCase {
+	uc.now = now
+	return uc
+}
+
+// WithClaimTTL
+//
+//	@receiver uc
+//	@param ttl a running job is claimed again after it since it reported its progress, longer than the worker runs
+//	@return *JobUseCase
+func (uc *JobUseCase) WithClaimTTL(ttl time.Duration) *JobUseCase {
+	uc.claimTTL = ttl
+	return uc
+}
+
+// Register
+//
+//	@receiver uc
+//	@param jobType
+//	@param executor
+//	@return *JobUseCase
+func (uc *JobUseCase) Register(jobType string, executor JobExecutor) *JobUseCase {
+	uc.executors[jobType] = executor
+	return uc
+}
+
+// Submit
+// store a pending job and hand it to the workers, the job fails when it cannot be handed
+//
+//	@receiver uc
+//	@param ctx
+//	@param jobType
+//	@param input marshaled as json
+//	@return *JobBo
+//	@return error ErrInvalidInput for unknown types or too large inputs, ErrForbidden without a caller, and others
+func (uc *JobUseCase) Submit(ctx context.Context, jobType string, input interface{}) (*JobBo, error) {
+	if _, ok := uc.executors[jobType]; !ok {
+		return nil, errors.Wrapf(ErrInvalidInput, "unknown job type: %s", jobType)
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. job type: %s", jobType)
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidInput, "marshal job input error: %s", err.Error())
+	}
+	if len(data) > maxJobInputBytes {
+		return nil, errors.Wrapf(ErrInvalidInput, "job input size: %d, max: %d", len(data), maxJobInputBytes)
+	}
+	now := uc.now().UTC()
+	job := &domain.Job{
+		ID:    uuid.New().String(),
+		Type:  jobType,
+		State: domain.JobPending,
+		Owner: caller.UserID,
+		Caller: &domain.JobCaller{
+			UserID:        caller.UserID,
+			UserName:      caller.UserName,
+			Locale:        caller.Locale,
+			ZoneID:        caller.ZoneID,
+			PermissionBit: caller.PermissionBit,
+		},
+		Input:     data,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = uc.jobRepo.CreateJob(ctx, job)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with job type: %s", jobType)
+	}
+	err = uc.queue.Enqueue(ctx, job.ID)
+	if err != nil {
+		uc.abandon(ctx, job, err)
+		return nil, errors.Wrapf(err, "failed to enqueue job: %s", job.ID)
+	}
+	logger.Info("job submitted. id: %s, type: %s, owner: %s", job.ID, job.Type, job.Owner)
+	return uc.buildBo(job), nil
+}
+
+// Get
+//
+//	@receiver uc
+//	@param ctx
+//	@param id
+//	@return *JobBo
+//	@return error ErrInvalidInput, ErrForbidden when the caller is not the owner, domain.ErrNotFound and others
+func (uc *JobUseCase) Get(ctx context.Context, id string) (*JobBo, error) {
+	if len(id) == 0 {
+		return nil, errors.Wrap(ErrInvalidInput, "blank id")
+	}
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil, errors.Wrapf(ErrForbidden, "no authenticated user. job id: %s", id)
+	}
+	job, err := uc.jobRepo.GetJob(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "repository error with job id: %s", id)
+	}
+	if job.Owner != caller.UserID {
+		return nil, errors.Wrapf(ErrForbidden, "user: %s, job id: %s", caller.UserID, id)
+	}
+	return uc.buildBo(job), nil
+}
+
+// Execute
+// run a job handed to a worker. a failing executor fails the job, a job claimed by another worker, finished or
+// missing is skipped. a job left running by a crashed worker is run again once its claim expired.
+//
+//	@receiver uc
+//	@param ctx
+//	@param id
+//	@return error errors of the repository, the job should be redelivered
+func (uc *JobUseCase) Execute(ctx context.Context, id string) error {
+	job, err := uc.jobRepo.GetJob(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		logger.Warn("skip missing job. id: %s", id)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "repository error with job id: %s", id)
+	}
+	now := uc.now().UTC()
+	if !job.IsClaimable(now) {
+		logger.Info("skip started job. id: %s, state: %s", id, job.State)
+		return nil
+	}
+	if job.State == domain.JobRunning {
+		logger.Warn("claim job left by a worker. id: %s, claimed until: %s", id, job.ClaimedUntil)
+	}
+	executor, ok := uc.executors[job.Type]
+	if !ok {
+		return uc.finish(ctx, job, nil, errors.Errorf("unknown job type: %s", job.Type))
+	}
+	job.State = domain.JobRunning
+	job.ClaimedUntil = now.Add(uc.claimTTL)
+	job.UpdatedAt = now
+	err = uc.jobRepo.ClaimJob(ctx, job, now)
+	if errors.Is(err, domain.ErrConditionFailed) {
+		logger.Info("skip job claimed by another worker. id: %s", id)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to start job: %s", id)
+	}
+	// the job runs as its owner
+	ctx = context.WithValue(ctx, authentication.UserContextKey, uc.callerOf(job))
+	ctx = context.WithValue(ctx, authentication.UserIDKey, job.Owner)
+	result, err := executor(ctx, job.Input, func(ctx context.Context, done int, total int) error {
+		job.Progress = domain.JobProgress{Done: done, Total: total}
+		job.UpdatedAt = uc.now().UTC()
+		// the progress renews the claim
+		job.ClaimedUntil = job.UpdatedAt.Add(uc.claimTTL)
+		return uc.jobRepo.UpdateJob(ctx, job)
+	})
+	return uc.finish(ctx, job, result, err)
+}
+
+// finish
+//
+//	@receiver uc
+//	@param ctx
+//	@param job
+//	@param result
+//	@param execErr
+//	@return error
+func (uc *JobUseCase) finish(ctx context.Context, job *domain.Job, result interface{}, execErr error) error {
+	job.State = domain.JobSucceeded
+	if execErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			execErr = errors.Wrap(errors.New(err.Error()), "marshal job result error")
+		} else {
+			job.Result = data
+		}
+	}
+	if execErr != nil {
+		logger.Error("job failed. id: %s, type: %s", execErr, job.ID, job.Type)
+		job.State = domain.JobFailed
+		job.Result = nil
+		job.Error = execErr.Error()
+	}
+	job.ClaimedUntil = time.Time{}
+	job.UpdatedAt = uc.now().UTC()
+	err := uc.jobRepo.UpdateJob(ctx, job)
+	if err != nil {
+		return errors.Wrapf(err, "failed to finish job: %s", job.ID)
+	}
+	logger.Info("job finished. id: %s, state: %s", job.ID, job.State)
+	return nil
+}
+
+// abandon
+// fail a job that no worker will be given, unless a worker claimed it as the queue took it despite the error
+//
+//	@receiver uc
+//	@param ctx
+//	@param job
+//	@param enqueueErr
+func (uc *JobUseCase) abandon(ctx context.Context, job *domain.Job, enqueueErr error) {
+	now := uc.now().UTC()
+	job.State = domain.JobFailed
+	job.Error = errors.Wrap(enqueueErr, "failed to enqueue job").Error()
+	job.UpdatedAt = now
+	err := uc.jobRepo.ClaimJob(ctx, job, now)
+	if err != nil && !errors.Is(err, domain.ErrConditionFailed) {
+		logger.Error("failed to fail job left out of queue. id: %s", err, job.ID)
+	}
+}
+
+// callerOf
+//
+//	@receiver uc
+//	@param job
+//	@return authentication.UserContext only the owner id for the jobs stored without their caller
+func (uc *JobUseCase) callerOf(job *domain.Job) authentication.UserContext {
+	if job.Caller == nil {
+		return authentication.UserContext{UserID: job.Owner}
+	}
+	return authentication.UserContext{
+		UserID:        job.Caller.UserID,
+		UserName:      job.Caller.UserName,
+		Locale:        job.Caller.Locale,
+		ZoneID:        job.Caller.ZoneID,
+		PermissionBit: job.Caller.PermissionBit,
+	}
+}
+
+// buildBo
+//
+//	@receiver uc
+//	@param job
+//	@return *JobBo
+func (uc *JobUseCase) buildBo(job *domain.Job) *JobBo {
+	if job == nil {
+		return nil
+	}
+	return &JobBo{
+		ID:        job.ID,
+		Type:      job.Type,
+		State:     string(job.State),
+		Owner:     job.Owner,
+		Done:      job.Progress.Done,
+		Total:     job.Progress.Total,
+		Result:    job.Result,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+}
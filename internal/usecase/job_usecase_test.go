@@ -0,0 +1,267 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"local.com/go-clean-lambda/internal/domain"
+	"local.com/go-clean-lambda/internal/sdk/authentication"
+	"local.com/go-clean-lambda/internal/usecase"
+)
+
+func TestJobSubmitWithDummyImportExecuteInBatches(t *testing.T) {
+	repo := NewDummyMockRepository([]*domain.Dummy{})
+	jobRepo := NewJobMockRepository()
+	queue := &JobMockQueue{}
+	u := usecase.NewJobUseCase(jobRepo, queue).
+		Register(usecase.JobTypeDummyImport, usecase.NewDummyImportJob(usecase.NewDummyUseCase(repo, nil)))
+	count := usecase.MaxBatchSize + 5
+	bos := make([]*usecase.DummyBo, 0, count+1)
+	for i := 0; i < count; i++ {
+		bos = append(bos, &usecase.DummyBo{ID: fmt.Sprintf("id_%d", i), Name: "test_name"})
+	}
+	bos = append(bos, &usecase.DummyBo{ID: "no_name"})
+
+	submitted, err1 := u.Submit(userCtx(testOwnerID, 0), usecase.JobTypeDummyImport, bos)
+	err2 := u.Execute(context.TODO(), queue.ids[0])
+	finished, err3 := u.Get(userCtx(testOwnerID, 0), submitted.ID)
+
+	msg := "failed to import by a job"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found submit error")
+	assertions.Nil(err2, msg, "found execute error")
+	assertions.Nil(err3, msg, "found get error")
+	assertions.Equal(string(domain.JobPending), submitted.State, msg, "state of submitted job")
+	assertions.Equal([]string{submitted.ID}, queue.ids, msg, "enqueued jobs")
+	assertions.Equal(string(domain.JobSucceeded), finished.State, msg, "state of finished job")
+	assertions.Equal(count+1, finished.Done, msg, "progress done")
+	assertions.Equal(count+1, finished.Total, msg, "progress total")
+	assertions.Equal(2, jobRepo.progressUpdates, msg, "progress updates, one per batch")
+	result := &usecase.DummyImportResult{}
+	assertions.Nil(json.Unmarshal(finished.Result, result), msg, "invalid result")
+	assertions.Equal(count, result.Imported, msg, "imported count")
+	assertions.Len(result.Failed, 1, msg, "failed items")
+	assertions.Equal("no_name", result.Failed[0].ID, msg, "failed item")
+	stored, _ := repo.GetByID(context.TODO(), "id_0")
+	assertions.Equal(testOwnerID, stored.CreatedBy, msg, "job not run as its owner")
+}
+
+func TestJobSubmitWithUnknownTypeReturnInvalidInput(t *testing.T) {
+	queue := &JobMockQueue{}
+	u := usecase.NewJobUseCase(NewJobMockRepository(), queue)
+
+	bo, err := u.Submit(userCtx(testOwnerID, 0), "unknown", nil)
+
+	msg := "submit unknown job type didn't fail"
+	assertions := assert.New(t)
+	assertions.Nil(bo, msg, "returned bo")
+	assertions.True(errors.Is(err, usecase.ErrInvalidInput), msg, "error type")
+	assertions.Empty(queue.ids, msg, "enqueued jobs")
+}
+
+func TestJobGetWithOtherUserReturnForbidden(t *testing.T) {
+	u := usecase.NewJobUseCase(NewJobMockRepository(), &JobMockQueue{}).
+		Register("test", func(ctx context.Context, input json.RawMessage, progress usecase.JobProgressFunc) (interface{}, error) {
+			return nil, nil
+		})
+	submitted, err := u.Submit(userCtx(testOwnerID, 0), "test", nil)
+	if err != nil {
+		t.Fatalf("error happened when submitting job, %v", err)
+	}
+
+	bo, err := u.Get(userCtx("other_user", 0), submitted.ID)
+
+	msg := "get job of other user didn't fail"
+	assertions := assert.New(t)
+	assertions.Nil(bo, msg, "returned bo")
+	assertions.True(errors.Is(err, usecase.ErrForbidden), msg, "error type")
+}
+
+func TestJobExecuteWithExecutorErrorFailJobOnce(t *testing.T) {
+	calls := 0
+	queue := &JobMockQueue{}
+	u := usecase.NewJobUseCase(NewJobMockRepository(), queue).
+		Register("test", func(ctx context.Context, input json.RawMessage, progress usecase.JobProgressFunc) (interface{}, error) {
+			calls++
+			return nil, errBadRepositoryAction
+		})
+	submitted, err := u.Submit(userCtx(testOwnerID, 0), "test", nil)
+	if err != nil {
+		t.Fatalf("error happened when submitting job, %v", err)
+	}
+
+	err1 := u.Execute(context.TODO(), submitted.ID)
+	err2 := u.Execute(context.TODO(), submitted.ID)
+	failed, _ := u.Get(userCtx(testOwnerID, 0), submitted.ID)
+
+	msg := "failed job is not recorded"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found execute error")
+	assertions.Nil(err2, msg, "found redelivered execute error")
+	assertions.Equal(1, calls, msg, "finished job executed again")
+	assertions.Equal(string(domain.JobFailed), failed.State, msg, "state")
+	assertions.Contains(failed.Error, errBadRepositoryAction.Error(), msg, "error of job")
+}
+
+func TestJobExecuteWithCallerRunAsCaller(t *testing.T) {
+	var caller authentication.UserContext
+	queue := &JobMockQueue{}
+	u := usecase.NewJobUseCase(NewJobMockRepository(), queue).
+		Register("test", func(ctx context.Context, input json.RawMessage, progress usecase.JobProgressFunc) (interface{}, error) {
+			caller, _ = ctx.Value(authentication.UserContextKey).(authentication.UserContext)
+			return nil, nil
+		})
+	submitted, err := u.Submit(userCtx(testOwnerID, 4), "test", nil)
+	if err != nil {
+		t.Fatalf("error happened when submitting job, %v", err)
+	}
+
+	err = u.Execute(context.TODO(), submitted.ID)
+
+	msg := "job not run as its caller"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found execute error")
+	assertions.Equal(testOwnerID, caller.UserID, msg, "user id")
+	assertions.Equal(uint64(4), caller.PermissionBit, msg, "permission bit")
+}
+
+func TestJobExecuteWithClaimedJobSkipIt(t *testing.T) {
+	calls := 0
+	jobRepo := NewJobMockRepository()
+	u := usecase.NewJobUseCase(jobRepo, &JobMockQueue{}).
+		Register("test", func(ctx context.Context, input json.RawMessage, progress usecase.JobProgressFunc) (interface{}, error) {
+			calls++
+			return nil, nil
+		})
+	submitted, err := u.Submit(userCtx(testOwnerID, 0), "test", nil)
+	if err != nil {
+		t.Fatalf("error happened when submitting job, %v", err)
+	}
+	jobRepo.jobs[submitted.ID].State = domain.JobRunning
+
+	err = u.Execute(context.TODO(), submitted.ID)
+	running, _ := u.Get(userCtx(testOwnerID, 0), submitted.ID)
+
+	msg := "claimed job executed again"
+	assertions := assert.New(t)
+	assertions.Nil(err, msg, "found execute error")
+	assertions.Equal(0, calls, msg, "executor calls")
+	assertions.Equal(string(domain.JobRunning), running.State, msg, "state")
+}
+
+func TestJobSubmitWithEnqueueErrorFailJob(t *testing.T) {
+	jobRepo := NewJobMockRepository()
+	u := usecase.NewJobUseCase(jobRepo, &JobMockQueue{err: errBadRepositoryAction}).
+		Register("test", func(ctx context.Context, input json.RawMessage, progress usecase.JobProgressFunc) (interface{}, error) {
+			return nil, nil
+		})
+
+	bo, err := u.Submit(userCtx(testOwnerID, 0), "test", nil)
+
+	msg := "job left out of queue is not failed"
+	assertions := assert.New(t)
+	assertions.Nil(bo, msg, "returned bo")
+	assertions.True(errors.Is(err, errBadRepositoryAction), msg, "error type")
+	assertions.Len(jobRepo.jobs, 1, msg, "stored jobs")
+	for _, job := range jobRepo.jobs {
+		assertions.Equal(domain.JobFailed, job.State, msg, "state")
+		assertions.Contains(job.Error, errBadRepositoryAction.Error(), msg, "error of job")
+	}
+}
+
+func TestJobExecuteWithExpiredClaimRunItAgain(t *testing.T) {
+	calls := 0
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	jobRepo := NewJobMockRepository()
+	u := usecase.NewJobUseCase(jobRepo, &JobMockQueue{}).
+		WithClaimTTL(time.Minute).
+		WithClock(func() time.Time { return now }).
+		Register("test", func(ctx context.Context, input json.RawMessage, progress usecase.JobProgressFunc) (interface{}, error) {
+			calls++
+			return nil, nil
+		})
+	submitted, err := u.Submit(userCtx(testOwnerID, 0), "test", nil)
+	if err != nil {
+		t.Fatalf("error happened when submitting job, %v", err)
+	}
+	jobRepo.jobs[submitted.ID].State = domain.JobRunning
+	jobRepo.jobs[submitted.ID].ClaimedUntil = now.Add(time.Minute)
+
+	err1 := u.Execute(context.TODO(), submitted.ID)
+	now = now.Add(time.Minute + time.Second)
+	err2 := u.Execute(context.TODO(), submitted.ID)
+	finished, _ := u.Get(userCtx(testOwnerID, 0), submitted.ID)
+
+	msg := "job left by a worker is not run again"
+	assertions := assert.New(t)
+	assertions.Nil(err1, msg, "found execute error before claim expired")
+	assertions.Nil(err2, msg, "found execute error")
+	assertions.Equal(1, calls, msg, "executor calls")
+	assertions.Equal(string(domain.JobSucceeded), finished.State, msg, "state")
+}
+
+type JobMockQueue struct {
+	ids []string
+	err error
+}
+
+func (q *JobMockQueue) Enqueue(ctx context.Context, jobID string) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.ids = append(q.ids, jobID)
+	return nil
+}
+
+type JobMockRepository struct {
+	jobs            map[string]*domain.Job
+	progressUpdates int
+}
+
+func NewJobMockRepository() *JobMockRepository {
+	return &JobMockRepository{
+		jobs: make(map[string]*domain.Job),
+	}
+}
+
+func (r *JobMockRepository) CreateJob(ctx context.Context, job *domain.Job) error {
+	copied := *job
+	r.jobs[job.ID] = &copied
+	return nil
+}
+
+func (r *JobMockRepository) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, errors.Wrap(domain.ErrNotFound, id)
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *JobMockRepository) UpdateJob(ctx context.Context, job *domain.Job) error {
+	if _, ok := r.jobs[job.ID]; !ok {
+		return errors.Wrap(domain.ErrNotFound, job.ID)
+	}
+	if job.State == domain.JobRunning && job.Progress.Done > 0 {
+		r.progressUpdates++
+	}
+	copied := *job
+	r.jobs[job.ID] = &copied
+	return nil
+}
+
+func (r *JobMockRepository) ClaimJob(ctx context.Context, job *domain.Job, now time.Time) error {
+	stored, ok := r.jobs[job.ID]
+	if !ok || !stored.IsClaimable(now) {
+		return errors.Wrap(domain.ErrConditionFailed, job.ID)
+	}
+	copied := *job
+	r.jobs[job.ID] = &copied
+	return nil
+}